@@ -97,8 +97,10 @@ func DefaultConfig() SsmagentConfig {
 		CommandRetryLimit:   DefaultCommandRetryLimit,
 	}
 	var mgs = MgsConfig{
-		SessionWorkersLimit: DefaultSessionWorkersLimit,
-		StopTimeoutMillis:   DefaultStopTimeoutMillis,
+		SessionWorkersLimit:       DefaultSessionWorkersLimit,
+		StopTimeoutMillis:         DefaultStopTimeoutMillis,
+		SessionIdleTimeoutMinutes: DefaultSessionIdleTimeoutMinutes,
+		SessionMaxDurationMinutes: DefaultSessionMaxDurationMinutes,
 	}
 	var ssm = SsmCfg{
 		HealthFrequencyMinutes:                DefaultSsmHealthFrequencyMinutes,
@@ -113,6 +115,8 @@ func DefaultConfig() SsmagentConfig {
 		Name:                 "amazon-ssm-agent",
 		OrchestrationRootDir: defaultOrchestrationRootDirName,
 		ContainerMode:        false,
+		DocumentStateStore:   DocumentStateStoreFile,
+		LogFormat:            LogFormatText,
 	}
 	var os = OsInfo{
 		Lang:    "en-US",
@@ -120,17 +124,48 @@ func DefaultConfig() SsmagentConfig {
 	}
 	var birdwatcher BirdwatcherCfg
 	var kms KmsConfig
+	var metrics = MetricsCfg{
+		Enabled: false,
+		Port:    DefaultMetricsPort,
+	}
+	var download = DownloadCfg{
+		Concurrency:        DefaultDownloadConcurrency,
+		ChunkSizeMB:        DefaultDownloadChunkSizeMB,
+		BandwidthLimitKBps: DefaultDownloadBandwidthLimitKBps,
+		CacheSizeMB:        DefaultDownloadCacheSizeMB,
+	}
+	var redaction = RedactionCfg{
+		Patterns: append([]string{}, DefaultRedactionPatterns...),
+	}
+	var outputStreaming = OutputStreamingCfg{
+		MaxFileSizeMB: DefaultOutputStreamingMaxFileSizeMB,
+		MaxFiles:      DefaultOutputStreamingMaxFiles,
+	}
+	var inventory = InventoryCfg{
+		HistorySize:   DefaultInventoryHistorySize,
+		ReportChanges: false,
+	}
+	var portForwarding = PortForwardingCfg{
+		AllowedHosts:       []string{},
+		AllowedUnixSockets: []string{},
+	}
 
 	var ssmagentCfg = SsmagentConfig{
-		Profile:     credsProfile,
-		Mds:         mds,
-		Ssm:         ssm,
-		Mgs:         mgs,
-		Agent:       agent,
-		Os:          os,
-		S3:          s3,
-		Birdwatcher: birdwatcher,
-		Kms:         kms,
+		Profile:         credsProfile,
+		Mds:             mds,
+		Ssm:             ssm,
+		Mgs:             mgs,
+		Agent:           agent,
+		Os:              os,
+		S3:              s3,
+		Birdwatcher:     birdwatcher,
+		Kms:             kms,
+		Metrics:         metrics,
+		Download:        download,
+		OutputStreaming: outputStreaming,
+		Redaction:       redaction,
+		Inventory:       inventory,
+		PortForwarding:  portForwarding,
 	}
 
 	return ssmagentCfg
@@ -17,6 +17,9 @@ package appconfig
 
 import (
 	"log"
+	"path/filepath"
+	"regexp"
+	"strings"
 )
 
 //func parser(config *T) {
@@ -27,6 +30,10 @@ func parser(config *SsmagentConfig) {
 	config.Agent.Name = getStringValue(config.Agent.Name, DefaultAgentName)
 	config.Agent.OrchestrationRootDir = getStringValue(config.Agent.OrchestrationRootDir, defaultOrchestrationRootDirName)
 	config.Agent.Region = getStringValue(config.Agent.Region, "")
+	config.Agent.DocumentStateStore = getStringValue(config.Agent.DocumentStateStore, DocumentStateStoreFile)
+	if config.Agent.LogFormat != LogFormatJSON {
+		config.Agent.LogFormat = LogFormatText
+	}
 
 	// MDS config
 	config.Mds.CommandWorkersLimit = getNumericValue(
@@ -45,6 +52,79 @@ func parser(config *SsmagentConfig) {
 		DefaultStopTimeoutMillisMax,
 		DefaultStopTimeoutMillis)
 	config.Mds.Endpoint = getStringValue(config.Mds.Endpoint, "")
+	for documentType, documentTypeCfg := range config.Mds.DocumentTypes {
+		documentTypeCfg.WorkersLimit = getNumericValueAboveMin(documentTypeCfg.WorkersLimit, 0, 0)
+		config.Mds.DocumentTypes[documentType] = documentTypeCfg
+	}
+
+	// Metrics config
+	config.Metrics.Port = getNumericValue(
+		config.Metrics.Port,
+		DefaultMetricsPortMin,
+		DefaultMetricsPortMax,
+		DefaultMetricsPort)
+
+	// Download config
+	config.Download.Concurrency = getNumericValue(
+		config.Download.Concurrency,
+		DefaultDownloadConcurrencyMin,
+		DefaultDownloadConcurrencyMax,
+		DefaultDownloadConcurrency)
+	config.Download.ChunkSizeMB = getNumericValue(
+		config.Download.ChunkSizeMB,
+		DefaultDownloadChunkSizeMBMin,
+		DefaultDownloadChunkSizeMBMax,
+		DefaultDownloadChunkSizeMB)
+	config.Download.BandwidthLimitKBps = getNumericValue(
+		config.Download.BandwidthLimitKBps,
+		DefaultDownloadBandwidthLimitKBpsMin,
+		DefaultDownloadBandwidthLimitKBpsMax,
+		DefaultDownloadBandwidthLimitKBps)
+	config.Download.CacheSizeMB = getNumericValue(
+		config.Download.CacheSizeMB,
+		DefaultDownloadCacheSizeMBMin,
+		DefaultDownloadCacheSizeMBMax,
+		DefaultDownloadCacheSizeMB)
+
+	// OutputStreaming config
+	config.OutputStreaming.Sink = getOutputStreamingSink(config.OutputStreaming.Sink)
+	config.OutputStreaming.MaxFileSizeMB = getNumericValue(
+		config.OutputStreaming.MaxFileSizeMB,
+		DefaultOutputStreamingMaxFileSizeMBMin,
+		DefaultOutputStreamingMaxFileSizeMBMax,
+		DefaultOutputStreamingMaxFileSizeMB)
+	config.OutputStreaming.MaxFiles = getNumericValue(
+		config.OutputStreaming.MaxFiles,
+		DefaultOutputStreamingMaxFilesMin,
+		DefaultOutputStreamingMaxFilesMax,
+		DefaultOutputStreamingMaxFiles)
+
+	// Redaction config
+	config.Redaction.Patterns = getRedactionPatterns(config.Redaction.Patterns)
+
+	// Inventory config
+	config.Inventory.HistorySize = getNumericValue(
+		config.Inventory.HistorySize,
+		DefaultInventoryHistorySizeMin,
+		DefaultInventoryHistorySizeMax,
+		DefaultInventoryHistorySize)
+
+	// MGS config
+	config.Mgs.SessionIdleTimeoutMinutes = getNumericValue(
+		config.Mgs.SessionIdleTimeoutMinutes,
+		DefaultSessionIdleTimeoutMinutesMin,
+		DefaultSessionIdleTimeoutMinutesMax,
+		DefaultSessionIdleTimeoutMinutes)
+	config.Mgs.SessionMaxDurationMinutes = getNumericValue(
+		config.Mgs.SessionMaxDurationMinutes,
+		DefaultSessionMaxDurationMinutesMin,
+		DefaultSessionMaxDurationMinutesMax,
+		DefaultSessionMaxDurationMinutes)
+	config.Mgs.SessionUserMappingFile = getSessionUserMappingFile(config.Mgs.SessionUserMappingFile)
+
+	// Port forwarding config
+	config.PortForwarding.AllowedHosts = getPortForwardingHosts(config.PortForwarding.AllowedHosts)
+	config.PortForwarding.AllowedUnixSockets = getPortForwardingUnixSockets(config.PortForwarding.AllowedUnixSockets)
 
 	// SSM config
 	config.Ssm.Endpoint = getStringValue(config.Ssm.Endpoint, "")
@@ -66,7 +146,88 @@ func parser(config *SsmagentConfig) {
 		config.Ssm.RunCommandLogsRetentionDurationHours,
 		DefaultStateOrchestrationLogsRetentionDurationHoursMin,
 		DefaultRunCommandLogsRetentionDurationHours)
+	config.Ssm.AssociationWindows = getAssociationWindows(config.Ssm.AssociationWindows)
+
+}
 
+// getAssociationWindows returns the windows with a known type and a positive duration
+func getAssociationWindows(windows []AssociationWindowCfg) []AssociationWindowCfg {
+	var validWindows []AssociationWindowCfg
+	for _, window := range windows {
+		switch {
+		case strings.EqualFold(window.Type, AssociationWindowTypeBlackout):
+			window.Type = AssociationWindowTypeBlackout
+		case strings.EqualFold(window.Type, AssociationWindowTypeMaintenance):
+			window.Type = AssociationWindowTypeMaintenance
+		default:
+			log.Printf("ignoring association window %v with unknown type %v", window.Name, window.Type)
+			continue
+		}
+		if window.DurationMinutes <= 0 || window.Schedule == "" {
+			log.Printf("ignoring association window %v without schedule or duration", window.Name)
+			continue
+		}
+		validWindows = append(validWindows, window)
+	}
+	return validWindows
+}
+
+// getOutputStreamingSink returns the known sink matching the config value, streaming is disabled for unknown sinks
+func getOutputStreamingSink(sink string) string {
+	for _, knownSink := range []string{OutputStreamingSinkFile, OutputStreamingSinkSyslog, OutputStreamingSinkSocket} {
+		if strings.EqualFold(sink, knownSink) {
+			return knownSink
+		}
+	}
+	if sink != "" {
+		log.Printf("disabling output streaming to unknown sink %v", sink)
+	}
+	return ""
+}
+
+// getRedactionPatterns returns the patterns which are valid regular expressions, an empty pattern would mask everything
+func getRedactionPatterns(patterns []string) []string {
+	validPatterns := []string{}
+	for _, pattern := range patterns {
+		if pattern == "" {
+			continue
+		}
+		if _, err := regexp.Compile(pattern); err != nil {
+			log.Printf("ignoring invalid redaction pattern %v: %v", pattern, err)
+			continue
+		}
+		validPatterns = append(validPatterns, pattern)
+	}
+	return validPatterns
+}
+
+// getPortForwardingHosts returns the non empty allowed hosts without surrounding spaces
+func getPortForwardingHosts(hosts []string) []string {
+	validHosts := []string{}
+	for _, host := range hosts {
+		if host = strings.TrimSpace(host); host == "" {
+			continue
+		}
+		validHosts = append(validHosts, host)
+	}
+	return validHosts
+}
+
+// getPortForwardingUnixSockets returns the allowed socket paths which are absolute and valid patterns
+func getPortForwardingUnixSockets(paths []string) []string {
+	validPaths := []string{}
+	for _, path := range paths {
+		if !filepath.IsAbs(path) {
+			log.Printf("ignoring unix socket path %v which is not absolute", path)
+			continue
+		}
+		if _, err := filepath.Match(path, path); err != nil {
+			log.Printf("ignoring invalid unix socket path %v: %v", path, err)
+			continue
+		}
+		validPaths = append(validPaths, filepath.Clean(path))
+	}
+	return validPaths
 }
 
 // getStringValue returns the default value if config is empty, else the config value
@@ -77,6 +238,19 @@ func getStringValue(configValue string, defaultValue string) string {
 	return configValue
 }
 
+// getSessionUserMappingFile returns the cleaned mapping file path, or empty when the path is not absolute
+func getSessionUserMappingFile(configValue string) string {
+	path := strings.TrimSpace(configValue)
+	if path == "" {
+		return ""
+	}
+	if !filepath.IsAbs(path) {
+		log.Printf("ignoring session user mapping file %s, the path must be absolute", path)
+		return ""
+	}
+	return filepath.Clean(path)
+}
+
 // getNumericValueAboveMin returns the default if config is below minimum
 func getNumericValueAboveMin(configValue int, minValue int, defaultValue int) int {
 	if configValue < minValue {
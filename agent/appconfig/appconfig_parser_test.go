@@ -92,3 +92,139 @@ func TestGetNumeric64Value(t *testing.T) {
 		assert.Equal(t, test.Output, output)
 	}
 }
+
+func TestParserDocumentTypes(t *testing.T) {
+	config := DefaultConfig()
+	config.Mds.DocumentTypes = map[string]DocumentTypeCfg{
+		"SendCommand": {Priority: 10, WorkersLimit: 2},
+		"Association": {Priority: -1, WorkersLimit: -3},
+	}
+	parser(&config)
+	assert.Equal(t, DocumentTypeCfg{Priority: 10, WorkersLimit: 2}, config.Mds.DocumentTypes["SendCommand"])
+	assert.Equal(t, DocumentTypeCfg{Priority: -1, WorkersLimit: 0}, config.Mds.DocumentTypes["Association"])
+	assert.Equal(t, DocumentStateStoreFile, config.Agent.DocumentStateStore)
+}
+
+func TestParserLogFormat(t *testing.T) {
+	config := DefaultConfig()
+	config.Agent.LogFormat = LogFormatJSON
+	parser(&config)
+	assert.Equal(t, LogFormatJSON, config.Agent.LogFormat)
+
+	config.Agent.LogFormat = "xml"
+	parser(&config)
+	assert.Equal(t, LogFormatText, config.Agent.LogFormat)
+}
+
+func TestParserAssociationWindows(t *testing.T) {
+	config := DefaultConfig()
+	config.Ssm.AssociationWindows = []AssociationWindowCfg{
+		{Name: "trading", Type: "blackout", Schedule: "cron(0 9 ? * MON-FRI *)", DurationMinutes: 480},
+		{Name: "nightly", Type: "Maintenance", Schedule: "cron(0 1 * * ? *)", DurationMinutes: 120},
+		{Name: "unknown", Type: "Freeze", Schedule: "cron(0 1 * * ? *)", DurationMinutes: 120},
+		{Name: "empty", Type: "Blackout", Schedule: "cron(0 1 * * ? *)"},
+	}
+	parser(&config)
+	assert.Equal(t, []AssociationWindowCfg{
+		{Name: "trading", Type: AssociationWindowTypeBlackout, Schedule: "cron(0 9 ? * MON-FRI *)", DurationMinutes: 480},
+		{Name: "nightly", Type: AssociationWindowTypeMaintenance, Schedule: "cron(0 1 * * ? *)", DurationMinutes: 120},
+	}, config.Ssm.AssociationWindows)
+}
+
+func TestParserDownload(t *testing.T) {
+	config := DefaultConfig()
+	config.Download = DownloadCfg{Concurrency: 64, ChunkSizeMB: 16, BandwidthLimitKBps: -1, CacheSizeMB: 0}
+	parser(&config)
+	assert.Equal(t, DownloadCfg{
+		Concurrency:        DefaultDownloadConcurrency,
+		ChunkSizeMB:        16,
+		BandwidthLimitKBps: DefaultDownloadBandwidthLimitKBps,
+		CacheSizeMB:        0,
+	}, config.Download)
+}
+
+func TestParserOutputStreaming(t *testing.T) {
+	config := DefaultConfig()
+	config.OutputStreaming = OutputStreamingCfg{Sink: "syslog", MaxFileSizeMB: 0, MaxFiles: 1000}
+	parser(&config)
+	assert.Equal(t, OutputStreamingCfg{
+		Sink:          OutputStreamingSinkSyslog,
+		MaxFileSizeMB: DefaultOutputStreamingMaxFileSizeMB,
+		MaxFiles:      DefaultOutputStreamingMaxFiles,
+	}, config.OutputStreaming)
+
+	config.OutputStreaming.Sink = "kafka"
+	parser(&config)
+	assert.Equal(t, "", config.OutputStreaming.Sink)
+}
+
+func TestParserRedaction(t *testing.T) {
+	config := DefaultConfig()
+	parser(&config)
+	assert.Equal(t, DefaultRedactionPatterns, config.Redaction.Patterns)
+
+	config.Redaction.Patterns = []string{"password=\\S+", "token=(", ""}
+	parser(&config)
+	assert.Equal(t, []string{"password=\\S+"}, config.Redaction.Patterns)
+}
+
+func TestParserInventory(t *testing.T) {
+	config := DefaultConfig()
+	parser(&config)
+	assert.Equal(t, DefaultInventoryHistorySize, config.Inventory.HistorySize)
+	assert.False(t, config.Inventory.ReportChanges)
+
+	config.Inventory.HistorySize = 0
+	parser(&config)
+	assert.Equal(t, 0, config.Inventory.HistorySize)
+
+	config.Inventory.HistorySize = 1000
+	parser(&config)
+	assert.Equal(t, DefaultInventoryHistorySize, config.Inventory.HistorySize)
+}
+
+func TestParserPortForwarding(t *testing.T) {
+	config := DefaultConfig()
+	parser(&config)
+	assert.Empty(t, config.PortForwarding.AllowedHosts)
+	assert.Empty(t, config.PortForwarding.AllowedUnixSockets)
+
+	config.PortForwarding.AllowedHosts = []string{" *.rds.amazonaws.com:5432 ", "", "10.0.0.0/16"}
+	config.PortForwarding.AllowedUnixSockets = []string{"/var/run/docker.sock", "relative.sock", "/tmp/[.sock", "/var/run/postgresql/*"}
+	parser(&config)
+	assert.Equal(t, []string{"*.rds.amazonaws.com:5432", "10.0.0.0/16"}, config.PortForwarding.AllowedHosts)
+	assert.Equal(t, []string{"/var/run/docker.sock", "/var/run/postgresql/*"}, config.PortForwarding.AllowedUnixSockets)
+}
+
+func TestParserSessionTimeouts(t *testing.T) {
+	config := DefaultConfig()
+	parser(&config)
+	assert.Equal(t, 0, config.Mgs.SessionIdleTimeoutMinutes)
+	assert.Equal(t, 0, config.Mgs.SessionMaxDurationMinutes)
+
+	config.Mgs.SessionIdleTimeoutMinutes = 20
+	config.Mgs.SessionMaxDurationMinutes = 480
+	parser(&config)
+	assert.Equal(t, 20, config.Mgs.SessionIdleTimeoutMinutes)
+	assert.Equal(t, 480, config.Mgs.SessionMaxDurationMinutes)
+
+	config.Mgs.SessionIdleTimeoutMinutes = -1
+	config.Mgs.SessionMaxDurationMinutes = 100000
+	parser(&config)
+	assert.Equal(t, DefaultSessionIdleTimeoutMinutes, config.Mgs.SessionIdleTimeoutMinutes)
+	assert.Equal(t, DefaultSessionMaxDurationMinutes, config.Mgs.SessionMaxDurationMinutes)
+}
+
+func TestParserSessionUserMappingFile(t *testing.T) {
+	config := DefaultConfig()
+	parser(&config)
+	assert.Equal(t, "", config.Mgs.SessionUserMappingFile)
+
+	config.Mgs.SessionUserMappingFile = " /etc/amazon/ssm/session-users.json "
+	parser(&config)
+	assert.Equal(t, "/etc/amazon/ssm/session-users.json", config.Mgs.SessionUserMappingFile)
+
+	config.Mgs.SessionUserMappingFile = "session-users.json"
+	parser(&config)
+	assert.Equal(t, "", config.Mgs.SessionUserMappingFile)
+}
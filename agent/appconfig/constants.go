@@ -31,6 +31,58 @@ const (
 	DefaultStopTimeoutMillisMin = 10000
 	DefaultStopTimeoutMillisMax = 1000000
 
+	// AssociationWindowTypeBlackout is the type of the windows associations must not run in
+	AssociationWindowTypeBlackout = "Blackout"
+	// AssociationWindowTypeMaintenance is the type of the windows associations are restricted to
+	AssociationWindowTypeMaintenance = "Maintenance"
+
+	// DefaultMetricsPort is the localhost port of the metrics endpoint
+	DefaultMetricsPort    = 9788
+	DefaultMetricsPortMin = 1024
+	DefaultMetricsPortMax = 65535
+
+	// DefaultDownloadConcurrency is the number of chunks of a file downloaded in parallel
+	DefaultDownloadConcurrency    = 4
+	DefaultDownloadConcurrencyMin = 1
+	DefaultDownloadConcurrencyMax = 16
+
+	// DefaultDownloadChunkSizeMB is the size of the chunks of a file downloaded in parallel
+	DefaultDownloadChunkSizeMB    = 8
+	DefaultDownloadChunkSizeMBMin = 1
+	DefaultDownloadChunkSizeMBMax = 1024
+
+	// DefaultDownloadBandwidthLimitKBps is the bandwidth limit of the downloads, 0 for no limit
+	DefaultDownloadBandwidthLimitKBps    = 0
+	DefaultDownloadBandwidthLimitKBpsMin = 0
+	DefaultDownloadBandwidthLimitKBpsMax = 10 * 1024 * 1024
+
+	// DefaultDownloadCacheSizeMB is the size the artifact cache is pruned to, 0 disables the cache
+	DefaultDownloadCacheSizeMB    = 0
+	DefaultDownloadCacheSizeMBMin = 0
+	DefaultDownloadCacheSizeMBMax = 1024 * 1024
+
+	// OutputStreamingSinkFile streams the output of the commands to a file rotated by size
+	OutputStreamingSinkFile = "File"
+	// OutputStreamingSinkSyslog streams the output of the commands to the local syslog
+	OutputStreamingSinkSyslog = "Syslog"
+	// OutputStreamingSinkSocket streams the output of the commands to a process listening on a Unix socket
+	OutputStreamingSinkSocket = "Socket"
+
+	// DefaultOutputStreamingMaxFileSizeMB is the size the streamed output file is rotated at
+	DefaultOutputStreamingMaxFileSizeMB    = 10
+	DefaultOutputStreamingMaxFileSizeMBMin = 1
+	DefaultOutputStreamingMaxFileSizeMBMax = 1024
+
+	// DefaultOutputStreamingMaxFiles is the number of rotated streamed output files kept
+	DefaultOutputStreamingMaxFiles    = 5
+	DefaultOutputStreamingMaxFilesMin = 1
+	DefaultOutputStreamingMaxFilesMax = 100
+
+	// DefaultInventoryHistorySize is the number of inventory snapshots kept per inventory type
+	DefaultInventoryHistorySize    = 5
+	DefaultInventoryHistorySizeMin = 0
+	DefaultInventoryHistorySizeMax = 100
+
 	// SSM defaults
 	DefaultSsmHealthFrequencyMinutes    = 5
 	DefaultSsmHealthFrequencyMinutesMin = 5
@@ -48,6 +100,18 @@ const (
 	DefaultLocationOfState       = "state"
 	DefaultLocationOfAssociation = "association"
 
+	// DocumentStateStoreFile persists document states as json files moved between the bookkeeping folders
+	DocumentStateStoreFile = "file"
+	// DocumentStateStoreEmbedded persists document states in a single embedded transactional database
+	DocumentStateStoreEmbedded = "embedded"
+	// DocumentStateStoreFileName is the name of the embedded database file under the state folder
+	DocumentStateStoreFileName = "documentstate.db"
+
+	// LogFormatText writes the log messages with the formats of the seelog configuration
+	LogFormatText = "text"
+	// LogFormatJSON writes every log message as one json object per line
+	LogFormatJSON = "json"
+
 	//aws-ssm-agent state and orchestration logs duration for Run Command and Association
 	DefaultAssociationLogsRetentionDurationHours           = 24  // 1 day default retention
 	DefaultRunCommandLogsRetentionDurationHours            = 336 // 14 days default retention
@@ -67,6 +131,7 @@ const (
 	FileInventoryRootDirName     = "file"
 	RoleInventoryRootDirName     = "role"
 	InventoryContentHashFileName = "contentHash"
+	InventoryHistoryDirName      = "history"
 
 	//aws-ssm-agent bookkeeping constants for failed sent replies
 	RepliesRootDirName = "replies"
@@ -167,6 +232,14 @@ const (
 	DefaultSessionWorkersLimit    = 1000
 	DefaultSessionWorkersLimitMin = 1
 
+	// Session idle timeout and maximum duration enforced by the agent, 0 disables them
+	DefaultSessionIdleTimeoutMinutes    = 0
+	DefaultSessionIdleTimeoutMinutesMin = 0
+	DefaultSessionIdleTimeoutMinutesMax = 1440
+	DefaultSessionMaxDurationMinutes    = 0
+	DefaultSessionMaxDurationMinutesMin = 0
+	DefaultSessionMaxDurationMinutesMax = 10080
+
 	// PluginNameStandardStream is the name for session manager standard stream plugin aka shell.
 	PluginNameStandardStream = "Standard_Stream"
 
@@ -194,6 +267,12 @@ var SupportedDocumentVersions = map[string]struct{}{
 	"2.2":   {},
 }
 
+// DefaultRedactionPatterns match AWS access key IDs and secret access keys assigned in the output of the commands
+var DefaultRedactionPatterns = []string{
+	`\b(AKIA|ASIA)[0-9A-Z]{16}\b`,
+	`(?i)aws_secret_access_key\s*[=:]\s*["']?[A-Za-z0-9/+=]{40}`,
+}
+
 // Session Manager Document versions that are supported by this Agent version.
 var SupportedSessionDocumentVersions = map[string]struct{}{
 	"1.0": {},
@@ -29,6 +29,12 @@ const (
 	// PackageLockRoot specifies the directory under which package lock files will reside
 	PackageLockRoot = DefaultProgramFolder + "locks/packages"
 
+	// ArtifactCacheRoot specifies the directory of the cache of downloaded artifacts
+	ArtifactCacheRoot = DefaultProgramFolder + "cache/artifacts"
+
+	// ExternalPluginsRoot specifies the directory where third-party worker plugins are installed
+	ExternalPluginsRoot = DefaultProgramFolder + "external-plugins"
+
 	// PackagePlatform is the platform name to use when looking for packages
 	PackagePlatform = "darwin"
 
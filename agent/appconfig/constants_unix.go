@@ -31,6 +31,12 @@ const (
 	// PackageLockRoot specifies the directory under which package lock files will reside
 	PackageLockRoot = "/var/lib/amazon/ssm/locks/packages"
 
+	// ArtifactCacheRoot specifies the directory of the cache of downloaded artifacts
+	ArtifactCacheRoot = "/var/lib/amazon/ssm/cache/artifacts"
+
+	// ExternalPluginsRoot specifies the directory where third-party worker plugins are installed
+	ExternalPluginsRoot = "/var/lib/amazon/ssm/external-plugins"
+
 	// PackagePlatform is the platform name to use when looking for packages
 	PackagePlatform = "linux"
 
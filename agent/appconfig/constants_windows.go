@@ -89,6 +89,12 @@ var PackageRoot string
 // PackageLockRoot specifies the directory under which package lock files will reside
 var PackageLockRoot string
 
+// ArtifactCacheRoot specifies the directory of the cache of downloaded artifacts
+var ArtifactCacheRoot string
+
+// ExternalPluginsRoot specifies the directory where third-party worker plugins are installed
+var ExternalPluginsRoot string
+
 // DaemonRoot specifies the directory where daemon registration information is stored
 var DaemonRoot string
 
@@ -174,6 +180,8 @@ func init() {
 	DefaultDataStorePath = filepath.Join(SSMDataPath, "InstanceData")
 	PackageRoot = filepath.Join(SSMDataPath, "Packages")
 	PackageLockRoot = filepath.Join(SSMDataPath, "Locks\\Packages")
+	ArtifactCacheRoot = filepath.Join(SSMDataPath, "Cache", "Artifacts")
+	ExternalPluginsRoot = filepath.Join(SSMDataPath, "ExternalPlugins")
 	DaemonRoot = filepath.Join(SSMDataPath, "Daemons")
 	LocalCommandRoot = filepath.Join(SSMDataPath, "LocalCommands")
 	LocalCommandRootSubmitted = filepath.Join(LocalCommandRoot, "Submitted")
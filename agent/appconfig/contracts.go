@@ -26,6 +26,15 @@ type MdsCfg struct {
 	CommandWorkersLimit int
 	StopTimeoutMillis   int64
 	CommandRetryLimit   int
+	DocumentTypes       map[string]DocumentTypeCfg
+}
+
+// DocumentTypeCfg represents how the documents of a type share the command workers
+type DocumentTypeCfg struct {
+	// Priority orders the documents waiting for a worker, higher priorities run first
+	Priority int
+	// WorkersLimit is the number of workers the document type can use at the same time, 0 means no limit
+	WorkersLimit int
 }
 
 // SsmCfg represents configuration for Simple system manager (SSM)
@@ -41,6 +50,19 @@ type SsmCfg struct {
 	AssociationLogsRetentionDurationHours int
 	RunCommandLogsRetentionDurationHours  int
 	SessionLogsRetentionDurationHours     int
+	AssociationWindows                    []AssociationWindowCfg
+}
+
+// AssociationWindowCfg represents a recurring period that restricts when associations run on the instance
+type AssociationWindowCfg struct {
+	// Name identifies the window in the reason reported for a deferred association
+	Name string
+	// Type is Blackout for periods where associations must not run, or Maintenance for the only periods they may run in
+	Type string
+	// Schedule is the cron expression of the start of the window, e.g. cron(0 9 ? * MON-FRI *) TZ=Europe/London
+	Schedule string
+	// DurationMinutes is how long the window lasts after each start
+	DurationMinutes int
 }
 
 // AgentInfo represents metadata for amazon-ssm-agent
@@ -51,6 +73,8 @@ type AgentInfo struct {
 	OrchestrationRootDir string
 	DownloadRootDir      string
 	ContainerMode        bool
+	DocumentStateStore   string
+	LogFormat            string
 }
 
 // MgsConfig represents configuration for Message Gateway service
@@ -59,6 +83,16 @@ type MgsConfig struct {
 	Endpoint            string
 	StopTimeoutMillis   int64
 	SessionWorkersLimit int
+	// AsciicastRecordingEnabled records the shell sessions in asciicast v2 format alongside the session log
+	AsciicastRecordingEnabled bool
+	// AsciicastRecordInput adds the input sent by the client to the asciicast recordings
+	AsciicastRecordInput bool
+	// SessionIdleTimeoutMinutes terminates the sessions without input for this duration, disabled when 0
+	SessionIdleTimeoutMinutes int
+	// SessionMaxDurationMinutes terminates the sessions lasting longer than this duration, disabled when 0
+	SessionMaxDurationMinutes int
+	// SessionUserMappingFile is the absolute path of the file mapping the session principals to local users, disabled when empty
+	SessionUserMappingFile string
 }
 
 // KmsConfig represents configuration for Key Management Service
@@ -86,17 +120,70 @@ type BirdwatcherCfg struct {
 	ForceEnable bool
 }
 
+// MetricsCfg represents configuration for the local metrics endpoint
+type MetricsCfg struct {
+	Enabled bool
+	Port    int
+}
+
+// DownloadCfg represents configuration for the downloads of documents, packages and agent updates
+type DownloadCfg struct {
+	Concurrency        int
+	ChunkSizeMB        int
+	BandwidthLimitKBps int
+	CacheSizeMB        int
+}
+
+// OutputStreamingCfg represents configuration for streaming the output of the commands to a local sink while they run
+type OutputStreamingCfg struct {
+	// Sink is File, Syslog or Socket, streaming is disabled when empty
+	Sink string
+	// Path is the path of the file or of the Unix socket
+	Path          string
+	MaxFileSizeMB int
+	MaxFiles      int
+}
+
+// RedactionCfg represents configuration for masking secrets in the output of the commands
+type RedactionCfg struct {
+	// Patterns are regular expressions of the text to mask, in addition to the resolved SecureString parameters
+	Patterns []string
+}
+
+// InventoryCfg represents configuration for the local history of the inventory collected on the instance
+type InventoryCfg struct {
+	// HistorySize is the number of snapshots kept per inventory type, the history is disabled when 0
+	HistorySize int
+	// ReportChanges uploads the changes between the last two snapshots as the AWS:InventoryChange type
+	ReportChanges bool
+}
+
+// PortForwardingCfg represents configuration for the destinations the port sessions may forward to, besides localhost
+type PortForwardingCfg struct {
+	// AllowedHosts are the remote hosts sessions may forward to, as host names, *.domain wildcards,
+	// IP addresses or CIDR blocks, each optionally followed by :port to restrict the port
+	AllowedHosts []string
+	// AllowedUnixSockets are the paths of the Unix domain sockets sessions may forward to, as filepath.Match patterns
+	AllowedUnixSockets []string
+}
+
 // SsmagentConfig stores agent configuration values.
 type SsmagentConfig struct {
-	Profile     CredentialProfile
-	Mds         MdsCfg
-	Ssm         SsmCfg
-	Mgs         MgsConfig
-	Agent       AgentInfo
-	Os          OsInfo
-	S3          S3Cfg
-	Birdwatcher BirdwatcherCfg
-	Kms         KmsConfig
+	Profile         CredentialProfile
+	Mds             MdsCfg
+	Ssm             SsmCfg
+	Mgs             MgsConfig
+	Agent           AgentInfo
+	Os              OsInfo
+	S3              S3Cfg
+	Birdwatcher     BirdwatcherCfg
+	Kms             KmsConfig
+	Metrics         MetricsCfg
+	Download        DownloadCfg
+	OutputStreaming OutputStreamingCfg
+	Redaction       RedactionCfg
+	Inventory       InventoryCfg
+	PortForwarding  PortForwardingCfg
 }
 
 // AppConstants represents some run time constant variable for various module.
@@ -40,7 +40,9 @@ type InstanceAssociation struct {
 // ParseExpression parses the expression with the given association
 func (newAssoc *InstanceAssociation) ParseExpression(log log.T) error {
 
-	parsedScheduleExpression, err := scheduleexpression.CreateScheduleExpression(log, *newAssoc.Association.ScheduleExpression)
+	// the jitter offset is stable for the association on this instance and spread across instances
+	jitterSeed := aws.StringValue(newAssoc.Association.InstanceId) + aws.StringValue(newAssoc.Association.AssociationId)
+	parsedScheduleExpression, err := scheduleexpression.CreateScheduleExpressionWithJitterSeed(log, *newAssoc.Association.ScheduleExpression, jitterSeed)
 
 	if err != nil {
 		return fmt.Errorf("Failed to parse schedule expression %v, %v", *newAssoc.Association.ScheduleExpression, err)
@@ -15,7 +15,6 @@
 package parser
 
 import (
-	"encoding/json"
 	"fmt"
 	"path"
 	"path/filepath"
@@ -48,7 +47,7 @@ func ParseDocumentForPayload(log log.T,
 
 	payload := &messageContracts.SendCommandPayload{}
 
-	if err = json.Unmarshal([]byte(*rawData.Document), &payload.DocumentContent); err != nil {
+	if _, err = docparser.LoadDocumentContent([]byte(*rawData.Document), &payload.DocumentContent); err != nil {
 		log.Debugf("Could not unmarshal parameters ", err)
 		return nil, fmt.Errorf("%v", ErrorMsg)
 	}
@@ -15,8 +15,11 @@
 package processor
 
 import (
+	"time"
+
 	"github.com/aws/amazon-ssm-agent/agent/association/model"
 	"github.com/aws/amazon-ssm-agent/agent/association/parser"
+	"github.com/aws/amazon-ssm-agent/agent/association/recorder"
 	"github.com/aws/amazon-ssm-agent/agent/context"
 	"github.com/aws/amazon-ssm-agent/agent/contracts"
 	"github.com/aws/amazon-ssm-agent/agent/framework/docmanager"
@@ -29,6 +32,7 @@ type AssocList []string
 
 var assocParser parserService = &assocParserService{}
 var assocBookkeeping bookkeepingService = &assocBookkeepingService{}
+var assocRecorder recorderService = &assocRecorderService{}
 
 //PluginAssociationInstances cached the number of associations attached to a specific type of plugin
 var pluginAssociationInstances = make(map[string]AssocList)
@@ -51,6 +55,24 @@ func (assocBookkeepingService) DeleteOldOrchestrationDirectories(log log.T, inst
 	docmanager.DeleteOldOrchestrationDirectories(log, instanceID, orchestrationRootDirName, retentionDurationHours, associationRetentionDurationHours)
 }
 
+// recorderService represents the dependency for recorder
+type recorderService interface {
+	RecordDeferredAssociation(instanceID string, associationID string, reason string, until time.Time) (isNew bool, err error)
+	ClearDeferredAssociation(instanceID string, associationID string) error
+}
+
+type assocRecorderService struct{}
+
+// RecordDeferredAssociation wraps recorder RecordDeferredAssociation
+func (assocRecorderService) RecordDeferredAssociation(instanceID string, associationID string, reason string, until time.Time) (isNew bool, err error) {
+	return recorder.RecordDeferredAssociation(instanceID, associationID, reason, until)
+}
+
+// ClearDeferredAssociation wraps recorder ClearDeferredAssociation
+func (assocRecorderService) ClearDeferredAssociation(instanceID string, associationID string) error {
+	return recorder.ClearDeferredAssociation(instanceID, associationID)
+}
+
 // system represents the dependency for platform
 type system interface {
 	InstanceID() (string, error)
@@ -59,6 +59,7 @@ type Processor struct {
 	proc               processor.Processor
 	resChan            chan contracts.DocumentResult
 	onBoot             bool
+	windows            assocScheduler.Windows
 }
 
 var lock sync.RWMutex
@@ -91,6 +92,7 @@ func NewAssociationProcessor(context context.T) *Processor {
 		agentInfo:          &agentInfo,
 		proc:               proc,
 		onBoot:             true,
+		windows:            assocScheduler.NewWindows(assocContext.Log(), config.Ssm.AssociationWindows),
 	}
 }
 
@@ -324,6 +326,12 @@ func (p *Processor) runScheduledAssociation(log log.T) {
 		return
 	}
 
+	if until, reason, deferred := p.windows.Deferral(time.Now().UTC()); deferred {
+		p.deferAssociation(log, scheduledAssociation, until, reason)
+		return
+	}
+	assocRecorder.ClearDeferredAssociation(*scheduledAssociation.Association.InstanceId, *scheduledAssociation.Association.AssociationId)
+
 	log.Debugf("Update association %v to pending ", *scheduledAssociation.Association.AssociationId)
 	// Update association status to pending
 	p.assocSvc.UpdateInstanceAssociationStatus(
@@ -394,6 +402,42 @@ func (p *Processor) runScheduledAssociation(log log.T) {
 	}
 }
 
+// deferAssociation postpones the association blocked by the association windows and reports the deferral reason
+func (p *Processor) deferAssociation(log log.T, assoc *model.InstanceAssociation, until time.Time, reason string) {
+	associationID := *assoc.Association.AssociationId
+	deferredUntil := "the next refresh"
+	if !until.IsZero() {
+		deferredUntil = times.ToIso8601UTC(until)
+	}
+	message := fmt.Sprintf(contracts.AssociationDeferredMessage, deferredUntil, reason)
+	log.Infof("Association %v: %v", associationID, message)
+
+	schedulemanager.DeferNextScheduledDate(log, associationID, until)
+	if nextScheduledDate := schedulemanager.LoadNextScheduledDate(log); nextScheduledDate != nil {
+		signal.ResetWaitTimerForNextScheduledAssociation(log, *nextScheduledDate)
+	}
+	// another association may be due and allowed to run
+	signal.ExecuteAssociation(log)
+
+	isNew, err := assocRecorder.RecordDeferredAssociation(*assoc.Association.InstanceId, associationID, reason, until)
+	if err != nil {
+		log.Errorf("Failed to record the deferral of association %v, %v", associationID, err)
+	}
+	if !isNew {
+		return
+	}
+	p.assocSvc.UpdateInstanceAssociationStatus(
+		log,
+		associationID,
+		*assoc.Association.Name,
+		*assoc.Association.InstanceId,
+		contracts.AssociationStatusPending,
+		contracts.AssociationErrorCodeNoError,
+		times.ToIso8601UTC(time.Now()),
+		message,
+		service.NoOutputUrl)
+}
+
 func isAssociationTimedOut(assoc *model.InstanceAssociation) bool {
 	if assoc.Association.LastExecutionDate == nil {
 		return false
@@ -16,11 +16,14 @@ package processor
 
 import (
 	"errors"
+	"fmt"
 	"testing"
 	"time"
 
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
 	"github.com/aws/amazon-ssm-agent/agent/association/model"
 	"github.com/aws/amazon-ssm-agent/agent/association/schedulemanager"
+	assocScheduler "github.com/aws/amazon-ssm-agent/agent/association/scheduler"
 	"github.com/aws/amazon-ssm-agent/agent/association/service"
 	complianceUploader "github.com/aws/amazon-ssm-agent/agent/compliance/uploader"
 	"github.com/aws/amazon-ssm-agent/agent/context"
@@ -227,6 +230,60 @@ func TestProcessAssociationSuccessful(t *testing.T) {
 	assert.True(t, complianceUploader.AssertNumberOfCalls(t, "UpdateAssociationCompliance", 0))
 }
 
+func TestRunScheduledAssociationDefersOutsideMaintenanceWindow(t *testing.T) {
+	processor := createProcessor()
+	svcMock := service.NewMockDefault()
+	processorMock := &processormock.MockedProcessor{}
+	recorderMock := &recorderMock{}
+	processor.assocSvc = svcMock
+	processor.proc = processorMock
+	assocRecorder = recorderMock
+	defer func() { assocRecorder = &assocRecorderService{} }()
+
+	// a maintenance window opening twelve hours from now is closed now
+	now := time.Now().UTC()
+	windowStart := time.Date(now.Year(), now.Month(), now.Day(), (now.Hour()+12)%24, 0, 0, 0, time.UTC)
+	if !windowStart.After(now) {
+		windowStart = windowStart.AddDate(0, 0, 1)
+	}
+	processor.windows = assocScheduler.NewWindows(log.NewMockLog(), []appconfig.AssociationWindowCfg{{
+		Name:            "nightly",
+		Type:            appconfig.AssociationWindowTypeMaintenance,
+		Schedule:        fmt.Sprintf("cron(0 %v * * ? *)", windowStart.Hour()),
+		DurationMinutes: 60,
+	}})
+
+	// the association has never run so it is due now
+	assocRawData := createAssociationRawData()
+	assocRawData[0].Association.LastExecutionDate = nil
+	schedulemanager.Refresh(log.NewMockLog(), assocRawData)
+	defer schedulemanager.Refresh(log.NewMockLog(), []*model.InstanceAssociation{})
+
+	svcMock.On(
+		"UpdateInstanceAssociationStatus",
+		mock.AnythingOfType("*log.Mock"),
+		"Id-Test",
+		"Test-Association",
+		"test-association-id",
+		mock.AnythingOfType("*ssm.InstanceAssociationExecutionResult"))
+	recorderMock.On("RecordDeferredAssociation", "test-association-id", "Id-Test", mock.AnythingOfType("string"), windowStart).Return(true, nil)
+
+	processor.runScheduledAssociation(log.NewMockLog())
+
+	// the association is reported as pending and rescheduled to the start of the window instead of running
+	recorderMock.AssertExpectations(t)
+	assert.Contains(t, recorderMock.Calls[0].Arguments.String(2), "outside of the maintenance windows")
+	svcMock.AssertNumberOfCalls(t, "UpdateInstanceAssociationStatus", 1)
+	processorMock.AssertNotCalled(t, "Submit", mock.Anything)
+	assert.Equal(t, windowStart, *schedulemanager.LoadNextScheduledDate(log.NewMockLog()))
+
+	// an association deferred again until the same time is not reported again
+	recorderMock.ExpectedCalls = nil
+	recorderMock.On("RecordDeferredAssociation", "test-association-id", "Id-Test", "reason", windowStart).Return(false, nil)
+	processor.deferAssociation(log.NewMockLog(), assocRawData[0], windowStart, "reason")
+	svcMock.AssertNumberOfCalls(t, "UpdateInstanceAssociationStatus", 1)
+}
+
 //make sure this operation is thread safe
 func TestUpdatePluginAssociationInstances(t *testing.T) {
 	testAssociationID := "testAssociationID"
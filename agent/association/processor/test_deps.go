@@ -15,6 +15,8 @@
 package processor
 
 import (
+	"time"
+
 	"github.com/aws/amazon-ssm-agent/agent/association/model"
 	"github.com/aws/amazon-ssm-agent/agent/context"
 	"github.com/aws/amazon-ssm-agent/agent/contracts"
@@ -67,3 +69,19 @@ func (m *parserMock) InitializeDocumentState(
 
 	return args.Get(0).(contracts.DocumentState), args.Error(1)
 }
+
+type recorderMock struct {
+	mock.Mock
+}
+
+// RecordDeferredAssociation mocks implementation for RecordDeferredAssociation
+func (m *recorderMock) RecordDeferredAssociation(instanceID string, associationID string, reason string, until time.Time) (bool, error) {
+	args := m.Called(instanceID, associationID, reason, until)
+	return args.Bool(0), args.Error(1)
+}
+
+// ClearDeferredAssociation mocks implementation for ClearDeferredAssociation
+func (m *recorderMock) ClearDeferredAssociation(instanceID string, associationID string) error {
+	args := m.Called(instanceID, associationID)
+	return args.Error(0)
+}
@@ -19,6 +19,7 @@ import (
 	"os"
 	"path"
 	"sync"
+	"time"
 
 	"github.com/aws/amazon-ssm-agent/agent/appconfig"
 	"github.com/aws/amazon-ssm-agent/agent/fileutil"
@@ -28,6 +29,21 @@ import (
 // AssociatedDocumentName represents file recording the name of the last associated document
 const AssociatedDocumentName = "InstanceDocument.json"
 
+// DeferredAssociationsName represents file recording the associations deferred by association windows
+const DeferredAssociationsName = "DeferredAssociations.json"
+
+// DeferredStatus is the status recorded for an association postponed by association windows
+const DeferredStatus = "Deferred"
+
+// DeferredAssociation records why and until when an association is postponed
+type DeferredAssociation struct {
+	AssociationID string
+	Status        string
+	Reason        string
+	DeferredUntil time.Time
+	RecordedAt    time.Time
+}
+
 // AssociatedDocument contains the association name
 type AssociatedDocument struct {
 	AssociationID string
@@ -35,6 +51,9 @@ type AssociatedDocument struct {
 
 var lock sync.RWMutex
 
+// dataStorePath is the folder holding the association records of each instance
+var dataStorePath = appconfig.DefaultDataStorePath
+
 // HasExecuted returns if given document has been executed
 func HasExecuted(instanceID string, associationName string) bool {
 	lock.Lock()
@@ -86,9 +105,81 @@ func UpdateAssociatedDocument(InstanceID string, associationName string) error {
 	return nil
 }
 
+// RecordDeferredAssociation persists the deferral of the association.
+// Returns false if the association was already recorded as deferred until the same time.
+func RecordDeferredAssociation(instanceID string, associationID string, reason string, until time.Time) (isNew bool, err error) {
+	lock.Lock()
+	defer lock.Unlock()
+
+	deferred := loadDeferredAssociations(instanceID)
+	if previous, found := deferred[associationID]; found && previous.DeferredUntil.Equal(until) {
+		return false, nil
+	}
+	deferred[associationID] = DeferredAssociation{
+		AssociationID: associationID,
+		Status:        DeferredStatus,
+		Reason:        reason,
+		DeferredUntil: until,
+		RecordedAt:    time.Now().UTC(),
+	}
+	return true, saveDeferredAssociations(instanceID, deferred)
+}
+
+// GetDeferredAssociation returns the recorded deferral of the association
+func GetDeferredAssociation(instanceID string, associationID string) (deferred DeferredAssociation, found bool) {
+	lock.Lock()
+	defer lock.Unlock()
+
+	deferred, found = loadDeferredAssociations(instanceID)[associationID]
+	return
+}
+
+// ClearDeferredAssociation removes the recorded deferral of the association once it runs
+func ClearDeferredAssociation(instanceID string, associationID string) error {
+	lock.Lock()
+	defer lock.Unlock()
+
+	deferred := loadDeferredAssociations(instanceID)
+	if _, found := deferred[associationID]; !found {
+		return nil
+	}
+	delete(deferred, associationID)
+	return saveDeferredAssociations(instanceID, deferred)
+}
+
+// loadDeferredAssociations reads the recorded deferrals, an unreadable file is treated as empty
+func loadDeferredAssociations(instanceID string) map[string]DeferredAssociation {
+	deferred := make(map[string]DeferredAssociation)
+	fileName := path.Join(getLocation(instanceID), DeferredAssociationsName)
+	if fileutil.Exists(fileName) {
+		jsonutil.UnmarshalFile(fileName, &deferred)
+	}
+	return deferred
+}
+
+// saveDeferredAssociations persists the recorded deferrals
+func saveDeferredAssociations(instanceID string, deferred map[string]DeferredAssociation) error {
+	location := getLocation(instanceID)
+	if !fileutil.Exists(location) {
+		if err := fileutil.MakeDirs(location); err != nil {
+			return fmt.Errorf("cannot make directory of %v because: %v", location, err)
+		}
+	}
+
+	content, err := jsonutil.Marshal(deferred)
+	if err != nil {
+		return err
+	}
+	_, err = fileutil.WriteIntoFileWithPermissions(
+		path.Join(location, DeferredAssociationsName),
+		content,
+		os.FileMode(int(appconfig.ReadWriteAccess)))
+	return err
+}
+
 // getLocation returns the full path for recording last associated document.
 func getLocation(instanceID string) string {
-	return path.Join(appconfig.DefaultDataStorePath,
+	return path.Join(dataStorePath,
 		instanceID,
 		appconfig.DefaultDocumentRootDirName,
 		appconfig.DefaultLocationOfAssociation)
@@ -96,7 +187,7 @@ func getLocation(instanceID string) string {
 
 // getFileName returns the full file name of the last associated document.
 func getFileName(instanceID string) string {
-	return path.Join(appconfig.DefaultDataStorePath,
+	return path.Join(dataStorePath,
 		instanceID,
 		appconfig.DefaultDocumentRootDirName,
 		appconfig.DefaultLocationOfAssociation,
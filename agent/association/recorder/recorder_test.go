@@ -0,0 +1,68 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package recorder
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/fileutil"
+	"github.com/aws/amazon-ssm-agent/agent/jsonutil"
+	"github.com/stretchr/testify/assert"
+)
+
+const testInstanceID = "i-1234567890"
+
+func TestRecordDeferredAssociation(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "recorder")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+	defer func(original string) { dataStorePath = original }(dataStorePath)
+	dataStorePath = tempDir
+
+	until := time.Date(2018, 7, 3, 1, 0, 0, 0, time.UTC)
+	isNew, err := RecordDeferredAssociation(testInstanceID, "assoc-1", "outside of the maintenance windows", until)
+	assert.NoError(t, err)
+	assert.True(t, isNew)
+
+	// the deferral is written to the association folder of the instance
+	fileName := path.Join(tempDir, testInstanceID, appconfig.DefaultDocumentRootDirName, appconfig.DefaultLocationOfAssociation, DeferredAssociationsName)
+	assert.True(t, fileutil.Exists(fileName))
+	var recorded map[string]DeferredAssociation
+	assert.NoError(t, jsonutil.UnmarshalFile(fileName, &recorded))
+	assert.Equal(t, "assoc-1", recorded["assoc-1"].AssociationID)
+	assert.Equal(t, DeferredStatus, recorded["assoc-1"].Status)
+	assert.Equal(t, "outside of the maintenance windows", recorded["assoc-1"].Reason)
+	assert.True(t, until.Equal(recorded["assoc-1"].DeferredUntil))
+
+	// the same deferral is only recorded once, a new date is recorded again
+	isNew, err = RecordDeferredAssociation(testInstanceID, "assoc-1", "outside of the maintenance windows", until)
+	assert.NoError(t, err)
+	assert.False(t, isNew)
+	isNew, err = RecordDeferredAssociation(testInstanceID, "assoc-1", "outside of the maintenance windows", until.AddDate(0, 0, 1))
+	assert.NoError(t, err)
+	assert.True(t, isNew)
+
+	deferred, found := GetDeferredAssociation(testInstanceID, "assoc-1")
+	assert.True(t, found)
+	assert.True(t, until.AddDate(0, 0, 1).Equal(deferred.DeferredUntil))
+
+	assert.NoError(t, ClearDeferredAssociation(testInstanceID, "assoc-1"))
+	_, found = GetDeferredAssociation(testInstanceID, "assoc-1")
+	assert.False(t, found)
+}
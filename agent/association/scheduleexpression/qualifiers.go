@@ -0,0 +1,119 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package scheduleexpression
+
+import (
+	"fmt"
+	"hash/fnv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/gorhill/cronexpr"
+)
+
+const (
+	qualifierTimeZone = "tz"
+	qualifierJitter   = "jitter"
+
+	// maxDSTRetries bounds the search for a scheduled time after a daylight saving time transition
+	maxDSTRetries = 4
+)
+
+// splitQualifiers separates the cron(...) or rate(...) part of the expression from the qualifiers following it.
+// Qualifiers must be separated from the expression by white spaces, otherwise the expression is returned as is.
+func splitQualifiers(scheduleExpression string) (expression string, qualifiers []string) {
+	end := strings.LastIndex(scheduleExpression, ")")
+	if end < 0 || end == len(scheduleExpression)-1 {
+		return scheduleExpression, nil
+	}
+	rest := scheduleExpression[end+1:]
+	if !unicode.IsSpace(rune(rest[0])) {
+		return scheduleExpression, nil
+	}
+	return scheduleExpression[:end+1], strings.Fields(rest)
+}
+
+// parseQualifiers returns the time zone and the jitter window set by the qualifiers
+func parseQualifiers(qualifiers []string) (location *time.Location, jitter time.Duration, err error) {
+	location = time.UTC
+	for _, qualifier := range qualifiers {
+		separator := strings.Index(qualifier, "=")
+		if separator <= 0 {
+			return nil, 0, fmt.Errorf("invalid qualifier %v", qualifier)
+		}
+		key, value := strings.ToLower(qualifier[:separator]), qualifier[separator+1:]
+		switch key {
+		case qualifierTimeZone:
+			if location, err = time.LoadLocation(value); err != nil {
+				return nil, 0, fmt.Errorf("unknown time zone %v: %v", value, err)
+			}
+		case qualifierJitter:
+			if jitter, err = time.ParseDuration(value); err != nil || jitter <= 0 {
+				return nil, 0, fmt.Errorf("invalid jitter %v, expected a positive duration such as 30m", value)
+			}
+		default:
+			return nil, 0, fmt.Errorf("unknown qualifier %v", qualifier)
+		}
+	}
+	return location, jitter, nil
+}
+
+// jitterOffset returns an offset within the jitter window, derived from the seed and the expression
+func jitterOffset(scheduleExpression string, jitterSeed string, jitter time.Duration) time.Duration {
+	hash := fnv.New64a()
+	hash.Write([]byte(jitterSeed))
+	hash.Write([]byte(scheduleExpression))
+	offset := time.Duration(hash.Sum64() % uint64(jitter))
+	if jitter > time.Second {
+		offset = offset.Truncate(time.Second)
+	}
+	return offset
+}
+
+// zonedCronExpression evaluates a cron expression in a time zone
+type zonedCronExpression struct {
+	expression *cronexpr.Expression
+	location   *time.Location
+}
+
+// Next returns the first scheduled time after fromTime.
+// When a daylight saving time transition repeats an hour, the scheduled times of that hour run once.
+// When it skips an hour, the scheduled times of that hour run after the clock change.
+func (c *zonedCronExpression) Next(fromTime time.Time) time.Time {
+	next := fromTime.In(c.location)
+	for i := 0; i < maxDSTRetries; i++ {
+		next = c.expression.Next(next)
+		// the wall clock time of a repeated hour can resolve to an occurrence that is not after fromTime
+		if next.IsZero() || next.After(fromTime) {
+			return next
+		}
+	}
+	return time.Time{}
+}
+
+// jitteredExpression delays all the scheduled times of an expression by the same offset
+type jitteredExpression struct {
+	expression ScheduleExpression
+	offset     time.Duration
+}
+
+// Next returns the first scheduled time after fromTime.
+func (j *jitteredExpression) Next(fromTime time.Time) time.Time {
+	next := j.expression.Next(fromTime.Add(-j.offset))
+	if next.IsZero() {
+		return next
+	}
+	return next.Add(j.offset)
+}
@@ -16,6 +16,7 @@
 package scheduleexpression
 
 import (
+	"errors"
 	"fmt"
 	"regexp"
 	"strings"
@@ -36,8 +37,40 @@ type ScheduleExpression interface {
 	Next(fromTime time.Time) time.Time
 }
 
+// CreateScheduleExpression parses a cron or rate expression, see CreateScheduleExpressionWithJitterSeed.
 func CreateScheduleExpression(log log.T, scheduleExpression string) (ScheduleExpression, error) {
+	return CreateScheduleExpressionWithJitterSeed(log, scheduleExpression, "")
+}
+
+// CreateScheduleExpressionWithJitterSeed parses a cron or rate expression optionally followed by qualifiers,
+// e.g. cron(0 2 * * ? *) TZ=Europe/London JITTER=30m
+//
+// Cron expressions have 6 fields, or 7 fields when they start with the seconds. They are evaluated in UTC
+// unless TZ names the time zone to use. JITTER delays every scheduled time by an offset within the given
+// duration, the offset is derived from the jitter seed so it is spread across instances but stable over time.
+func CreateScheduleExpressionWithJitterSeed(log log.T, scheduleExpression string, jitterSeed string) (ScheduleExpression, error) {
+	expression, qualifiers := splitQualifiers(scheduleExpression)
+	location, jitter, err := parseQualifiers(qualifiers)
+	if err != nil {
+		message := fmt.Sprintf("Error %v received while parsing schedule expression %v", err, scheduleExpression)
+		log.Error(message)
+		return nil, errors.New(message)
+	}
 
+	parsedExpression, err := createExpression(log, expression, location)
+	if err != nil {
+		return nil, err
+	}
+	if jitter > 0 {
+		parsedExpression = &jitteredExpression{
+			expression: parsedExpression,
+			offset:     jitterOffset(scheduleExpression, jitterSeed, jitter),
+		}
+	}
+	return parsedExpression, nil
+}
+
+func createExpression(log log.T, scheduleExpression string, location *time.Location) (ScheduleExpression, error) {
 	lowerCasedScheduledExpression := strings.ToLower(scheduleExpression)
 
 	if strings.HasPrefix(lowerCasedScheduledExpression, expressionTypeCron) {
@@ -50,7 +83,7 @@ func CreateScheduleExpression(log log.T, scheduleExpression string) (ScheduleExp
 		parsedCronExpression, err := cronexpr.Parse(cronExpression)
 
 		if err == nil {
-			return parsedCronExpression, nil
+			return &zonedCronExpression{expression: parsedCronExpression, location: location}, nil
 		} else {
 			message := fmt.Sprintf("Error %v received while parsing cron expression %v", err, scheduleExpression)
 			log.Error(message)
@@ -58,6 +91,10 @@ func CreateScheduleExpression(log log.T, scheduleExpression string) (ScheduleExp
 		}
 	}
 
+	if location != time.UTC {
+		return nil, fmt.Errorf("Time zone is only supported with cron expressions, expression %v", scheduleExpression)
+	}
+
 	if strings.HasPrefix(lowerCasedScheduledExpression, expressionTypeRate) {
 		parsedRateExpression, err := rateexpr.Parse(scheduleExpression)
 
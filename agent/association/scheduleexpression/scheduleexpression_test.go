@@ -15,6 +15,7 @@ package scheduleexpression
 
 import (
 	"testing"
+	"time"
 
 	"github.com/aws/amazon-ssm-agent/agent/log"
 	"github.com/stretchr/testify/assert"
@@ -131,3 +132,103 @@ func TestParseReturnsErrorWhenScheduleExpressionIsOfUnknownType(t *testing.T) {
 	assert.NotNil(t, err)
 	assert.Equal(t, "Unknown expression type detected in expression at(12:00)", err.Error())
 }
+
+func TestNextScheduledTime(t *testing.T) {
+	london, _ := time.LoadLocation("Europe/London")
+	testCases := []struct {
+		name       string
+		expression string
+		from       time.Time
+		expected   time.Time
+	}{
+		{
+			"cron is evaluated in UTC",
+			"cron(0 2 * * ? *)",
+			time.Date(2018, 7, 1, 3, 0, 0, 0, time.UTC),
+			time.Date(2018, 7, 2, 2, 0, 0, 0, time.UTC),
+		},
+		{
+			"cron with seconds",
+			"cron(30 0 2 * * ? *)",
+			time.Date(2018, 7, 1, 2, 0, 0, 0, time.UTC),
+			time.Date(2018, 7, 1, 2, 0, 30, 0, time.UTC),
+		},
+		{
+			"cron in a time zone during summer time",
+			"cron(0 2 * * ? *) TZ=Europe/London",
+			time.Date(2018, 7, 1, 3, 0, 0, 0, time.UTC),
+			time.Date(2018, 7, 2, 1, 0, 0, 0, time.UTC),
+		},
+		{
+			"cron in a time zone during winter time",
+			"cron(0 2 * * ? *) tz=Europe/London",
+			time.Date(2018, 12, 1, 3, 0, 0, 0, time.UTC),
+			time.Date(2018, 12, 2, 2, 0, 0, 0, time.UTC),
+		},
+		{
+			"cron keeps the local time across the start of summer time",
+			"cron(0 12 * * ? *) TZ=Europe/London",
+			time.Date(2018, 3, 24, 13, 0, 0, 0, london),
+			time.Date(2018, 3, 25, 12, 0, 0, 0, london),
+		},
+		{
+			"skipped local time runs after the clock change",
+			"cron(30 1 * * ? *) TZ=Europe/London",
+			time.Date(2018, 3, 25, 0, 59, 0, 0, time.UTC),
+			time.Date(2018, 3, 25, 1, 30, 0, 0, time.UTC),
+		},
+		{
+			"repeated local time runs once",
+			"cron(45 1 * * ? *) TZ=Europe/London",
+			time.Date(2018, 10, 28, 1, 45, 0, 0, time.UTC),
+			time.Date(2018, 10, 29, 1, 45, 0, 0, time.UTC),
+		},
+		{
+			"rate expression",
+			"rate(30 minutes)",
+			time.Date(2018, 7, 1, 3, 0, 0, 0, time.UTC),
+			time.Date(2018, 7, 1, 3, 30, 0, 0, time.UTC),
+		},
+	}
+
+	for _, testCase := range testCases {
+		parsedExpression, err := CreateScheduleExpression(log.NewMockLog(), testCase.expression)
+		assert.NoError(t, err, testCase.name)
+		next := parsedExpression.Next(testCase.from)
+		assert.True(t, testCase.expected.Equal(next), "%v: expected %v, got %v", testCase.name, testCase.expected, next)
+	}
+}
+
+func TestJitterIsStablePerSeedAndWithinWindow(t *testing.T) {
+	from := time.Date(2018, 7, 1, 3, 0, 0, 0, time.UTC)
+	scheduled := time.Date(2018, 7, 2, 2, 0, 0, 0, time.UTC)
+	offsets := make(map[time.Duration]bool)
+	for _, seed := range []string{"i-1assoc", "i-2assoc", "i-3assoc", "i-4assoc", "i-5assoc"} {
+		parsedExpression, err := CreateScheduleExpressionWithJitterSeed(log.NewMockLog(), "cron(0 2 * * ? *) JITTER=30m", seed)
+		assert.NoError(t, err)
+		next := parsedExpression.Next(from)
+		offset := next.Sub(scheduled)
+		assert.True(t, offset >= 0 && offset < 30*time.Minute, "offset %v out of the jitter window", offset)
+		offsets[offset] = true
+
+		// the next run after the jittered time keeps the same offset
+		again, _ := CreateScheduleExpressionWithJitterSeed(log.NewMockLog(), "cron(0 2 * * ? *) JITTER=30m", seed)
+		assert.Equal(t, next.Add(24*time.Hour), again.Next(next))
+	}
+	assert.True(t, len(offsets) > 1, "offsets are not spread across seeds")
+}
+
+func TestParseReturnsErrorForInvalidQualifiers(t *testing.T) {
+	for _, expression := range []string{
+		"cron(0 2 * * ? *) TZ=Mars/Olympus",
+		"cron(0 2 * * ? *) JITTER=soon",
+		"cron(0 2 * * ? *) JITTER=-5m",
+		"cron(0 2 * * ? *) REPEAT=2",
+		"cron(0 2 * * ? *) Europe/London",
+		"rate(30 minutes) TZ=Europe/London",
+	} {
+		parsedExpression, err := CreateScheduleExpression(log.NewMockLog(), expression)
+		assert.Nil(t, parsedExpression, expression)
+		assert.Error(t, err, expression)
+	}
+}
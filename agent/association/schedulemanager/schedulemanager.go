@@ -32,6 +32,9 @@ import (
 var associations = []*model.InstanceAssociation{}
 var lock sync.RWMutex
 
+// deferrals holds the time until which associations are deferred by association windows, by association id
+var deferrals = map[string]time.Time{}
+
 // Refresh refreshes cached associationRawData
 func Refresh(log log.T, assocs []*model.InstanceAssociation) {
 	lock.Lock()
@@ -48,9 +51,17 @@ func Refresh(log log.T, assocs []*model.InstanceAssociation) {
 		}
 	}
 
+	// forget the deferrals of the associations that are no longer scheduled
+	for associationID := range deferrals {
+		if !AssociationExists(associationID) {
+			delete(deferrals, associationID)
+		}
+	}
+
 	numberOfNewAssoc := 0
 	for _, assoc := range associations {
 		assoc.SetNextScheduledDate(log)
+		applyDeferral(log, assoc)
 		if assoc.NextScheduledDate != nil {
 			log.Infof("Scheduling association %v, setting next ScheduledDate to %v", *assoc.Association.AssociationId, times.ToIsoDashUTC(*assoc.NextScheduledDate))
 		}
@@ -131,6 +142,7 @@ func UpdateNextScheduledDate(log log.T, associationID string) {
 	for _, assoc := range associations {
 		if *assoc.Association.AssociationId == associationID {
 			assoc.Association.LastExecutionDate = aws.Time(time.Now().UTC())
+			delete(deferrals, associationID)
 			assoc.SetNextScheduledDate(log)
 			if assoc.NextScheduledDate != nil {
 				log.Infof("Scheduling association %v, setting next ScheduledDate to %v", *assoc.Association.AssociationId, times.ToIsoDashUTC(*assoc.NextScheduledDate))
@@ -140,6 +152,38 @@ func UpdateNextScheduledDate(log log.T, associationID string) {
 	}
 }
 
+// DeferNextScheduledDate postpones the given association until the given date.
+// The deferral is kept across refreshes until the association runs, a zero date unschedules
+// the association until the next refresh.
+func DeferNextScheduledDate(log log.T, associationID string, until time.Time) {
+	lock.Lock()
+	defer lock.Unlock()
+
+	for _, assoc := range associations {
+		if *assoc.Association.AssociationId == associationID {
+			if until.IsZero() {
+				delete(deferrals, associationID)
+				assoc.NextScheduledDate = nil
+				log.Infof("Association %v is deferred until the next refresh", associationID)
+				break
+			}
+			deferrals[associationID] = until
+			applyDeferral(log, assoc)
+			break
+		}
+	}
+}
+
+// applyDeferral moves the next scheduled date of the association to the end of its deferral
+func applyDeferral(log log.T, assoc *model.InstanceAssociation) {
+	until, found := deferrals[*assoc.Association.AssociationId]
+	if !found || assoc.NextScheduledDate == nil || !assoc.NextScheduledDate.Before(until) {
+		return
+	}
+	assoc.NextScheduledDate = aws.Time(until.UTC())
+	log.Infof("Association %v is deferred, setting next ScheduledDate to %v", *assoc.Association.AssociationId, times.ToIsoDashUTC(until))
+}
+
 // UpdateAssociationStatus sets detailed status for the given association
 func UpdateAssociationStatus(associationID string, status string) {
 	lock.Lock()
@@ -0,0 +1,76 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package schedulemanager
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/association/model"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/stretchr/testify/assert"
+)
+
+func createAssociation(associationID string) *model.InstanceAssociation {
+	return &model.InstanceAssociation{
+		Association: &ssm.InstanceAssociationSummary{
+			Name:               aws.String("Test-Association"),
+			AssociationId:      aws.String(associationID),
+			InstanceId:         aws.String("i-1234567890"),
+			LastExecutionDate:  aws.Time(time.Now().UTC()),
+			ScheduleExpression: aws.String("rate(30 minutes)"),
+		},
+	}
+}
+
+func TestDeferNextScheduledDate(t *testing.T) {
+	logger := log.NewMockLog()
+	defer Refresh(logger, []*model.InstanceAssociation{})
+
+	Refresh(logger, []*model.InstanceAssociation{createAssociation("assoc-1")})
+	scheduled := *LoadNextScheduledDate(logger)
+
+	// the association is rescheduled to the start of the next window
+	windowStart := time.Now().UTC().Add(12 * time.Hour).Truncate(time.Hour)
+	DeferNextScheduledDate(logger, "assoc-1", windowStart)
+	assert.Equal(t, windowStart, *LoadNextScheduledDate(logger))
+
+	// the deferral is kept when the associations are refreshed
+	Refresh(logger, []*model.InstanceAssociation{createAssociation("assoc-1")})
+	assert.Equal(t, windowStart, *LoadNextScheduledDate(logger))
+
+	// a deferral before the next scheduled date does not bring it forward
+	DeferNextScheduledDate(logger, "assoc-1", scheduled.Add(-time.Minute))
+	assert.Equal(t, windowStart, *LoadNextScheduledDate(logger))
+
+	// the association runs at its schedule again once it has run
+	UpdateNextScheduledDate(logger, "assoc-1")
+	assert.True(t, LoadNextScheduledDate(logger).Before(windowStart))
+}
+
+func TestDeferNextScheduledDateUntilRefresh(t *testing.T) {
+	logger := log.NewMockLog()
+	defer Refresh(logger, []*model.InstanceAssociation{})
+
+	Refresh(logger, []*model.InstanceAssociation{createAssociation("assoc-1")})
+
+	// without a window opening anymore the association is unscheduled until the next refresh
+	DeferNextScheduledDate(logger, "assoc-1", time.Time{})
+	assert.Nil(t, LoadNextScheduledDate(logger))
+
+	Refresh(logger, []*model.InstanceAssociation{createAssociation("assoc-1")})
+	assert.NotNil(t, LoadNextScheduledDate(logger))
+}
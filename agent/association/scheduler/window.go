@@ -0,0 +1,121 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package scheduler
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/association/scheduleexpression"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+)
+
+// maxDeferralSteps bounds the search for a time allowed by all the windows
+const maxDeferralSteps = 100
+
+// Window is a recurring period restricting when associations run
+type Window struct {
+	Name        string
+	Type        string
+	schedule    scheduleexpression.ScheduleExpression
+	duration    time.Duration
+	description string
+}
+
+// Windows is the set of blackout and maintenance windows of the instance
+type Windows []*Window
+
+// NewWindows creates the windows of the configuration, windows with an invalid schedule are skipped
+func NewWindows(log log.T, windowCfgs []appconfig.AssociationWindowCfg) Windows {
+	var windows Windows
+	for _, cfg := range windowCfgs {
+		schedule, err := scheduleexpression.CreateScheduleExpression(log, cfg.Schedule)
+		if err != nil {
+			log.Errorf("Ignoring association window %v, %v", cfg.Name, err)
+			continue
+		}
+		windows = append(windows, &Window{
+			Name:        cfg.Name,
+			Type:        cfg.Type,
+			schedule:    schedule,
+			duration:    time.Duration(cfg.DurationMinutes) * time.Minute,
+			description: fmt.Sprintf("%v window %v (%v for %v minutes)", cfg.Type, cfg.Name, cfg.Schedule, cfg.DurationMinutes),
+		})
+	}
+	return windows
+}
+
+// activeUntil returns the end of the window if the window is open at the given time
+func (w *Window) activeUntil(t time.Time) (end time.Time, active bool) {
+	// the window is open when it started less than its duration before t
+	start := w.schedule.Next(t.Add(-w.duration))
+	if start.IsZero() || start.After(t) {
+		return time.Time{}, false
+	}
+	return start.Add(w.duration), true
+}
+
+// Deferral returns whether an association is not allowed to run at the given time,
+// and if so the first time it is allowed to run and the reason of the deferral
+func (windows Windows) Deferral(t time.Time) (until time.Time, reason string, deferred bool) {
+	until = t
+	for i := 0; i < maxDeferralSteps; i++ {
+		next, stepReason, blocked := windows.step(until)
+		if !blocked {
+			return until, reason, deferred
+		}
+		if next.IsZero() {
+			// no maintenance window opens anymore
+			return time.Time{}, stepReason, true
+		}
+		if !deferred {
+			reason = stepReason
+		}
+		until, deferred = next, true
+	}
+	return until, reason, deferred
+}
+
+// step returns the time an association blocked at the given time can be reconsidered
+func (windows Windows) step(t time.Time) (next time.Time, reason string, blocked bool) {
+	// blackout windows defer to their end
+	for _, window := range windows {
+		if window.Type != appconfig.AssociationWindowTypeBlackout {
+			continue
+		}
+		if end, active := window.activeUntil(t); active && end.After(next) {
+			next, reason, blocked = end, "inside "+window.description, true
+		}
+	}
+	if blocked {
+		return next, reason, blocked
+	}
+
+	// maintenance windows, when there are any, defer to the first one that opens
+	hasMaintenanceWindows := false
+	for _, window := range windows {
+		if window.Type != appconfig.AssociationWindowTypeMaintenance {
+			continue
+		}
+		if _, active := window.activeUntil(t); active {
+			return time.Time{}, "", false
+		}
+		hasMaintenanceWindows = true
+		if start := window.schedule.Next(t); !start.IsZero() && (next.IsZero() || start.Before(next)) {
+			next, reason = start, "outside of the maintenance windows, next one is "+window.description
+		}
+	}
+	return next, reason, hasMaintenanceWindows
+}
@@ -0,0 +1,144 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	businessHours = appconfig.AssociationWindowCfg{
+		Name:            "business-hours",
+		Type:            appconfig.AssociationWindowTypeBlackout,
+		Schedule:        "cron(0 9 ? * MON-FRI *)",
+		DurationMinutes: 8 * 60,
+	}
+	lunchFreeze = appconfig.AssociationWindowCfg{
+		Name:            "lunch",
+		Type:            appconfig.AssociationWindowTypeBlackout,
+		Schedule:        "cron(0 16 ? * MON-FRI *)",
+		DurationMinutes: 120,
+	}
+	nightly = appconfig.AssociationWindowCfg{
+		Name:            "nightly",
+		Type:            appconfig.AssociationWindowTypeMaintenance,
+		Schedule:        "cron(0 1 * * ? *)",
+		DurationMinutes: 60,
+	}
+)
+
+func TestWindowsDeferral(t *testing.T) {
+	// 2018-07-02 is a Monday
+	testCases := []struct {
+		name     string
+		windows  []appconfig.AssociationWindowCfg
+		at       time.Time
+		deferred bool
+		until    time.Time
+	}{
+		{
+			"no windows",
+			nil,
+			time.Date(2018, 7, 2, 10, 0, 0, 0, time.UTC),
+			false,
+			time.Date(2018, 7, 2, 10, 0, 0, 0, time.UTC),
+		},
+		{
+			"inside a blackout window",
+			[]appconfig.AssociationWindowCfg{businessHours},
+			time.Date(2018, 7, 2, 10, 0, 0, 0, time.UTC),
+			true,
+			time.Date(2018, 7, 2, 17, 0, 0, 0, time.UTC),
+		},
+		{
+			"at the start of a blackout window",
+			[]appconfig.AssociationWindowCfg{businessHours},
+			time.Date(2018, 7, 2, 9, 0, 0, 0, time.UTC),
+			true,
+			time.Date(2018, 7, 2, 17, 0, 0, 0, time.UTC),
+		},
+		{
+			"at the end of a blackout window",
+			[]appconfig.AssociationWindowCfg{businessHours},
+			time.Date(2018, 7, 2, 17, 0, 0, 0, time.UTC),
+			false,
+			time.Date(2018, 7, 2, 17, 0, 0, 0, time.UTC),
+		},
+		{
+			"outside of a blackout window",
+			[]appconfig.AssociationWindowCfg{businessHours},
+			time.Date(2018, 7, 7, 10, 0, 0, 0, time.UTC),
+			false,
+			time.Date(2018, 7, 7, 10, 0, 0, 0, time.UTC),
+		},
+		{
+			"overlapping blackout windows",
+			[]appconfig.AssociationWindowCfg{businessHours, lunchFreeze},
+			time.Date(2018, 7, 2, 10, 0, 0, 0, time.UTC),
+			true,
+			time.Date(2018, 7, 2, 18, 0, 0, 0, time.UTC),
+		},
+		{
+			"outside of the maintenance windows",
+			[]appconfig.AssociationWindowCfg{nightly},
+			time.Date(2018, 7, 2, 10, 0, 0, 0, time.UTC),
+			true,
+			time.Date(2018, 7, 3, 1, 0, 0, 0, time.UTC),
+		},
+		{
+			"inside a maintenance window",
+			[]appconfig.AssociationWindowCfg{nightly},
+			time.Date(2018, 7, 2, 1, 30, 0, 0, time.UTC),
+			false,
+			time.Date(2018, 7, 2, 1, 30, 0, 0, time.UTC),
+		},
+	}
+
+	for _, testCase := range testCases {
+		windows := NewWindows(log.NewMockLog(), testCase.windows)
+		until, reason, deferred := windows.Deferral(testCase.at)
+		assert.Equal(t, testCase.deferred, deferred, testCase.name)
+		assert.True(t, testCase.until.Equal(until), "%v: expected %v, got %v", testCase.name, testCase.until, until)
+		assert.Equal(t, testCase.deferred, reason != "", testCase.name)
+	}
+}
+
+func TestWindowsDeferralSkipsMaintenanceWindowsInsideBlackouts(t *testing.T) {
+	earlyMaintenance := appconfig.AssociationWindowCfg{
+		Name:            "early",
+		Type:            appconfig.AssociationWindowTypeMaintenance,
+		Schedule:        "cron(0 8 * * ? *)",
+		DurationMinutes: 90,
+	}
+	windows := NewWindows(log.NewMockLog(), []appconfig.AssociationWindowCfg{earlyMaintenance, businessHours})
+
+	// the maintenance window of Monday opens at 8:00, runs are only allowed before the business hours
+	until, reason, deferred := windows.Deferral(time.Date(2018, 7, 2, 9, 10, 0, 0, time.UTC))
+	assert.True(t, deferred)
+	assert.Contains(t, reason, "business-hours")
+	assert.Equal(t, time.Date(2018, 7, 3, 8, 0, 0, 0, time.UTC), until)
+}
+
+func TestNewWindowsSkipsInvalidSchedules(t *testing.T) {
+	invalid := businessHours
+	invalid.Schedule = "cron(every day)"
+	windows := NewWindows(log.NewMockLog(), []appconfig.AssociationWindowCfg{invalid, nightly})
+	assert.Len(t, windows, 1)
+	assert.Equal(t, "nightly", windows[0].Name)
+}
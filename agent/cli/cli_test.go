@@ -68,3 +68,13 @@ func TestCliCmdExecSuccess(t *testing.T) {
 	assert.Equal(t, cliutil.CLI_SUCCESS_EXITCODE, exitCode, "command execution success return exit code 0")
 	cliCmdMock.AssertExpectations(t)
 }
+
+func TestParseCommandWithSubcommands(t *testing.T) {
+	args := []string{"ssm-cli", "--debug", "artifact-cache", "Prune", "--max-size-mb", "100"}
+	err, options, command, subcommands, parameters := parseCommand(args)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"debug"}, options)
+	assert.Equal(t, "artifact-cache", command)
+	assert.Equal(t, []string{"prune"}, subcommands)
+	assert.Equal(t, map[string][]string{"max-size-mb": {"100"}}, parameters)
+}
@@ -0,0 +1,169 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package clicommand contains the implementation of all commands for the ssm agent cli
+package clicommand
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/cli/cliutil"
+	"github.com/aws/amazon-ssm-agent/agent/fileutil/artifactcache"
+	"github.com/aws/amazon-ssm-agent/agent/jsonutil"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+)
+
+const (
+	artifactCacheCommand          = "artifact-cache"
+	artifactCacheListSubcommand   = "list"
+	artifactCachePruneSubcommand  = "prune"
+	artifactCacheMaxSizeParameter = "max-size-mb"
+)
+
+const artifactCacheCommandHelp = `NAME:
+    {{.ArtifactCacheCommandName}}
+
+DESCRIPTION
+    Inspects and prunes the cache of the artifacts downloaded by the agent for packages,
+    content and agent updates. Artifacts are keyed by their sha256 or sha512 hash.
+
+SYNOPSIS
+    {{.ArtifactCacheCommandName}} {{.ListSubcommand}}
+    {{.ArtifactCacheCommandName}} {{.PruneSubcommand}} [{{.MaxSizeFlag}} <value>]
+
+PARAMETERS
+    {{.MaxSizeFlag}} (int) Size to prune the cache to, the configured cache size by default.
+    The least recently used artifacts are removed first, the artifacts of the installed
+    package versions are never removed. Use 0 to remove all the other artifacts.
+
+EXAMPLES
+    This example lists the artifacts of the cache, the most recently used first.
+
+    Command:
+
+      {{.SsmCliName}} {{.ArtifactCacheCommandName}} {{.ListSubcommand}}
+
+    This example removes the artifacts which are not pinned by an installed package version.
+
+    Command:
+
+      {{.SsmCliName}} {{.ArtifactCacheCommandName}} {{.PruneSubcommand}} {{.MaxSizeFlag}} 0
+
+OUTPUT
+    The listed or removed artifacts in JSON format
+`
+
+type artifactCacheHelpParams struct {
+	SsmCliName               string
+	ArtifactCacheCommandName string
+	ListSubcommand           string
+	PruneSubcommand          string
+	MaxSizeFlag              string
+}
+
+func init() {
+	cliutil.Register(&ArtifactCacheCommand{})
+}
+
+// ArtifactCacheCommand inspects and prunes the artifact cache
+type ArtifactCacheCommand struct {
+	helpText string
+}
+
+// Execute validates and executes the artifact-cache cli command
+func (c *ArtifactCacheCommand) Execute(subcommands []string, parameters map[string][]string) (error, string) {
+	validation, subcommand, maxSizeMB := c.validateArtifactCacheCommandInput(subcommands, parameters)
+	// return validation errors if any were found
+	if len(validation) > 0 {
+		return errors.New(strings.Join(validation, "\n")), ""
+	}
+
+	cache := artifactcache.New(appconfig.ArtifactCacheRoot, 0)
+	var entries []artifactcache.Entry
+	var err error
+	if subcommand == artifactCacheListSubcommand {
+		entries, err = cache.List()
+	} else {
+		entries, err = cache.Prune(log.NewMockLog(), int64(maxSizeMB)*1024*1024)
+	}
+	if err != nil {
+		return err, ""
+	}
+	result, _ := jsonutil.MarshalIndent(entries)
+	return nil, result
+}
+
+// Help prints help for the artifact-cache cli command
+func (c *ArtifactCacheCommand) Help() string {
+	if len(c.helpText) == 0 {
+		t, _ := template.New("ArtifactCacheCommandHelp").Parse(artifactCacheCommandHelp)
+		params := artifactCacheHelpParams{
+			cliutil.SsmCliName,
+			artifactCacheCommand,
+			artifactCacheListSubcommand,
+			artifactCachePruneSubcommand,
+			cliutil.FormatFlag(artifactCacheMaxSizeParameter),
+		}
+		buf := new(bytes.Buffer)
+		t.Execute(buf, params)
+		c.helpText = buf.String()
+	}
+	return c.helpText
+}
+
+// Name is the command name used in the cli
+func (ArtifactCacheCommand) Name() string {
+	return artifactCacheCommand
+}
+
+// validateArtifactCacheCommandInput checks the subcommands and parameters for required values, format, and unsupported values
+func (ArtifactCacheCommand) validateArtifactCacheCommandInput(subcommands []string, parameters map[string][]string) (validation []string, subcommand string, maxSizeMB int) {
+	validation = make([]string, 0)
+
+	if len(subcommands) != 1 || (subcommands[0] != artifactCacheListSubcommand && subcommands[0] != artifactCachePruneSubcommand) {
+		validation = append(validation, fmt.Sprintf("%v expects one subcommand, %v or %v", artifactCacheCommand, artifactCacheListSubcommand, artifactCachePruneSubcommand))
+		return validation, "", 0
+	}
+	subcommand = subcommands[0]
+
+	if config, err := appconfig.Config(false); err == nil {
+		maxSizeMB = config.Download.CacheSizeMB
+	} else {
+		maxSizeMB = appconfig.DefaultDownloadCacheSizeMB
+	}
+	if values, exists := parameters[artifactCacheMaxSizeParameter]; exists {
+		if subcommand != artifactCachePruneSubcommand {
+			validation = append(validation, fmt.Sprintf("parameter %v is only supported by %v", cliutil.FormatFlag(artifactCacheMaxSizeParameter), artifactCachePruneSubcommand))
+		} else if len(values) != 1 {
+			validation = append(validation, fmt.Sprintf("expected 1 value for parameter %v", cliutil.FormatFlag(artifactCacheMaxSizeParameter)))
+		} else if size, err := strconv.Atoi(values[0]); err != nil || size < 0 {
+			validation = append(validation, fmt.Sprintf("invalid value %v for parameter %v, expected a size in MB", values[0], cliutil.FormatFlag(artifactCacheMaxSizeParameter)))
+		} else {
+			maxSizeMB = size
+		}
+	}
+
+	// look for unsupported parameters
+	for key := range parameters {
+		if key != artifactCacheMaxSizeParameter {
+			validation = append(validation, fmt.Sprintf("unknown parameter %v", cliutil.FormatFlag(key)))
+		}
+	}
+	return validation, subcommand, maxSizeMB
+}
@@ -25,6 +25,8 @@ import (
 	"github.com/aws/amazon-ssm-agent/agent/appconfig"
 	"github.com/aws/amazon-ssm-agent/agent/cli/cliutil"
 	"github.com/aws/amazon-ssm-agent/agent/fileutil"
+	"github.com/aws/amazon-ssm-agent/agent/framework/docmanager"
+	"github.com/aws/amazon-ssm-agent/agent/log"
 )
 
 const (
@@ -151,14 +153,21 @@ func (c *GetOfflineCommand) getCommandStatus(commandID string, showDetails bool)
 	if c.isCommandCompleted(commandID) {
 		return nil, "Complete"
 	}
-	if c.isCommandInState(appconfig.DefaultLocationOfPending, commandID) {
-		return nil, "Pending"
+	states := []struct {
+		folder string
+		status string
+	}{
+		{appconfig.DefaultLocationOfPending, "Pending"},
+		{appconfig.DefaultLocationOfCurrent, "In Progress"},
+		{appconfig.DefaultLocationOfCorrupt, "Corrupt"},
 	}
-	if c.isCommandInState(appconfig.DefaultLocationOfCurrent, commandID) {
-		return nil, "In Progress"
-	}
-	if c.isCommandInState(appconfig.DefaultLocationOfCorrupt, commandID) {
-		return nil, "Corrupt"
+	docMgr := newDocumentMgr()
+	for _, state := range states {
+		if found, err := c.isCommandInState(docMgr, state.folder, commandID); err != nil {
+			return fmt.Errorf("failed to read the status of command ID %v: %v", commandID, err), ""
+		} else if found {
+			return nil, state.status
+		}
 	}
 
 	// If not found, return error
@@ -169,21 +178,42 @@ func (c *GetOfflineCommand) isCommandCompleted(commandID string) bool {
 	return fileutil.Exists(path.Join(appconfig.LocalCommandRootCompleted, commandID))
 }
 
-func (GetOfflineCommand) isCommandInState(stateFolder string, commandID string) bool {
+// documentDataStorePath is the folder holding the document states of each instance
+var documentDataStorePath = appconfig.DefaultDataStorePath
+
+// newDocumentMgr returns the document state manager of the store selected by the agent config
+var newDocumentMgr = func() docmanager.DocumentMgr {
+	config, err := appconfig.Config(false)
+	if err != nil {
+		config = appconfig.DefaultConfig()
+	}
+	return docmanager.NewDocumentMgr(config, documentDataStorePath)
+}
+
+// isCommandInState looks for the document state of the command in stateFolder through the document state manager,
+// which keeps the states either in files or in the embedded store
+func (GetOfflineCommand) isCommandInState(docMgr docmanager.DocumentMgr, stateFolder string, commandID string) (bool, error) {
 	// TODO:MF: Find a way to get the current instanceID instead of trying all possible folders
-	dirs, _ := fileutil.GetDirectoryNames(appconfig.DefaultDataStorePath)
+	dirs, _ := fileutil.GetDirectoryNames(documentDataStorePath)
 
 	for _, dir := range dirs {
-		potentialFolder := path.Join(appconfig.DefaultDataStorePath,
+		stateDir := path.Join(documentDataStorePath,
 			dir,
 			appconfig.DefaultDocumentRootDirName,
-			appconfig.DefaultLocationOfState,
-			stateFolder,
-			commandID)
-		if fileutil.Exists(potentialFolder) {
-			return true
+			appconfig.DefaultLocationOfState)
+		if !fileutil.Exists(stateDir) {
+			continue
+		}
+		names, err := docMgr.GetDocumentStateNames(log.NewMockLog(), dir, stateFolder)
+		if err != nil {
+			return false, err
+		}
+		for _, name := range names {
+			if name == commandID {
+				return true, nil
+			}
 		}
 	}
 
-	return false
+	return false, nil
 }
@@ -0,0 +1,85 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package clicommand
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/fileutil"
+	"github.com/aws/amazon-ssm-agent/agent/framework/docmanager"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/stretchr/testify/assert"
+)
+
+const testGetCommandID = "01234567-890a-bcde-f012-34567890abcd"
+
+// useTestDocumentStore points the command at a temporary data store kept by docMgr
+func useTestDocumentStore(t *testing.T, newMgr func(dataStorePath string) docmanager.DocumentMgr) (docmanager.DocumentMgr, func()) {
+	dataStorePath, err := ioutil.TempDir("", "getcommand")
+	assert.NoError(t, err)
+	assert.NoError(t, fileutil.MakeDirs(path.Join(dataStorePath, "i-123", appconfig.DefaultDocumentRootDirName, appconfig.DefaultLocationOfState)))
+
+	docMgr := newMgr(dataStorePath)
+	originalPath, originalMgr := documentDataStorePath, newDocumentMgr
+	documentDataStorePath = dataStorePath
+	newDocumentMgr = func() docmanager.DocumentMgr { return docMgr }
+	return docMgr, func() {
+		documentDataStorePath, newDocumentMgr = originalPath, originalMgr
+		os.RemoveAll(dataStorePath)
+	}
+}
+
+func TestGetCommandStatusFromEmbeddedStore(t *testing.T) {
+	docMgr, cleanup := useTestDocumentStore(t, func(dataStorePath string) docmanager.DocumentMgr {
+		return docmanager.NewDocumentBoltMgr(dataStorePath, appconfig.DefaultDocumentRootDirName, appconfig.DefaultLocationOfState)
+	})
+	defer cleanup()
+	command := &GetOfflineCommand{}
+
+	err, _ := command.getCommandStatus(testGetCommandID, false)
+	assert.Error(t, err)
+
+	docMgr.PersistDocumentState(log.NewMockLog(), testGetCommandID, "i-123", appconfig.DefaultLocationOfPending, contracts.DocumentState{})
+	err, status := command.getCommandStatus(testGetCommandID, false)
+	assert.NoError(t, err)
+	assert.Equal(t, "Pending", status)
+
+	docMgr.MoveDocumentState(log.NewMockLog(), testGetCommandID, "i-123", appconfig.DefaultLocationOfPending, appconfig.DefaultLocationOfCurrent)
+	err, status = command.getCommandStatus(testGetCommandID, false)
+	assert.NoError(t, err)
+	assert.Equal(t, "In Progress", status)
+}
+
+func TestGetCommandStatusFromStateFiles(t *testing.T) {
+	docMgr, cleanup := useTestDocumentStore(t, func(dataStorePath string) docmanager.DocumentMgr {
+		return docmanager.NewDocumentFileMgr(dataStorePath, appconfig.DefaultDocumentRootDirName, appconfig.DefaultLocationOfState)
+	})
+	defer cleanup()
+	command := &GetOfflineCommand{}
+
+	err, _ := command.getCommandStatus(testGetCommandID, false)
+	assert.Error(t, err)
+
+	// the pending and current folders are missing, only the corrupt one exists
+	assert.NoError(t, fileutil.MakeDirs(path.Join(documentDataStorePath, "i-123", appconfig.DefaultDocumentRootDirName, appconfig.DefaultLocationOfState, appconfig.DefaultLocationOfCorrupt)))
+	docMgr.PersistDocumentState(log.NewMockLog(), testGetCommandID, "i-123", appconfig.DefaultLocationOfCorrupt, contracts.DocumentState{})
+	err, status := command.getCommandStatus(testGetCommandID, false)
+	assert.NoError(t, err)
+	assert.Equal(t, "Corrupt", status)
+}
@@ -0,0 +1,217 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package clicommand contains the implementation of all commands for the ssm agent cli
+package clicommand
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/cli/cliutil"
+	"github.com/aws/amazon-ssm-agent/agent/jsonutil"
+	"github.com/aws/amazon-ssm-agent/agent/platform"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/history"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/model"
+)
+
+const (
+	inventoryHistoryCommand           = "inventory-history"
+	inventoryHistoryListSubcommand    = "list"
+	inventoryHistoryChangesSubcommand = "changes"
+	inventoryHistoryTypeNameParameter = "type-name"
+)
+
+const inventoryHistoryCommandHelp = `NAME:
+    {{.InventoryHistoryCommandName}}
+
+DESCRIPTION
+    Inspects the local history of the inventory collected by the aws:softwareInventory plugin.
+    The agent keeps the last snapshots of each inventory type, as configured by Inventory.HistorySize.
+
+SYNOPSIS
+    {{.InventoryHistoryCommandName}} {{.ListSubcommand}}
+    {{.InventoryHistoryCommandName}} {{.ChangesSubcommand}} [{{.TypeNameFlag}} <value>]
+
+PARAMETERS
+    {{.TypeNameFlag}} (string) Inventory type to show the changes of, such as AWS:Application.
+    The changes of all the inventory types are shown by default.
+
+EXAMPLES
+    This example lists the inventory types and the capture times of their snapshots.
+
+    Command:
+
+      {{.SsmCliName}} {{.InventoryHistoryCommandName}} {{.ListSubcommand}}
+
+    This example shows the applications added, removed or changed between the last two snapshots.
+
+    Command:
+
+      {{.SsmCliName}} {{.InventoryHistoryCommandName}} {{.ChangesSubcommand}} {{.TypeNameFlag}} AWS:Application
+
+OUTPUT
+    The snapshots or the changes in JSON format
+`
+
+type inventoryHistoryHelpParams struct {
+	SsmCliName                  string
+	InventoryHistoryCommandName string
+	ListSubcommand              string
+	ChangesSubcommand           string
+	TypeNameFlag                string
+}
+
+// inventorySnapshotSummary describes a snapshot without its content
+type inventorySnapshotSummary struct {
+	CaptureTime string
+	ContentHash string
+}
+
+// inventoryHistorySummary describes the snapshots of an inventory type
+type inventoryHistorySummary struct {
+	TypeName  string
+	Snapshots []inventorySnapshotSummary
+}
+
+func init() {
+	cliutil.Register(&InventoryHistoryCommand{})
+}
+
+// InventoryHistoryCommand inspects the local inventory history
+type InventoryHistoryCommand struct {
+	helpText string
+}
+
+// Execute validates and executes the inventory-history cli command
+func (c *InventoryHistoryCommand) Execute(subcommands []string, parameters map[string][]string) (error, string) {
+	validation, subcommand, typeName := c.validateInventoryHistoryCommandInput(subcommands, parameters)
+	// return validation errors if any were found
+	if len(validation) > 0 {
+		return errors.New(strings.Join(validation, "\n")), ""
+	}
+
+	instanceID, err := platform.InstanceID()
+	if err != nil {
+		return err, ""
+	}
+	size := appconfig.DefaultInventoryHistorySize
+	if config, err := appconfig.Config(false); err == nil {
+		size = config.Inventory.HistorySize
+	}
+	store := history.NewStore(instanceID, size)
+
+	var histories []history.TypeHistory
+	if typeName != "" {
+		var typeHistory history.TypeHistory
+		if typeHistory, err = store.Get(typeName); err == nil {
+			histories = append(histories, typeHistory)
+		}
+	} else {
+		histories, err = store.List()
+	}
+	if err != nil {
+		return err, ""
+	}
+
+	var output interface{}
+	if subcommand == inventoryHistoryListSubcommand {
+		output = summarizeInventoryHistories(histories)
+	} else if output, err = inventoryHistoryChanges(histories); err != nil {
+		return err, ""
+	}
+	result, _ := jsonutil.MarshalIndent(output)
+	return nil, result
+}
+
+// summarizeInventoryHistories returns the histories without the content of the snapshots
+func summarizeInventoryHistories(histories []history.TypeHistory) []inventoryHistorySummary {
+	summaries := make([]inventoryHistorySummary, 0, len(histories))
+	for _, typeHistory := range histories {
+		summary := inventoryHistorySummary{TypeName: typeHistory.TypeName, Snapshots: []inventorySnapshotSummary{}}
+		for _, snapshot := range typeHistory.Snapshots {
+			summary.Snapshots = append(summary.Snapshots, inventorySnapshotSummary{CaptureTime: snapshot.CaptureTime, ContentHash: snapshot.ContentHash})
+		}
+		summaries = append(summaries, summary)
+	}
+	return summaries
+}
+
+// inventoryHistoryChanges returns the changes between the last two snapshots of each history
+func inventoryHistoryChanges(histories []history.TypeHistory) ([]model.InventoryChangeData, error) {
+	changes := []model.InventoryChangeData{}
+	for _, typeHistory := range histories {
+		typeChanges, err := typeHistory.LastChanges()
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, typeChanges...)
+	}
+	return changes, nil
+}
+
+// Help prints help for the inventory-history cli command
+func (c *InventoryHistoryCommand) Help() string {
+	if len(c.helpText) == 0 {
+		t, _ := template.New("InventoryHistoryCommandHelp").Parse(inventoryHistoryCommandHelp)
+		params := inventoryHistoryHelpParams{
+			cliutil.SsmCliName,
+			inventoryHistoryCommand,
+			inventoryHistoryListSubcommand,
+			inventoryHistoryChangesSubcommand,
+			cliutil.FormatFlag(inventoryHistoryTypeNameParameter),
+		}
+		buf := new(bytes.Buffer)
+		t.Execute(buf, params)
+		c.helpText = buf.String()
+	}
+	return c.helpText
+}
+
+// Name is the command name used in the cli
+func (InventoryHistoryCommand) Name() string {
+	return inventoryHistoryCommand
+}
+
+// validateInventoryHistoryCommandInput checks the subcommands and parameters for required values, format, and unsupported values
+func (InventoryHistoryCommand) validateInventoryHistoryCommandInput(subcommands []string, parameters map[string][]string) (validation []string, subcommand string, typeName string) {
+	validation = make([]string, 0)
+
+	if len(subcommands) != 1 || (subcommands[0] != inventoryHistoryListSubcommand && subcommands[0] != inventoryHistoryChangesSubcommand) {
+		validation = append(validation, fmt.Sprintf("%v expects one subcommand, %v or %v", inventoryHistoryCommand, inventoryHistoryListSubcommand, inventoryHistoryChangesSubcommand))
+		return validation, "", ""
+	}
+	subcommand = subcommands[0]
+
+	if values, exists := parameters[inventoryHistoryTypeNameParameter]; exists {
+		if subcommand != inventoryHistoryChangesSubcommand {
+			validation = append(validation, fmt.Sprintf("parameter %v is only supported by %v", cliutil.FormatFlag(inventoryHistoryTypeNameParameter), inventoryHistoryChangesSubcommand))
+		} else if len(values) != 1 || values[0] == "" {
+			validation = append(validation, fmt.Sprintf("expected 1 value for parameter %v", cliutil.FormatFlag(inventoryHistoryTypeNameParameter)))
+		} else {
+			typeName = values[0]
+		}
+	}
+
+	// look for unsupported parameters
+	for key := range parameters {
+		if key != inventoryHistoryTypeNameParameter {
+			validation = append(validation, fmt.Sprintf("unknown parameter %v", cliutil.FormatFlag(key)))
+		}
+	}
+	return validation, subcommand, typeName
+}
@@ -0,0 +1,336 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package clicommand contains the implementation of all commands for the ssm agent cli
+package clicommand
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/cli/cliutil"
+	"github.com/aws/amazon-ssm-agent/agent/context"
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/docparser"
+	"github.com/aws/amazon-ssm-agent/agent/fileutil"
+	"github.com/aws/amazon-ssm-agent/agent/fileutil/artifact"
+	"github.com/aws/amazon-ssm-agent/agent/framework/processor"
+	"github.com/aws/amazon-ssm-agent/agent/framework/processor/executer"
+	"github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/basicexecuter"
+	"github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/plugin"
+	"github.com/aws/amazon-ssm-agent/agent/framework/runpluginutil"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/platform"
+	"github.com/aws/amazon-ssm-agent/agent/times"
+	"github.com/twinj/uuid"
+)
+
+const (
+	runDocument           = "run-offline-document"
+	runDocumentContent    = "content"
+	runDocumentParameters = "parameters"
+	runDocumentInstanceID = "instance-id"
+)
+
+const runDocumentHelp = `NAME:
+    {{.RunDocumentName}}
+
+DESCRIPTION
+SYNOPSIS
+    {{.RunDocumentName}}
+    {{.ContentFlag}}
+    [{{.ParametersFlag}}]
+    [{{.InstanceIDFlag}}]
+
+PARAMETERS
+    {{.ContentFlag}} (string) JSON, path or URL to a JSON or YAML command document.
+    The document is parsed and executed by this process using the same document processor as the agent,
+    no message delivery service endpoint is required.
+
+    {{.ParametersFlag}} (string) JSON object with the values for the document parameters.
+    Parameters that are not provided take their default value from the document.
+
+    {{.InstanceIDFlag}} (string) Instance id to run the document as, required only when it cannot be
+    determined from the instance metadata or the registration of the managed instance.
+
+EXAMPLES
+    This example runs a local YAML document with one parameter.
+
+    Command:
+
+      {{.SsmCliName}} {{.RunDocumentName}} {{.ContentFlag}} file:///tmp/document.yaml {{.ParametersFlag}} '{"commands":["echo hello"]}'
+
+    Output:
+
+      Document 01234567-890a-bcde-f012-34567890abcd finished with status Success
+      ...
+
+OUTPUT
+    Status of the document followed by the result, standard output and standard error of every plugin
+`
+
+// runDocumentExecuterCreator creates the executer running the plugins of the document in this process
+var runDocumentExecuterCreator processor.ExecuterCreator = func(ctx context.T) executer.Executer {
+	return basicexecuter.NewBasicExecuter(ctx)
+}
+
+type runDocumentHelpParams struct {
+	SsmCliName      string
+	RunDocumentName string
+	ContentFlag     string
+	ParametersFlag  string
+	InstanceIDFlag  string
+}
+
+func init() {
+	cliutil.Register(&RunOfflineDocument{})
+}
+
+type RunOfflineDocument struct {
+	helpText string
+}
+
+// Execute validates and executes the run-offline-document cli command
+func (c *RunOfflineDocument) Execute(subcommands []string, parameters map[string][]string) (error, string) {
+	validation := c.validateRunDocumentInput(subcommands, parameters)
+	// return validation errors if any were found
+	if len(validation) > 0 {
+		return errors.New(strings.Join(validation, "\n")), ""
+	}
+
+	var params map[string]interface{}
+	if values, exists := parameters[runDocumentParameters]; exists {
+		if err := json.Unmarshal([]byte(values[0]), &params); err != nil {
+			return err, ""
+		}
+	}
+
+	if err := c.setInstanceID(parameters[runDocumentInstanceID]); err != nil {
+		return err, ""
+	}
+
+	if err, content := c.loadContent(parameters[runDocumentContent][0]); err != nil {
+		return err, ""
+	} else if err := (SendOfflineCommand{}).validateContent(contracts.DocumentContent(*content)); err != nil {
+		return err, ""
+	} else {
+		return c.runDocument(content, params)
+	}
+}
+
+// Help prints help for the run-offline-document cli command
+func (c *RunOfflineDocument) Help() string {
+	if len(c.helpText) == 0 {
+		t, _ := template.New("RunOfflineDocumentHelp").Parse(runDocumentHelp)
+		params := runDocumentHelpParams{
+			cliutil.SsmCliName,
+			runDocument,
+			cliutil.FormatFlag(runDocumentContent),
+			cliutil.FormatFlag(runDocumentParameters),
+			cliutil.FormatFlag(runDocumentInstanceID),
+		}
+		buf := new(bytes.Buffer)
+		t.Execute(buf, params)
+		c.helpText = buf.String()
+	}
+	return c.helpText
+}
+
+// Name is the command name used in the cli
+func (RunOfflineDocument) Name() string {
+	return runDocument
+}
+
+// validateRunDocumentInput checks the subcommands and parameters for required values, format, and unsupported values
+func (RunOfflineDocument) validateRunDocumentInput(subcommands []string, parameters map[string][]string) []string {
+	validation := make([]string, 0)
+	if subcommands != nil && len(subcommands) > 0 {
+		validation = append(validation, fmt.Sprintf("%v does not support subcommand %v", runDocument, subcommands), "")
+		return validation // invalid subcommand is an attempt to execute something that really isn't this command, so the rest of the validation is skipped in this case
+	}
+
+	// look for required parameters
+	if _, exists := parameters[runDocumentContent]; !exists {
+		validation = append(validation, fmt.Sprintf("%v is required", cliutil.FormatFlag(runDocumentContent)))
+	} else if len(parameters[runDocumentContent]) != 1 {
+		validation = append(validation, fmt.Sprintf("expected 1 value for parameter %v", cliutil.FormatFlag(runDocumentContent)))
+	} else {
+		// must be valid json, a valid URI or an existing file
+		val := parameters[runDocumentContent][0]
+		if !cliutil.ValidJson(val) && !cliutil.ValidUrl(val) && !fileutil.Exists(val) {
+			validation = append(validation, fmt.Sprintf("%v value must be valid json, a file path or a URL", cliutil.FormatFlag(runDocumentContent)))
+		}
+	}
+
+	// look for optional parameters
+	if values, exists := parameters[runDocumentParameters]; exists {
+		if len(values) != 1 {
+			validation = append(validation, fmt.Sprintf("expected 1 value for parameter %v", cliutil.FormatFlag(runDocumentParameters)))
+		} else if !cliutil.ValidJson(values[0]) {
+			validation = append(validation, fmt.Sprintf("%v value must be a valid json object", cliutil.FormatFlag(runDocumentParameters)))
+		}
+	}
+	if values, exists := parameters[runDocumentInstanceID]; exists && len(values) != 1 {
+		validation = append(validation, fmt.Sprintf("expected 1 value for parameter %v", cliutil.FormatFlag(runDocumentInstanceID)))
+	}
+
+	// look for unsupported parameters
+	for key := range parameters {
+		if key != runDocumentContent && key != runDocumentParameters && key != runDocumentInstanceID {
+			validation = append(validation, fmt.Sprintf("unknown parameter %v", cliutil.FormatFlag(key)))
+		}
+	}
+	return validation
+}
+
+// setInstanceID overrides the platform instance id if one was provided and makes sure an instance id is available
+func (RunOfflineDocument) setInstanceID(values []string) error {
+	if len(values) == 1 {
+		return platform.SetInstanceID(values[0])
+	}
+	if instanceID, err := platform.InstanceID(); err != nil || instanceID == "" {
+		return fmt.Errorf("unable to determine the instance id, please provide it with %v", cliutil.FormatFlag(runDocumentInstanceID))
+	}
+	return nil
+}
+
+// loadContent loads raw json, or json or yaml obtained from a file or URL into DocContent
+func (RunOfflineDocument) loadContent(rawContent string) (error, *docparser.DocContent) {
+	var data []byte
+	if cliutil.ValidJson(rawContent) {
+		data = []byte(rawContent)
+	} else {
+		var url = rawContent
+		if strings.HasPrefix(strings.ToLower(url), "file://") {
+			url = url[7:]
+		}
+		if fileutil.Exists(url) {
+			if content, err := ioutil.ReadFile(url); err != nil {
+				return err, nil
+			} else {
+				data = content
+			}
+		} else {
+			input := &artifact.DownloadInput{SourceURL: url}
+			output, err := artifact.Download(log.NewMockLog(), *input)
+			if err != nil {
+				return err, nil
+			}
+			if data, err = ioutil.ReadFile(output.LocalFilePath); err != nil {
+				return err, nil
+			}
+		}
+	}
+
+	content := &docparser.DocContent{}
+	_, err := docparser.LoadDocumentContent(data, content)
+	return err, content
+}
+
+// runDocument executes the document content through the engine processor and formats the plugin results
+func (RunOfflineDocument) runDocument(content *docparser.DocContent, params map[string]interface{}) (error, string) {
+	config, err := appconfig.Config(false)
+	if err != nil {
+		return err, ""
+	}
+	ctx := context.Default(log.DefaultLogger(), config).With("[" + runDocument + "]")
+	instanceID, _ := platform.InstanceID()
+
+	uuid.SwitchFormat(uuid.CleanHyphen)
+	commandID := uuid.NewV4().String()
+	documentInfo := contracts.DocumentInfo{
+		CommandID:      commandID,
+		DocumentID:     commandID,
+		InstanceID:     instanceID,
+		MessageID:      fmt.Sprintf("aws.ssm.%v.%v", commandID, instanceID),
+		RunID:          times.ToIsoDashUTC(times.DefaultClock.Now()),
+		CreatedDate:    times.ToIso8601UTC(time.Now()),
+		DocumentName:   runDocument,
+		DocumentStatus: contracts.ResultStatusInProgress,
+	}
+	orchestrationDir := filepath.Join(documentDataStorePath,
+		instanceID,
+		appconfig.DefaultDocumentRootDirName,
+		config.Agent.OrchestrationRootDir,
+		commandID)
+	parserInfo := docparser.DocumentParserInfo{
+		OrchestrationDir: orchestrationDir,
+		MessageId:        documentInfo.MessageID,
+		DocumentId:       documentInfo.DocumentID,
+	}
+	docState, err := docparser.InitializeDocState(ctx.Log(), contracts.SendCommandOffline, content, documentInfo, parserInfo, params)
+	if err != nil {
+		return err, ""
+	}
+
+	// plugins run in this process, the document worker binary does not need to be installed
+	runpluginutil.SSMPluginRegistry = plugin.RegisteredWorkerPlugins(ctx)
+	engine := processor.NewEngineProcessorWithExecuter(ctx, 1, 1, []contracts.DocumentType{contracts.SendCommandOffline}, runDocumentExecuterCreator, documentDataStorePath)
+	resChan, err := engine.Start()
+	if err != nil {
+		return err, ""
+	}
+	engine.Submit(docState)
+
+	var final *contracts.DocumentResult
+	for res := range resChan {
+		if res.LastPlugin == "" {
+			final = &res
+			break
+		}
+	}
+	// stop only after the final result was received, the processor closes the result channel once its workers are done
+	go func() {
+		for range resChan {
+		}
+	}()
+	engine.Stop(contracts.StopTypeSoftStop)
+
+	if final == nil {
+		return fmt.Errorf("document %v did not complete", commandID), ""
+	}
+	return nil, formatDocumentResult(commandID, orchestrationDir, docState.InstancePluginsInformation, final)
+}
+
+// formatDocumentResult prints the result of every plugin in document order
+func formatDocumentResult(commandID string, orchestrationDir string, plugins []contracts.PluginState, result *contracts.DocumentResult) string {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "Document %v finished with status %v\n", commandID, result.Status)
+	fmt.Fprintf(&buf, "Orchestration directory: %v\n", orchestrationDir)
+	for _, pluginState := range plugins {
+		pluginResult, found := result.PluginResults[pluginState.Id]
+		if !found {
+			fmt.Fprintf(&buf, "\nStep %v (%v): no result\n", pluginState.Id, pluginState.Name)
+			continue
+		}
+		fmt.Fprintf(&buf, "\nStep %v (%v): status %v, exit code %v\n", pluginState.Id, pluginState.Name, pluginResult.Status, pluginResult.Code)
+		if pluginResult.Error != "" {
+			fmt.Fprintf(&buf, "Error:\n%v\n", pluginResult.Error)
+		}
+		if pluginResult.StandardOutput != "" {
+			fmt.Fprintf(&buf, "Standard output:\n%v\n", pluginResult.StandardOutput)
+		}
+		if pluginResult.StandardError != "" {
+			fmt.Fprintf(&buf, "Standard error:\n%v\n", pluginResult.StandardError)
+		}
+	}
+	return strings.TrimRight(buf.String(), "\n")
+}
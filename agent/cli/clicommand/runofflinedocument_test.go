@@ -0,0 +1,177 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package clicommand
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/cli/cliutil"
+	"github.com/aws/amazon-ssm-agent/agent/context"
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/fileutil"
+	"github.com/aws/amazon-ssm-agent/agent/framework/processor/executer"
+	executermocks "github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/mock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+const (
+	testRunDocumentInstanceID = "i-runofflinedocumenttest"
+	testRunDocumentContent    = `{
+		"schemaVersion": "2.2",
+		"parameters": {"commands": {"type": "StringList", "default": ["echo default"]}},
+		"mainSteps": [{"action": "aws:runShellScript", "name": "run", "inputs": {"runCommand": "{{ commands }}"}}]
+	}`
+)
+
+func TestRunOfflineDocumentValidation(t *testing.T) {
+	documentPath := filepath.Join(os.TempDir(), "runofflinedocument.json")
+	assert.NoError(t, ioutil.WriteFile(documentPath, []byte(testRunDocumentContent), 0600))
+	defer os.Remove(documentPath)
+
+	testCases := []struct {
+		name        string
+		subcommands []string
+		parameters  map[string][]string
+		validation  []string
+	}{
+		{"json content", nil, map[string][]string{runDocumentContent: {testRunDocumentContent}}, []string{}},
+		{"file content with all parameters", nil, map[string][]string{
+			runDocumentContent:    {documentPath},
+			runDocumentParameters: {`{"commands": ["echo hello"]}`},
+			runDocumentInstanceID: {testRunDocumentInstanceID},
+		}, []string{}},
+		{"url content", nil, map[string][]string{runDocumentContent: {"https://example.com/document.yaml"}}, []string{}},
+		{"subcommand", []string{"now"}, map[string][]string{runDocumentContent: {testRunDocumentContent}},
+			[]string{runDocument + " does not support subcommand [now]", ""}},
+		{"missing content", nil, map[string][]string{}, []string{"--content is required"}},
+		{"several contents", nil, map[string][]string{runDocumentContent: {testRunDocumentContent, testRunDocumentContent}},
+			[]string{"expected 1 value for parameter --content"}},
+		{"invalid content", nil, map[string][]string{runDocumentContent: {"missing-document.json"}},
+			[]string{"--content value must be valid json, a file path or a URL"}},
+		{"invalid parameters", nil, map[string][]string{runDocumentContent: {testRunDocumentContent}, runDocumentParameters: {"commands"}},
+			[]string{"--parameters value must be a valid json object"}},
+		{"several parameters", nil, map[string][]string{runDocumentContent: {testRunDocumentContent}, runDocumentParameters: {"{}", "{}"}},
+			[]string{"expected 1 value for parameter --parameters"}},
+		{"several instance ids", nil, map[string][]string{runDocumentContent: {testRunDocumentContent}, runDocumentInstanceID: {"i-1", "i-2"}},
+			[]string{"expected 1 value for parameter --instance-id"}},
+		{"unknown parameter", nil, map[string][]string{runDocumentContent: {testRunDocumentContent}, "timeout": {"10"}},
+			[]string{"unknown parameter --timeout"}},
+	}
+	for _, testCase := range testCases {
+		validation := RunOfflineDocument{}.validateRunDocumentInput(testCase.subcommands, testCase.parameters)
+		assert.Equal(t, testCase.validation, validation, testCase.name)
+	}
+}
+
+func TestRunOfflineDocumentRejectsInvalidInput(t *testing.T) {
+	command := &RunOfflineDocument{}
+
+	err, output := command.Execute(nil, map[string][]string{})
+	assert.EqualError(t, err, "--content is required")
+	assert.Empty(t, output)
+
+	err, _ = command.Execute(nil, map[string][]string{
+		runDocumentContent:    {`{"schemaVersion": "2.2", "mainSteps": []}`},
+		runDocumentInstanceID: {testRunDocumentInstanceID},
+	})
+	assert.EqualError(t, err, "mainSteps cannot be empty")
+
+	err, _ = command.Execute(nil, map[string][]string{
+		runDocumentContent:    {`{"schemaVersion": "3.0", "mainSteps": []}`},
+		runDocumentInstanceID: {testRunDocumentInstanceID},
+	})
+	assert.EqualError(t, err, "unsupported schema version 3.0")
+}
+
+func TestRunOfflineDocumentHelp(t *testing.T) {
+	command := &RunOfflineDocument{}
+
+	assert.Equal(t, runDocument, command.Name())
+	assert.Contains(t, command.Help(), cliutil.FormatFlag(runDocumentContent))
+	assert.Contains(t, command.Help(), cliutil.FormatFlag(runDocumentInstanceID))
+}
+
+func TestRunOfflineDocumentRunsDocumentWithExecuter(t *testing.T) {
+	dataStorePath, err := ioutil.TempDir("", "runofflinedocument")
+	assert.NoError(t, err)
+	originalPath, originalCreator := documentDataStorePath, runDocumentExecuterCreator
+	defer func() {
+		documentDataStorePath, runDocumentExecuterCreator = originalPath, originalCreator
+		os.RemoveAll(dataStorePath)
+	}()
+	documentDataStorePath = dataStorePath
+	stateDir := filepath.Join(dataStorePath, testRunDocumentInstanceID, appconfig.DefaultDocumentRootDirName, appconfig.DefaultLocationOfState)
+	for _, folder := range []string{appconfig.DefaultLocationOfPending, appconfig.DefaultLocationOfCurrent} {
+		assert.NoError(t, fileutil.MakeDirs(filepath.Join(stateDir, folder)))
+	}
+
+	executerMock := executermocks.NewMockExecuter()
+	results := make(chan contracts.DocumentResult, 2)
+	results <- contracts.DocumentResult{
+		LastPlugin: "run",
+		Status:     contracts.ResultStatusInProgress,
+		PluginResults: map[string]*contracts.PluginResult{
+			"run": {Status: contracts.ResultStatusSuccess},
+		},
+	}
+	results <- contracts.DocumentResult{
+		Status: contracts.ResultStatusFailed,
+		PluginResults: map[string]*contracts.PluginResult{
+			"run": {Status: contracts.ResultStatusFailed, Code: 1, StandardOutput: "hello", StandardError: "failed", Error: "exit status 1"},
+		},
+	}
+	close(results)
+	stateInDataStore := false
+	executerMock.On("Run", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		documentID := args.Get(1).(executer.DocumentStore).Load().DocumentInformation.DocumentID
+		stateInDataStore = fileutil.Exists(filepath.Join(stateDir, appconfig.DefaultLocationOfCurrent, documentID))
+	}).Return(results)
+	runDocumentExecuterCreator = func(ctx context.T) executer.Executer {
+		return executerMock
+	}
+
+	err, output := (&RunOfflineDocument{}).Execute(nil, map[string][]string{
+		runDocumentContent:    {testRunDocumentContent},
+		runDocumentParameters: {`{"commands": ["echo hello"]}`},
+		runDocumentInstanceID: {testRunDocumentInstanceID},
+	})
+
+	assert.NoError(t, err)
+	executerMock.AssertExpectations(t)
+	docState := executerMock.Calls[0].Arguments.Get(1).(executer.DocumentStore).Load()
+	assert.Equal(t, contracts.SendCommandOffline, docState.DocumentType)
+	assert.Equal(t, testRunDocumentInstanceID, docState.DocumentInformation.InstanceID)
+	assert.Equal(t, 1, len(docState.InstancePluginsInformation))
+	assert.Equal(t, []interface{}{"echo hello"}, docState.InstancePluginsInformation[0].Configuration.Properties.(map[string]interface{})["runCommand"])
+
+	lines := strings.Split(output, "\n")
+	assert.True(t, strings.HasPrefix(lines[0], "Document "+docState.DocumentInformation.CommandID+" finished with status Failed"), output)
+	// the orchestration directory of the document holds the one of each plugin
+	orchestrationDir := filepath.Dir(docState.InstancePluginsInformation[0].Configuration.OrchestrationDirectory)
+	assert.Equal(t, "Orchestration directory: "+orchestrationDir, lines[1])
+	assert.True(t, strings.HasPrefix(orchestrationDir, filepath.Join(dataStorePath, testRunDocumentInstanceID)), orchestrationDir)
+	// the processor keeps the state of the document in the same data store
+	assert.True(t, stateInDataStore)
+	assert.False(t, fileutil.Exists(filepath.Join(appconfig.DefaultDataStorePath, testRunDocumentInstanceID)))
+	assert.Contains(t, output, "Step run (aws:runShellScript): status Failed, exit code 1")
+	assert.Contains(t, output, "Error:\nexit status 1")
+	assert.Contains(t, output, "Standard output:\nhello")
+	assert.Contains(t, output, "Standard error:\nfailed")
+}
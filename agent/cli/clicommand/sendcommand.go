@@ -27,6 +27,7 @@ import (
 	"github.com/aws/amazon-ssm-agent/agent/appconfig"
 	"github.com/aws/amazon-ssm-agent/agent/cli/cliutil"
 	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/docparser"
 	"github.com/aws/amazon-ssm-agent/agent/fileutil"
 	"github.com/aws/amazon-ssm-agent/agent/fileutil/artifact"
 	"github.com/aws/amazon-ssm-agent/agent/jsonutil"
@@ -48,7 +49,7 @@ SYNOPSIS
     {{.ContentFlag}}
 
 PARAMETERS
-    {{.ContentFlag}} (string) JSON or URL to command document.
+    {{.ContentFlag}} (string) JSON or URL to a JSON or YAML command document.
     A valid command document is a configuration document with all parameters filled in.
     For information about writing a configuration document, see Configuration Document in the SSM API Reference.
 
@@ -149,7 +150,7 @@ func (SendOfflineCommand) validateSendCommandInput(subcommands []string, paramet
 	return validation
 }
 
-// loadContent loads raw json or json obtained from a URL into DocumentContent
+// loadContent loads raw json or json or yaml obtained from a URL into DocumentContent
 func (SendOfflineCommand) loadContent(rawContent string) (error, contracts.DocumentContent) {
 	var content contracts.DocumentContent
 	if cliutil.ValidJson(rawContent) {
@@ -166,7 +167,7 @@ func (SendOfflineCommand) loadContent(rawContent string) (error, contracts.Docum
 	if output, err := artifact.Download(log.NewMockLog(), *input); err != nil {
 		return err, content
 	} else {
-		err = jsonutil.UnmarshalFile(output.LocalFilePath, &content)
+		_, err = docparser.LoadDocumentContentFile(output.LocalFilePath, &content)
 		// TODO:MF: ideally we'd delete the file if we downloaded it - but it might've been a local file and we don't have a good way to tell
 		return err, content
 	}
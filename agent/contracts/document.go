@@ -16,6 +16,8 @@ package contracts
 
 import (
 	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/context"
 )
 
 // DocumentType defines the type of document persists locally.
@@ -75,6 +77,8 @@ type DocumentInfo struct {
 	ProcInfo        OSProcInfo
 	ClientId        string
 	RunAsUser       string
+	PrincipalArn    string
+	PrincipalTags   map[string]string
 }
 
 //CloudWatchConfiguration represents information relevant to command output in cloudWatch
@@ -90,6 +94,8 @@ type IOConfiguration struct {
 	OutputS3BucketName     string
 	OutputS3KeyPrefix      string
 	CloudWatchConfig       CloudWatchConfiguration
+	// CommandID identifies the command or association run in the streamed output, streaming is disabled when empty
+	CommandID string
 }
 
 // DocumentState represents information relevant to a command that gets executed by agent
@@ -112,6 +118,34 @@ func (c *DocumentState) IsAssociation() bool {
 	return c.DocumentType == Association
 }
 
+// LogContext returns the context whose log messages carry the ids and the name of the document
+func (c *DocumentState) LogContext(ctx context.T) context.T {
+	info := c.DocumentInformation
+	switch {
+	case c.DocumentType == StartSession || c.DocumentType == TerminateSession:
+		ctx = withLogContext(ctx, "sessionId", info.DocumentID)
+	case c.IsAssociation():
+		ctx = withLogContext(ctx, "associationId", info.AssociationID)
+	default:
+		ctx = withLogContext(ctx, "commandId", info.CommandID)
+	}
+	return withLogContext(ctx, "documentName", info.DocumentName)
+}
+
+// withLogContext adds the [key=value] context unless the value is empty or the context already has it
+func withLogContext(ctx context.T, key string, value string) context.T {
+	if value == "" {
+		return ctx
+	}
+	logContext := "[" + key + "=" + value + "]"
+	for _, current := range ctx.CurrentContext() {
+		if current == logContext {
+			return ctx
+		}
+	}
+	return ctx.With(logContext)
+}
+
 // CancelCommandInfo represents information relevant to a cancel-command that agent receives
 // TODO  This might be revisited when Agent-cli is written to list previously executed commands
 type CancelCommandInfo struct {
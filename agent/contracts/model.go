@@ -137,6 +137,8 @@ const (
 	AssociationPendingMessage string = "Association is pending"
 	// DocumentInProgressMessage represents the summary message for inprogress association
 	AssociationInProgressMessage string = "Executing association"
+	// AssociationDeferredMessage represents the summary message for association deferred by association windows
+	AssociationDeferredMessage string = "Association is deferred until %v, %v"
 )
 
 const (
@@ -181,8 +183,23 @@ type InstancePluginConfig struct {
 	Settings      interface{}         `json:"settings" yaml:"settings"`
 	Timeout       int                 `json:"timeoutSeconds" yaml:"timeoutSeconds"`
 	Preconditions map[string][]string `json:"precondition" yaml:"precondition"`
+	Outputs       []StepOutput        `json:"outputs" yaml:"outputs"`
 }
 
+// StepOutput declares a value captured from a step, referenced by later steps as {{ steps.<step name>.<output name> }}
+type StepOutput struct {
+	Name     string `json:"name" yaml:"name"`
+	Selector string `json:"selector" yaml:"selector"` // stdout, stderr, exitCode or a JSON path such as $.key[0] into the plugin output
+}
+
+// Selectors of step outputs.
+const (
+	StepOutputSelectorStdout   = "stdout"
+	StepOutputSelectorStderr   = "stderr"
+	StepOutputSelectorExitCode = "exitCode"
+	StepOutputSelectorJSONPath = "$"
+)
+
 // DocumentContent object which represents ssm document content.
 type DocumentContent struct {
 	SchemaVersion string                   `json:"schemaVersion" yaml:"schemaVersion"`
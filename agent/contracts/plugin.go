@@ -28,19 +28,32 @@ const (
 
 // PluginResult represents a plugin execution result.
 type PluginResult struct {
-	PluginID           string       `json:"pluginID"`
-	PluginName         string       `json:"pluginName"`
-	Status             ResultStatus `json:"status"`
-	Code               int          `json:"code"`
-	Output             interface{}  `json:"output"`
-	StartDateTime      time.Time    `json:"startDateTime"`
-	EndDateTime        time.Time    `json:"endDateTime"`
-	OutputS3BucketName string       `json:"outputS3BucketName"`
-	OutputS3KeyPrefix  string       `json:"outputS3KeyPrefix"`
-	StepName           string       `json:"stepName"`
-	Error              string       `json:"error"`
-	StandardOutput     string       `json:"standardOutput"`
-	StandardError      string       `json:"standardError"`
+	PluginID           string            `json:"pluginID"`
+	PluginName         string            `json:"pluginName"`
+	Status             ResultStatus      `json:"status"`
+	Code               int               `json:"code"`
+	Output             interface{}       `json:"output"`
+	StartDateTime      time.Time         `json:"startDateTime"`
+	EndDateTime        time.Time         `json:"endDateTime"`
+	OutputS3BucketName string            `json:"outputS3BucketName"`
+	OutputS3KeyPrefix  string            `json:"outputS3KeyPrefix"`
+	StepName           string            `json:"stepName"`
+	Error              string            `json:"error"`
+	StandardOutput     string            `json:"standardOutput"`
+	StandardError      string            `json:"standardError"`
+	Attempts           []PluginAttempt   `json:"attempts,omitempty"`
+	Outputs            map[string]string `json:"outputs,omitempty"`
+}
+
+// PluginAttempt records an execution attempt of a step and the decision taken after it.
+type PluginAttempt struct {
+	Attempt       int          `json:"attempt"`
+	Status        ResultStatus `json:"status"`
+	Code          int          `json:"code"`
+	StartDateTime time.Time    `json:"startDateTime"`
+	EndDateTime   time.Time    `json:"endDateTime"`
+	Error         string       `json:"error,omitempty"`
+	Decision      string       `json:"decision,omitempty"`
 }
 
 // IPlugin is interface for authoring a functionality of work.
@@ -87,8 +100,26 @@ type Configuration struct {
 	KmsKeyId                    string
 	RunAsEnabled                bool
 	RunAsUser                   string
+	PrincipalArn                string
+	PrincipalTags               map[string]string
+	MaxAttempts                 int
+	OnFailure                   string
+	TimeoutSeconds              int
+	Outputs                     []StepOutput
 }
 
+// Values of the onFailure property of a step, deciding what runs after the last attempt of the step failed.
+const (
+	// OnFailureExit skips the next steps
+	OnFailureExit = "exit"
+	// OnFailureSuccessAndExit marks the step successful and skips the next steps
+	OnFailureSuccessAndExit = "successAndExit"
+	// OnFailureContinue runs the next step, the default
+	OnFailureContinue = "continue"
+	// OnFailureStepPrefix prefixes the name of a later step to run next, the steps in between are skipped
+	OnFailureStepPrefix = "step:"
+)
+
 // Plugin wraps the plugin configuration and plugin result.
 type Plugin struct {
 	Configuration
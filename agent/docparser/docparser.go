@@ -18,6 +18,7 @@ package docparser
 import (
 	"fmt"
 	"path/filepath"
+	"regexp"
 	"strings"
 
 	"github.com/aws/amazon-ssm-agent/agent/appconfig"
@@ -89,6 +90,7 @@ func (docContent *DocContent) GetIOConfiguration(parserInfo DocumentParserInfo)
 		OutputS3BucketName:     parserInfo.S3Bucket,
 		OutputS3KeyPrefix:      parserInfo.S3Prefix,
 		CloudWatchConfig:       parserInfo.CloudWatchConfig,
+		CommandID:              parserInfo.DocumentId,
 	}
 }
 
@@ -138,7 +140,7 @@ func (sessionDocContent *SessionDocContent) ParseDocument(log log.T,
 	resolvedDocContent, _ := jsonutil.MarshalIndent(*sessionDocContent)
 	log.Debugf("Resolved session document content %s", resolvedDocContent)
 
-	return sessionDocContent.parsePluginStateForStartSession(parserInfo, docInfo.DocumentID, docInfo.ClientId, docInfo.RunAsUser)
+	return sessionDocContent.parsePluginStateForStartSession(parserInfo, docInfo)
 }
 
 // validateAndReplaceSessionDocumentParameters validates the parameters and modifies the document content by replacing all parameters with their actual values.
@@ -301,7 +303,7 @@ func parsePluginStateForV20Schema(
 	isPreconditionEnabled := isPreconditionEnabled(docContent.SchemaVersion)
 
 	// getPluginConfigurations converts from PluginConfig (structure from the MDS message) to plugin.Configuration (structure expected by the plugin)
-	for _, instancePluginConfig := range docContent.MainSteps {
+	for index, instancePluginConfig := range docContent.MainSteps {
 		pluginName := instancePluginConfig.Action
 		config := contracts.Configuration{
 			Settings:                instancePluginConfig.Settings,
@@ -318,6 +320,20 @@ func parsePluginStateForV20Schema(
 			DefaultWorkingDirectory: defaultWorkingDir,
 		}
 
+		// retries, failure handling and step timeouts are supported by the same schema versions as preconditions
+		if isPreconditionEnabled {
+			if err = validateStepExecutionOptions(index, docContent.MainSteps); err != nil {
+				return pluginsInfo, err
+			}
+			if err = validateStepOutputs(index, docContent.MainSteps); err != nil {
+				return pluginsInfo, err
+			}
+			config.MaxAttempts = instancePluginConfig.MaxAttempts
+			config.OnFailure = instancePluginConfig.OnFailure
+			config.TimeoutSeconds = instancePluginConfig.Timeout
+			config.Outputs = instancePluginConfig.Outputs
+		}
+
 		var plugin contracts.PluginState
 		plugin.Configuration = config
 		plugin.Id = config.PluginID
@@ -327,20 +343,91 @@ func parsePluginStateForV20Schema(
 	return
 }
 
+// validateStepExecutionOptions checks the maxAttempts, onFailure and timeoutSeconds properties of a step,
+// onFailure can only name a later step since steps run in order
+func validateStepExecutionOptions(index int, mainSteps []*contracts.InstancePluginConfig) error {
+	step := mainSteps[index]
+	if step.MaxAttempts < 0 {
+		return fmt.Errorf("Invalid maxAttempts %v for step %v", step.MaxAttempts, step.Name)
+	}
+	if step.Timeout < 0 {
+		return fmt.Errorf("Invalid timeoutSeconds %v for step %v", step.Timeout, step.Name)
+	}
+	switch {
+	case step.OnFailure == "", step.OnFailure == contracts.OnFailureExit,
+		step.OnFailure == contracts.OnFailureSuccessAndExit, step.OnFailure == contracts.OnFailureContinue:
+		return nil
+	case strings.HasPrefix(step.OnFailure, contracts.OnFailureStepPrefix):
+		target := strings.TrimPrefix(step.OnFailure, contracts.OnFailureStepPrefix)
+		for _, nextStep := range mainSteps[index+1:] {
+			if nextStep.Name == target {
+				return nil
+			}
+		}
+		return fmt.Errorf("Invalid onFailure %v for step %v, %v is not a later step", step.OnFailure, step.Name, target)
+	}
+	return fmt.Errorf("Invalid onFailure %v for step %v", step.OnFailure, step.Name)
+}
+
+// validateStepOutputs checks the outputs declared by a step and the step outputs referenced by its inputs,
+// which must be declared by a previous step
+func validateStepOutputs(index int, mainSteps []*contracts.InstancePluginConfig) error {
+	step := mainSteps[index]
+	outputNameValidator := regexp.MustCompile(parameters.StepOutputNameRegex)
+	declared := make(map[string]struct{})
+	for _, output := range step.Outputs {
+		if !outputNameValidator.MatchString(output.Name) {
+			return fmt.Errorf("Invalid output name %v for step %v", output.Name, step.Name)
+		}
+		if _, duplicate := declared[output.Name]; duplicate {
+			return fmt.Errorf("Duplicate output %v for step %v", output.Name, step.Name)
+		}
+		declared[output.Name] = struct{}{}
+		switch {
+		case output.Selector == contracts.StepOutputSelectorStdout,
+			output.Selector == contracts.StepOutputSelectorStderr,
+			output.Selector == contracts.StepOutputSelectorExitCode,
+			strings.HasPrefix(output.Selector, contracts.StepOutputSelectorJSONPath):
+		default:
+			return fmt.Errorf("Invalid selector %v for output %v of step %v", output.Selector, output.Name, step.Name)
+		}
+	}
+
+	for _, reference := range parameters.StepOutputReferences(step.Inputs) {
+		if !isOutputOfPreviousStep(reference, mainSteps[:index]) {
+			return fmt.Errorf("Step %v references %v, which is not an output of a previous step", step.Name, reference)
+		}
+	}
+	return nil
+}
+
+// isOutputOfPreviousStep returns whether the output is declared by one of the previous steps
+func isOutputOfPreviousStep(reference parameters.StepOutputReference, previousSteps []*contracts.InstancePluginConfig) bool {
+	for _, previousStep := range previousSteps {
+		if previousStep.Name != reference.Step {
+			continue
+		}
+		for _, output := range previousStep.Outputs {
+			if output.Name == reference.Output {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 // parsePluginStateForStartSession initializes instancePluginsInfo for the docState. Used by startSession.
 func (sessionDocContent *SessionDocContent) parsePluginStateForStartSession(
 	parserInfo DocumentParserInfo,
-	sessionId string,
-	clientId string,
-	runAsUserFromIam string) (pluginsInfo []contracts.PluginState, err error) {
+	docInfo contracts.DocumentInfo) (pluginsInfo []contracts.PluginState, err error) {
 
 	// getPluginConfigurations converts from PluginConfig (structure from the MGS message) to plugin.Configuration (structure expected by the plugin)
 	pluginName := sessionDocContent.SessionType
 
 	// decides which user to use. User from IAM principal always has higher priority than the default one.
 	runAsUser := sessionDocContent.Inputs.RunAsDefaultUser
-	if strings.TrimSpace(runAsUserFromIam) != "" {
-		runAsUser = runAsUserFromIam
+	if strings.TrimSpace(docInfo.RunAsUser) != "" {
+		runAsUser = docInfo.RunAsUser
 	}
 
 	config := contracts.Configuration{
@@ -349,18 +436,20 @@ func (sessionDocContent *SessionDocContent) parsePluginStateForStartSession(
 		PluginName:                  pluginName,
 		PluginID:                    pluginName,
 		DefaultWorkingDirectory:     parserInfo.DefaultWorkingDir,
-		SessionId:                   sessionId,
+		SessionId:                   docInfo.DocumentID,
 		OutputS3KeyPrefix:           sessionDocContent.Inputs.S3KeyPrefix,
 		OutputS3BucketName:          sessionDocContent.Inputs.S3BucketName,
 		S3EncryptionEnabled:         sessionDocContent.Inputs.S3EncryptionEnabled,
 		OrchestrationDirectory:      fileutil.BuildPath(parserInfo.OrchestrationDir, pluginName),
-		ClientId:                    clientId,
+		ClientId:                    docInfo.ClientId,
 		CloudWatchLogGroup:          sessionDocContent.Inputs.CloudWatchLogGroupName,
 		CloudWatchEncryptionEnabled: sessionDocContent.Inputs.CloudWatchEncryptionEnabled,
 		KmsKeyId:                    sessionDocContent.Inputs.KmsKeyId,
 		Properties:                  sessionDocContent.Properties,
 		RunAsEnabled:                sessionDocContent.Inputs.RunAsEnabled,
 		RunAsUser:                   runAsUser,
+		PrincipalArn:                docInfo.PrincipalArn,
+		PrincipalTags:               docInfo.PrincipalTags,
 	}
 
 	var plugin contracts.PluginState
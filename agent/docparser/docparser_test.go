@@ -479,6 +479,63 @@ func TestIsCrossPlatformEnabledForSchema22(t *testing.T) {
 	assert.True(t, isCrossPlatformEnabled)
 }
 
+func TestParsePluginStateForV22SchemaWithStepExecutionOptions(t *testing.T) {
+	docContent := DocContent{
+		SchemaVersion: "2.2",
+		MainSteps: []*contracts.InstancePluginConfig{
+			{Action: "aws:runShellScript", Name: "first", MaxAttempts: 3, OnFailure: "step:last", Timeout: 60},
+			{Action: "aws:runShellScript", Name: "last", OnFailure: contracts.OnFailureSuccessAndExit},
+		},
+	}
+	pluginsInfo, err := parsePluginStateForV20Schema(docContent, testOrchDir, testS3Bucket, testS3Prefix, testMessageID, testDocumentID, testWorkingDir)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, pluginsInfo[0].Configuration.MaxAttempts)
+	assert.Equal(t, "step:last", pluginsInfo[0].Configuration.OnFailure)
+	assert.Equal(t, 60, pluginsInfo[0].Configuration.TimeoutSeconds)
+
+	// steps can only go to a later step
+	docContent.MainSteps[1].OnFailure = "step:first"
+	_, err = parsePluginStateForV20Schema(docContent, testOrchDir, testS3Bucket, testS3Prefix, testMessageID, testDocumentID, testWorkingDir)
+	assert.Error(t, err)
+
+	docContent.MainSteps[1].OnFailure = "abort"
+	_, err = parsePluginStateForV20Schema(docContent, testOrchDir, testS3Bucket, testS3Prefix, testMessageID, testDocumentID, testWorkingDir)
+	assert.Error(t, err)
+
+	// the options are ignored before schema 2.2
+	docContent.SchemaVersion = "2.0"
+	pluginsInfo, err = parsePluginStateForV20Schema(docContent, testOrchDir, testS3Bucket, testS3Prefix, testMessageID, testDocumentID, testWorkingDir)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, pluginsInfo[0].Configuration.MaxAttempts)
+}
+
+func TestParsePluginStateForV22SchemaWithStepOutputs(t *testing.T) {
+	docContent := DocContent{
+		SchemaVersion: "2.2",
+		MainSteps: []*contracts.InstancePluginConfig{
+			{Action: "aws:runShellScript", Name: "query", Outputs: []contracts.StepOutput{{Name: "count", Selector: "stdout"}}},
+			{Action: "aws:runShellScript", Name: "report", Inputs: map[string]interface{}{"runCommand": []interface{}{"echo {{ steps.query.count }}"}}},
+		},
+	}
+	pluginsInfo, err := parsePluginStateForV20Schema(docContent, testOrchDir, testS3Bucket, testS3Prefix, testMessageID, testDocumentID, testWorkingDir)
+	assert.NoError(t, err)
+	assert.Equal(t, docContent.MainSteps[0].Outputs, pluginsInfo[0].Configuration.Outputs)
+
+	// outputs must be declared by a previous step
+	docContent.MainSteps[1].Inputs = map[string]interface{}{"runCommand": "echo {{ steps.query.size }}"}
+	_, err = parsePluginStateForV20Schema(docContent, testOrchDir, testS3Bucket, testS3Prefix, testMessageID, testDocumentID, testWorkingDir)
+	assert.Error(t, err)
+
+	docContent.MainSteps[1].Inputs = map[string]interface{}{"runCommand": "echo {{ steps.report.count }}"}
+	_, err = parsePluginStateForV20Schema(docContent, testOrchDir, testS3Bucket, testS3Prefix, testMessageID, testDocumentID, testWorkingDir)
+	assert.Error(t, err)
+
+	docContent.MainSteps[1].Inputs = nil
+	docContent.MainSteps[0].Outputs = []contracts.StepOutput{{Name: "count", Selector: "stdin"}}
+	_, err = parsePluginStateForV20Schema(docContent, testOrchDir, testS3Bucket, testS3Prefix, testMessageID, testDocumentID, testWorkingDir)
+	assert.Error(t, err)
+}
+
 func TestParseMessageWithParams(t *testing.T) {
 	type testCase struct {
 		Input       string
@@ -0,0 +1,192 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package docparser
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/aws/amazon-ssm-agent/agent/jsonutil"
+	"github.com/go-yaml/yaml"
+)
+
+// DocumentFormat is the serialization format of a document
+type DocumentFormat string
+
+const (
+	// DocumentFormatJSON represents a document written in JSON
+	DocumentFormatJSON DocumentFormat = "JSON"
+	// DocumentFormatYAML represents a document written in YAML
+	DocumentFormatYAML DocumentFormat = "YAML"
+)
+
+var (
+	yamlErrorLine  = regexp.MustCompile(`line (\d+): (.*)`)
+	yamlErrorValue = regexp.MustCompile("`([^`]*)`")
+)
+
+// DocumentLoadError describes where a document could not be parsed.
+// Line and Column are 1-based, zero means the position is unknown.
+type DocumentLoadError struct {
+	Format  DocumentFormat
+	Line    int
+	Column  int
+	Message string
+}
+
+// Error returns the error message with the position of the problem in the document
+func (e *DocumentLoadError) Error() string {
+	if e.Line == 0 {
+		return fmt.Sprintf("invalid %v document: %v", e.Format, e.Message)
+	}
+	return fmt.Sprintf("invalid %v document at line %v, column %v: %v", e.Format, e.Line, e.Column, e.Message)
+}
+
+// DetectDocumentFormat returns the format of the raw document, documents that are not a JSON object are treated as YAML
+func DetectDocumentFormat(data []byte) DocumentFormat {
+	trimmed := bytes.TrimSpace(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf")))
+	if len(trimmed) > 0 && trimmed[0] == '{' {
+		return DocumentFormatJSON
+	}
+	return DocumentFormatYAML
+}
+
+// LoadDocumentContent parses a JSON or YAML document into content, which must be a pointer to a document
+// structure such as DocContent, SessionDocContent, contracts.DocumentContent or contracts.SessionDocumentContent.
+// Parse errors are returned as *DocumentLoadError.
+func LoadDocumentContent(data []byte, content interface{}) (format DocumentFormat, err error) {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	format = DetectDocumentFormat(data)
+	if format == DocumentFormatJSON {
+		return format, loadJSON(data, content)
+	}
+	return format, loadYAML(data, content)
+}
+
+// LoadDocumentContentFile reads a JSON or YAML document from a file and parses it into content
+func LoadDocumentContentFile(filePath string, content interface{}) (format DocumentFormat, err error) {
+	var data []byte
+	if data, err = ioutil.ReadFile(filePath); err != nil {
+		return
+	}
+	return LoadDocumentContent(data, content)
+}
+
+// loadJSON decodes a JSON document and converts decoder offsets into line and column
+func loadJSON(data []byte, content interface{}) error {
+	err := json.Unmarshal(data, content)
+	switch e := err.(type) {
+	case nil:
+		return nil
+	case *json.SyntaxError:
+		line, column := offsetPosition(data, e.Offset)
+		return &DocumentLoadError{Format: DocumentFormatJSON, Line: line, Column: column, Message: e.Error()}
+	case *json.UnmarshalTypeError:
+		line, column := offsetPosition(data, e.Offset)
+		return &DocumentLoadError{Format: DocumentFormatJSON, Line: line, Column: column, Message: e.Error()}
+	default:
+		return &DocumentLoadError{Format: DocumentFormatJSON, Message: e.Error()}
+	}
+}
+
+// loadYAML decodes a YAML document. The yaml decoder reports positions for type errors, so the document is first
+// decoded into a scratch value of the target type, it is then decoded generically and converted to JSON because
+// nested yaml maps are map[interface{}]interface{}, which plugins cannot remarshal.
+func loadYAML(data []byte, content interface{}) error {
+	scratch := reflect.New(reflect.TypeOf(content).Elem()).Interface()
+	if err := yaml.Unmarshal(data, scratch); err != nil {
+		return yamlLoadError(data, err)
+	}
+	var raw interface{}
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return yamlLoadError(data, err)
+	}
+	if _, isObject := raw.(map[interface{}]interface{}); !isObject {
+		return &DocumentLoadError{Format: DocumentFormatYAML, Line: 1, Column: 1, Message: "document must be a mapping of keys to values"}
+	}
+	if err := jsonutil.Remarshal(stringKeys(raw), content); err != nil {
+		return &DocumentLoadError{Format: DocumentFormatYAML, Message: err.Error()}
+	}
+	return nil
+}
+
+// yamlLoadError extracts the position from a yaml decoder error.
+// The decoder only reports the line, 0-based for syntax errors and 1-based for type errors. The column is the
+// position of the offending value when the error quotes it, otherwise the first non-blank character of the line.
+func yamlLoadError(data []byte, err error) error {
+	message := strings.TrimPrefix(err.Error(), "yaml: ")
+	lineOffset := 1
+	if typeErr, ok := err.(*yaml.TypeError); ok && len(typeErr.Errors) > 0 {
+		message = typeErr.Errors[0]
+		lineOffset = 0
+	}
+	match := yamlErrorLine.FindStringSubmatch(message)
+	if match == nil {
+		return &DocumentLoadError{Format: DocumentFormatYAML, Message: message}
+	}
+	line, _ := strconv.Atoi(match[1])
+	line += lineOffset
+	column := 0
+	lines := strings.Split(string(data), "\n")
+	if line > 0 && line <= len(lines) {
+		text := lines[line-1]
+		column = len(text) - len(strings.TrimLeft(text, " \t")) + 1
+		if value := yamlErrorValue.FindStringSubmatch(match[2]); value != nil {
+			if index := strings.Index(text, strings.TrimSuffix(value[1], "...")); index >= 0 {
+				column = index + 1
+			}
+		}
+	}
+	return &DocumentLoadError{Format: DocumentFormatYAML, Line: line, Column: column, Message: match[2]}
+}
+
+// offsetPosition converts a byte offset reported by the json decoder into a 1-based line and column
+func offsetPosition(data []byte, offset int64) (line int, column int) {
+	if offset > int64(len(data)) {
+		offset = int64(len(data))
+	}
+	if offset > 0 {
+		// the decoder reports the offset after the byte it failed on
+		offset--
+	}
+	before := data[:offset]
+	line = bytes.Count(before, []byte("\n")) + 1
+	column = int(offset) - bytes.LastIndexByte(before, '\n')
+	return
+}
+
+// stringKeys converts the maps produced by the yaml decoder into maps with string keys
+func stringKeys(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[interface{}]interface{}:
+		result := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			result[fmt.Sprintf("%v", key)] = stringKeys(item)
+		}
+		return result
+	case []interface{}:
+		for i, item := range v {
+			v[i] = stringKeys(item)
+		}
+		return v
+	default:
+		return value
+	}
+}
@@ -0,0 +1,138 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package docparser
+
+import (
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/jsonutil"
+	"github.com/stretchr/testify/assert"
+)
+
+const yamlDocumentV12 = `schemaVersion: "1.2"
+description: Run a shell script.
+parameters:
+  commands:
+    type: StringList
+runtimeConfig:
+  aws:runShellScript:
+    properties:
+      - id: "0.aws:runShellScript"
+        runCommand: "{{ commands }}"
+`
+
+const yamlDocumentV22 = `---
+schemaVersion: "2.2"
+description: Run a shell script.
+mainSteps:
+  - action: aws:runShellScript
+    name: runShellScript
+    precondition:
+      StringEquals: [platformType, Linux]
+    timeoutSeconds: 60
+    inputs:
+      runCommand:
+        - echo hello
+`
+
+const yamlSessionDocument = `schemaVersion: "1.0"
+description: Session preferences.
+sessionType: Standard_Stream
+inputs:
+  s3BucketName: bucket
+  runAsEnabled: true
+  runAsDefaultUser: ssm-user
+`
+
+func TestLoadDocumentContent_YAMLSchema12(t *testing.T) {
+	var content DocContent
+	format, err := LoadDocumentContent([]byte(yamlDocumentV12), &content)
+
+	assert.NoError(t, err)
+	assert.Equal(t, DocumentFormatYAML, format)
+	assert.Equal(t, "1.2", content.SchemaVersion)
+	assert.Equal(t, "StringList", content.Parameters["commands"].ParamType)
+	properties := content.RuntimeConfig["aws:runShellScript"].Properties.([]interface{})
+	assert.Equal(t, "{{ commands }}", properties[0].(map[string]interface{})["runCommand"])
+
+	// nested values must be remarshalable by plugins
+	_, err = jsonutil.Marshal(content)
+	assert.NoError(t, err)
+}
+
+func TestLoadDocumentContent_YAMLSchema22(t *testing.T) {
+	var content contracts.DocumentContent
+	format, err := LoadDocumentContent([]byte(yamlDocumentV22), &content)
+
+	assert.NoError(t, err)
+	assert.Equal(t, DocumentFormatYAML, format)
+	assert.Equal(t, "2.2", content.SchemaVersion)
+	assert.Equal(t, 1, len(content.MainSteps))
+	assert.Equal(t, "aws:runShellScript", content.MainSteps[0].Action)
+	assert.Equal(t, 60, content.MainSteps[0].Timeout)
+	assert.Equal(t, []string{"platformType", "Linux"}, content.MainSteps[0].Preconditions["StringEquals"])
+}
+
+func TestLoadDocumentContent_YAMLSessionDocument(t *testing.T) {
+	var content SessionDocContent
+	format, err := LoadDocumentContent([]byte(yamlSessionDocument), &content)
+
+	assert.NoError(t, err)
+	assert.Equal(t, DocumentFormatYAML, format)
+	assert.Equal(t, "Standard_Stream", content.SessionType)
+	assert.Equal(t, "bucket", content.Inputs.S3BucketName)
+	assert.True(t, content.Inputs.RunAsEnabled)
+}
+
+func TestLoadDocumentContent_JSON(t *testing.T) {
+	var content DocContent
+	format, err := LoadDocumentContent([]byte(parameterdocument), &content)
+
+	assert.NoError(t, err)
+	assert.Equal(t, DocumentFormatJSON, format)
+	assert.Equal(t, "1.2", content.SchemaVersion)
+}
+
+func TestLoadDocumentContent_Errors(t *testing.T) {
+	testCases := []struct {
+		name     string
+		document string
+		format   DocumentFormat
+		line     int
+		column   int
+	}{
+		{"json syntax", "{\n  \"schemaVersion\": \"2.2\",\n  \"mainSteps\": [\n}", DocumentFormatJSON, 4, 1},
+		{"json type", "{\n  \"schemaVersion\": 2.2\n}", DocumentFormatJSON, 2, 22},
+		{"yaml syntax", "schemaVersion: \"2.2\"\nmainSteps:\n  - action: a\n   name: b\n", DocumentFormatYAML, 4, 4},
+		{"yaml type", "schemaVersion: \"2.2\"\nmainSteps:\n  - action: aws:runShellScript\n    timeoutSeconds: soon\n", DocumentFormatYAML, 4, 21},
+		{"yaml flow", "schemaVersion: \"2.2\"\nparameters: [1, 2\ndescription: d\n", DocumentFormatYAML, 3, 1},
+		{"yaml scalar", "just some text", DocumentFormatYAML, 1, 1},
+	}
+
+	for _, testCase := range testCases {
+		var content DocContent
+		format, err := LoadDocumentContent([]byte(testCase.document), &content)
+
+		assert.Equal(t, testCase.format, format, testCase.name)
+		if assert.Error(t, err, testCase.name) {
+			loadErr, ok := err.(*DocumentLoadError)
+			if assert.True(t, ok, testCase.name) {
+				assert.Equal(t, testCase.line, loadErr.Line, testCase.name)
+				assert.Equal(t, testCase.column, loadErr.Column, testCase.name)
+				assert.Contains(t, err.Error(), "line", testCase.name)
+			}
+		}
+	}
+}
@@ -18,6 +18,7 @@ import (
 	"crypto/md5"
 	"crypto/sha1"
 	"crypto/sha256"
+	"crypto/sha512"
 	"encoding/hex"
 	"fmt"
 	"io"
@@ -25,10 +26,12 @@ import (
 	"net/url"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 
 	"github.com/aws/amazon-ssm-agent/agent/appconfig"
 	"github.com/aws/amazon-ssm-agent/agent/fileutil"
+	"github.com/aws/amazon-ssm-agent/agent/fileutil/artifactcache"
 	"github.com/aws/amazon-ssm-agent/agent/log"
 	"github.com/aws/amazon-ssm-agent/agent/platform"
 	"github.com/aws/amazon-ssm-agent/agent/s3util"
@@ -39,6 +42,9 @@ import (
 	"github.com/aws/aws-sdk-go/service/s3"
 )
 
+// newArtifactCache returns the artifact cache shared by the downloads, nil if the cache is disabled
+var newArtifactCache = artifactcache.Default
+
 // DownloadOutput holds the result of file download operation.
 type DownloadOutput struct {
 	LocalFilePath string
@@ -56,66 +62,92 @@ type DownloadInput struct {
 // httpDownload attempts to download a file via http/s call
 func httpDownload(log log.T, fileURL string, destFile string) (output DownloadOutput, err error) {
 	log.Debugf("attempting to download as http/https download from %v to %v", fileURL, destFile)
-	eTagFile := destFile + ".etag"
-	var check http.Client
+	source := &httpSource{
+		fileURL: fileURL,
+		client: http.Client{
+			CheckRedirect: func(r *http.Request, via []*http.Request) error {
+				r.URL.Opaque = r.URL.Path
+				return nil
+			},
+		},
+	}
+	return resumableDownload(log, fileURL, source, destFile, loadDownloadSettings(log))
+}
+
+// httpSource is a file downloaded with http range requests
+type httpSource struct {
+	fileURL string
+	client  http.Client
+}
+
+func (s *httpSource) get(log log.T, input rangeRequest) (output *rangeResponse, err error) {
 	var request *http.Request
-	request, err = http.NewRequest("GET", fileURL, nil)
-	if err != nil {
+	if request, err = http.NewRequest("GET", s.fileURL, nil); err != nil {
 		return
 	}
-	if fileutil.Exists(destFile) == true && fileutil.Exists(eTagFile) == true {
-		log.Debugf("destFile exists at %v, etag file exists at %v", destFile, eTagFile)
-		var existingETag string
-		existingETag, err = fileutil.ReadAllText(eTagFile)
-		request.Header.Add("If-None-Match", existingETag)
+	if input.offset > 0 || input.length > 0 {
+		request.Header.Set("Range", byteRange(input.offset, input.length))
 	}
-
-	check = http.Client{
-		CheckRedirect: func(r *http.Request, via []*http.Request) error {
-			r.URL.Opaque = r.URL.Path
-			return nil
-		},
+	if input.ifRange != "" {
+		request.Header.Set("If-Range", input.ifRange)
+	}
+	if input.ifNoneMatch != "" {
+		request.Header.Set("If-None-Match", input.ifNoneMatch)
 	}
 
 	var resp *http.Response
-	resp, err = check.Do(request)
-	if err != nil {
+	if resp, err = s.client.Do(request); err != nil {
 		log.Debug("failed to download from http/https, ", err)
-		fileutil.DeleteFile(destFile)
-		fileutil.DeleteFile(eTagFile)
 		return
 	}
+	output = &rangeResponse{
+		body:         resp.Body,
+		size:         -1,
+		etag:         resp.Header.Get("Etag"),
+		acceptRanges: resp.Header.Get("Accept-Ranges") == "bytes",
+	}
+	switch resp.StatusCode {
+	case http.StatusNotModified:
+		resp.Body.Close()
+		output.body = nil
+		output.notModified = true
+	case http.StatusOK:
+		output.size = resp.ContentLength
+	case http.StatusPartialContent:
+		output.acceptRanges = true
+		if output.offset, output.size, err = parseContentRange(resp.Header.Get("Content-Range")); err != nil {
+			resp.Body.Close()
+			return nil, err
+		}
+	default:
+		resp.Body.Close()
+		return nil, fmt.Errorf("http request failed. status:%v statuscode:%v", resp.Status, resp.StatusCode)
+	}
+	return output, nil
+}
 
-	if resp.StatusCode == http.StatusNotModified {
-		log.Debugf("Unchanged file.")
-		output.IsUpdated = false
-		output.LocalFilePath = destFile
-		return output, nil
-	} else if resp.StatusCode != http.StatusOK {
-		log.Debug("failed to download from http/https, ", err)
-		fileutil.DeleteFile(destFile)
-		fileutil.DeleteFile(eTagFile)
-		err = fmt.Errorf("http request failed. status:%v statuscode:%v", resp.Status, resp.StatusCode)
-		return
+// byteRange returns the value of the Range header requesting length bytes from offset, or the rest of the file if length is 0
+func byteRange(offset int64, length int64) string {
+	if length > 0 {
+		return fmt.Sprintf("bytes=%v-%v", offset, offset+length-1)
 	}
-	defer resp.Body.Close()
-	eTagValue := resp.Header.Get("Etag")
-	if eTagValue != "" {
-		log.Debug("file eTagValue is ", eTagValue)
-		err = fileutil.WriteAllText(eTagFile, eTagValue)
-		if err != nil {
-			log.Errorf("failed to write eTagfile %v, %v ", eTagFile, err)
-			return
+	return fmt.Sprintf("bytes=%v-", offset)
+}
+
+// parseContentRange returns the offset and the total size of a Content-Range header, the size is -1 if unknown
+func parseContentRange(contentRange string) (offset int64, size int64, err error) {
+	var last int64
+	if _, err = fmt.Sscanf(contentRange, "bytes %d-%d/", &offset, &last); err != nil {
+		return 0, 0, fmt.Errorf("invalid content range %v", contentRange)
+	}
+	size = -1
+	total := contentRange[strings.LastIndex(contentRange, "/")+1:]
+	if total != "*" {
+		if size, err = strconv.ParseInt(total, 10, 64); err != nil {
+			return 0, 0, fmt.Errorf("invalid content range %v", contentRange)
 		}
 	}
-	_, err = FileCopy(log, destFile, resp.Body)
-	if err == nil {
-		output.LocalFilePath = destFile
-		output.IsUpdated = true
-	} else {
-		log.Errorf("failed to write destFile %v, %v ", destFile, err)
-	}
-	return
+	return offset, size, nil
 }
 
 // awsConfig creates a config and sets region and credential information given an S3 URL
@@ -247,63 +279,68 @@ func ListS3Directory(log log.T, amazonS3URL s3util.AmazonS3URL) (folderNames []s
 // s3Download attempts to download a file via the aws sdk.
 func s3Download(log log.T, amazonS3URL s3util.AmazonS3URL, destFile string) (output DownloadOutput, err error) {
 	log.Debugf("attempting to download as s3 download %v", destFile)
-	eTagFile := destFile + ".etag"
 
 	config, _ := awsConfig(log, amazonS3URL)
-	params := &s3.GetObjectInput{
-		Bucket: aws.String(amazonS3URL.Bucket),
-		Key:    aws.String(amazonS3URL.Key),
-	}
-
-	if fileutil.Exists(destFile) == true && fileutil.Exists(eTagFile) == true {
-		var existingETag string
-		existingETag, err = fileutil.ReadAllText(eTagFile)
-		if err != nil {
-			log.Debugf("failed to read etag file %v, %v", eTagFile, err)
-			return
-		}
-		params.IfNoneMatch = aws.String(existingETag)
-	}
 	appConfig, _ := appconfig.Config(false)
 	sess := session.New(config)
 	sess.Handlers.Build.PushBack(request.MakeAddToUserAgentHandler(appConfig.Agent.Name, appConfig.Agent.Version))
 
-	s3client := s3.New(sess)
+	source := &s3Source{
+		amazonS3URL: amazonS3URL,
+		client:      s3.New(sess),
+	}
+	return resumableDownload(log, fmt.Sprintf("s3://%v/%v", amazonS3URL.Bucket, amazonS3URL.Key), source, destFile, loadDownloadSettings(log))
+}
 
-	req, resp := s3client.GetObjectRequest(params)
-	err = req.Send()
-	if err != nil {
-		if req.HTTPResponse == nil || req.HTTPResponse.StatusCode != http.StatusNotModified {
-			log.Debug("failed to download from s3, ", err)
-			fileutil.DeleteFile(destFile)
-			fileutil.DeleteFile(eTagFile)
-			return
-		}
+// s3Source is an s3 object downloaded with ranged GetObject requests
+type s3Source struct {
+	amazonS3URL s3util.AmazonS3URL
+	client      *s3.S3
+}
 
-		log.Debugf("Unchanged file.")
-		output.IsUpdated = false
-		output.LocalFilePath = destFile
-		return output, nil
+func (s *s3Source) get(log log.T, input rangeRequest) (output *rangeResponse, err error) {
+	params := &s3.GetObjectInput{
+		Bucket: aws.String(s.amazonS3URL.Bucket),
+		Key:    aws.String(s.amazonS3URL.Key),
+	}
+	if input.offset > 0 || input.length > 0 {
+		params.Range = aws.String(byteRange(input.offset, input.length))
+	}
+	if input.ifRange != "" {
+		params.IfMatch = aws.String(input.ifRange)
+	}
+	if input.ifNoneMatch != "" {
+		params.IfNoneMatch = aws.String(input.ifNoneMatch)
 	}
 
-	if *resp.ETag != "" {
-		log.Debug("files etag is ", *resp.ETag)
-		err = fileutil.WriteAllText(eTagFile, *resp.ETag)
-		if err != nil {
-			log.Errorf("failed to write eTagfile %v, %v ", eTagFile, err)
-			return
+	req, resp := s.client.GetObjectRequest(params)
+	if err = req.Send(); err != nil {
+		if req.HTTPResponse != nil {
+			switch req.HTTPResponse.StatusCode {
+			case http.StatusNotModified:
+				return &rangeResponse{notModified: true}, nil
+			case http.StatusPreconditionFailed:
+				return nil, errSourceChanged
+			}
 		}
+		log.Debug("failed to download from s3, ", err)
+		return
 	}
-
-	defer resp.Body.Close()
-	_, err = FileCopy(log, destFile, resp.Body)
-	if err == nil {
-		output.LocalFilePath = destFile
-		output.IsUpdated = true
-	} else {
-		log.Errorf("failed to write destFile %v, %v ", destFile, err)
+	output = &rangeResponse{
+		body:         resp.Body,
+		size:         -1,
+		etag:         aws.StringValue(resp.ETag),
+		acceptRanges: true,
 	}
-	return
+	if resp.ContentRange != nil {
+		if output.offset, output.size, err = parseContentRange(*resp.ContentRange); err != nil {
+			resp.Body.Close()
+			return nil, err
+		}
+	} else if resp.ContentLength != nil {
+		output.size = *resp.ContentLength
+	}
+	return output, nil
 }
 
 // FileCopy copies the content from reader to destinationPath file
@@ -366,6 +403,13 @@ func Download(log log.T, input DownloadInput) (output DownloadOutput, err error)
 		urlHash := sha1.Sum([]byte(fileURL.String()))
 		output.LocalFilePath = filepath.Join(destinationDir, fmt.Sprintf("%x", urlHash))
 
+		cache := newArtifactCache()
+		if copyFromCache(log, cache, input, output.LocalFilePath) {
+			output.IsUpdated = true
+			output.IsHashMatched, err = VerifyHash(log, input, output)
+			return
+		}
+
 		amazonS3URL := s3util.ParseAmazonS3URL(log, fileURL)
 		if amazonS3URL.IsBucketAndKeyPresent() {
 			var tempOutput DownloadOutput
@@ -386,12 +430,50 @@ func Download(log log.T, input DownloadInput) (output DownloadOutput, err error)
 		isLocalFile, err = fileutil.LocalFileExist(output.LocalFilePath)
 		if isLocalFile == true {
 			output.IsHashMatched, err = VerifyHash(log, input, output)
+			if !output.IsHashMatched {
+				// drop the cached copy so that the next attempt downloads the file again
+				log.Warnf("checksum of %v does not match, removing the downloaded file", input.SourceURL)
+				fileutil.DeleteFile(output.LocalFilePath)
+				fileutil.DeleteFile(output.LocalFilePath + ".etag")
+			} else if output.IsUpdated {
+				addToCache(log, cache, input, output.LocalFilePath)
+			}
 		}
 	}
 
 	return
 }
 
+// copyFromCache copies the artifact with the checksums of the input from the cache, if the cache has it
+func copyFromCache(log log.T, cache *artifactcache.Cache, input DownloadInput, destFile string) bool {
+	algorithm, hash, found := artifactcache.Key(input.SourceChecksums)
+	if cache == nil || !found {
+		return false
+	}
+	if found, err := cache.CopyTo(log, algorithm, hash, destFile); err != nil {
+		log.Debugf("failed to copy %v %v from the artifact cache, %v", algorithm, hash, err)
+		return false
+	} else if !found {
+		return false
+	}
+	// the etag of the previous download does not describe the cached artifact
+	fileutil.DeleteFile(destFile + ".etag")
+	log.Infof("%v found in the artifact cache, skipping download", input.SourceURL)
+	return true
+}
+
+// addToCache adds a verified download to the cache, downloads without a checksum the cache supports are
+// not added since copyFromCache could never find them
+func addToCache(log log.T, cache *artifactcache.Cache, input DownloadInput, filePath string) {
+	algorithm, hash, found := artifactcache.Key(input.SourceChecksums)
+	if cache == nil || !found {
+		return
+	}
+	if err := cache.Add(log, algorithm, hash, filePath); err != nil {
+		log.Warnf("failed to add %v to the artifact cache, %v", input.SourceURL, err)
+	}
+}
+
 // VerifyHash verifies the hash of the url file as per specified hash algorithm type and its value
 func VerifyHash(log log.T, input DownloadInput, output DownloadOutput) (bool, error) {
 	hasMatchingHash := false
@@ -419,6 +501,8 @@ func VerifyHash(log log.T, input DownloadInput, output DownloadOutput) (bool, er
 		// check the sha256 algorithm by default
 		if hashAlgorithm == "" || strings.EqualFold(hashAlgorithm, "sha256") {
 			computedHashValue, err = Sha256HashValue(log, output.LocalFilePath)
+		} else if strings.EqualFold(hashAlgorithm, "sha512") {
+			computedHashValue, err = Sha512HashValue(log, output.LocalFilePath)
 		} else if strings.EqualFold(hashAlgorithm, "md5") {
 			computedHashValue, err = Md5HashValue(log, output.LocalFilePath)
 		} else {
@@ -467,6 +551,29 @@ func Sha256HashValue(log log.T, filePath string) (hash string, err error) {
 	return
 }
 
+// Sha512HashValue gets the sha512 hash value
+func Sha512HashValue(log log.T, filePath string) (hash string, err error) {
+	var exists = false
+	exists, err = fileutil.LocalFileExist(filePath)
+	if err != nil || exists == false {
+		return
+	}
+
+	var f *os.File
+	f, err = os.Open(filePath)
+	if err != nil {
+		log.Error(err)
+	}
+	defer f.Close()
+	hasher := sha512.New()
+	if _, err = io.Copy(hasher, f); err != nil {
+		log.Error(err)
+	}
+	hash = hex.EncodeToString(hasher.Sum(nil))
+	log.Debugf("Hash=%v, FilePath=%v", hash, filePath)
+	return
+}
+
 // Md5HashValue gets the md5 hash value
 func Md5HashValue(log log.T, filePath string) (hash string, err error) {
 	var exists = false
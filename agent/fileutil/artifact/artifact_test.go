@@ -0,0 +1,55 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package artifact
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/fileutil/artifactcache"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAddToCacheRequiresChecksum(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "artifact")
+	defer os.RemoveAll(dir)
+	filePath := filepath.Join(dir, "file")
+	assert.NoError(t, ioutil.WriteFile(filePath, []byte("content"), 0600))
+	cache := artifactcache.New(filepath.Join(dir, "cache"), 1024*1024)
+	const hash = "ed7002b439e9ac845f22357d822bac1444730fbdb6016d3ec9432297b9ec9f73"
+
+	// a download without checksum could never be found by copyFromCache
+	addToCache(log.NewMockLog(), cache, DownloadInput{SourceURL: "https://example.com/file"}, filePath)
+	entries, err := cache.List()
+	assert.NoError(t, err)
+	assert.Empty(t, entries)
+
+	input := DownloadInput{
+		SourceURL:       "https://example.com/file",
+		SourceChecksums: map[string]string{"sha256": hash},
+	}
+	addToCache(log.NewMockLog(), cache, input, filePath)
+	entries, err = cache.List()
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1)
+	assert.Equal(t, hash, entries[0].Hash)
+
+	destFile := filepath.Join(dir, "copy")
+	assert.True(t, copyFromCache(log.NewMockLog(), cache, input, destFile))
+	content, _ := ioutil.ReadFile(destFile)
+	assert.Equal(t, "content", string(content))
+}
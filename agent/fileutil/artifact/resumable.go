@@ -0,0 +1,490 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package artifact
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/fileutil"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+)
+
+const (
+	// partialFileSuffix is the suffix of the file holding the content downloaded so far
+	partialFileSuffix = ".partial"
+	// stateFileSuffix is the suffix of the file recording the progress of the download
+	stateFileSuffix = ".partial.json"
+
+	downloadBufferSize = 32 * 1024
+	// stateSaveInterval is the number of bytes downloaded between two saves of the progress
+	stateSaveInterval = 4 * 1024 * 1024
+	// maxDownloadAttempts bounds the number of times an interrupted download is resumed
+	maxDownloadAttempts = 3
+)
+
+var (
+	errSourceChanged   = errors.New("source changed since the download started")
+	errDownloadAborted = errors.New("download aborted")
+)
+
+// rangeRequest is a request for the content of a source
+type rangeRequest struct {
+	offset int64
+	// length is the number of bytes requested, 0 to read up to the end of the source
+	length int64
+	// ifRange is the etag the source must still have to return the requested range
+	ifRange string
+	// ifNoneMatch is the etag of the cached copy of the source
+	ifNoneMatch string
+}
+
+// rangeResponse is the content returned by a source
+type rangeResponse struct {
+	body io.ReadCloser
+	// offset is the position of the first byte of body in the source
+	offset int64
+	// size is the size of the source, -1 if unknown
+	size         int64
+	etag         string
+	acceptRanges bool
+	notModified  bool
+}
+
+// rangeSource is a remote file which can be downloaded in parts
+type rangeSource interface {
+	get(log log.T, request rangeRequest) (*rangeResponse, error)
+}
+
+// downloadSettings holds the configuration of the downloads
+type downloadSettings struct {
+	concurrency    int
+	chunkSize      int64
+	bytesPerSecond int64
+}
+
+// loadDownloadSettings reads the download settings of the agent configuration
+func loadDownloadSettings(log log.T) downloadSettings {
+	config, err := appconfig.Config(false)
+	if err != nil {
+		log.Debugf("failed to read appconfig, using the default download settings, %v", err)
+		config = appconfig.DefaultConfig()
+	}
+	return downloadSettings{
+		concurrency:    config.Download.Concurrency,
+		chunkSize:      int64(config.Download.ChunkSizeMB) * 1024 * 1024,
+		bytesPerSecond: int64(config.Download.BandwidthLimitKBps) * 1024,
+	}
+}
+
+// downloadChunk is a part of the source downloaded by a single request
+type downloadChunk struct {
+	Offset int64
+	// Length is -1 when the size of the source is unknown
+	Length  int64
+	Written int64
+}
+
+func (c *downloadChunk) done() bool {
+	return c.Length >= 0 && c.Written >= c.Length
+}
+
+// downloadState is the progress of a download, saved next to the partial file to resume the download
+type downloadState struct {
+	SourceURL string
+	ETag      string
+	Size      int64
+	Chunks    []*downloadChunk
+}
+
+// downloader downloads a source to a file, resuming from the partial file of a previous attempt
+type downloader struct {
+	log         log.T
+	sourceURL   string
+	source      rangeSource
+	settings    downloadSettings
+	limiter     *rateLimiter
+	destFile    string
+	eTagFile    string
+	partialFile string
+	stateFile   string
+
+	mutex   sync.Mutex
+	state   *downloadState
+	unsaved int64
+	aborted int32
+}
+
+// resumableDownload downloads the source to destFile, unless destFile is a copy of the current version of the source
+func resumableDownload(log log.T, sourceURL string, source rangeSource, destFile string, settings downloadSettings) (output DownloadOutput, err error) {
+	d := &downloader{
+		log:         log,
+		sourceURL:   sourceURL,
+		source:      source,
+		settings:    settings,
+		destFile:    destFile,
+		eTagFile:    destFile + ".etag",
+		partialFile: destFile + partialFileSuffix,
+		stateFile:   destFile + stateFileSuffix,
+	}
+	d.limiter = newRateLimiter(d.settings.bytesPerSecond)
+
+	for attempt := 1; attempt <= maxDownloadAttempts; attempt++ {
+		if output, err = d.run(); err == nil {
+			return
+		}
+		if err == errSourceChanged {
+			log.Infof("%v changed since the download started, restarting the download", sourceURL)
+			d.discard()
+			continue
+		}
+		if !d.resumable() {
+			break
+		}
+		log.Warnf("download of %v interrupted after %v bytes, resuming, %v", sourceURL, d.written(), err)
+	}
+	log.Debugf("failed to download %v, %v", sourceURL, err)
+	if !d.resumable() {
+		d.discard()
+	}
+	fileutil.DeleteFile(destFile)
+	fileutil.DeleteFile(d.eTagFile)
+	return
+}
+
+// run makes one attempt to complete the download
+func (d *downloader) run() (output DownloadOutput, err error) {
+	atomic.StoreInt32(&d.aborted, 0)
+	var first *rangeResponse
+	if d.state = d.loadState(); d.state != nil {
+		d.log.Infof("resuming download of %v after %v bytes", d.sourceURL, d.written())
+	} else {
+		if first, err = d.source.get(d.log, rangeRequest{ifNoneMatch: d.cachedETag()}); err != nil {
+			return
+		}
+		if first.notModified {
+			d.log.Debugf("Unchanged file.")
+			output.LocalFilePath = d.destFile
+			return output, nil
+		}
+		d.start(first)
+	}
+
+	// the file is created with the permissions of os.Create, as the cached files always were
+	file, err := os.OpenFile(d.partialFile, os.O_WRONLY|os.O_CREATE, 0666)
+	if err != nil {
+		if first != nil {
+			first.body.Close()
+		}
+		return
+	}
+	err = d.fetchChunks(file, first)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		d.saveState()
+		return
+	}
+	return d.complete()
+}
+
+// start initializes the progress of a new download from the response to its first request
+func (d *downloader) start(first *rangeResponse) {
+	fileutil.DeleteFile(d.partialFile)
+	d.state = &downloadState{
+		SourceURL: d.sourceURL,
+		ETag:      first.etag,
+		Size:      first.size,
+	}
+	// chunks are only requested when the source can tell it has not changed in between
+	if first.acceptRanges && first.etag != "" && d.settings.concurrency > 1 && first.size >= 2*d.settings.chunkSize {
+		for offset := int64(0); offset < first.size; offset += d.settings.chunkSize {
+			length := d.settings.chunkSize
+			if offset+length > first.size {
+				length = first.size - offset
+			}
+			d.state.Chunks = append(d.state.Chunks, &downloadChunk{Offset: offset, Length: length})
+		}
+		d.log.Debugf("downloading %v bytes of %v in %v chunks", first.size, d.sourceURL, len(d.state.Chunks))
+	} else {
+		d.state.Chunks = []*downloadChunk{{Offset: 0, Length: first.size}}
+	}
+}
+
+// fetchChunks downloads the chunks that are not done yet, the response to the first request carries the first chunk
+func (d *downloader) fetchChunks(file *os.File, first *rangeResponse) error {
+	pending := make(chan *downloadChunk, len(d.state.Chunks))
+	for i, chunk := range d.state.Chunks {
+		if !chunk.done() && !(first != nil && i == 0) {
+			pending <- chunk
+		}
+	}
+	close(pending)
+
+	workers := d.settings.concurrency
+	if workers > len(d.state.Chunks) {
+		workers = len(d.state.Chunks)
+	}
+	errs := make(chan error, workers)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			if worker == 0 && first != nil {
+				if err := d.fetchChunk(file, d.state.Chunks[0], first); err != nil {
+					d.abort(errs, err)
+					return
+				}
+			}
+			for chunk := range pending {
+				if err := d.fetchChunk(file, chunk, nil); err != nil {
+					d.abort(errs, err)
+					return
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != errDownloadAborted {
+			return err
+		}
+	}
+	return nil
+}
+
+// abort stops the other chunks of the download and records the error
+func (d *downloader) abort(errs chan<- error, err error) {
+	atomic.StoreInt32(&d.aborted, 1)
+	errs <- err
+}
+
+// fetchChunk downloads the rest of a chunk, requesting it unless response is given
+func (d *downloader) fetchChunk(file *os.File, chunk *downloadChunk, response *rangeResponse) (err error) {
+	if response == nil {
+		request := rangeRequest{offset: chunk.Offset + chunk.Written, ifRange: d.state.ETag}
+		if chunk.Length >= 0 {
+			request.length = chunk.Length - chunk.Written
+		}
+		if response, err = d.source.get(d.log, request); err != nil {
+			return
+		}
+		if response.notModified || response.offset != request.offset || (response.etag != "" && response.etag != d.state.ETag) {
+			if response.body != nil {
+				response.body.Close()
+			}
+			return errSourceChanged
+		}
+	}
+	defer response.body.Close()
+
+	reader := io.Reader(response.body)
+	if chunk.Length >= 0 {
+		reader = io.LimitReader(reader, chunk.Length-chunk.Written)
+	}
+	buffer := make([]byte, downloadBufferSize)
+	for {
+		if atomic.LoadInt32(&d.aborted) != 0 {
+			return errDownloadAborted
+		}
+		n, readErr := reader.Read(buffer)
+		if n > 0 {
+			if _, err = file.WriteAt(buffer[:n], chunk.Offset+chunk.Written); err != nil {
+				return
+			}
+			d.advance(file, chunk, int64(n))
+			d.limiter.wait(n)
+		}
+		if readErr == io.EOF {
+			break
+		}
+		if readErr != nil {
+			return readErr
+		}
+	}
+
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	if chunk.Length < 0 {
+		chunk.Length = chunk.Written
+	} else if chunk.Written < chunk.Length {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+
+// advance records the bytes written for a chunk, saving the progress every stateSaveInterval bytes
+func (d *downloader) advance(file *os.File, chunk *downloadChunk, written int64) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	chunk.Written += written
+	d.unsaved += written
+	if d.unsaved < stateSaveInterval || !d.resumable() {
+		return
+	}
+	// the recorded progress must never be ahead of the content of the partial file
+	if err := file.Sync(); err != nil {
+		d.log.Debugf("failed to sync %v, %v", d.partialFile, err)
+		return
+	}
+	d.saveStateLocked()
+}
+
+// complete moves the partial file to the destination once all the chunks are downloaded
+func (d *downloader) complete() (output DownloadOutput, err error) {
+	size := d.written()
+	if d.state.Size >= 0 && size != d.state.Size {
+		d.discard()
+		return output, fmt.Errorf("downloaded %v bytes of %v, expected %v bytes", size, d.sourceURL, d.state.Size)
+	}
+	if err = os.Rename(d.partialFile, d.destFile); err != nil {
+		d.log.Errorf("failed to write destFile %v, %v ", d.destFile, err)
+		return
+	}
+	fileutil.DeleteFile(d.stateFile)
+	if d.state.ETag != "" {
+		d.log.Debug("file eTagValue is ", d.state.ETag)
+		if err = fileutil.WriteAllText(d.eTagFile, d.state.ETag); err != nil {
+			d.log.Errorf("failed to write eTagfile %v, %v ", d.eTagFile, err)
+			return
+		}
+	} else {
+		fileutil.DeleteFile(d.eTagFile)
+	}
+	d.log.Infof("%s with %v bytes downloaded", d.destFile, size)
+	output.LocalFilePath = d.destFile
+	output.IsUpdated = true
+	return output, nil
+}
+
+// cachedETag returns the etag of the cached copy of the source, if any
+func (d *downloader) cachedETag() string {
+	if !fileutil.Exists(d.destFile) || !fileutil.Exists(d.eTagFile) {
+		return ""
+	}
+	eTag, err := fileutil.ReadAllText(d.eTagFile)
+	if err != nil {
+		d.log.Debugf("failed to read etag file %v, %v", d.eTagFile, err)
+		return ""
+	}
+	d.log.Debugf("destFile exists at %v, etag file exists at %v", d.destFile, d.eTagFile)
+	return eTag
+}
+
+// written returns the number of bytes downloaded so far
+func (d *downloader) written() (written int64) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	if d.state == nil {
+		return 0
+	}
+	for _, chunk := range d.state.Chunks {
+		written += chunk.Written
+	}
+	return written
+}
+
+// resumable returns whether the download can continue from the partial file
+func (d *downloader) resumable() bool {
+	return d.state != nil && d.state.ETag != ""
+}
+
+// loadState returns the progress of a previous attempt to download the same source, nil if there is none
+func (d *downloader) loadState() *downloadState {
+	if !fileutil.Exists(d.stateFile) {
+		return nil
+	}
+	var state downloadState
+	content, err := ioutil.ReadFile(d.stateFile)
+	if err == nil {
+		err = json.Unmarshal(content, &state)
+	}
+	if err != nil || state.SourceURL != d.sourceURL || state.ETag == "" || len(state.Chunks) == 0 || !fileutil.Exists(d.partialFile) {
+		d.log.Debugf("discarding the partial download of %v", d.sourceURL)
+		d.discard()
+		return nil
+	}
+	return &state
+}
+
+// saveState records the progress of the download if it can be resumed, the partial file of
+// a download that cannot be resumed is left to the caller, which discards it once the download fails
+func (d *downloader) saveState() {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	d.saveStateLocked()
+}
+
+func (d *downloader) saveStateLocked() {
+	if !d.resumable() {
+		return
+	}
+	content, err := json.Marshal(d.state)
+	if err == nil {
+		err = ioutil.WriteFile(d.stateFile, content, appconfig.ReadWriteAccess)
+	}
+	if err != nil {
+		d.log.Debugf("failed to save the progress of the download of %v, %v", d.sourceURL, err)
+		return
+	}
+	d.unsaved = 0
+}
+
+// discard removes the partial file and its progress
+func (d *downloader) discard() {
+	fileutil.DeleteFile(d.partialFile)
+	fileutil.DeleteFile(d.stateFile)
+}
+
+// rateLimiter spreads the bytes transferred by all the chunks of a download to stay under a bandwidth limit
+type rateLimiter struct {
+	mutex          sync.Mutex
+	bytesPerSecond int64
+	next           time.Time
+}
+
+// newRateLimiter returns a limiter for the given bandwidth, nil for no limit
+func newRateLimiter(bytesPerSecond int64) *rateLimiter {
+	if bytesPerSecond <= 0 {
+		return nil
+	}
+	return &rateLimiter{bytesPerSecond: bytesPerSecond}
+}
+
+// wait blocks until the transfer of n bytes fits in the bandwidth limit
+func (r *rateLimiter) wait(n int) {
+	if r == nil {
+		return
+	}
+	r.mutex.Lock()
+	now := time.Now()
+	if r.next.Before(now) {
+		r.next = now
+	}
+	r.next = r.next.Add(time.Duration(int64(n) * int64(time.Second) / r.bytesPerSecond))
+	delay := r.next.Sub(now)
+	r.mutex.Unlock()
+	time.Sleep(delay)
+}
@@ -0,0 +1,238 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package artifact
+
+import (
+	"bytes"
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/stretchr/testify/assert"
+)
+
+// testServer serves content with range support and records the Range headers of the requests
+type testServer struct {
+	*httptest.Server
+	content []byte
+	etag    string
+
+	mutex  sync.Mutex
+	ranges []string
+}
+
+func newTestServer(content []byte, etag string) *testServer {
+	server := &testServer{content: content, etag: etag}
+	server.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		server.mutex.Lock()
+		server.ranges = append(server.ranges, r.Header.Get("Range"))
+		server.mutex.Unlock()
+		w.Header().Set("Etag", server.etag)
+		http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(server.content))
+	}))
+	return server
+}
+
+func (s *testServer) requestedRanges() []string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return append([]string(nil), s.ranges...)
+}
+
+func testContent(size int) []byte {
+	content := make([]byte, size)
+	for i := range content {
+		content[i] = byte(i % 251)
+	}
+	return content
+}
+
+// tempFile returns a path in a new temporary directory and the function removing the directory
+func tempFile(t *testing.T) (string, func()) {
+	dir, err := ioutil.TempDir("", "artifact")
+	assert.NoError(t, err)
+	return filepath.Join(dir, "file"), func() { os.RemoveAll(dir) }
+}
+
+func testDownload(t *testing.T, server *testServer, destFile string, settings downloadSettings) (DownloadOutput, error) {
+	source := &httpSource{fileURL: server.URL}
+	return resumableDownload(log.NewMockLog(), server.URL, source, destFile, settings)
+}
+
+func TestResumableDownloadUsesETagOfCachedFile(t *testing.T) {
+	server := newTestServer(testContent(1000), `"v1"`)
+	defer server.Close()
+	destFile, cleanup := tempFile(t)
+	defer cleanup()
+	settings := downloadSettings{concurrency: 1, chunkSize: 1024}
+
+	output, err := testDownload(t, server, destFile, settings)
+	assert.NoError(t, err)
+	assert.True(t, output.IsUpdated)
+	downloaded, _ := ioutil.ReadFile(destFile)
+	assert.Equal(t, server.content, downloaded)
+	eTag, _ := ioutil.ReadFile(destFile + ".etag")
+	assert.Equal(t, `"v1"`, string(eTag))
+
+	output, err = testDownload(t, server, destFile, settings)
+	assert.NoError(t, err)
+	assert.False(t, output.IsUpdated)
+	assert.Equal(t, destFile, output.LocalFilePath)
+}
+
+func TestResumableDownloadResumesPartialFile(t *testing.T) {
+	server := newTestServer(testContent(1000), `"v1"`)
+	defer server.Close()
+	destFile, cleanup := tempFile(t)
+	defer cleanup()
+
+	// a previous attempt stopped after 400 bytes
+	assert.NoError(t, ioutil.WriteFile(destFile+partialFileSuffix, server.content[:400], 0600))
+	state, _ := json.Marshal(downloadState{
+		SourceURL: server.URL,
+		ETag:      `"v1"`,
+		Size:      1000,
+		Chunks:    []*downloadChunk{{Offset: 0, Length: 1000, Written: 400}},
+	})
+	assert.NoError(t, ioutil.WriteFile(destFile+stateFileSuffix, state, 0600))
+
+	output, err := testDownload(t, server, destFile, downloadSettings{concurrency: 1, chunkSize: 1024})
+	assert.NoError(t, err)
+	assert.True(t, output.IsUpdated)
+	assert.Equal(t, []string{"bytes=400-999"}, server.requestedRanges())
+	downloaded, _ := ioutil.ReadFile(destFile)
+	assert.Equal(t, server.content, downloaded)
+	_, err = os.Stat(destFile + stateFileSuffix)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestResumableDownloadRestartsWhenSourceChanged(t *testing.T) {
+	server := newTestServer(testContent(1000), `"v2"`)
+	defer server.Close()
+	destFile, cleanup := tempFile(t)
+	defer cleanup()
+
+	assert.NoError(t, ioutil.WriteFile(destFile+partialFileSuffix, bytes.Repeat([]byte{'x'}, 400), 0600))
+	state, _ := json.Marshal(downloadState{
+		SourceURL: server.URL,
+		ETag:      `"v1"`,
+		Size:      1000,
+		Chunks:    []*downloadChunk{{Offset: 0, Length: 1000, Written: 400}},
+	})
+	assert.NoError(t, ioutil.WriteFile(destFile+stateFileSuffix, state, 0600))
+
+	_, err := testDownload(t, server, destFile, downloadSettings{concurrency: 1, chunkSize: 1024})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"bytes=400-999", ""}, server.requestedRanges())
+	downloaded, _ := ioutil.ReadFile(destFile)
+	assert.Equal(t, server.content, downloaded)
+}
+
+func TestResumableDownloadInChunks(t *testing.T) {
+	server := newTestServer(testContent(10000), `"v1"`)
+	defer server.Close()
+	destFile, cleanup := tempFile(t)
+	defer cleanup()
+
+	output, err := testDownload(t, server, destFile, downloadSettings{concurrency: 3, chunkSize: 3000})
+	assert.NoError(t, err)
+	assert.True(t, output.IsUpdated)
+	ranges := server.requestedRanges()
+	sort.Strings(ranges)
+	assert.Equal(t, []string{"", "bytes=3000-5999", "bytes=6000-8999", "bytes=9000-9999"}, ranges)
+	downloaded, _ := ioutil.ReadFile(destFile)
+	assert.Equal(t, server.content, downloaded)
+}
+
+func TestResumableDownloadWithoutETag(t *testing.T) {
+	// the progress of a source without etag is never saved, the partial file must be kept until the download completes
+	server := newTestServer(testContent(stateSaveInterval+2*1024*1024), "")
+	defer server.Close()
+	destFile, cleanup := tempFile(t)
+	defer cleanup()
+
+	output, err := testDownload(t, server, destFile, downloadSettings{concurrency: 1, chunkSize: 1024 * 1024})
+	assert.NoError(t, err)
+	assert.True(t, output.IsUpdated)
+	downloaded, _ := ioutil.ReadFile(destFile)
+	assert.Equal(t, server.content, downloaded)
+	_, err = os.Stat(destFile + stateFileSuffix)
+	assert.True(t, os.IsNotExist(err))
+	_, err = os.Stat(destFile + ".etag")
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestResumableDownloadFailureRemovesCachedFile(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+	destFile, cleanup := tempFile(t)
+	defer cleanup()
+	assert.NoError(t, ioutil.WriteFile(destFile, []byte("old"), 0600))
+
+	_, err := resumableDownload(log.NewMockLog(), server.URL, &httpSource{fileURL: server.URL}, destFile, downloadSettings{concurrency: 1, chunkSize: 1024})
+	assert.Error(t, err)
+	_, err = os.Stat(destFile)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestRateLimiter(t *testing.T) {
+	limiter := newRateLimiter(100 * 1024)
+	start := time.Now()
+	for i := 0; i < 4; i++ {
+		limiter.wait(10 * 1024)
+	}
+	assert.True(t, time.Since(start) >= 350*time.Millisecond)
+	assert.Nil(t, newRateLimiter(0))
+}
+
+func TestParseContentRange(t *testing.T) {
+	offset, size, err := parseContentRange("bytes 100-199/1000")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(100), offset)
+	assert.Equal(t, int64(1000), size)
+
+	offset, size, err = parseContentRange("bytes 100-199/*")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(100), offset)
+	assert.Equal(t, int64(-1), size)
+
+	_, _, err = parseContentRange("bytes */1000")
+	assert.Error(t, err)
+}
+
+func TestVerifyHashSha512(t *testing.T) {
+	filePath, cleanup := tempFile(t)
+	defer cleanup()
+	assert.NoError(t, ioutil.WriteFile(filePath, []byte("content"), 0600))
+	hash := sha512.Sum512([]byte("content"))
+	output := DownloadOutput{LocalFilePath: filePath}
+
+	matched, err := VerifyHash(log.NewMockLog(), DownloadInput{SourceChecksums: map[string]string{"sha512": hex.EncodeToString(hash[:])}}, output)
+	assert.NoError(t, err)
+	assert.True(t, matched)
+
+	matched, err = VerifyHash(log.NewMockLog(), DownloadInput{SourceChecksums: map[string]string{"SHA512": "00"}}, output)
+	assert.Error(t, err)
+	assert.False(t, matched)
+}
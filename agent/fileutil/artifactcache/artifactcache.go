@@ -0,0 +1,367 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package artifactcache implements a cache of downloaded artifacts keyed by their hash,
+// shared by the plugins downloading packages, content and agent updates.
+package artifactcache
+
+import (
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/fileutil"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+)
+
+const (
+	// AlgorithmSHA256 is the name of the sha256 checksums
+	AlgorithmSHA256 = "sha256"
+	// AlgorithmSHA512 is the name of the sha512 checksums
+	AlgorithmSHA512 = "sha512"
+
+	pinsDirectory = "pins"
+	tempPrefix    = ".tmp-"
+	// staleTempAge is the age after which a temporary file left by an interrupted Add is removed
+	staleTempAge = time.Hour
+)
+
+// algorithms lists the supported algorithms, the strongest first
+var algorithms = []string{AlgorithmSHA512, AlgorithmSHA256}
+
+// Entry describes an artifact of the cache
+type Entry struct {
+	Algorithm string    `json:"algorithm"`
+	Hash      string    `json:"hash"`
+	Size      int64     `json:"size"`
+	LastUsed  time.Time `json:"lastUsed"`
+	PinnedBy  []string  `json:"pinnedBy,omitempty"`
+}
+
+// pin keeps an artifact in the cache as long as its owner path exists
+type pin struct {
+	Owner string
+	// Hashes holds the hash of the artifact by algorithm
+	Hashes map[string]string
+}
+
+// Cache stores the artifacts under root/algorithm/hash, the modification time of an artifact is the last time it was used.
+// The cache only relies on the file system, so that the agent, the document workers and the cli can share it.
+type Cache struct {
+	root         string
+	maxSizeBytes int64
+}
+
+// New creates a cache in the root directory, pruned to maxSizeBytes whenever an artifact is added
+func New(root string, maxSizeBytes int64) *Cache {
+	return &Cache{root: root, maxSizeBytes: maxSizeBytes}
+}
+
+// Default returns the cache of the agent configuration, nil if the cache is disabled
+func Default() *Cache {
+	config, err := appconfig.Config(false)
+	if err != nil {
+		config = appconfig.DefaultConfig()
+	}
+	if config.Download.CacheSizeMB <= 0 {
+		return nil
+	}
+	return New(appconfig.ArtifactCacheRoot, int64(config.Download.CacheSizeMB)*1024*1024)
+}
+
+// Key returns the strongest checksum supported by the cache, found is false if there is none
+func Key(checksums map[string]string) (algorithm string, hash string, found bool) {
+	for _, candidate := range algorithms {
+		for name, value := range checksums {
+			if value = strings.ToLower(value); strings.EqualFold(name, candidate) && validHash(candidate, value) {
+				return candidate, value, true
+			}
+		}
+	}
+	return "", "", false
+}
+
+// validHash returns whether hash is a hexadecimal hash of the algorithm, which also makes it safe to use as a file name
+func validHash(algorithm string, hash string) bool {
+	decoded, err := hex.DecodeString(hash)
+	if err != nil {
+		return false
+	}
+	switch algorithm {
+	case AlgorithmSHA256:
+		return len(decoded) == sha256.Size
+	case AlgorithmSHA512:
+		return len(decoded) == sha512.Size
+	}
+	return false
+}
+
+func newHash(algorithm string) hash.Hash {
+	if algorithm == AlgorithmSHA512 {
+		return sha512.New()
+	}
+	return sha256.New()
+}
+
+func (c *Cache) path(algorithm string, hash string) string {
+	return filepath.Join(c.root, algorithm, hash)
+}
+
+// CopyTo copies the artifact to destPath, found is false if the artifact is not in the cache or is corrupted
+func (c *Cache) CopyTo(log log.T, algorithm string, hash string, destPath string) (found bool, err error) {
+	hash = strings.ToLower(hash)
+	if !validHash(algorithm, hash) {
+		return false, nil
+	}
+	artifactPath := c.path(algorithm, hash)
+	if !fileutil.Exists(artifactPath) {
+		return false, nil
+	}
+	hasher := newHash(algorithm)
+	if err = copyFile(artifactPath, destPath, hasher); err != nil {
+		return false, err
+	}
+	if hex.EncodeToString(hasher.Sum(nil)) != hash {
+		log.Warnf("removing corrupted artifact %v from the cache", artifactPath)
+		fileutil.DeleteFile(artifactPath)
+		fileutil.DeleteFile(destPath)
+		return false, nil
+	}
+	c.touch(artifactPath)
+	return true, nil
+}
+
+// Add copies the file to the cache and evicts the least recently used artifacts if the cache grew too large.
+// The caller is responsible for the hash matching the content of the file.
+func (c *Cache) Add(log log.T, algorithm string, hash string, filePath string) (err error) {
+	hash = strings.ToLower(hash)
+	if !validHash(algorithm, hash) {
+		return fmt.Errorf("invalid %v hash %v", algorithm, hash)
+	}
+	artifactPath := c.path(algorithm, hash)
+	if fileutil.Exists(artifactPath) {
+		c.touch(artifactPath)
+		return nil
+	}
+	if err = fileutil.MakeDirs(filepath.Dir(artifactPath)); err != nil {
+		return
+	}
+	// the artifact only appears under its name once complete
+	tempPath := filepath.Join(filepath.Dir(artifactPath), fmt.Sprintf("%v%v-%v", tempPrefix, hash, os.Getpid()))
+	if err = copyFile(filePath, tempPath, nil); err == nil {
+		err = os.Rename(tempPath, artifactPath)
+	}
+	if err != nil {
+		fileutil.DeleteFile(tempPath)
+		return
+	}
+	log.Debugf("added %v to the artifact cache as %v", filePath, artifactPath)
+	_, err = c.Prune(log, c.maxSizeBytes)
+	return
+}
+
+// Pin keeps the artifact in the cache as long as the owner path exists or until the owner is unpinned.
+// An owner pins a single artifact, pinning another one replaces the previous pin.
+func (c *Cache) Pin(owner string, algorithm string, hash string) error {
+	return c.savePin(pin{Owner: owner, Hashes: map[string]string{algorithm: strings.ToLower(hash)}})
+}
+
+// PinFile pins the artifact with the content of the file, whichever algorithm the artifact is keyed by
+func (c *Cache) PinFile(owner string, filePath string) error {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	hashers := make(map[string]hash.Hash)
+	writers := make([]io.Writer, 0, len(algorithms))
+	for _, algorithm := range algorithms {
+		hashers[algorithm] = newHash(algorithm)
+		writers = append(writers, hashers[algorithm])
+	}
+	if _, err = io.Copy(io.MultiWriter(writers...), file); err != nil {
+		return err
+	}
+	p := pin{Owner: owner, Hashes: make(map[string]string)}
+	for algorithm, hasher := range hashers {
+		p.Hashes[algorithm] = hex.EncodeToString(hasher.Sum(nil))
+	}
+	return c.savePin(p)
+}
+
+func (c *Cache) savePin(p pin) error {
+	content, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+	if err = fileutil.MakeDirs(filepath.Join(c.root, pinsDirectory)); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(c.pinPath(p.Owner), content, appconfig.ReadWriteAccess)
+}
+
+// Unpin removes the pin of the owner
+func (c *Cache) Unpin(owner string) error {
+	if err := os.Remove(c.pinPath(owner)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func (c *Cache) pinPath(owner string) string {
+	return filepath.Join(c.root, pinsDirectory, fmt.Sprintf("%x.json", sha1.Sum([]byte(owner))))
+}
+
+// pins returns the owners of the pinned artifacts by artifact path, the pins of owners that no longer exist are removed
+func (c *Cache) pins() map[string][]string {
+	pinned := make(map[string][]string)
+	pinsPath := filepath.Join(c.root, pinsDirectory)
+	files, err := ioutil.ReadDir(pinsPath)
+	if err != nil {
+		return pinned
+	}
+	for _, file := range files {
+		pinPath := filepath.Join(pinsPath, file.Name())
+		var p pin
+		content, err := ioutil.ReadFile(pinPath)
+		if err == nil {
+			err = json.Unmarshal(content, &p)
+		}
+		if err != nil || !fileutil.Exists(p.Owner) {
+			os.Remove(pinPath)
+			continue
+		}
+		for algorithm, hash := range p.Hashes {
+			artifactPath := c.path(algorithm, hash)
+			pinned[artifactPath] = append(pinned[artifactPath], p.Owner)
+		}
+	}
+	return pinned
+}
+
+// List returns the artifacts of the cache, the most recently used first
+func (c *Cache) List() (entries []Entry, err error) {
+	pinned := c.pins()
+	entries = []Entry{}
+	for _, algorithm := range algorithms {
+		files, err := ioutil.ReadDir(filepath.Join(c.root, algorithm))
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		for _, file := range files {
+			if file.IsDir() || !validHash(algorithm, file.Name()) {
+				continue
+			}
+			entry := Entry{
+				Algorithm: algorithm,
+				Hash:      file.Name(),
+				Size:      file.Size(),
+				LastUsed:  file.ModTime(),
+				PinnedBy:  pinned[c.path(algorithm, file.Name())],
+			}
+			sort.Strings(entry.PinnedBy)
+			entries = append(entries, entry)
+		}
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].LastUsed.After(entries[j].LastUsed)
+	})
+	return entries, nil
+}
+
+// Prune evicts the least recently used artifacts which are not pinned until the cache is no larger than maxSizeBytes
+func (c *Cache) Prune(log log.T, maxSizeBytes int64) (removed []Entry, err error) {
+	c.removeStaleTempFiles()
+	entries, err := c.List()
+	if err != nil {
+		return nil, err
+	}
+	var size int64
+	for _, entry := range entries {
+		size += entry.Size
+	}
+	removed = []Entry{}
+	for i := len(entries) - 1; i >= 0 && size > maxSizeBytes; i-- {
+		entry := entries[i]
+		if len(entry.PinnedBy) > 0 {
+			continue
+		}
+		// another process may have evicted it already
+		if err := os.Remove(c.path(entry.Algorithm, entry.Hash)); err != nil && !os.IsNotExist(err) {
+			log.Warnf("failed to evict %v %v from the artifact cache, %v", entry.Algorithm, entry.Hash, err)
+			continue
+		}
+		log.Debugf("evicted %v %v from the artifact cache", entry.Algorithm, entry.Hash)
+		size -= entry.Size
+		removed = append(removed, entry)
+	}
+	return removed, nil
+}
+
+// removeStaleTempFiles removes the temporary files left by interrupted additions
+func (c *Cache) removeStaleTempFiles() {
+	for _, algorithm := range algorithms {
+		directory := filepath.Join(c.root, algorithm)
+		files, err := ioutil.ReadDir(directory)
+		if err != nil {
+			continue
+		}
+		for _, file := range files {
+			if strings.HasPrefix(file.Name(), tempPrefix) && time.Since(file.ModTime()) > staleTempAge {
+				os.Remove(filepath.Join(directory, file.Name()))
+			}
+		}
+	}
+}
+
+// touch records that the artifact was used
+func (c *Cache) touch(artifactPath string) {
+	now := time.Now()
+	os.Chtimes(artifactPath, now, now)
+}
+
+// copyFile copies the file, writing its content to hasher too when not nil
+func copyFile(srcPath string, destPath string, hasher hash.Hash) (err error) {
+	src, err := os.Open(srcPath)
+	if err != nil {
+		return
+	}
+	defer src.Close()
+	dest, err := os.Create(destPath)
+	if err != nil {
+		return
+	}
+	writer := io.Writer(dest)
+	if hasher != nil {
+		writer = io.MultiWriter(dest, hasher)
+	}
+	_, err = io.Copy(writer, src)
+	if closeErr := dest.Close(); err == nil {
+		err = closeErr
+	}
+	return
+}
@@ -0,0 +1,138 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package artifactcache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/stretchr/testify/assert"
+)
+
+func sha256Of(content string) string {
+	hash := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(hash[:])
+}
+
+// addArtifact adds an artifact with the content to the cache and sets its last use
+func addArtifact(t *testing.T, cache *Cache, dir string, content string, lastUsed time.Time) string {
+	filePath := filepath.Join(dir, "download")
+	assert.NoError(t, ioutil.WriteFile(filePath, []byte(content), 0600))
+	hash := sha256Of(content)
+	assert.NoError(t, cache.Add(log.NewMockLog(), AlgorithmSHA256, hash, filePath))
+	os.Chtimes(cache.path(AlgorithmSHA256, hash), lastUsed, lastUsed)
+	return hash
+}
+
+func TestKey(t *testing.T) {
+	sha512Hash := hex.EncodeToString(make([]byte, 64))
+	algorithm, hash, found := Key(map[string]string{"SHA256": sha256Of("a"), "sha512": sha512Hash, "md5": "00"})
+	assert.True(t, found)
+	assert.Equal(t, AlgorithmSHA512, algorithm)
+	assert.Equal(t, sha512Hash, hash)
+
+	_, _, found = Key(map[string]string{"sha256": "../../etc/passwd"})
+	assert.False(t, found)
+	_, _, found = Key(map[string]string{"": sha256Of("a")})
+	assert.False(t, found)
+}
+
+func TestAddAndCopyTo(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "artifactcache")
+	defer os.RemoveAll(dir)
+	cache := New(filepath.Join(dir, "cache"), 1024)
+	hash := addArtifact(t, cache, dir, "content", time.Now())
+
+	destPath := filepath.Join(dir, "copy")
+	found, err := cache.CopyTo(log.NewMockLog(), AlgorithmSHA256, hash, destPath)
+	assert.NoError(t, err)
+	assert.True(t, found)
+	content, _ := ioutil.ReadFile(destPath)
+	assert.Equal(t, "content", string(content))
+
+	found, err = cache.CopyTo(log.NewMockLog(), AlgorithmSHA256, sha256Of("other"), destPath)
+	assert.NoError(t, err)
+	assert.False(t, found)
+}
+
+func TestCopyToRemovesCorruptedArtifact(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "artifactcache")
+	defer os.RemoveAll(dir)
+	cache := New(filepath.Join(dir, "cache"), 1024)
+	hash := addArtifact(t, cache, dir, "content", time.Now())
+	assert.NoError(t, ioutil.WriteFile(cache.path(AlgorithmSHA256, hash), []byte("corrupted"), 0600))
+
+	found, err := cache.CopyTo(log.NewMockLog(), AlgorithmSHA256, hash, filepath.Join(dir, "copy"))
+	assert.NoError(t, err)
+	assert.False(t, found)
+	entries, _ := cache.List()
+	assert.Empty(t, entries)
+}
+
+func TestPruneEvictsLeastRecentlyUsedUnpinnedArtifacts(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "artifactcache")
+	defer os.RemoveAll(dir)
+	cache := New(filepath.Join(dir, "cache"), 1024)
+	now := time.Now()
+	oldest := addArtifact(t, cache, dir, "oldest", now.Add(-3*time.Hour))
+	older := addArtifact(t, cache, dir, "older", now.Add(-2*time.Hour))
+	recent := addArtifact(t, cache, dir, "recent", now.Add(-time.Hour))
+
+	// the oldest artifact is pinned by an installed package
+	packageDir := filepath.Join(dir, "packages", "1.0")
+	assert.NoError(t, os.MkdirAll(packageDir, 0700))
+	assert.NoError(t, cache.Pin(packageDir, AlgorithmSHA256, oldest))
+
+	removed, err := cache.Prune(log.NewMockLog(), int64(len("oldest")+len("recent")))
+	assert.NoError(t, err)
+	assert.Len(t, removed, 1)
+	assert.Equal(t, older, removed[0].Hash)
+
+	entries, _ := cache.List()
+	assert.Len(t, entries, 2)
+	assert.Equal(t, recent, entries[0].Hash)
+	assert.Equal(t, oldest, entries[1].Hash)
+	assert.Equal(t, []string{packageDir}, entries[1].PinnedBy)
+
+	// pins of removed package versions no longer count
+	os.RemoveAll(packageDir)
+	removed, err = cache.Prune(log.NewMockLog(), 0)
+	assert.NoError(t, err)
+	assert.Len(t, removed, 2)
+	entries, _ = cache.List()
+	assert.Empty(t, entries)
+}
+
+func TestPinFile(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "artifactcache")
+	defer os.RemoveAll(dir)
+	cache := New(filepath.Join(dir, "cache"), 1024)
+	hash := addArtifact(t, cache, dir, "content", time.Now())
+
+	assert.NoError(t, cache.PinFile(dir, filepath.Join(dir, "download")))
+	entries, _ := cache.List()
+	assert.Len(t, entries, 1)
+	assert.Equal(t, hash, entries[0].Hash)
+	assert.Equal(t, []string{dir}, entries[0].PinnedBy)
+
+	assert.NoError(t, cache.Unpin(dir))
+	entries, _ = cache.List()
+	assert.Empty(t, entries[0].PinnedBy)
+}
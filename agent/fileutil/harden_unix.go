@@ -16,6 +16,7 @@
 package fileutil
 
 import (
+	"fmt"
 	"os"
 	"syscall"
 )
@@ -49,3 +50,23 @@ func Harden(path string) (err error) {
 	}
 	return
 }
+
+// CheckTrustedPermissions returns an error when the path is not owned by root or the user of the agent,
+// or when the group or other users can modify it.
+func CheckTrustedPermissions(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return fmt.Errorf("failed to read the owner of %v", path)
+	}
+	if stat.Uid != rootUid && int(stat.Uid) != os.Geteuid() {
+		return fmt.Errorf("%v is owned by user %v, expected root or the user of the agent", path, stat.Uid)
+	}
+	if info.Mode().Perm()&0022 != 0 {
+		return fmt.Errorf("%v is writable by group or others", path)
+	}
+	return nil
+}
@@ -16,8 +16,10 @@
 package fileutil
 
 import (
+	"encoding/binary"
 	"fmt"
 	"os"
+	"syscall"
 	"unsafe"
 
 	acl "github.com/hectane/go-acl"
@@ -73,3 +75,141 @@ func mallocSID(sidSize int) (sidPtr *windows.SID, sidLen uint32) {
 	sidLen = uint32(len(sid))
 	return
 }
+
+const (
+	seFileObject               = 1
+	ownerSecurityInformation   = 0x1
+	daclSecurityInformation    = 0x4
+	accessAllowedAceType       = 0x0
+	inheritOnlyAce             = 0x8
+	aclHeaderSize              = 8
+	accessAllowedAceSidOffset  = 8
+	accessAllowedAceHeaderSize = 4
+
+	// writeAccess are the rights allowing to modify a file or a directory, or its security descriptor
+	writeAccess = 0x2 | // FILE_WRITE_DATA, FILE_ADD_FILE
+		0x4 | // FILE_APPEND_DATA, FILE_ADD_SUBDIRECTORY
+		0x40 | // FILE_DELETE_CHILD
+		0x10000 | // DELETE
+		0x40000 | // WRITE_DAC
+		0x80000 | // WRITE_OWNER
+		0x10000000 | // GENERIC_ALL
+		0x40000000 // GENERIC_WRITE
+)
+
+var (
+	advapi32                 = syscall.NewLazyDLL("advapi32.dll")
+	getNamedSecurityInfoProc = advapi32.NewProc("GetNamedSecurityInfoW")
+
+	// trustedSidStrings are the accounts allowed to own and modify trusted files besides the user of the agent:
+	// LocalSystem, Administrators and TrustedInstaller
+	trustedSidStrings = []string{
+		"S-1-5-18",
+		"S-1-5-32-544",
+		"S-1-5-80-956008885-3418522649-1831038044-1856292015-2024683025",
+	}
+)
+
+// CheckTrustedPermissions returns an error when the path is not owned by LocalSystem, Administrators, TrustedInstaller
+// or the user of the agent, or when its ACL allows other accounts to modify it.
+func CheckTrustedPermissions(path string) error {
+	trusted, err := trustedSids()
+	if err != nil {
+		return err
+	}
+	pathPtr, err := syscall.UTF16PtrFromString(path)
+	if err != nil {
+		return err
+	}
+	var owner *windows.SID
+	var dacl *byte
+	var securityDescriptor windows.Handle
+	if rc, _, _ := syscall.Syscall9(getNamedSecurityInfoProc.Addr(), 8,
+		uintptr(unsafe.Pointer(pathPtr)),
+		seFileObject,
+		ownerSecurityInformation|daclSecurityInformation,
+		uintptr(unsafe.Pointer(&owner)),
+		0,
+		uintptr(unsafe.Pointer(&dacl)),
+		0,
+		uintptr(unsafe.Pointer(&securityDescriptor)),
+		0); rc != 0 {
+		return fmt.Errorf("failed to read the security descriptor of %v, %v", path, syscall.Errno(rc))
+	}
+	defer windows.LocalFree(securityDescriptor)
+
+	if owner == nil || !isTrusted(owner, trusted) {
+		return fmt.Errorf("%v is owned by %v, expected LocalSystem, Administrators or the user of the agent", path, sidString(owner))
+	}
+	// a null DACL grants full access to everyone
+	if dacl == nil {
+		return fmt.Errorf("%v has no ACL", path)
+	}
+	aclSize := binary.LittleEndian.Uint16((*[aclHeaderSize]byte)(unsafe.Pointer(dacl))[2:4])
+	acl := (*[1 << 16]byte)(unsafe.Pointer(dacl))[:aclSize:aclSize]
+	aceCount := int(binary.LittleEndian.Uint16(acl[4:6]))
+	for i, offset := 0, aclHeaderSize; i < aceCount; i++ {
+		if offset+accessAllowedAceHeaderSize > len(acl) {
+			return fmt.Errorf("invalid ACL of %v", path)
+		}
+		aceType, aceFlags := acl[offset], acl[offset+1]
+		aceSize := int(binary.LittleEndian.Uint16(acl[offset+2 : offset+4]))
+		if aceSize < accessAllowedAceHeaderSize || offset+aceSize > len(acl) {
+			return fmt.Errorf("invalid ACL of %v", path)
+		}
+		// deny entries do not grant access, and inherit only entries only apply to the children
+		if aceType == accessAllowedAceType && aceFlags&inheritOnlyAce == 0 && aceSize > accessAllowedAceSidOffset {
+			mask := binary.LittleEndian.Uint32(acl[offset+4 : offset+8])
+			sid := (*windows.SID)(unsafe.Pointer(&acl[offset+accessAllowedAceSidOffset]))
+			if mask&writeAccess != 0 && !isTrusted(sid, trusted) {
+				return fmt.Errorf("%v is writable by %v", path, sidString(sid))
+			}
+		}
+		offset += aceSize
+	}
+	return nil
+}
+
+// trustedSids returns the accounts allowed to own and modify trusted files
+func trustedSids() (sids []*windows.SID, err error) {
+	for _, sidString := range trustedSidStrings {
+		sid, err := windows.StringToSid(sidString)
+		if err != nil {
+			return nil, err
+		}
+		sids = append(sids, sid)
+	}
+	token, err := windows.OpenCurrentProcessToken()
+	if err != nil {
+		return nil, err
+	}
+	defer token.Close()
+	user, err := token.GetTokenUser()
+	if err != nil {
+		return nil, err
+	}
+	agentSid, err := user.User.Sid.Copy()
+	if err != nil {
+		return nil, err
+	}
+	return append(sids, agentSid), nil
+}
+
+func isTrusted(sid *windows.SID, trusted []*windows.SID) bool {
+	for _, trustedSid := range trusted {
+		if windows.EqualSid(sid, trustedSid) {
+			return true
+		}
+	}
+	return false
+}
+
+func sidString(sid *windows.SID) string {
+	if sid == nil {
+		return "nobody"
+	}
+	if value, err := sid.String(); err == nil {
+		return value
+	}
+	return "an unknown account"
+}
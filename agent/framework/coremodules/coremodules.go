@@ -19,6 +19,7 @@ import (
 	"github.com/aws/amazon-ssm-agent/agent/contracts"
 	"github.com/aws/amazon-ssm-agent/agent/health"
 	"github.com/aws/amazon-ssm-agent/agent/longrunning/manager"
+	"github.com/aws/amazon-ssm-agent/agent/metrics"
 	"github.com/aws/amazon-ssm-agent/agent/runcommand"
 	"github.com/aws/amazon-ssm-agent/agent/session"
 	"github.com/aws/amazon-ssm-agent/agent/ssm"
@@ -41,6 +42,9 @@ func RegisteredCoreModules(context context.T) *ModuleRegistry {
 
 // register core modules here
 func loadCoreModules(context context.T) {
+	if context.AppConfig().Metrics.Enabled {
+		registeredCoreModules = append(registeredCoreModules, metrics.NewMetricsServer(context))
+	}
 	if !context.AppConfig().Agent.ContainerMode {
 		registeredCoreModules = append(registeredCoreModules, health.NewHealthCheck(context, ssm.NewService()))
 		registeredCoreModules = append(registeredCoreModules, runcommand.NewMDSService(context))
@@ -0,0 +1,345 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package docmanager
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"sync"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/fileutil"
+	"github.com/aws/amazon-ssm-agent/agent/jsonutil"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/boltdb/bolt"
+)
+
+const (
+	// documentStoreOpenTimeout is how long to wait for the lock held by another process on the database file
+	documentStoreOpenTimeout = 5 * time.Second
+)
+
+// documentStoreIdleTimeout is how long the handle of a database is kept once its last operation is done
+var documentStoreIdleTimeout = time.Second
+
+// migratedLocationFolders are the bookkeeping folders whose state files are imported into the embedded store
+var migratedLocationFolders = []string{
+	appconfig.DefaultLocationOfPending,
+	appconfig.DefaultLocationOfCurrent,
+	appconfig.DefaultLocationOfCorrupt,
+}
+
+// documentStore is the handle of a database shared by the managers of the process
+type documentStore struct {
+	db       *bolt.DB
+	lastUsed time.Time
+}
+
+// bolt locks the database file for the lifetime of the handle, so the managers of the process share a single handle
+// per database, kept open while documents are processed. The document workers running nested documents and the cli
+// are other processes that need the same lock, so the handle is closed once the store has been idle for
+// documentStoreIdleTimeout, and opened again by the next operation.
+var (
+	documentStoresLock sync.Mutex
+	documentStores     = map[string]*documentStore{}
+)
+
+// DocumentBoltMgr persists document states in a single embedded database per instance, with one bucket per
+// location folder. Moving a state between locations is a single transaction.
+type DocumentBoltMgr struct {
+	dataStorePath string
+	rootDirName   string
+	stateLocation string
+}
+
+// NewDocumentBoltMgr creates a DocumentBoltMgr whose database is kept under dataStorePath/instanceID/rootDirName/stateLocation
+func NewDocumentBoltMgr(dataStorePath, rootDirName, stateLocation string) *DocumentBoltMgr {
+	return &DocumentBoltMgr{
+		dataStorePath: dataStorePath,
+		rootDirName:   rootDirName,
+		stateLocation: stateLocation,
+	}
+}
+
+// Open checks that the database of the instance can be opened and imports the state files left in the bookkeeping
+// folders, such as the ones of the file store used before. It is called once when the agent starts.
+func (d *DocumentBoltMgr) Open(log log.T, instanceID string) error {
+	return d.withDB(log, instanceID, func(db *bolt.DB) error {
+		if err := d.migrateStateFiles(log, db, instanceID); err != nil {
+			return fmt.Errorf("failed to import document state files into %v: %v", d.dbPath(instanceID), err)
+		}
+		return nil
+	})
+}
+
+func (d *DocumentBoltMgr) MoveDocumentState(log log.T, fileName, instanceID, srcLocationFolder, dstLocationFolder string) {
+	err := d.update(log, instanceID, func(tx *bolt.Tx) error {
+		src := tx.Bucket([]byte(srcLocationFolder))
+		if src == nil {
+			return fmt.Errorf("document state not found in %v", srcLocationFolder)
+		}
+		value := src.Get([]byte(fileName))
+		if value == nil {
+			return fmt.Errorf("document state not found in %v", srcLocationFolder)
+		}
+		// values returned by bolt are only valid until the bucket is modified
+		value = append([]byte(nil), value...)
+		dst, err := tx.CreateBucketIfNotExists([]byte(dstLocationFolder))
+		if err != nil {
+			return err
+		}
+		if err = dst.Put([]byte(fileName), value); err != nil {
+			return err
+		}
+		return src.Delete([]byte(fileName))
+	})
+	if err == nil {
+		log.Debugf("moved document state %v from %v to %v successfully", fileName, srcLocationFolder, dstLocationFolder)
+	} else {
+		log.Debugf("moving document state %v from %v to %v failed with error %v", fileName, srcLocationFolder, dstLocationFolder, err)
+	}
+}
+
+func (d *DocumentBoltMgr) PersistDocumentState(log log.T, fileName, instanceID, locationFolder string, state contracts.DocumentState) {
+	content, err := jsonutil.Marshal(state)
+	if err != nil {
+		log.Errorf("encountered error with message %v while marshalling %v to string", err, state)
+		return
+	}
+
+	log.Tracef("persisting interim state %v of %v", jsonutil.Indent(content), fileName)
+	err = d.update(log, instanceID, func(tx *bolt.Tx) error {
+		bucket, err := tx.CreateBucketIfNotExists([]byte(locationFolder))
+		if err != nil {
+			return err
+		}
+		return bucket.Put([]byte(fileName), []byte(content))
+	})
+	if err == nil {
+		log.Debugf("successfully persisted interim state in %v", locationFolder)
+	} else {
+		log.Debugf("persisting interim state in %v failed with error %v", locationFolder, err)
+	}
+}
+
+func (d *DocumentBoltMgr) GetDocumentState(log log.T, fileName, instanceID, locationFolder string) contracts.DocumentState {
+	var commandState contracts.DocumentState
+
+	var content []byte
+	err := d.view(log, instanceID, func(tx *bolt.Tx) error {
+		if bucket := tx.Bucket([]byte(locationFolder)); bucket != nil {
+			content = append([]byte(nil), bucket.Get([]byte(fileName))...)
+		}
+		if len(content) == 0 {
+			return fmt.Errorf("document state not found in %v", locationFolder)
+		}
+		return nil
+	})
+	if err == nil {
+		err = jsonutil.Unmarshal(string(content), &commandState)
+	}
+	if err != nil {
+		log.Errorf("encountered error with message %v while reading Interim state of command - %v", err, fileName)
+	} else {
+		log.Tracef("interim CommandState read from document store - %v", jsonutil.Indent(string(content)))
+	}
+
+	return commandState
+}
+
+func (d *DocumentBoltMgr) RemoveDocumentState(log log.T, fileName, instanceID, locationFolder string) {
+	err := d.update(log, instanceID, func(tx *bolt.Tx) error {
+		if bucket := tx.Bucket([]byte(locationFolder)); bucket != nil {
+			return bucket.Delete([]byte(fileName))
+		}
+		return nil
+	})
+	if err != nil {
+		log.Errorf("encountered error %v while deleting document state %v", err, fileName)
+	} else {
+		log.Debugf("successfully deleted document state %v", fileName)
+	}
+}
+
+func (d *DocumentBoltMgr) GetDocumentStateNames(log log.T, instanceID, locationFolder string) (names []string, err error) {
+	names = []string{}
+	err = d.view(log, instanceID, func(tx *bolt.Tx) error {
+		bucket := tx.Bucket([]byte(locationFolder))
+		if bucket == nil {
+			return nil
+		}
+		return bucket.ForEach(func(key, _ []byte) error {
+			names = append(names, string(key))
+			return nil
+		})
+	})
+	return names, err
+}
+
+// dbPath returns the absolute path of the database file of the instance
+func (d *DocumentBoltMgr) dbPath(instanceID string) string {
+	return path.Join(d.stateDir(instanceID), appconfig.DocumentStateStoreFileName)
+}
+
+// stateDir returns the absolute path of the folder holding the bookkeeping folders of the instance
+func (d *DocumentBoltMgr) stateDir(instanceID string) string {
+	return path.Join(d.dataStorePath,
+		instanceID,
+		d.rootDirName,
+		d.stateLocation)
+}
+
+// update runs fn in a read-write transaction of the database of the instance
+func (d *DocumentBoltMgr) update(log log.T, instanceID string, fn func(tx *bolt.Tx) error) error {
+	return d.withDB(log, instanceID, func(db *bolt.DB) error { return db.Update(fn) })
+}
+
+// view runs fn in a read-only transaction of the database of the instance
+func (d *DocumentBoltMgr) view(log log.T, instanceID string, fn func(tx *bolt.Tx) error) error {
+	return d.withDB(log, instanceID, func(db *bolt.DB) error { return db.View(fn) })
+}
+
+// withDB runs fn with the shared handle of the database of the instance, opening it if needed
+func (d *DocumentBoltMgr) withDB(log log.T, instanceID string, fn func(db *bolt.DB) error) error {
+	documentStoresLock.Lock()
+	defer documentStoresLock.Unlock()
+
+	dbPath := d.dbPath(instanceID)
+	store, found := documentStores[dbPath]
+	if !found {
+		if err := fileutil.MakeDirs(d.stateDir(instanceID)); err != nil {
+			return err
+		}
+		db, err := bolt.Open(dbPath, os.FileMode(int(appconfig.ReadWriteAccess)), &bolt.Options{Timeout: documentStoreOpenTimeout})
+		if err != nil {
+			return fmt.Errorf("failed to open document store %v: %v", dbPath, err)
+		}
+		store = &documentStore{db: db}
+		documentStores[dbPath] = store
+		time.AfterFunc(documentStoreIdleTimeout, func() { closeIdleDocumentStore(log, dbPath, store) })
+	}
+	defer func() { store.lastUsed = time.Now() }()
+
+	return fn(store.db)
+}
+
+// closeIdleDocumentStore closes the handle of the database once it has been idle for documentStoreIdleTimeout,
+// and checks again later otherwise
+func closeIdleDocumentStore(log log.T, dbPath string, store *documentStore) {
+	documentStoresLock.Lock()
+	defer documentStoresLock.Unlock()
+
+	if documentStores[dbPath] != store {
+		return
+	}
+	if idle := time.Since(store.lastUsed); idle < documentStoreIdleTimeout {
+		time.AfterFunc(documentStoreIdleTimeout-idle, func() { closeIdleDocumentStore(log, dbPath, store) })
+		return
+	}
+	delete(documentStores, dbPath)
+	if err := store.db.Close(); err != nil {
+		log.Warnf("failed to close document store %v: %v", dbPath, err)
+	}
+}
+
+// migrateStateFiles imports the state files of the bookkeeping folders in a single transaction and deletes them once
+// committed. Files that are not valid document states are imported into the corrupt bucket.
+func (d *DocumentBoltMgr) migrateStateFiles(log log.T, db *bolt.DB, instanceID string) error {
+	// the transaction is skipped when there is nothing to import
+	stateFiles := make(map[string][]string)
+	found := false
+	for _, locationFolder := range migratedLocationFolders {
+		folder := path.Join(d.stateDir(instanceID), locationFolder)
+		if !fileutil.Exists(folder) {
+			continue
+		}
+		files, err := fileutil.ReadDir(folder)
+		if err != nil {
+			return err
+		}
+		for _, f := range files {
+			if !f.IsDir() {
+				stateFiles[locationFolder] = append(stateFiles[locationFolder], f.Name())
+				found = true
+			}
+		}
+	}
+	if !found {
+		return nil
+	}
+
+	var migrated []string
+	err := db.Update(func(tx *bolt.Tx) error {
+		for _, locationFolder := range migratedLocationFolders {
+			folder := path.Join(d.stateDir(instanceID), locationFolder)
+			for _, fileName := range stateFiles[locationFolder] {
+				absoluteFileName := path.Join(folder, fileName)
+				if isStoredDocumentState(tx, fileName) {
+					// left over by an earlier import that could not delete the file
+					log.Debugf("document state file %v was already imported", absoluteFileName)
+					migrated = append(migrated, absoluteFileName)
+					continue
+				}
+				content, err := ioutil.ReadFile(absoluteFileName)
+				if err != nil {
+					return err
+				}
+
+				dstLocationFolder := locationFolder
+				var state contracts.DocumentState
+				if err = jsonutil.Unmarshal(string(content), &state); err != nil {
+					log.Warnf("document state file %v is not valid, importing it as corrupt: %v", absoluteFileName, err)
+					dstLocationFolder = appconfig.DefaultLocationOfCorrupt
+				}
+
+				bucket, err := tx.CreateBucketIfNotExists([]byte(dstLocationFolder))
+				if err != nil {
+					return err
+				}
+				if err = bucket.Put([]byte(fileName), content); err != nil {
+					return err
+				}
+				migrated = append(migrated, absoluteFileName)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, absoluteFileName := range migrated {
+		if err := fileutil.DeleteFile(absoluteFileName); err != nil {
+			log.Warnf("failed to delete imported document state file %v: %v", absoluteFileName, err)
+		}
+	}
+	if len(migrated) > 0 {
+		log.Infof("imported %v document state files into the document store", len(migrated))
+	}
+	return nil
+}
+
+// isStoredDocumentState checks whether a document state is already kept in any of the migrated buckets
+func isStoredDocumentState(tx *bolt.Tx, fileName string) bool {
+	for _, locationFolder := range migratedLocationFolders {
+		if bucket := tx.Bucket([]byte(locationFolder)); bucket != nil && bucket.Get([]byte(fileName)) != nil {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,189 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package docmanager
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/fileutil"
+	"github.com/aws/amazon-ssm-agent/agent/jsonutil"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/boltdb/bolt"
+	"github.com/stretchr/testify/assert"
+)
+
+const testInstanceID = "i-1234567890"
+
+func newTestDocState(documentID string) contracts.DocumentState {
+	docState := contracts.DocumentState{DocumentType: contracts.SendCommand}
+	docState.DocumentInformation.DocumentID = documentID
+	docState.DocumentInformation.InstanceID = testInstanceID
+	return docState
+}
+
+func TestDocumentBoltMgr_StateTransitions(t *testing.T) {
+	logger := log.NewMockLog()
+	dataStorePath, _ := ioutil.TempDir("", "docmanager")
+	defer os.RemoveAll(dataStorePath)
+
+	mgr := NewDocumentBoltMgr(dataStorePath, appconfig.DefaultDocumentRootDirName, appconfig.DefaultLocationOfState)
+
+	mgr.PersistDocumentState(logger, "doc1", testInstanceID, appconfig.DefaultLocationOfPending, newTestDocState("doc1"))
+	mgr.PersistDocumentState(logger, "doc2", testInstanceID, appconfig.DefaultLocationOfPending, newTestDocState("doc2"))
+
+	names, err := mgr.GetDocumentStateNames(logger, testInstanceID, appconfig.DefaultLocationOfPending)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"doc1", "doc2"}, names)
+
+	mgr.MoveDocumentState(logger, "doc1", testInstanceID, appconfig.DefaultLocationOfPending, appconfig.DefaultLocationOfCurrent)
+
+	pending, _ := mgr.GetDocumentStateNames(logger, testInstanceID, appconfig.DefaultLocationOfPending)
+	current, _ := mgr.GetDocumentStateNames(logger, testInstanceID, appconfig.DefaultLocationOfCurrent)
+	assert.Equal(t, []string{"doc2"}, pending)
+	assert.Equal(t, []string{"doc1"}, current)
+
+	docState := mgr.GetDocumentState(logger, "doc1", testInstanceID, appconfig.DefaultLocationOfCurrent)
+	assert.Equal(t, "doc1", docState.DocumentInformation.DocumentID)
+	assert.Equal(t, contracts.SendCommand, docState.DocumentType)
+
+	mgr.RemoveDocumentState(logger, "doc1", testInstanceID, appconfig.DefaultLocationOfCurrent)
+	current, _ = mgr.GetDocumentStateNames(logger, testInstanceID, appconfig.DefaultLocationOfCurrent)
+	assert.Empty(t, current)
+}
+
+func TestDocumentBoltMgr_MoveMissingDocumentKeepsStore(t *testing.T) {
+	logger := log.NewMockLog()
+	dataStorePath, _ := ioutil.TempDir("", "docmanager")
+	defer os.RemoveAll(dataStorePath)
+
+	mgr := NewDocumentBoltMgr(dataStorePath, appconfig.DefaultDocumentRootDirName, appconfig.DefaultLocationOfState)
+
+	mgr.PersistDocumentState(logger, "doc1", testInstanceID, appconfig.DefaultLocationOfCurrent, newTestDocState("doc1"))
+	mgr.MoveDocumentState(logger, "missing", testInstanceID, appconfig.DefaultLocationOfPending, appconfig.DefaultLocationOfCorrupt)
+
+	corrupt, err := mgr.GetDocumentStateNames(logger, testInstanceID, appconfig.DefaultLocationOfCorrupt)
+	assert.NoError(t, err)
+	assert.Empty(t, corrupt)
+	docState := mgr.GetDocumentState(logger, "missing", testInstanceID, appconfig.DefaultLocationOfPending)
+	assert.Equal(t, "", docState.DocumentInformation.DocumentID)
+}
+
+func TestDocumentBoltMgr_MigratesStateFiles(t *testing.T) {
+	logger := log.NewMockLog()
+	dataStorePath, _ := ioutil.TempDir("", "docmanager")
+	defer os.RemoveAll(dataStorePath)
+
+	fileMgr := NewDocumentFileMgr(dataStorePath, appconfig.DefaultDocumentRootDirName, appconfig.DefaultLocationOfState)
+	stateDir := path.Join(dataStorePath, testInstanceID, appconfig.DefaultDocumentRootDirName, appconfig.DefaultLocationOfState)
+	for _, folder := range migratedLocationFolders {
+		fileutil.MakeDirs(path.Join(stateDir, folder))
+	}
+	fileMgr.PersistDocumentState(logger, "pendingDoc", testInstanceID, appconfig.DefaultLocationOfPending, newTestDocState("pendingDoc"))
+	fileMgr.PersistDocumentState(logger, "currentDoc", testInstanceID, appconfig.DefaultLocationOfCurrent, newTestDocState("currentDoc"))
+	ioutil.WriteFile(path.Join(stateDir, appconfig.DefaultLocationOfPending, "brokenDoc"), []byte("{not json"), 0600)
+
+	mgr := NewDocumentBoltMgr(dataStorePath, appconfig.DefaultDocumentRootDirName, appconfig.DefaultLocationOfState)
+	assert.NoError(t, mgr.Open(logger, testInstanceID))
+
+	pending, _ := mgr.GetDocumentStateNames(logger, testInstanceID, appconfig.DefaultLocationOfPending)
+	current, _ := mgr.GetDocumentStateNames(logger, testInstanceID, appconfig.DefaultLocationOfCurrent)
+	corrupt, _ := mgr.GetDocumentStateNames(logger, testInstanceID, appconfig.DefaultLocationOfCorrupt)
+	assert.Equal(t, []string{"pendingDoc"}, pending)
+	assert.Equal(t, []string{"currentDoc"}, current)
+	assert.Equal(t, []string{"brokenDoc"}, corrupt)
+
+	docState := mgr.GetDocumentState(logger, "currentDoc", testInstanceID, appconfig.DefaultLocationOfCurrent)
+	assert.Equal(t, "currentDoc", docState.DocumentInformation.DocumentID)
+
+	// imported files are removed from the bookkeeping folders
+	for _, folder := range migratedLocationFolders {
+		isEmpty, _ := fileutil.IsDirEmpty(path.Join(stateDir, folder))
+		assert.True(t, isEmpty, folder)
+	}
+
+	// a file left over by an earlier import does not overwrite the stored state
+	mgr.MoveDocumentState(logger, "pendingDoc", testInstanceID, appconfig.DefaultLocationOfPending, appconfig.DefaultLocationOfCurrent)
+	content, _ := jsonutil.Marshal(newTestDocState("pendingDoc"))
+	ioutil.WriteFile(path.Join(stateDir, appconfig.DefaultLocationOfPending, "pendingDoc"), []byte(content), 0600)
+
+	assert.NoError(t, mgr.Open(logger, testInstanceID))
+	pending, _ = mgr.GetDocumentStateNames(logger, testInstanceID, appconfig.DefaultLocationOfPending)
+	current, _ = mgr.GetDocumentStateNames(logger, testInstanceID, appconfig.DefaultLocationOfCurrent)
+	assert.Empty(t, pending)
+	assert.Equal(t, []string{"currentDoc", "pendingDoc"}, current)
+	assert.False(t, fileutil.Exists(path.Join(stateDir, appconfig.DefaultLocationOfPending, "pendingDoc")))
+}
+
+func TestDocumentBoltMgr_SharesStoreHandle(t *testing.T) {
+	logger := log.NewMockLog()
+	dataStorePath, _ := ioutil.TempDir("", "docmanager")
+	defer os.RemoveAll(dataStorePath)
+
+	mgr := NewDocumentBoltMgr(dataStorePath, appconfig.DefaultDocumentRootDirName, appconfig.DefaultLocationOfState)
+	otherMgr := NewDocumentBoltMgr(dataStorePath, appconfig.DefaultDocumentRootDirName, appconfig.DefaultLocationOfState)
+
+	var handles []*bolt.DB
+	keepHandle := func(db *bolt.DB) error {
+		handles = append(handles, db)
+		return nil
+	}
+	assert.NoError(t, mgr.withDB(logger, testInstanceID, keepHandle))
+	assert.NoError(t, otherMgr.withDB(logger, testInstanceID, keepHandle))
+	assert.Len(t, handles, 2)
+	assert.True(t, handles[0] == handles[1])
+}
+
+func TestDocumentBoltMgr_ReleasesIdleStore(t *testing.T) {
+	setIdleTimeout := func(timeout time.Duration) {
+		documentStoresLock.Lock()
+		defer documentStoresLock.Unlock()
+		documentStoreIdleTimeout = timeout
+	}
+	defer setIdleTimeout(documentStoreIdleTimeout)
+	setIdleTimeout(50 * time.Millisecond)
+
+	logger := log.NewMockLog()
+	dataStorePath, _ := ioutil.TempDir("", "docmanager")
+	defer os.RemoveAll(dataStorePath)
+
+	mgr := NewDocumentBoltMgr(dataStorePath, appconfig.DefaultDocumentRootDirName, appconfig.DefaultLocationOfState)
+	assert.NoError(t, mgr.Open(logger, testInstanceID))
+	mgr.PersistDocumentState(logger, "doc1", testInstanceID, appconfig.DefaultLocationOfCurrent, newTestDocState("doc1"))
+
+	// another process such as the cli can open the store once the agent is idle
+	db, err := bolt.Open(mgr.dbPath(testInstanceID), 0600, &bolt.Options{Timeout: time.Second, ReadOnly: true})
+	assert.NoError(t, err)
+	assert.NoError(t, db.View(func(tx *bolt.Tx) error {
+		assert.NotNil(t, tx.Bucket([]byte(appconfig.DefaultLocationOfCurrent)).Get([]byte("doc1")))
+		return nil
+	}))
+	assert.NoError(t, db.Close())
+}
+
+func TestNewDocumentMgr(t *testing.T) {
+	config := appconfig.DefaultConfig()
+	_, isFileMgr := NewDocumentMgr(config, "dataStore").(*DocumentFileMgr)
+	assert.True(t, isFileMgr)
+
+	config.Agent.DocumentStateStore = appconfig.DocumentStateStoreEmbedded
+	boltMgr, isBoltMgr := NewDocumentMgr(config, "dataStore").(*DocumentBoltMgr)
+	assert.True(t, isBoltMgr)
+	assert.Equal(t, "dataStore", boltMgr.dataStorePath)
+}
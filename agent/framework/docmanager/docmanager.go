@@ -40,6 +40,16 @@ type DocumentMgr interface {
 	PersistDocumentState(log log.T, fileName, instanceID, locationFolder string, state contracts.DocumentState)
 	GetDocumentState(log log.T, fileName, instanceID, locationFolder string) contracts.DocumentState
 	RemoveDocumentState(log log.T, fileName, instanceID, locationFolder string)
+	GetDocumentStateNames(log log.T, instanceID, locationFolder string) ([]string, error)
+}
+
+// NewDocumentMgr returns the document state manager of the store selected by the agent config,
+// which keeps the document states of each instance under dataStorePath
+func NewDocumentMgr(appConfig appconfig.SsmagentConfig, dataStorePath string) DocumentMgr {
+	if appConfig.Agent.DocumentStateStore == appconfig.DocumentStateStoreEmbedded {
+		return NewDocumentBoltMgr(dataStorePath, appconfig.DefaultDocumentRootDirName, appconfig.DefaultLocationOfState)
+	}
+	return NewDocumentFileMgr(dataStorePath, appconfig.DefaultDocumentRootDirName, appconfig.DefaultLocationOfState)
 }
 
 //TODO use class lock instead of global lock?
@@ -143,6 +153,30 @@ func (d *DocumentFileMgr) RemoveDocumentState(log log.T, commandID, instanceID,
 	}
 }
 
+// GetDocumentStateNames returns the names of the document states persisted in locationFolder
+func (d *DocumentFileMgr) GetDocumentStateNames(log log.T, instanceID, locationFolder string) (names []string, err error) {
+
+	absoluteLocation := path.Join(d.dataStorePath,
+		instanceID,
+		d.rootDirName,
+		d.stateLocation,
+		locationFolder)
+
+	if isDirectoryEmpty, _ := fileutil.IsDirEmpty(absoluteLocation); isDirectoryEmpty || !fileutil.Exists(absoluteLocation) {
+		log.Debugf("no document states found in %v", absoluteLocation)
+		return []string{}, nil
+	}
+
+	files, err := fileutil.ReadDir(absoluteLocation)
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range files {
+		names = append(names, f.Name())
+	}
+	return names, nil
+}
+
 //TODO rework this part
 // DocumentStateDir returns absolute filename where command states are persisted
 func DocumentStateDir(instanceID, locationFolder string) string {
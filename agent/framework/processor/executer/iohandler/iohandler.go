@@ -25,6 +25,7 @@ import (
 	"github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/iohandler/iomodule"
 	"github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/iohandler/multiwriter"
 	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/redaction"
 )
 
 const (
@@ -36,6 +37,9 @@ const (
 	truncateError = "\n---Error truncated----"
 )
 
+// outputStreamSink returns the sink the output of the commands is streamed to, nil when streaming is disabled
+var outputStreamSink = iomodule.OutputStreamSink
+
 // PluginConfig is used for initializing plugins with default values
 type PluginConfig struct {
 	StdoutFileName        string
@@ -163,10 +167,29 @@ func (out *DefaultIOHandler) Init(log log.T, filePath ...string) {
 		OrchestrationDirectory: fullPath,
 	}
 
+	// Stream the output of commands to the local sink if configured
+	stepName := ""
+	if len(filePath) > 0 {
+		stepName = filePath[len(filePath)-1]
+	}
+	var streamSink iomodule.StreamSink
+	if out.ioConfig.CommandID != "" {
+		streamSink = outputStreamSink(log)
+	}
+
 	log.Debug("Initializing the Stdout Multi-writer with file and console listeners")
 	// Get a multi-writer for standard output
 	out.StdoutWriter = multiwriter.NewDocumentIOMultiWriter()
-	out.RegisterOutputSource(log, out.StdoutWriter, stdoutFile, stdoutConsole)
+	stdoutModules := []iomodule.IOModule{stdoutFile, stdoutConsole}
+	if streamSink != nil {
+		stdoutModules = append(stdoutModules, iomodule.Stream{
+			CommandID:  out.ioConfig.CommandID,
+			StepName:   stepName,
+			StreamName: iomodule.StreamStdout,
+			Sink:       streamSink,
+		})
+	}
+	out.RegisterOutputSource(log, out.StdoutWriter, stdoutModules...)
 
 	// Initialize file error module
 	stderrFile := iomodule.File{
@@ -188,7 +211,16 @@ func (out *DefaultIOHandler) Init(log log.T, filePath ...string) {
 	log.Debug("Initializing the Stderr Multi-writer with file and console listeners")
 	// Get a multi-writer for standard error
 	out.StderrWriter = multiwriter.NewDocumentIOMultiWriter()
-	out.RegisterOutputSource(log, out.StderrWriter, stderrFile, stderrConsole)
+	stderrModules := []iomodule.IOModule{stderrFile, stderrConsole}
+	if streamSink != nil {
+		stderrModules = append(stderrModules, iomodule.Stream{
+			CommandID:  out.ioConfig.CommandID,
+			StepName:   stepName,
+			StreamName: iomodule.StreamStderr,
+			Sink:       streamSink,
+		})
+	}
+	out.RegisterOutputSource(log, out.StderrWriter, stderrModules...)
 }
 
 // RegisterOutputSource returns a new output source by creating a multiwriter for the output modules.
@@ -246,7 +278,7 @@ func (out DefaultIOHandler) GetStatus() contracts.ResultStatus {
 
 // GetStdout returns the stdout
 func (out DefaultIOHandler) GetStdout() string {
-	return out.stdout
+	return redaction.Redact(out.stdout)
 }
 
 // GetExitCode returns the exit code
@@ -256,7 +288,7 @@ func (out DefaultIOHandler) GetExitCode() int {
 
 // GetStderr returns the stderr
 func (out DefaultIOHandler) GetStderr() string {
-	return out.stderr
+	return redaction.Redact(out.stderr)
 }
 
 // GetIOConfig returns the io configuration
@@ -418,6 +450,9 @@ func (out *DefaultIOHandler) AppendErrorf(format string, params ...interface{})
 
 // TruncateOutput truncates the output
 func TruncateOutput(stdout string, stderr string, capacity int) (response string) {
+	// redact before truncating, a secret cut by the truncation would no longer be found
+	stdout = redaction.Redact(stdout)
+	stderr = redaction.Redact(stderr)
 	outputSize := len(stdout)
 	errorSize := len(stderr)
 
@@ -15,15 +15,20 @@ package iohandler
 
 import (
 	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
 	"testing"
 
 	"sync"
 	"time"
 
 	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/iohandler/iomodule"
 	iomodulemock "github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/iohandler/iomodule/mock"
 	multiwritermock "github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/iohandler/multiwriter/mock"
 	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/redaction"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
@@ -70,6 +75,21 @@ func TestTruncateOutput(t *testing.T) {
 
 var logger = log.NewMockLog()
 
+func TestTruncateOutputRedactsSecrets(t *testing.T) {
+	redaction.AddSecret("iohandler-secret")
+	output := DefaultIOHandler{}
+	output.SetStdout("token iohandler-secret")
+	output.AppendError("failed with iohandler-secret")
+
+	assert.Equal(t, "token "+redaction.Mask, output.GetStdout())
+	assert.Equal(t, "failed with "+redaction.Mask, output.GetStderr())
+	assert.Equal(t, "token "+redaction.Mask+"\n----------ERROR-------\nfailed with "+redaction.Mask, output.String())
+	// the secret cut by the truncation is redacted too
+	truncated := TruncateOutput(strings.Repeat("x", 20)+"iohandler-secret"+strings.Repeat("y", 100), "", 60)
+	assert.Contains(t, truncated, "xx"+redaction.Mask+"yy")
+	assert.NotContains(t, truncated, "iohandler")
+}
+
 func TestRegisterOutputSource(t *testing.T) {
 	mockDocumentIOMultiWriter := new(multiwritermock.MockDocumentIOMultiWriter)
 
@@ -174,3 +194,51 @@ func TestAppendFormat(t *testing.T) {
 	assert.Contains(t, output.GetStdout(), testStringFormatted)
 	assert.Contains(t, output.GetStderr(), testStringFormatted)
 }
+
+type recordingStreamSink struct {
+	mutex sync.Mutex
+	lines []iomodule.StreamLine
+}
+
+func (sink *recordingStreamSink) WriteLine(line iomodule.StreamLine) error {
+	sink.mutex.Lock()
+	defer sink.mutex.Unlock()
+	sink.lines = append(sink.lines, line)
+	return nil
+}
+
+func TestInitStreamsOutputOfCommands(t *testing.T) {
+	sink := &recordingStreamSink{}
+	original := outputStreamSink
+	outputStreamSink = func(log.T) iomodule.StreamSink { return sink }
+	defer func() { outputStreamSink = original }()
+	dir, _ := ioutil.TempDir("", "iohandler")
+	defer os.RemoveAll(dir)
+
+	output := NewDefaultIOHandler(log.NewMockLog(), contracts.IOConfiguration{OrchestrationDirectory: dir, CommandID: "command-1"})
+	output.Init(log.NewMockLog(), "aws:runShellScript", "deploy")
+	output.AppendInfo("installing")
+	output.AppendError("warning")
+	output.Close(log.NewMockLog())
+
+	assert.Equal(t, "installing", output.GetStdout())
+	assert.Len(t, sink.lines, 2)
+	for _, line := range sink.lines {
+		assert.Equal(t, "command-1", line.CommandID)
+		assert.Equal(t, "deploy", line.StepName)
+		if line.Stream == iomodule.StreamStdout {
+			assert.Equal(t, "installing", line.Text)
+		} else {
+			assert.Equal(t, iomodule.StreamStderr, line.Stream)
+			assert.Equal(t, "warning", line.Text)
+		}
+	}
+
+	// outputs which do not belong to a command are not streamed
+	sink.lines = nil
+	output = NewDefaultIOHandler(log.NewMockLog(), contracts.IOConfiguration{OrchestrationDirectory: dir})
+	output.Init(log.NewMockLog(), "aws:refreshAssociation")
+	output.AppendInfo("refreshing")
+	output.Close(log.NewMockLog())
+	assert.Empty(t, sink.lines)
+}
@@ -0,0 +1,142 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package iomodule
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+)
+
+const (
+	// StreamStdout is the stream name of the standard output lines
+	StreamStdout = "stdout"
+	// StreamStderr is the stream name of the standard error lines
+	StreamStderr = "stderr"
+
+	// defaultStreamFileName is the name of the file the output is streamed to in the log directory
+	defaultStreamFileName = "command-output.log"
+)
+
+// StreamLine is a line of output of a step
+type StreamLine struct {
+	Time      time.Time
+	CommandID string
+	StepName  string
+	Stream    string
+	Text      string
+}
+
+// Tags returns the command ID, step name and stream of the line
+func (line StreamLine) Tags() string {
+	return fmt.Sprintf("commandId=%v step=%v stream=%v", line.CommandID, line.StepName, line.Stream)
+}
+
+// String returns the line with its time and tags
+func (line StreamLine) String() string {
+	return fmt.Sprintf("%v %v %v", line.Time.UTC().Format(time.RFC3339), line.Tags(), line.Text)
+}
+
+// StreamSink receives the lines of output of the steps while they run, it is shared by the concurrent steps
+type StreamSink interface {
+	WriteLine(line StreamLine) error
+}
+
+// Stream handles streaming the output lines to a sink as they are written
+type Stream struct {
+	CommandID  string
+	StepName   string
+	StreamName string
+	Sink       StreamSink
+}
+
+// Read reads lines from the stream and writes them to the sink, lines the sink fails to write are dropped.
+func (stream Stream) Read(log log.T, reader *io.PipeReader) {
+	defer func() { reader.Close() }()
+
+	failed := false
+	buffered := bufio.NewReader(reader)
+	for {
+		text, err := buffered.ReadString('\n')
+		if len(text) > 0 {
+			line := StreamLine{
+				Time:      time.Now(),
+				CommandID: stream.CommandID,
+				StepName:  stream.StepName,
+				Stream:    stream.StreamName,
+				Text:      strings.TrimRight(text, "\r\n"),
+			}
+			// log the first failure only, the sink may be unavailable for the whole step
+			if sinkErr := stream.Sink.WriteLine(line); sinkErr != nil && !failed {
+				log.Warnf("Failed to stream the %v of step %v: %v", stream.StreamName, stream.StepName, sinkErr)
+				failed = true
+			}
+		}
+		if err != nil {
+			if err != io.EOF {
+				log.Error("Error while reading the stream: ", err)
+			}
+			return
+		}
+	}
+}
+
+var (
+	streamSinkOnce sync.Once
+	streamSink     StreamSink
+)
+
+// OutputStreamSink returns the sink configured in the OutputStreaming section of the agent configuration,
+// or nil when streaming is disabled. The sink is created once per process.
+func OutputStreamSink(log log.T) StreamSink {
+	streamSinkOnce.Do(func() {
+		config, err := appconfig.Config(false)
+		if err != nil || config.OutputStreaming.Sink == "" {
+			return
+		}
+		if streamSink, err = NewStreamSink(config.OutputStreaming); err != nil {
+			log.Errorf("Failed to create the %v output streaming sink: %v", config.OutputStreaming.Sink, err)
+			streamSink = nil
+		}
+	})
+	return streamSink
+}
+
+// NewStreamSink creates the sink described by the configuration
+func NewStreamSink(config appconfig.OutputStreamingCfg) (StreamSink, error) {
+	switch config.Sink {
+	case appconfig.OutputStreamingSinkFile:
+		path := config.Path
+		if path == "" {
+			path = filepath.Join(log.DefaultLogDir, defaultStreamFileName)
+		}
+		return newFileSink(path, int64(config.MaxFileSizeMB)*1024*1024, config.MaxFiles), nil
+	case appconfig.OutputStreamingSinkSyslog:
+		return newSyslogSink()
+	case appconfig.OutputStreamingSinkSocket:
+		if config.Path == "" {
+			return nil, fmt.Errorf("no socket path configured")
+		}
+		return newSocketSink(config.Path), nil
+	default:
+		return nil, fmt.Errorf("unknown sink %v", config.Sink)
+	}
+}
@@ -0,0 +1,138 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package iomodule
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/fileutil"
+	"github.com/stretchr/testify/assert"
+)
+
+// recordingSink keeps the lines it receives, and fails when err is set
+type recordingSink struct {
+	mutex sync.Mutex
+	lines []StreamLine
+	err   error
+}
+
+func (sink *recordingSink) WriteLine(line StreamLine) error {
+	sink.mutex.Lock()
+	defer sink.mutex.Unlock()
+	sink.lines = append(sink.lines, line)
+	return sink.err
+}
+
+func streamThrough(stream Stream, writes ...string) {
+	r, w := io.Pipe()
+	done := make(chan bool)
+	go func() {
+		stream.Read(logger, r)
+		done <- true
+	}()
+	for _, data := range writes {
+		w.Write([]byte(data))
+	}
+	w.Close()
+	<-done
+}
+
+func TestStreamWritesLinesAsTheyComplete(t *testing.T) {
+	sink := &recordingSink{}
+	stream := Stream{CommandID: "command-1", StepName: "deploy", StreamName: StreamStdout, Sink: sink}
+
+	streamThrough(stream, "first li", "ne\r\nsecond line\n\nlast without newline")
+
+	var texts []string
+	for _, line := range sink.lines {
+		assert.Equal(t, "command-1", line.CommandID)
+		assert.Equal(t, "deploy", line.StepName)
+		assert.Equal(t, StreamStdout, line.Stream)
+		texts = append(texts, line.Text)
+	}
+	assert.Equal(t, []string{"first line", "second line", "", "last without newline"}, texts)
+}
+
+func TestStreamKeepsReadingWhenSinkFails(t *testing.T) {
+	sink := &recordingSink{err: errors.New("sink unavailable")}
+
+	streamThrough(Stream{Sink: sink}, "a\nb\nc\n")
+
+	assert.Len(t, sink.lines, 3)
+}
+
+func TestStreamLineString(t *testing.T) {
+	line := StreamLine{
+		Time:      time.Date(2018, 3, 4, 5, 6, 7, 0, time.UTC),
+		CommandID: "command-1",
+		StepName:  "deploy",
+		Stream:    StreamStderr,
+		Text:      "failed",
+	}
+	assert.Equal(t, "2018-03-04T05:06:07Z commandId=command-1 step=deploy stream=stderr failed", line.String())
+}
+
+func TestFileSinkRotates(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "stream")
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "logs", "output.log")
+	sink := newFileSink(path, 100, 2)
+
+	line := StreamLine{Text: strings.Repeat("x", 40)}
+	for i := 0; i < 10; i++ {
+		assert.NoError(t, sink.WriteLine(line))
+	}
+
+	assert.True(t, fileutil.Exists(path+".1"))
+	assert.True(t, fileutil.Exists(path+".2"))
+	assert.False(t, fileutil.Exists(path+".3"))
+	rotated, _ := ioutil.ReadFile(path + ".1")
+	assert.Equal(t, 2, strings.Count(string(rotated), "\n"))
+}
+
+func TestFileSinkReopensFileRotatedByAnotherProcess(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "stream")
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "output.log")
+	sink := newFileSink(path, 1024*1024, 2)
+
+	assert.NoError(t, sink.WriteLine(StreamLine{Text: "before"}))
+	os.Rename(path, path+".1")
+	assert.NoError(t, sink.WriteLine(StreamLine{Text: "after"}))
+
+	content, _ := ioutil.ReadFile(path)
+	assert.Contains(t, string(content), "after")
+	assert.NotContains(t, string(content), "before")
+}
+
+func TestNewStreamSink(t *testing.T) {
+	sink, err := NewStreamSink(appconfig.OutputStreamingCfg{Sink: appconfig.OutputStreamingSinkFile, MaxFileSizeMB: 1, MaxFiles: 3})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1024*1024), sink.(*fileSink).maxSize)
+	assert.Equal(t, defaultStreamFileName, filepath.Base(sink.(*fileSink).path))
+
+	_, err = NewStreamSink(appconfig.OutputStreamingCfg{Sink: appconfig.OutputStreamingSinkSocket})
+	assert.Error(t, err)
+	_, err = NewStreamSink(appconfig.OutputStreamingCfg{Sink: "kafka"})
+	assert.Error(t, err)
+}
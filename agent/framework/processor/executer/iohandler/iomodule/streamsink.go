@@ -0,0 +1,138 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package iomodule
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/fileutil"
+)
+
+var (
+	// socketWriteTimeout bounds how long a slow listener can hold the output of the steps
+	socketWriteTimeout = time.Second
+	// socketRetryInterval is how long lines are dropped after the socket could not be reached
+	socketRetryInterval = 5 * time.Second
+)
+
+// fileSink appends the lines to a file and rotates it by size, keeping maxFiles rotated files named path.1 to path.<maxFiles>.
+// Each document worker has its own sink, a sink reopens the file when another process rotated it.
+type fileSink struct {
+	mutex    sync.Mutex
+	path     string
+	maxSize  int64
+	maxFiles int
+	file     *os.File
+}
+
+func newFileSink(path string, maxSize int64, maxFiles int) *fileSink {
+	return &fileSink{path: path, maxSize: maxSize, maxFiles: maxFiles}
+}
+
+// WriteLine appends the line to the file
+func (sink *fileSink) WriteLine(line StreamLine) (err error) {
+	sink.mutex.Lock()
+	defer sink.mutex.Unlock()
+
+	if err = sink.open(); err != nil {
+		return
+	}
+	if _, err = sink.file.WriteString(line.String() + "\n"); err != nil {
+		sink.close()
+		return
+	}
+	return sink.rotate()
+}
+
+// open opens the file unless it is open and was not rotated by another process
+func (sink *fileSink) open() (err error) {
+	if sink.file != nil {
+		current, currentErr := sink.file.Stat()
+		info, infoErr := os.Stat(sink.path)
+		if currentErr == nil && infoErr == nil && os.SameFile(current, info) {
+			return nil
+		}
+		sink.close()
+	}
+	if err = fileutil.MakeDirs(filepath.Dir(sink.path)); err != nil {
+		return
+	}
+	sink.file, err = os.OpenFile(sink.path, appconfig.FileFlagsCreateOrAppend, appconfig.ReadWriteAccess)
+	return
+}
+
+// rotate renames the file once it reached the maximum size, the oldest rotated file is replaced
+func (sink *fileSink) rotate() error {
+	info, err := sink.file.Stat()
+	if err != nil || info.Size() < sink.maxSize {
+		return err
+	}
+	sink.close()
+	for i := sink.maxFiles - 1; i >= 1; i-- {
+		rotated := fmt.Sprintf("%v.%v", sink.path, i)
+		if fileutil.Exists(rotated) {
+			os.Rename(rotated, fmt.Sprintf("%v.%v", sink.path, i+1))
+		}
+	}
+	return os.Rename(sink.path, sink.path+".1")
+}
+
+func (sink *fileSink) close() {
+	if sink.file != nil {
+		sink.file.Close()
+		sink.file = nil
+	}
+}
+
+// socketSink writes the lines to a process listening on a Unix socket, one line per record.
+// Lines are dropped while no process listens, the sink connects again after socketRetryInterval.
+type socketSink struct {
+	mutex       sync.Mutex
+	path        string
+	conn        net.Conn
+	lastAttempt time.Time
+}
+
+func newSocketSink(path string) *socketSink {
+	return &socketSink{path: path}
+}
+
+// WriteLine writes the line to the socket
+func (sink *socketSink) WriteLine(line StreamLine) (err error) {
+	sink.mutex.Lock()
+	defer sink.mutex.Unlock()
+
+	if sink.conn == nil {
+		if time.Since(sink.lastAttempt) < socketRetryInterval {
+			return fmt.Errorf("socket %v unavailable", sink.path)
+		}
+		sink.lastAttempt = time.Now()
+		if sink.conn, err = net.DialTimeout("unix", sink.path, socketWriteTimeout); err != nil {
+			return
+		}
+	}
+	sink.conn.SetWriteDeadline(time.Now().Add(socketWriteTimeout))
+	if _, err = sink.conn.Write([]byte(line.String() + "\n")); err != nil {
+		sink.conn.Close()
+		sink.conn = nil
+		sink.lastAttempt = time.Now()
+	}
+	return
+}
@@ -0,0 +1,45 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// +build darwin freebsd linux netbsd openbsd
+
+package iomodule
+
+import (
+	"log/syslog"
+)
+
+// syslogTag is the program name of the lines in the syslog
+const syslogTag = "amazon-ssm-agent"
+
+// syslogSink writes the lines to the local syslog, stderr lines with the warning priority
+type syslogSink struct {
+	writer *syslog.Writer
+}
+
+func newSyslogSink() (StreamSink, error) {
+	writer, err := syslog.New(syslog.LOG_INFO|syslog.LOG_USER, syslogTag)
+	if err != nil {
+		return nil, err
+	}
+	return &syslogSink{writer: writer}, nil
+}
+
+// WriteLine writes the line to the syslog, which adds the time
+func (sink *syslogSink) WriteLine(line StreamLine) error {
+	message := line.Tags() + " " + line.Text
+	if line.Stream == StreamStderr {
+		return sink.writer.Warning(message)
+	}
+	return sink.writer.Info(message)
+}
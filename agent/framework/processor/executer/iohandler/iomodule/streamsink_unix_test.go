@@ -0,0 +1,70 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// +build darwin freebsd linux netbsd openbsd
+
+package iomodule
+
+import (
+	"bufio"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSocketSink(t *testing.T) {
+	original := socketRetryInterval
+	socketRetryInterval = 0
+	defer func() { socketRetryInterval = original }()
+	dir, _ := ioutil.TempDir("", "stream")
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "output.sock")
+	sink := newSocketSink(path)
+
+	// no listener, the line is dropped
+	assert.Error(t, sink.WriteLine(StreamLine{Text: "dropped"}))
+
+	listener, err := net.Listen("unix", path)
+	assert.NoError(t, err)
+	defer listener.Close()
+	received := make(chan string, 2)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		reader := bufio.NewReader(conn)
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+			received <- line
+		}
+	}()
+
+	line := StreamLine{Time: time.Now(), CommandID: "command-1", StepName: "deploy", Stream: StreamStdout, Text: "hello"}
+	assert.NoError(t, sink.WriteLine(line))
+	select {
+	case text := <-received:
+		assert.Equal(t, line.String()+"\n", text)
+	case <-time.After(5 * time.Second):
+		assert.Fail(t, "no line received")
+	}
+}
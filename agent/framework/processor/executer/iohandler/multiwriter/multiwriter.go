@@ -15,11 +15,17 @@
 package multiwriter
 
 import (
+	"bytes"
 	"fmt"
 	"io"
 	"sync"
+
+	"github.com/aws/amazon-ssm-agent/agent/redaction"
 )
 
+// maxPendingSize is the size of an incomplete line written as is, redacting it whole could hold the output forever
+const maxPendingSize = 64 * 1024
+
 // DocumentIOMultiWriter is a multi-writer with support for close channel.
 // This is responsible for creating a fan-out multi-writer which allows you to duplicate
 // the writes to all the provided writers.
@@ -32,15 +38,19 @@ type DocumentIOMultiWriter interface {
 }
 
 // DefaultDocumentIOMultiWriter is the default implementation of multi-writer.
+// Secrets are redacted before the output reaches the writers, one line at a time so that secrets written in
+// several chunks are masked too: the output is written once its line is complete or when the multi-writer is closed.
 type DefaultDocumentIOMultiWriter struct {
 	writers []*io.PipeWriter
 	wg      *sync.WaitGroup
+	mutex   sync.Mutex
+	pending []byte
 }
 
 // NewDocumentIOMultiWriter creates a new document multi-writer
 func NewDocumentIOMultiWriter() (b *DefaultDocumentIOMultiWriter) {
 	var w []*io.PipeWriter
-	b = &DefaultDocumentIOMultiWriter{w, new(sync.WaitGroup)}
+	b = &DefaultDocumentIOMultiWriter{writers: w, wg: new(sync.WaitGroup)}
 	return
 }
 
@@ -61,36 +71,46 @@ func (b *DefaultDocumentIOMultiWriter) Write(p []byte) (n int, err error) {
 		return 0, fmt.Errorf("No writers present.")
 	}
 
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.pending = append(b.pending, p...)
+	end := bytes.LastIndexByte(b.pending, '\n') + 1
+	if end == 0 && len(b.pending) >= maxPendingSize {
+		end = len(b.pending)
+	}
+	if end > 0 {
+		b.write([]byte(redaction.Redact(string(b.pending[:end]))))
+		b.pending = append([]byte{}, b.pending[end:]...)
+	}
+	return len(p), nil
+}
+
+// write writes the redacted output to all the attached pipes.
+func (b *DefaultDocumentIOMultiWriter) write(p []byte) {
 	for i := 0; i < len(b.writers); i++ {
-		n, err = b.writers[i].Write(p)
 		// TODO: Handler other error types and close the writers after a fixed number of retries
-		if err == io.ErrClosedPipe {
+		if _, err := b.writers[i].Write(p); err == io.ErrClosedPipe {
 			// remove the writer as the reader is closed
 			b.writers = append(b.writers[:i], b.writers[i+1:]...)
 			i--
 		}
-		if n != len(p) {
-			err = io.ErrShortWrite
-		}
 	}
-	return len(p), nil
 }
 
 // WriteString is responsible for writing a string to all the attached pipes.
 func (b *DefaultDocumentIOMultiWriter) WriteString(message string) (n int, err error) {
-	if len(b.writers) == 0 {
-		return 0, fmt.Errorf("No writers present.")
-	}
-
-	for _, w := range b.writers {
-		p := []byte(message)
-		n, err = w.Write(p)
-	}
-	return len(message), nil
+	return b.Write([]byte(message))
 }
 
-// Close waits for all the writers to be closed.
+// Close writes the incomplete line and waits for all the writers to be closed.
 func (b *DefaultDocumentIOMultiWriter) Close() (err error) {
+	b.mutex.Lock()
+	if len(b.pending) > 0 {
+		b.write([]byte(redaction.Redact(string(b.pending))))
+		b.pending = nil
+	}
+	b.mutex.Unlock()
+
 	for i := 0; i < len(b.writers); i++ {
 		err = b.writers[i].Close()
 	}
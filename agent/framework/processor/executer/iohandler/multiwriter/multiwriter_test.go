@@ -22,6 +22,7 @@ import (
 
 	"sync"
 
+	"github.com/aws/amazon-ssm-agent/agent/redaction"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -153,3 +154,17 @@ func TestCloseWriter(t *testing.T) {
 	assert.Nil(t, err)
 
 }
+
+// TestWriteRedactsSecretsSplitAcrossWrites runs to check secrets are redacted even when written in several chunks.
+func TestWriteRedactsSecretsSplitAcrossWrites(t *testing.T) {
+	redaction.AddSecret("multiwriter-secret")
+	mw := NewDocumentIOMultiWriter()
+	r, w := io.Pipe()
+	mw.AddWriter(w)
+	expected := "password: " + redaction.Mask + "\nlast line " + redaction.Mask
+	go testReadBulk(t, r, expected, mw.wg)
+
+	mw.WriteString("password: multiwriter")
+	mw.WriteString("-secret\nlast line multiwriter-secret")
+	mw.Close()
+}
@@ -16,6 +16,7 @@ const (
 const (
 	defaultChannelBufferSize = 100
 	defaultFileChannelPath   = "channels"
+	socketFileExtension      = ".sock"
 )
 
 type Mode string
@@ -32,6 +33,38 @@ type Channel interface {
 	Destroy()
 }
 
+//create the channel named as "documentID" under the default root dir, and return whether it was found
+//on platforms supporting it, the channel is a socket channel, unless a file channel was created before the agent restarted
+//the worker uses the socket only if the master listens on it, and the master falls back to a file channel if it cannot listen
+func CreateChannel(log log.T, mode Mode, filename string) (Channel, error, bool) {
+	if !socketChannelSupported {
+		return CreateFileChannel(log, mode, filename)
+	}
+	instanceID, err := platform.InstanceID()
+	if err != nil {
+		log.Errorf("failed to load instance ID: %v", err)
+		return nil, err, false
+	}
+	root := path.Join(appconfig.DefaultDataStorePath, instanceID, defaultFileChannelPath)
+	if fileutil.Exists(path.Join(root, filename)) {
+		log.Infof("file channel: %v found", filename)
+		return CreateFileChannel(log, mode, filename)
+	}
+	socketPath := path.Join(root, filename+socketFileExtension)
+	found := fileutil.Exists(socketPath)
+	if mode == ModeWorker && !found {
+		log.Infof("socket channel: %v not found, using a file channel", filename)
+		return CreateFileChannel(log, mode, filename)
+	}
+	s, err := NewSocketChannel(log, mode, socketPath)
+	if err != nil {
+		log.Warnf("failed to create socket channel: %v, falling back to a file channel", err)
+		return CreateFileChannel(log, mode, filename)
+	}
+	log.Infof("socket channel: %v created, found: %v", filename, found)
+	return s, nil, found
+}
+
 //find the folder named as "documentID" under the default root dir
 //if not found, create a new filechannel under the default root dir
 //return the channel and the found flag
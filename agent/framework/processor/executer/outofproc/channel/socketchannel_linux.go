@@ -0,0 +1,359 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package channel
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/log"
+)
+
+const (
+	socketChannelSupported = true
+
+	defaultSocketFileMode = 0600
+	//size of the header holding the length of a datagram
+	datagramHeaderSize = 4
+	//datagrams are json documents and plugin outputs, anything bigger is treated as a broken stream
+	maxDatagramSize = 64 * 1024 * 1024
+)
+
+var (
+	//interval between two attempts of the worker to connect to the master
+	socketConnectRetryInterval = 500 * time.Millisecond
+	//a peer not reading its datagrams within this period is disconnected, the datagram whose write failed is sent again on reconnect
+	socketWriteTimeout = time.Minute
+)
+
+/*
+	socketChannel exchanges datagrams over a Unix domain socket, the master listens and the worker connects.
+	Each datagram is sent as a 4 bytes big endian length followed by the raw json.
+	Datagrams sent while the peer is not connected are queued and sent once it connects, and the worker reconnects
+	when the connection breaks, for instance when the agent restarts while the document is running.
+	Datagrams are not kept once written: those the kernel accepted but the peer did not read before it died are lost
+	with the connection and are not sent again on reconnect. Each reply of the worker carries the results of all the
+	plugins run so far, so the next reply makes up for a lost one. A lost completion leaves the new master without the
+	final results, it reports the document as failed once the worker is gone, like a worker exiting before completion.
+	Both ends check the credentials of their peer, which must run as the same user or as root.
+*/
+type socketChannel struct {
+	logger        log.T
+	path          string
+	mode          Mode
+	listener      *net.UnixListener
+	onMessageChan chan string
+	done          chan struct{}
+	wg            sync.WaitGroup
+	mu            sync.Mutex
+	conn          *net.UnixConn
+	pending       []string
+	closed        bool
+}
+
+/*
+	Create a socket channel, name is the path of the socket file.
+	The master removes a socket left by a previous agent process and listens on it, the worker connects to it.
+*/
+func NewSocketChannel(logger log.T, mode Mode, name string) (*socketChannel, error) {
+	ch := &socketChannel{
+		logger:        logger,
+		path:          name,
+		mode:          mode,
+		onMessageChan: make(chan string, defaultChannelBufferSize),
+		done:          make(chan struct{}),
+	}
+	if mode == ModeMaster {
+		if err := ch.listen(); err != nil {
+			logger.Errorf("failed to listen on socket %v: %v", name, err)
+			return nil, err
+		}
+		ch.wg.Add(1)
+		go ch.accept()
+	} else {
+		ch.wg.Add(1)
+		go ch.connect()
+	}
+	return ch, nil
+}
+
+func (ch *socketChannel) listen() error {
+	if err := createIfNotExist(path.Dir(ch.path)); err != nil {
+		return err
+	}
+	if err := os.Remove(ch.path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	listener, err := net.ListenUnix("unix", &net.UnixAddr{Name: ch.path, Net: "unix"})
+	if err != nil {
+		return err
+	}
+	//keep the socket file when closed, a new master process finds it and looks for the orphan worker
+	listener.SetUnlinkOnClose(false)
+	if err = os.Chmod(ch.path, defaultSocketFileMode); err != nil {
+		listener.Close()
+		os.Remove(ch.path)
+		return err
+	}
+	ch.listener = listener
+	return nil
+}
+
+//accept the connections of the worker, a new connection replaces the current one
+func (ch *socketChannel) accept() {
+	defer ch.wg.Done()
+	log := ch.logger
+	log.Debugf("%v listener started on socket: %v", ch.mode, ch.path)
+	for {
+		conn, err := ch.listener.AcceptUnix()
+		if err != nil {
+			if ch.isClosed() {
+				return
+			}
+			log.Errorf("failed to accept connection on socket %v: %v", ch.path, err)
+			if !ch.sleep(socketConnectRetryInterval) {
+				return
+			}
+			continue
+		}
+		if err = checkPeerCredentials(conn); err != nil {
+			log.Errorf("rejected connection on socket %v: %v", ch.path, err)
+			conn.Close()
+			continue
+		}
+		if ch.attach(conn) {
+			ch.wg.Add(1)
+			go func() {
+				defer ch.wg.Done()
+				ch.receive(conn)
+			}()
+		}
+	}
+}
+
+//connect to the master, and connect again when the connection breaks until the channel is closed
+func (ch *socketChannel) connect() {
+	defer ch.wg.Done()
+	log := ch.logger
+	log.Debugf("%v connecting to socket: %v", ch.mode, ch.path)
+	for !ch.isClosed() {
+		conn, err := net.DialUnix("unix", nil, &net.UnixAddr{Name: ch.path, Net: "unix"})
+		if err == nil {
+			if err = checkPeerCredentials(conn); err != nil {
+				log.Errorf("rejected connection to socket %v: %v", ch.path, err)
+				conn.Close()
+			} else if ch.attach(conn) {
+				ch.receive(conn)
+				continue
+			}
+		} else {
+			log.Debugf("failed to connect to socket %v: %v", ch.path, err)
+		}
+		if !ch.sleep(socketConnectRetryInterval) {
+			return
+		}
+	}
+}
+
+//attach makes the connection the current one and sends the queued datagrams, returns false if the channel is closed
+func (ch *socketChannel) attach(conn *net.UnixConn) bool {
+	ch.mu.Lock()
+	defer ch.mu.Unlock()
+	if ch.closed {
+		conn.Close()
+		return false
+	}
+	if ch.conn != nil {
+		ch.logger.Info("peer reconnected, replacing the previous connection")
+		ch.conn.Close()
+	}
+	ch.conn = conn
+	for len(ch.pending) > 0 {
+		if err := ch.write(ch.pending[0]); err != nil {
+			ch.logger.Errorf("failed to send queued message: %v", err)
+			break
+		}
+		ch.pending = ch.pending[1:]
+	}
+	return true
+}
+
+//receive reads datagrams until the connection breaks
+func (ch *socketChannel) receive(conn *net.UnixConn) {
+	log := ch.logger
+	defer func() {
+		ch.mu.Lock()
+		if ch.conn == conn {
+			ch.conn = nil
+		}
+		ch.mu.Unlock()
+		conn.Close()
+	}()
+	header := make([]byte, datagramHeaderSize)
+	for {
+		if _, err := io.ReadFull(conn, header); err != nil {
+			if !ch.isClosed() {
+				log.Infof("connection on socket %v closed: %v", ch.path, err)
+			}
+			return
+		}
+		size := binary.BigEndian.Uint32(header)
+		if size > maxDatagramSize {
+			log.Errorf("received datagram of %v bytes on socket %v, dropping the connection", size, ch.path)
+			return
+		}
+		buf := make([]byte, size)
+		if _, err := io.ReadFull(conn, buf); err != nil {
+			log.Errorf("failed to read datagram on socket %v: %v", ch.path, err)
+			return
+		}
+		select {
+		case ch.onMessageChan <- string(buf):
+		case <-ch.done:
+			return
+		}
+	}
+}
+
+//write a datagram to the current connection, the connection is dropped when the write fails. Must hold the lock.
+func (ch *socketChannel) write(rawJson string) (err error) {
+	if ch.conn == nil {
+		return errors.New("peer not connected")
+	}
+	buf := make([]byte, datagramHeaderSize+len(rawJson))
+	binary.BigEndian.PutUint32(buf, uint32(len(rawJson)))
+	copy(buf[datagramHeaderSize:], rawJson)
+	ch.conn.SetWriteDeadline(time.Now().Add(socketWriteTimeout))
+	if _, err = ch.conn.Write(buf); err != nil {
+		//the peer discards the partial datagram when the connection closes
+		ch.conn.Close()
+		ch.conn = nil
+	}
+	return
+}
+
+/*
+	send a datagram to the peer, or queue it until the peer connects
+	the datagram is in the kernel buffer of the socket once sent, the peer can read it even if this end closes,
+	but it is lost if the peer dies before reading it
+*/
+func (ch *socketChannel) Send(rawJson string) error {
+	if len(rawJson) > maxDatagramSize {
+		return fmt.Errorf("message of %v bytes exceeds the maximum size of %v bytes", len(rawJson), maxDatagramSize)
+	}
+	ch.mu.Lock()
+	defer ch.mu.Unlock()
+	if ch.closed {
+		return errors.New("channel already closed")
+	}
+	if len(ch.pending) == 0 && ch.conn != nil {
+		err := ch.write(rawJson)
+		if err == nil {
+			return nil
+		}
+		ch.logger.Warnf("failed to send message on socket %v, queueing it until the peer reconnects: %v", ch.path, err)
+	}
+	ch.pending = append(ch.pending, rawJson)
+	return nil
+}
+
+func (ch *socketChannel) GetMessage() <-chan string {
+	return ch.onMessageChan
+}
+
+// Close a socket channel
+// stop listening or connecting, close the connection and close the GetMessage() go channel once the readers returned
+func (ch *socketChannel) Close() {
+	ch.mu.Lock()
+	if ch.closed {
+		ch.mu.Unlock()
+		return
+	}
+	log := ch.logger
+	log.Infof("channel %v requested close", ch.path)
+	ch.closed = true
+	close(ch.done)
+	if len(ch.pending) > 0 {
+		log.Warnf("dropping %v messages not sent to the peer", len(ch.pending))
+		ch.pending = nil
+	}
+	if ch.listener != nil {
+		ch.listener.Close()
+	}
+	if ch.conn != nil {
+		ch.conn.Close()
+	}
+	ch.mu.Unlock()
+
+	ch.wg.Wait()
+	close(ch.onMessageChan)
+	log.Infof("channel %v closed", ch.path)
+}
+
+func (ch *socketChannel) Destroy() {
+	ch.Close()
+	//only master can remove the socket at close
+	if ch.mode == ModeMaster {
+		ch.logger.Debug("master removing socket...")
+		if err := os.Remove(ch.path); err != nil && !os.IsNotExist(err) {
+			ch.logger.Errorf("failed to remove socket %v : %v", ch.path, err)
+		}
+	}
+}
+
+func (ch *socketChannel) isClosed() bool {
+	ch.mu.Lock()
+	defer ch.mu.Unlock()
+	return ch.closed
+}
+
+//sleep for the duration, returns false if the channel is closed meanwhile
+func (ch *socketChannel) sleep(duration time.Duration) bool {
+	select {
+	case <-time.After(duration):
+		return true
+	case <-ch.done:
+		return false
+	}
+}
+
+//checkPeerCredentials verifies the process on the other end runs as the same user as this process, or as root
+var checkPeerCredentials = func(conn *net.UnixConn) error {
+	raw, err := conn.SyscallConn()
+	if err != nil {
+		return err
+	}
+	var cred *syscall.Ucred
+	var credErr error
+	if err = raw.Control(func(fd uintptr) {
+		cred, credErr = syscall.GetsockoptUcred(int(fd), syscall.SOL_SOCKET, syscall.SO_PEERCRED)
+	}); err != nil {
+		return err
+	}
+	if credErr != nil {
+		return fmt.Errorf("failed to read peer credentials: %v", credErr)
+	}
+	if uid := uint32(os.Geteuid()); cred.Uid != uid && cred.Uid != 0 {
+		return fmt.Errorf("peer process %v runs as user %v, expected user %v", cred.Pid, cred.Uid, uid)
+	}
+	return nil
+}
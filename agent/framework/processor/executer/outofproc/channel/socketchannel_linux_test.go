@@ -0,0 +1,145 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package channel
+
+import (
+	"errors"
+	"io/ioutil"
+	"net"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/fileutil"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/stretchr/testify/assert"
+)
+
+var masterMessages = []string{"m000", "m001", "m002"}
+var workerMessages = []string{"w000", "w001", "w002"}
+
+func socketTestPath() (string, func()) {
+	dir, _ := ioutil.TempDir("", "socketchannel")
+	return path.Join(dir, "channels", "document"+socketFileExtension), func() { os.RemoveAll(dir) }
+}
+
+//receive the next message or fail after a timeout
+func receive(t *testing.T, ch Channel) string {
+	select {
+	case msg, ok := <-ch.GetMessage():
+		assert.True(t, ok, "channel closed")
+		return msg
+	case <-time.After(5 * time.Second):
+		assert.Fail(t, "no message received")
+		return ""
+	}
+}
+
+func TestSocketChannelDuplexTransmission(t *testing.T) {
+	socketPath, cleanup := socketTestPath()
+	defer cleanup()
+	master, err := NewSocketChannel(log.NewMockLogWithContext("MASTER"), ModeMaster, socketPath)
+	assert.NoError(t, err)
+	//messages sent before the worker connects are queued
+	for _, msg := range masterMessages {
+		assert.NoError(t, master.Send(msg))
+	}
+
+	worker, err := NewSocketChannel(log.NewMockLogWithContext("WORKER"), ModeWorker, socketPath)
+	assert.NoError(t, err)
+	for _, msg := range workerMessages {
+		assert.NoError(t, worker.Send(msg))
+	}
+
+	for _, msg := range masterMessages {
+		assert.Equal(t, msg, receive(t, worker))
+	}
+	for _, msg := range workerMessages {
+		assert.Equal(t, msg, receive(t, master))
+	}
+
+	worker.Close()
+	_, more := <-worker.GetMessage()
+	assert.False(t, more)
+	assert.Error(t, worker.Send("after close"))
+	master.Destroy()
+	_, more = <-master.GetMessage()
+	assert.False(t, more)
+	assert.False(t, fileutil.Exists(socketPath))
+}
+
+func TestSocketChannelWorkerReconnectsToNewMaster(t *testing.T) {
+	original := socketConnectRetryInterval
+	socketConnectRetryInterval = 10 * time.Millisecond
+	defer func() { socketConnectRetryInterval = original }()
+	socketPath, cleanup := socketTestPath()
+	defer cleanup()
+
+	master, err := NewSocketChannel(log.NewMockLogWithContext("MASTER"), ModeMaster, socketPath)
+	assert.NoError(t, err)
+	worker, err := NewSocketChannel(log.NewMockLogWithContext("WORKER"), ModeWorker, socketPath)
+	assert.NoError(t, err)
+	assert.NoError(t, worker.Send("w000"))
+	assert.Equal(t, "w000", receive(t, master))
+
+	//the agent restarts, the socket is kept for the new master
+	master.Close()
+	assert.True(t, fileutil.Exists(socketPath))
+	newMaster, err := NewSocketChannel(log.NewMockLogWithContext("NEWMASTER"), ModeMaster, socketPath)
+	assert.NoError(t, err)
+	assert.NoError(t, newMaster.Send("m001"))
+	assert.Equal(t, "m001", receive(t, worker))
+	assert.NoError(t, worker.Send("w001"))
+	assert.Equal(t, "w001", receive(t, newMaster))
+
+	worker.Close()
+	newMaster.Destroy()
+}
+
+func TestSocketChannelRejectsPeer(t *testing.T) {
+	original := checkPeerCredentials
+	checkPeerCredentials = func(conn *net.UnixConn) error { return errors.New("peer runs as another user") }
+	defer func() { checkPeerCredentials = original }()
+	socketPath, cleanup := socketTestPath()
+	defer cleanup()
+
+	master, err := NewSocketChannel(log.NewMockLog(), ModeMaster, socketPath)
+	assert.NoError(t, err)
+	worker, err := NewSocketChannel(log.NewMockLog(), ModeWorker, socketPath)
+	assert.NoError(t, err)
+	assert.NoError(t, worker.Send("w000"))
+
+	select {
+	case msg := <-master.GetMessage():
+		assert.Fail(t, "unexpected message", msg)
+	case <-time.After(200 * time.Millisecond):
+	}
+	worker.Close()
+	master.Destroy()
+}
+
+func TestCheckPeerCredentialsAcceptsSameUser(t *testing.T) {
+	socketPath, cleanup := socketTestPath()
+	defer cleanup()
+	os.MkdirAll(path.Dir(socketPath), defaultFileCreateMode)
+	listener, err := net.ListenUnix("unix", &net.UnixAddr{Name: socketPath, Net: "unix"})
+	assert.NoError(t, err)
+	defer listener.Close()
+
+	conn, err := net.DialUnix("unix", nil, &net.UnixAddr{Name: socketPath, Net: "unix"})
+	assert.NoError(t, err)
+	defer conn.Close()
+	assert.NoError(t, checkPeerCredentials(conn))
+}
@@ -35,7 +35,7 @@ type OutOfProcExecuter struct {
 }
 
 var channelCreator = func(log log.T, mode channel.Mode, documentID string) (channel.Channel, error, bool) {
-	return channel.CreateFileChannel(log, mode, documentID)
+	return channel.CreateChannel(log, mode, documentID)
 }
 
 var processFinder = func(log log.T, procinfo contracts.OSProcInfo) bool {
@@ -140,7 +140,7 @@ func (p *WorkerBackend) Process(datagram string) error {
 		}
 		p.once.Do(func() {
 			statusChan := make(chan contracts.PluginResult)
-			go p.runner(p.ctx, docState, statusChan, p.cancelFlag)
+			go p.runner(docState.LogContext(p.ctx), docState, statusChan, p.cancelFlag)
 			go p.pluginListener(statusChan)
 		})
 
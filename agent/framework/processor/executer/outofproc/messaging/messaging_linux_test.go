@@ -0,0 +1,84 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package messaging
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/outofproc/channel"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+//master and worker exchange datagrams over a socket channel, then the worker shuts down and the master terminates
+func TestMessagingOverSocketChannel(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "messaging")
+	defer os.RemoveAll(dir)
+	socketPath := path.Join(dir, "document.sock")
+	masterIPC, err := channel.NewSocketChannel(logger, channel.ModeMaster, socketPath)
+	assert.NoError(t, err)
+	workerIPC, err := channel.NewSocketChannel(logger, channel.ModeWorker, socketPath)
+	assert.NoError(t, err)
+
+	masterSendChan := make(chan string)
+	masterStopChan := make(chan int)
+	masterBackend := new(BackendMock)
+	masterBackend.On("Accept").Return(masterSendChan)
+	masterBackend.On("Stop").Return(masterStopChan)
+	workerSendChan := make(chan string)
+	workerStopChan := make(chan int)
+	workerBackend := new(BackendMock)
+	workerBackend.On("Accept").Return(workerSendChan)
+	workerBackend.On("Stop").Return(workerStopChan)
+	workerBackend.On("Close").Return()
+
+	processed := make(chan string, 2)
+	workerBackend.On("Process", "pluginconfig").Run(func(args mock.Arguments) {
+		processed <- args.String(0)
+	}).Return(nil)
+	masterBackend.On("Process", "complete").Run(func(args mock.Arguments) {
+		processed <- args.String(0)
+	}).Return(nil)
+
+	masterDone := make(chan bool)
+	workerDone := make(chan bool)
+	go func() {
+		Messaging(logger, masterIPC, masterBackend, make(chan bool))
+		masterDone <- true
+	}()
+	go func() {
+		Messaging(logger, workerIPC, workerBackend, make(chan bool))
+		workerDone <- true
+	}()
+
+	masterSendChan <- "pluginconfig"
+	assert.Equal(t, "pluginconfig", <-processed)
+	workerSendChan <- "complete"
+	assert.Equal(t, "complete", <-processed)
+
+	//the worker stops once its backend is done, then the master destroys the channel
+	workerStopChan <- stopTypeShutdown
+	close(workerSendChan)
+	<-workerDone
+	masterStopChan <- stopTypeTerminate
+	<-masterDone
+
+	masterBackend.AssertExpectations(t)
+	workerBackend.AssertExpectations(t)
+	_, err = os.Stat(socketPath)
+	assert.True(t, os.IsNotExist(err))
+}
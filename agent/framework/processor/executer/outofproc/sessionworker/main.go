@@ -84,18 +84,18 @@ func main() {
 		return
 	}
 
-	createFileChannelAndExecutePlugin(context, channelName)
+	createChannelAndExecutePlugin(context, channelName)
 	log.Info("Session worker closed")
 }
 
 // TODO Add interface for worker
-// createFileChannelAndExecutePlugin creates file channel using channel name
+// createChannelAndExecutePlugin creates the channel using channel name
 // and initiates communication between master agent process and session worker process
-func createFileChannelAndExecutePlugin(context context.T, channelName string) {
+func createChannelAndExecutePlugin(context context.T, channelName string) {
 	log := context.Log()
 	log.Infof("document: %v worker started", channelName)
 	//create channel from the given handle identifier by master
-	ipc, err, _ := channel.CreateFileChannel(log, channel.ModeWorker, channelName)
+	ipc, err, _ := channel.CreateChannel(log, channel.ModeWorker, channelName)
 	if err != nil {
 		log.Errorf("failed to create channel: %v", err)
 		return
@@ -26,6 +26,7 @@ import (
 	"github.com/aws/amazon-ssm-agent/agent/plugins/configurepackage"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/dockercontainer"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/downloadcontent"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/externalplugin"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/lrpminvoker"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/refreshassociation"
@@ -167,8 +168,9 @@ func RegisteredSessionWorkerPlugins() runpluginutil.PluginRegistry {
 	return *registeredPlugins
 }
 
-// loadWorkers loads all worker plugins that are invokers for interacting with long running plugins and
+// loadWorkers loads all worker plugins that are invokers for interacting with long running plugins,
 // then all standard worker plugins (if there are any conflicting names, the standard worker plugin wins)
+// and then the external plugins installed on the instance
 func loadWorkers(context context.T) {
 	plugins := runpluginutil.PluginRegistry{}
 
@@ -186,6 +188,15 @@ func loadWorkers(context context.T) {
 		context.Log().Infof("Successfully loaded platform dependent plugin %v", key)
 	}
 
+	for key, value := range externalplugin.Discover(context.Log(), appconfig.ExternalPluginsRoot) {
+		if _, exists := plugins[key]; exists {
+			context.Log().Warnf("Ignoring external plugin %v, a plugin of the agent has the same name", key)
+			continue
+		}
+		plugins[key] = value
+		context.Log().Infof("Successfully loaded external plugin %v", key)
+	}
+
 	registeredPlugins = &plugins
 }
 
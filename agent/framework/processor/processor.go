@@ -16,8 +16,6 @@ package processor
 
 import (
 	"fmt"
-	"io/ioutil"
-	"os"
 	"runtime/debug"
 	"sync"
 	"time"
@@ -31,7 +29,9 @@ import (
 	"github.com/aws/amazon-ssm-agent/agent/framework/docmanager"
 	"github.com/aws/amazon-ssm-agent/agent/framework/processor/executer"
 	"github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/outofproc"
+	"github.com/aws/amazon-ssm-agent/agent/framework/runpluginutil"
 	"github.com/aws/amazon-ssm-agent/agent/longrunning/manager"
+	"github.com/aws/amazon-ssm-agent/agent/metrics"
 	"github.com/aws/amazon-ssm-agent/agent/platform"
 	"github.com/aws/amazon-ssm-agent/agent/rebooter"
 	"github.com/aws/amazon-ssm-agent/agent/task"
@@ -48,6 +48,15 @@ const (
 	maxDocumentTimeOutHour = time.Hour * 48
 )
 
+var (
+	documentsProcessed = metrics.NewCounter("ssm_agent_documents_processed_total",
+		"Number of documents run to completion by the processors, by document type and status.",
+		"document_type", "status")
+	documentQueueDepth = metrics.NewGauge("ssm_agent_document_queue_depth",
+		"Number of documents waiting for a command worker, by document type.",
+		"document_type")
+)
+
 type Processor interface {
 	//Start activate the Processor and pick up the left over document in the last run, it returns a channel to caller to gather DocumentResult
 	Start() (chan contracts.DocumentResult, error)
@@ -77,18 +86,25 @@ type EngineProcessor struct {
 //TODO worker pool should be triggered in the Start() function
 //supported document types indicate the domain of the documentes the Processor with run upon. There'll be race-conditions if there're multiple Processors in a certain domain.
 func NewEngineProcessor(ctx context.T, commandWorkerLimit int, cancelWorkerLimit int, supportedDocs []contracts.DocumentType) *EngineProcessor {
+	executerCreator := func(ctx context.T) executer.Executer {
+		return outofproc.NewOutOfProcExecuter(ctx)
+	}
+	return NewEngineProcessorWithExecuter(ctx, commandWorkerLimit, cancelWorkerLimit, supportedDocs, executerCreator, appconfig.DefaultDataStorePath)
+}
+
+// NewEngineProcessorWithExecuter creates an EngineProcessor whose documents are run by executers built from executerCreator,
+// keeping the document states under dataStorePath, for callers such as the offline document runner that execute plugins
+// in the current process
+func NewEngineProcessorWithExecuter(ctx context.T, commandWorkerLimit int, cancelWorkerLimit int, supportedDocs []contracts.DocumentType, executerCreator ExecuterCreator, dataStorePath string) *EngineProcessor {
 	log := ctx.Log()
 	// sendCommand and cancelCommand will be processed by separate worker pools
 	// so we can define the number of workers per each
 	cancelWaitDuration := 10000 * time.Millisecond
 	clock := times.DefaultClock
-	sendCommandTaskPool := task.NewPool(log, commandWorkerLimit, cancelWaitDuration, clock)
+	sendCommandTaskPool := task.NewPriorityPool(log, commandWorkerLimit, documentJobClasses(ctx.AppConfig().Mds.DocumentTypes, supportedDocs), cancelWaitDuration, clock)
 	cancelCommandTaskPool := task.NewPool(log, cancelWorkerLimit, cancelWaitDuration, clock)
 	resChan := make(chan contracts.DocumentResult)
-	executerCreator := func(ctx context.T) executer.Executer {
-		return outofproc.NewOutOfProcExecuter(ctx)
-	}
-	documentMgr := docmanager.NewDocumentFileMgr(appconfig.DefaultDataStorePath, appconfig.DefaultDocumentRootDirName, appconfig.DefaultLocationOfState)
+	documentMgr := newDocumentMgr(ctx, dataStorePath)
 	return &EngineProcessor{
 		context:           ctx.With("[EngineProcessor]"),
 		executerCreator:   executerCreator,
@@ -100,6 +116,42 @@ func NewEngineProcessor(ctx context.T, commandWorkerLimit int, cancelWorkerLimit
 	}
 }
 
+// documentJobClasses returns the scheduling classes of the supported document types configured in the agent config
+func documentJobClasses(documentTypes map[string]appconfig.DocumentTypeCfg, supportedDocs []contracts.DocumentType) map[string]task.JobClass {
+	classes := make(map[string]task.JobClass)
+	for _, docType := range supportedDocs {
+		documentTypeCfg := documentTypes[string(docType)]
+		classes[string(docType)] = task.JobClass{
+			Priority:   documentTypeCfg.Priority,
+			MaxWorkers: documentTypeCfg.WorkersLimit,
+		}
+	}
+	return classes
+}
+
+// newDocumentMgr returns the document state manager selected by the agent config for the states under dataStorePath,
+// falling back to state files when the embedded store cannot be opened
+func newDocumentMgr(ctx context.T, dataStorePath string) docmanager.DocumentMgr {
+	log := ctx.Log()
+	documentMgr := docmanager.NewDocumentMgr(ctx.AppConfig(), dataStorePath)
+	storeMgr, ok := documentMgr.(*docmanager.DocumentBoltMgr)
+	if !ok {
+		return documentMgr
+	}
+
+	fileMgr := docmanager.NewDocumentFileMgr(dataStorePath, appconfig.DefaultDocumentRootDirName, appconfig.DefaultLocationOfState)
+	instanceID, err := platform.InstanceID()
+	if err != nil {
+		log.Errorf("no instanceID provided, keeping document states in files: %v", err)
+		return fileMgr
+	}
+	if err = storeMgr.Open(log, instanceID); err != nil {
+		log.Errorf("keeping document states in files: %v", err)
+		return fileMgr
+	}
+	return storeMgr
+}
+
 func (p *EngineProcessor) Start() (resChan chan contracts.DocumentResult, err error) {
 	context := p.context
 	if context == nil {
@@ -159,7 +211,8 @@ func (p *EngineProcessor) submit(docState *contracts.DocumentState) error {
 	} else {
 		jobID = docState.DocumentInformation.MessageID
 	}
-	return p.sendCommandPool.Submit(log, jobID, func(cancelFlag task.CancelFlag) {
+	err := p.sendCommandPool.SubmitWithClass(log, jobID, string(docState.DocumentType), func(cancelFlag task.CancelFlag) {
+		p.recordQueueDepth()
 		processCommand(
 			p.context,
 			p.executerCreator,
@@ -168,7 +221,28 @@ func (p *EngineProcessor) submit(docState *contracts.DocumentState) error {
 			docState,
 			p.documentMgr)
 	})
+	if err == nil {
+		p.recordQueueDepth()
+	}
+	return err
+}
 
+// recordQueueDepth publishes the number of documents waiting for a worker
+func (p *EngineProcessor) recordQueueDepth() {
+	depth := p.QueueDepth()
+	for docType, count := range depth {
+		documentQueueDepth.Set(float64(count), string(docType))
+	}
+	p.context.Log().Debugf("documents waiting for a worker per document type: %v", depth)
+}
+
+// QueueDepth returns the number of submitted documents waiting for a worker, per document type
+func (p *EngineProcessor) QueueDepth() map[contracts.DocumentType]int {
+	depth := make(map[contracts.DocumentType]int)
+	for class, count := range p.sendCommandPool.QueueDepth() {
+		depth[contracts.DocumentType(class)] = count
+	}
+	return depth
 }
 
 func (p *EngineProcessor) Cancel(docState contracts.DocumentState) {
@@ -223,31 +297,25 @@ func (p *EngineProcessor) Stop(stopType contracts.StopType) {
 	close(p.resChan)
 }
 
-//TODO remove the direct file dependency once we encapsulate docmanager package
 func (p *EngineProcessor) processPendingDocuments(instanceID string) {
 	log := p.context.Log()
-	files := []os.FileInfo{}
-	var err error
-
-	//process older documents from PENDING folder
-	pendingDocsLocation := docmanager.DocumentStateDir(instanceID, appconfig.DefaultLocationOfPending)
 
-	if isDirectoryEmpty, _ := fileutil.IsDirEmpty(pendingDocsLocation); isDirectoryEmpty {
-		log.Debugf("No pending documents to process from %v", pendingDocsLocation)
+	//get all pending messages
+	files, err := p.documentMgr.GetDocumentStateNames(log, instanceID, appconfig.DefaultLocationOfPending)
+	if err != nil {
+		log.Errorf("skipping reading pending documents. unexpected error encountered - %v", err)
 		return
 	}
-
-	//get all pending messages
-	if files, err = fileutil.ReadDir(pendingDocsLocation); err != nil {
-		log.Errorf("skipping reading pending documents from %v. unexpected error encountered - %v", pendingDocsLocation, err)
+	if len(files) == 0 {
+		log.Debugf("No pending documents to process")
 		return
 	}
 
 	//iterate through all pending messages
-	for _, f := range files {
-		log.Infof("Found pending document - %v", f.Name())
+	for _, fileName := range files {
+		log.Infof("Found pending document - %v", fileName)
 		//inspect document state
-		docState := p.documentMgr.GetDocumentState(log, f.Name(), instanceID, appconfig.DefaultLocationOfPending)
+		docState := p.documentMgr.GetDocumentState(log, fileName, instanceID, appconfig.DefaultLocationOfPending)
 
 		if p.isSupportedDocumentType(docState.DocumentType) {
 			log.Infof("Processing pending document %v", docState.DocumentInformation.DocumentID)
@@ -261,32 +329,27 @@ func (p *EngineProcessor) processPendingDocuments(instanceID string) {
 func (p *EngineProcessor) processInProgressDocuments(instanceID string, skipDocumentIfExpired bool) {
 	log := p.context.Log()
 	config := p.context.AppConfig()
-	var err error
-
-	pendingDocsLocation := docmanager.DocumentStateDir(instanceID, appconfig.DefaultLocationOfCurrent)
 
-	if isDirectoryEmpty, _ := fileutil.IsDirEmpty(pendingDocsLocation); isDirectoryEmpty {
-		log.Debugf("No in-progress document to process from %v", pendingDocsLocation)
+	files, err := p.documentMgr.GetDocumentStateNames(log, instanceID, appconfig.DefaultLocationOfCurrent)
+	if err != nil {
+		log.Errorf("skipping reading inprogress document. unexpected error encountered - %v", err)
 		return
-
 	}
-
-	files := []os.FileInfo{}
-	if files, err = ioutil.ReadDir(pendingDocsLocation); err != nil {
-		log.Errorf("skipping reading inprogress document from %v. unexpected error encountered - %v", pendingDocsLocation, err)
+	if len(files) == 0 {
+		log.Debugf("No in-progress document to process")
 		return
 	}
 
 	//iterate through all InProgress docs
-	for _, f := range files {
-		log.Infof("Found in-progress document - %v", f.Name())
+	for _, fileName := range files {
+		log.Infof("Found in-progress document - %v", fileName)
 
 		//inspect document state
-		docState := p.documentMgr.GetDocumentState(log, f.Name(), instanceID, appconfig.DefaultLocationOfCurrent)
+		docState := p.documentMgr.GetDocumentState(log, fileName, instanceID, appconfig.DefaultLocationOfCurrent)
 
 		retryLimit := config.Mds.CommandRetryLimit
 		if docState.DocumentInformation.RunCount >= retryLimit {
-			p.documentMgr.MoveDocumentState(log, f.Name(), instanceID, appconfig.DefaultLocationOfCurrent, appconfig.DefaultLocationOfCorrupt)
+			p.documentMgr.MoveDocumentState(log, fileName, instanceID, appconfig.DefaultLocationOfCurrent, appconfig.DefaultLocationOfCorrupt)
 			continue
 		}
 
@@ -304,7 +367,7 @@ func (p *EngineProcessor) processInProgressDocuments(instanceID string, skipDocu
 				// Do not resume in-progress document is create date is 48 hours ago.
 				if createDate.Add(maxDocumentTimeOutHour).Before(time.Now().UTC()) {
 					log.Infof("Document %v expired %v, skipping", docState.DocumentInformation.DocumentID, docState.DocumentInformation.CreatedDate)
-					p.documentMgr.MoveDocumentState(log, f.Name(), instanceID, appconfig.DefaultLocationOfCurrent, appconfig.DefaultLocationOfCorrupt)
+					p.documentMgr.MoveDocumentState(log, fileName, instanceID, appconfig.DefaultLocationOfCurrent, appconfig.DefaultLocationOfCorrupt)
 					continue
 				}
 			}
@@ -312,7 +375,7 @@ func (p *EngineProcessor) processInProgressDocuments(instanceID string, skipDocu
 			//Submit the work to Job Pool so that we don't block for processing of new messages
 			if err := p.submit(&docState); err != nil {
 				log.Errorf("failed to submit in progress document %v : %v", docState.DocumentInformation.DocumentID, err)
-				p.documentMgr.MoveDocumentState(log, f.Name(), instanceID, appconfig.DefaultLocationOfCurrent, appconfig.DefaultLocationOfCorrupt)
+				p.documentMgr.MoveDocumentState(log, fileName, instanceID, appconfig.DefaultLocationOfCurrent, appconfig.DefaultLocationOfCorrupt)
 			}
 		}
 	}
@@ -328,6 +391,7 @@ func (p *EngineProcessor) isSupportedDocumentType(documentType contracts.Documen
 }
 
 func processCommand(context context.T, executerCreator ExecuterCreator, cancelFlag task.CancelFlag, resChan chan contracts.DocumentResult, docState *contracts.DocumentState, docMgr docmanager.DocumentMgr) {
+	context = docState.LogContext(context)
 	log := context.Log()
 	//persist the current running document
 	docMgr.MoveDocumentState(log,
@@ -358,8 +422,12 @@ func processCommand(context context.T, executerCreator ExecuterCreator, cancelFl
 
 			if res.LastPlugin == "" {
 				log.Infof("sending document: %v complete response", documentID)
+				documentsProcessed.Inc(string(docState.DocumentType), string(res.Status))
 			} else {
 				log.Infof("sending reply for plugin update: %v", res.LastPlugin)
+				if pluginResult, found := res.PluginResults[res.LastPlugin]; found && pluginResult != nil {
+					runpluginutil.RecordPluginResult(*pluginResult)
+				}
 			}
 
 			final = &res
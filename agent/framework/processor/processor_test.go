@@ -15,6 +15,8 @@
 package processor
 
 import (
+	"io/ioutil"
+	"os"
 	"testing"
 
 	"fmt"
@@ -38,7 +40,8 @@ func TestEngineProcessor_Submit(t *testing.T) {
 	creator := func(ctx context.T) executer.Executer {
 		return executerMock
 	}
-	sendCommandPoolMock.On("Submit", ctx.Log(), "messageID", mock.Anything).Return(nil)
+	sendCommandPoolMock.On("SubmitWithClass", ctx.Log(), "messageID", string(contracts.SendCommand), mock.Anything).Return(nil)
+	sendCommandPoolMock.On("QueueDepth").Return(map[string]int{string(contracts.SendCommand): 1})
 	docMock := new(DocumentMgrMock)
 	processor := EngineProcessor{
 		executerCreator: creator,
@@ -46,11 +49,12 @@ func TestEngineProcessor_Submit(t *testing.T) {
 		context:         ctx,
 		documentMgr:     docMock,
 	}
-	docState := contracts.DocumentState{}
+	docState := contracts.DocumentState{DocumentType: contracts.SendCommand}
 	docState.DocumentInformation.MessageID = "messageID"
 	docMock.On("PersistDocumentState", mock.Anything, mock.Anything, mock.Anything, appconfig.DefaultLocationOfPending, docState)
 	processor.Submit(docState)
 	sendCommandPoolMock.AssertExpectations(t)
+	assert.Equal(t, map[contracts.DocumentType]int{contracts.SendCommand: 1}, processor.QueueDepth())
 }
 
 func TestEngineProcessor_Cancel(t *testing.T) {
@@ -94,6 +98,31 @@ func TestEngineProcessor_Stop(t *testing.T) {
 	cancelCommandPoolMock.AssertExpectations(t)
 }
 
+func TestEngineProcessor_ProcessPendingDocuments(t *testing.T) {
+	sendCommandPoolMock := new(task.MockedPool)
+	ctx := context.NewMockDefault()
+	docMock := new(DocumentMgrMock)
+	processor := EngineProcessor{
+		sendCommandPool:   sendCommandPoolMock,
+		context:           ctx,
+		documentMgr:       docMock,
+		supportedDocTypes: []contracts.DocumentType{contracts.SendCommand},
+	}
+	docState := contracts.DocumentState{DocumentType: contracts.SendCommand}
+	docState.DocumentInformation.MessageID = "messageID"
+	docState.DocumentInformation.DocumentID = "documentID"
+	otherDocState := contracts.DocumentState{DocumentType: contracts.Association}
+	docMock.On("GetDocumentStateNames", mock.Anything, "instanceID", appconfig.DefaultLocationOfPending).Return([]string{"documentID", "associationID"}, nil)
+	docMock.On("GetDocumentState", mock.Anything, "documentID", "instanceID", appconfig.DefaultLocationOfPending).Return(docState)
+	docMock.On("GetDocumentState", mock.Anything, "associationID", "instanceID", appconfig.DefaultLocationOfPending).Return(otherDocState)
+	docMock.On("PersistDocumentState", mock.Anything, "documentID", mock.Anything, appconfig.DefaultLocationOfPending, docState)
+	sendCommandPoolMock.On("SubmitWithClass", ctx.Log(), "messageID", string(contracts.SendCommand), mock.Anything).Return(nil)
+	sendCommandPoolMock.On("QueueDepth").Return(map[string]int{})
+	processor.processPendingDocuments("instanceID")
+	docMock.AssertExpectations(t)
+	sendCommandPoolMock.AssertExpectations(t)
+}
+
 //TODO add shutdown and reboot test once we encapsulate docmanager
 func TestProcessCommand(t *testing.T) {
 	ctx := context.NewMockDefault()
@@ -191,6 +220,43 @@ func TestProcessCancelCommand_Success(t *testing.T) {
 
 }
 
+func TestNewEngineProcessorWithExecuter(t *testing.T) {
+	ctx := context.NewMockDefault()
+	executerMock := executermocks.NewMockExecuter()
+	creator := func(ctx context.T) executer.Executer {
+		return executerMock
+	}
+	supportedDocs := []contracts.DocumentType{contracts.SendCommandOffline}
+	dataStorePath, err := ioutil.TempDir("", "processor")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dataStorePath)
+
+	processor := NewEngineProcessorWithExecuter(ctx, 1, 1, supportedDocs, creator, dataStorePath)
+
+	assert.Equal(t, executerMock, processor.executerCreator(ctx))
+	assert.Equal(t, supportedDocs, processor.supportedDocTypes)
+	assert.NotNil(t, processor.documentMgr)
+	resChan, err := processor.Start()
+	assert.NoError(t, err)
+	assert.NotNil(t, resChan)
+
+	processor.Stop(contracts.StopTypeSoftStop)
+	_, open := <-resChan
+	assert.False(t, open)
+}
+
+func TestDocumentJobClasses(t *testing.T) {
+	documentTypes := map[string]appconfig.DocumentTypeCfg{
+		string(contracts.SendCommand): {Priority: 10},
+		string(contracts.Association): {WorkersLimit: 2},
+	}
+	classes := documentJobClasses(documentTypes, []contracts.DocumentType{contracts.SendCommand, contracts.SendCommandOffline})
+	assert.Equal(t, map[string]task.JobClass{
+		string(contracts.SendCommand):        {Priority: 10},
+		string(contracts.SendCommandOffline): {},
+	}, classes)
+}
+
 type DocumentMgrMock struct {
 	mock.Mock
 }
@@ -214,3 +280,8 @@ func (m *DocumentMgrMock) RemoveDocumentState(log log.T, documentID, instanceID,
 	m.Called(log, documentID, instanceID, location)
 	return
 }
+
+func (m *DocumentMgrMock) GetDocumentStateNames(log log.T, instanceID, locationFolder string) ([]string, error) {
+	args := m.Called(log, instanceID, locationFolder)
+	return args.Get(0).([]string), args.Error(1)
+}
@@ -0,0 +1,332 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package runpluginutil
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/aws/amazon-ssm-agent/agent/fileutil"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/platform"
+)
+
+// Precondition operators.
+// Comparison operators take an operand and a value in any order, e.g. "VersionGreaterThan": ["platformVersion", "7"].
+// And, Or and Not take precondition expressions such as "StringLike(platformName, Amazon*)",
+// values containing commas or parentheses must be quoted in expressions.
+const (
+	operatorStringEquals             = "StringEquals"
+	operatorNotEquals                = "NotEquals"
+	operatorStringLike               = "StringLike"
+	operatorVersionEquals            = "VersionEquals"
+	operatorVersionGreaterThan       = "VersionGreaterThan"
+	operatorVersionGreaterThanEquals = "VersionGreaterThanEquals"
+	operatorVersionLessThan          = "VersionLessThan"
+	operatorVersionLessThanEquals    = "VersionLessThanEquals"
+	operatorAnd                      = "And"
+	operatorOr                       = "Or"
+	operatorNot                      = "Not"
+
+	// operandEnvPrefix prefixes the name of an environment variable of the agent
+	operandEnvPrefix = "env:"
+	// operandFileExistsPrefix prefixes a path, the operand is true if the path exists and false otherwise
+	operandFileExistsPrefix = "fileExists:"
+)
+
+// preconditionOperands returns the value of the operands describing the instance
+var preconditionOperands = map[string]func(log log.T) (string, error){
+	"platformType":    platform.PlatformType,
+	"platformName":    platform.PlatformName,
+	"platformVersion": platform.PlatformVersion,
+	"architecture":    func(log.T) (string, error) { return architecture(), nil },
+	"instanceId":      func(log.T) (string, error) { return platform.InstanceID() },
+}
+
+// conditionResult is the result of a precondition, unknown when the precondition is not recognized
+type conditionResult int
+
+const (
+	conditionFalse conditionResult = iota
+	conditionTrue
+	conditionUnknown
+)
+
+func toConditionResult(value bool) conditionResult {
+	if value {
+		return conditionTrue
+	}
+	return conditionFalse
+}
+
+// preconditionEvaluator evaluates the preconditions of a step, resolving each operand at most once
+type preconditionEvaluator struct {
+	log    log.T
+	values map[string]string
+}
+
+func newPreconditionEvaluator(log log.T) *preconditionEvaluator {
+	return &preconditionEvaluator{log: log, values: make(map[string]string)}
+}
+
+// evaluate returns the result of the operator applied to the arguments
+func (e *preconditionEvaluator) evaluate(operator string, args []string) conditionResult {
+	switch operator {
+	case operatorAnd, operatorOr:
+		if len(args) == 0 {
+			return conditionUnknown
+		}
+		// an unknown condition only decides the result if the known conditions do not
+		result := toConditionResult(operator == operatorAnd)
+		for _, arg := range args {
+			switch e.evaluateExpression(arg) {
+			case conditionUnknown:
+				result = conditionUnknown
+			case toConditionResult(operator == operatorOr):
+				return toConditionResult(operator == operatorOr)
+			}
+		}
+		return result
+	case operatorNot:
+		if len(args) != 1 {
+			return conditionUnknown
+		}
+		switch e.evaluateExpression(args[0]) {
+		case conditionTrue:
+			return conditionFalse
+		case conditionFalse:
+			return conditionTrue
+		}
+		return conditionUnknown
+	default:
+		return e.compare(operator, args)
+	}
+}
+
+// evaluateExpression evaluates a precondition expression such as StringEquals(platformType, Linux)
+func (e *preconditionEvaluator) evaluateExpression(expression string) conditionResult {
+	operator, args, err := parsePreconditionExpression(expression)
+	if err != nil {
+		e.log.Debugf("invalid precondition expression %v, %v", expression, err)
+		return conditionUnknown
+	}
+	return e.evaluate(operator, args)
+}
+
+// compare evaluates a comparison between an operand and a value
+func (e *preconditionEvaluator) compare(operator string, args []string) conditionResult {
+	if len(args) != 2 || isPreconditionOperand(args[0]) == isPreconditionOperand(args[1]) {
+		return conditionUnknown
+	}
+	operand, expected := args[0], args[1]
+	if !isPreconditionOperand(operand) {
+		operand, expected = expected, operand
+	}
+	actual := e.resolve(operand)
+	e.log.Debugf("precondition operand %v = %v", operand, actual)
+
+	switch operator {
+	case operatorStringEquals:
+		return toConditionResult(strings.EqualFold(actual, expected))
+	case operatorNotEquals:
+		return toConditionResult(!strings.EqualFold(actual, expected))
+	case operatorStringLike:
+		return toConditionResult(globPattern(expected).MatchString(actual))
+	case operatorVersionEquals, operatorVersionGreaterThan, operatorVersionGreaterThanEquals, operatorVersionLessThan, operatorVersionLessThanEquals:
+		expectedVersion, err := parseVersion(expected)
+		if err != nil {
+			return conditionUnknown
+		}
+		// an instance without a version fails any version condition
+		actualVersion, err := parseVersion(actual)
+		if err != nil {
+			return conditionFalse
+		}
+		order := compareVersions(actualVersion, expectedVersion)
+		switch operator {
+		case operatorVersionEquals:
+			return toConditionResult(order == 0)
+		case operatorVersionGreaterThan:
+			return toConditionResult(order > 0)
+		case operatorVersionGreaterThanEquals:
+			return toConditionResult(order >= 0)
+		case operatorVersionLessThan:
+			return toConditionResult(order < 0)
+		default:
+			return toConditionResult(order <= 0)
+		}
+	}
+	return conditionUnknown
+}
+
+// resolve returns the value of an operand on this instance, empty if it cannot be determined
+func (e *preconditionEvaluator) resolve(operand string) string {
+	if value, found := e.values[operand]; found {
+		return value
+	}
+	var value string
+	switch {
+	case strings.HasPrefix(operand, operandEnvPrefix):
+		value = os.Getenv(strings.TrimPrefix(operand, operandEnvPrefix))
+	case strings.HasPrefix(operand, operandFileExistsPrefix):
+		value = strconv.FormatBool(fileutil.Exists(strings.TrimPrefix(operand, operandFileExistsPrefix)))
+	default:
+		var err error
+		if value, err = preconditionOperands[operand](e.log); err != nil {
+			e.log.Debugf("failed to get precondition operand %v, %v", operand, err)
+		}
+	}
+	e.values[operand] = value
+	return value
+}
+
+// isPreconditionOperand returns whether the argument of a comparison is an operand or a value
+func isPreconditionOperand(arg string) bool {
+	if _, found := preconditionOperands[arg]; found {
+		return true
+	}
+	return (strings.HasPrefix(arg, operandEnvPrefix) && len(arg) > len(operandEnvPrefix)) ||
+		(strings.HasPrefix(arg, operandFileExistsPrefix) && len(arg) > len(operandFileExistsPrefix))
+}
+
+// architecture returns the processor architecture of the instance, named as uname does
+func architecture() string {
+	switch runtime.GOARCH {
+	case "amd64":
+		return "x86_64"
+	case "386":
+		return "i386"
+	}
+	return runtime.GOARCH
+}
+
+// globPattern returns a case insensitive regular expression matching the pattern, * matches any characters and ? one character
+func globPattern(pattern string) *regexp.Regexp {
+	expression := regexp.QuoteMeta(pattern)
+	expression = strings.Replace(expression, `\*`, ".*", -1)
+	expression = strings.Replace(expression, `\?`, ".", -1)
+	return regexp.MustCompile("(?is)^" + expression + "$")
+}
+
+// parseVersion returns the numeric components of a dotted version, ignoring what follows the digits of a component
+func parseVersion(version string) ([]int, error) {
+	var components []int
+	for _, component := range strings.Split(strings.TrimSpace(version), ".") {
+		digits := 0
+		for digits < len(component) && component[digits] >= '0' && component[digits] <= '9' {
+			digits++
+		}
+		if digits == 0 {
+			if len(components) == 0 {
+				return nil, fmt.Errorf("invalid version %v", version)
+			}
+			break
+		}
+		value, err := strconv.Atoi(component[:digits])
+		if err != nil {
+			return nil, fmt.Errorf("invalid version %v", version)
+		}
+		components = append(components, value)
+		if digits < len(component) {
+			break
+		}
+	}
+	return components, nil
+}
+
+// compareVersions returns -1, 0 or 1 when a is lower than, equal to or greater than b, missing components are 0
+func compareVersions(a []int, b []int) int {
+	for i := 0; i < len(a) || i < len(b); i++ {
+		var componentA, componentB int
+		if i < len(a) {
+			componentA = a[i]
+		}
+		if i < len(b) {
+			componentB = b[i]
+		}
+		if componentA != componentB {
+			if componentA < componentB {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+// parsePreconditionExpression splits an expression such as Or(StringEquals(platformName, Ubuntu), "env:A") into
+// its operator and its arguments, the arguments which are expressions are returned unparsed
+func parsePreconditionExpression(expression string) (operator string, args []string, err error) {
+	expression = strings.TrimSpace(expression)
+	open := strings.Index(expression, "(")
+	if open <= 0 || !strings.HasSuffix(expression, ")") {
+		return "", nil, fmt.Errorf("expected Operator(arguments)")
+	}
+	operator = strings.TrimSpace(expression[:open])
+	inner := expression[open+1 : len(expression)-1]
+
+	var current bytes.Buffer
+	depth := 0
+	var quote rune
+	quoted := false
+	flush := func() {
+		arg := current.String()
+		if !quoted {
+			arg = strings.TrimSpace(arg)
+		}
+		args = append(args, arg)
+		current.Reset()
+		quoted = false
+	}
+	for _, char := range inner {
+		switch {
+		case quote != 0:
+			if char == quote {
+				quote = 0
+			} else {
+				current.WriteRune(char)
+			}
+		case quoted && unicode.IsSpace(char):
+			// spaces around quoted values are not part of the value
+		case (char == '"' || char == '\'') && depth == 0 && strings.TrimSpace(current.String()) == "":
+			current.Reset()
+			quote, quoted = char, true
+		case char == '(':
+			depth++
+			current.WriteRune(char)
+		case char == ')':
+			if depth--; depth < 0 {
+				return "", nil, fmt.Errorf("unbalanced parentheses")
+			}
+			current.WriteRune(char)
+		case char == ',' && depth == 0:
+			flush()
+		default:
+			current.WriteRune(char)
+		}
+	}
+	if quote != 0 || depth != 0 {
+		return "", nil, fmt.Errorf("unbalanced quotes or parentheses")
+	}
+	if strings.TrimSpace(inner) != "" {
+		flush()
+	}
+	return operator, args, nil
+}
@@ -0,0 +1,124 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package runpluginutil
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/stretchr/testify/assert"
+)
+
+// setPreconditionOperandsMock replaces the instance operands and returns the function restoring them
+func setPreconditionOperandsMock() func() {
+	original := preconditionOperands
+	constant := func(value string) func(log.T) (string, error) {
+		return func(log.T) (string, error) { return value, nil }
+	}
+	preconditionOperands = map[string]func(log log.T) (string, error){
+		"platformType":    constant("linux"),
+		"platformName":    constant("Amazon Linux"),
+		"platformVersion": constant("2.0.20180622"),
+		"architecture":    constant("x86_64"),
+		"instanceId":      constant("i-1234567890abcdef0"),
+	}
+	return func() { preconditionOperands = original }
+}
+
+func TestEvaluatePreconditions(t *testing.T) {
+	defer setPreconditionOperandsMock()()
+	os.Setenv("SSM_PRECONDITION_TEST", "Production")
+	defer os.Unsetenv("SSM_PRECONDITION_TEST")
+	file, _ := ioutil.TempFile("", "precondition")
+	file.Close()
+	defer os.Remove(file.Name())
+
+	testCases := []struct {
+		operator string
+		args     []string
+		result   conditionResult
+	}{
+		{"StringEquals", []string{"platformType", "Linux"}, conditionTrue},
+		{"StringEquals", []string{"Windows", "platformType"}, conditionFalse},
+		{"NotEquals", []string{"architecture", "arm64"}, conditionTrue},
+		{"StringLike", []string{"platformName", "amazon*"}, conditionTrue},
+		{"StringLike", []string{"instanceId", "i-?234*"}, conditionTrue},
+		{"StringLike", []string{"platformName", "Ubuntu*"}, conditionFalse},
+		{"VersionGreaterThan", []string{"platformVersion", "2"}, conditionTrue},
+		{"VersionGreaterThan", []string{"2.0.20180622", "platformVersion"}, conditionFalse},
+		{"VersionGreaterThanEquals", []string{"platformVersion", "2.0.20180622"}, conditionTrue},
+		{"VersionLessThan", []string{"platformVersion", "2.1"}, conditionTrue},
+		{"VersionLessThanEquals", []string{"platformVersion", "1.9"}, conditionFalse},
+		{"VersionEquals", []string{"platformVersion", "2.0.20180622.0"}, conditionTrue},
+		{"VersionEquals", []string{"platformVersion", "latest"}, conditionUnknown},
+		{"VersionGreaterThan", []string{"platformName", "1"}, conditionFalse},
+		{"StringEquals", []string{"env:SSM_PRECONDITION_TEST", "production"}, conditionTrue},
+		{"StringEquals", []string{"env:SSM_PRECONDITION_UNSET", ""}, conditionTrue},
+		{"StringEquals", []string{"fileExists:" + file.Name(), "true"}, conditionTrue},
+		{"StringEquals", []string{"fileExists:" + file.Name() + ".missing", "true"}, conditionFalse},
+		{"StringEquals", []string{"env:", "foo"}, conditionUnknown},
+		{"StringEquals", []string{"platformName", "platformType"}, conditionUnknown},
+		{"StringLike", []string{"platformName"}, conditionUnknown},
+		{"foo", []string{"platformType", "Linux"}, conditionUnknown},
+		{"And", []string{"StringEquals(platformType, Linux)", "VersionGreaterThanEquals(platformVersion, 2)"}, conditionTrue},
+		{"And", []string{"StringEquals(platformType, Linux)", "StringEquals(architecture, arm64)"}, conditionFalse},
+		{"And", []string{"StringEquals(platformType, Windows)", "foo(platformType, Linux)"}, conditionFalse},
+		{"And", []string{"StringEquals(platformType, Linux)", "foo(platformType, Linux)"}, conditionUnknown},
+		{"Or", []string{"StringEquals(platformType, Windows)", "StringLike(platformName, 'Amazon*')"}, conditionTrue},
+		{"Or", []string{"StringEquals(platformType, Linux)", "foo(platformType, Linux)"}, conditionTrue},
+		{"Or", []string{"StringEquals(platformType, Windows)", "StringEquals(platformType, MacOS)"}, conditionFalse},
+		{"Or", []string{}, conditionUnknown},
+		{"Not", []string{"Or(StringEquals(platformType, Windows), And(NotEquals(architecture, x86_64), StringEquals(platformType, Linux)))"}, conditionTrue},
+		{"Not", []string{"StringEquals(\"fileExists:" + file.Name() + "\", true)"}, conditionFalse},
+		{"Not", []string{"StringEquals(platformType, Linux)", "StringEquals(platformType, Linux)"}, conditionUnknown},
+		{"Not", []string{"StringEquals(platformType, Linux"}, conditionUnknown},
+	}
+
+	for _, testCase := range testCases {
+		result := newPreconditionEvaluator(log.NewMockLog()).evaluate(testCase.operator, testCase.args)
+		assert.Equal(t, testCase.result, result, "%v %v", testCase.operator, testCase.args)
+	}
+}
+
+func TestEvaluatePreconditionsReportsUnrecognizedPreconditions(t *testing.T) {
+	defer setPreconditionOperandsMock()()
+
+	isAllowed, unrecognized := evaluatePreconditions(log.NewMockLog(), map[string][]string{
+		"StringLike":         {"platformName", "Amazon*"},
+		"VersionGreaterThan": {"platformVersion", "3"},
+	})
+	assert.False(t, isAllowed)
+	assert.Empty(t, unrecognized)
+
+	isAllowed, unrecognized = evaluatePreconditions(log.NewMockLog(), map[string][]string{
+		"StringLike": {"platformName", "Amazon*"},
+		"Xor":        {"StringEquals(platformType, Linux)"},
+	})
+	assert.True(t, isAllowed)
+	assert.Equal(t, []string{"\"Xor\": [StringEquals(platformType, Linux)]"}, unrecognized)
+}
+
+func TestParsePreconditionExpression(t *testing.T) {
+	operator, args, err := parsePreconditionExpression(` And( StringEquals(platformType, Linux), "fileExists:C:\Program Files (x86)\a, b" ,' padded ')`)
+	assert.NoError(t, err)
+	assert.Equal(t, "And", operator)
+	assert.Equal(t, []string{"StringEquals(platformType, Linux)", `fileExists:C:\Program Files (x86)\a, b`, " padded "}, args)
+
+	_, _, err = parsePreconditionExpression("StringEquals(platformType, 'Linux)")
+	assert.Error(t, err)
+	_, _, err = parsePreconditionExpression("(platformType, Linux)")
+	assert.Error(t, err)
+}
@@ -26,7 +26,7 @@ import (
 	"github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/iohandler"
 	"github.com/aws/amazon-ssm-agent/agent/jsonutil"
 	"github.com/aws/amazon-ssm-agent/agent/log"
-	"github.com/aws/amazon-ssm-agent/agent/platform"
+	"github.com/aws/amazon-ssm-agent/agent/metrics"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/pluginutil"
 	"github.com/aws/amazon-ssm-agent/agent/task"
 )
@@ -46,6 +46,13 @@ type PluginFactory interface {
 	Create(context context.T) (T, error)
 }
 
+// ExternalPluginFactory is implemented by the factories of the plugins installed outside of the agent.
+// These plugins are not in the list of known plugins, and are supported on the instances they are installed on.
+type ExternalPluginFactory interface {
+	PluginFactory
+	ExternalPluginPath() string
+}
+
 // PluginRegistry stores a set of plugins (both worker and long running plugins), indexed by ID.
 type PluginRegistry map[string]PluginFactory
 
@@ -83,6 +90,20 @@ var allSessionPlugins = map[string]struct{}{
 // Assign method to global variables to allow unittest to override
 var isSupportedPlugin = IsPluginSupportedForCurrentPlatform
 
+var pluginDurations = metrics.NewHistogram("ssm_agent_plugin_duration_seconds",
+	"Duration of the plugin executions, by plugin and status.",
+	metrics.DefaultDurationBuckets,
+	"plugin", "status")
+
+// RecordPluginResult records the duration of a completed plugin.
+// Plugins usually run in a document worker process, so the agent records the results it receives from the executer.
+func RecordPluginResult(res contracts.PluginResult) {
+	if res.StartDateTime.IsZero() || res.EndDateTime.Before(res.StartDateTime) {
+		return
+	}
+	pluginDurations.Observe(res.EndDateTime.Sub(res.StartDateTime).Seconds(), res.PluginName, string(res.Status))
+}
+
 // TODO remove executionID and creation date
 // RunPlugins executes a set of plugins. The plugin configurations are given in a map with pluginId as key.
 // Outputs the results of running the plugins, indexed by pluginId.
@@ -101,6 +122,9 @@ func RunPlugins(
 	//Contains the logStreamPrefix without the pluginID
 	logStreamPrefix := ioConfig.CloudWatchConfig.LogStreamPrefix
 
+	// set by the onFailure property of a failed step to skip the next steps, until skipToStep or the end of the document
+	var skipReason, skipToStep string
+
 	for _, pluginState := range plugins {
 		pluginID := pluginState.Id     // the identifier of the plugin
 		pluginName := pluginState.Name // the name of the plugin
@@ -162,6 +186,9 @@ func RunPlugins(
 
 		pluginFactory, pluginHandlerFound = registry[pluginName]
 		isKnown, isSupported, _ = isSupportedPlugin(context.Log(), pluginName)
+		if _, isExternal := pluginFactory.(ExternalPluginFactory); isExternal {
+			isKnown, isSupported = true, true
+		}
 		operation, logMessage := getStepExecutionOperation(
 			context.Log(),
 			pluginName,
@@ -172,10 +199,28 @@ func RunPlugins(
 			configuration.IsPreconditionEnabled,
 			configuration.Preconditions)
 
+		if skipReason != "" && pluginID == skipToStep {
+			skipReason, skipToStep = "", ""
+		}
+		if skipReason != "" {
+			operation = skipStep
+			logMessage = fmt.Sprintf("Step execution skipped %s. Step name: %s", skipReason, pluginID)
+		}
+		if operation == executeStep {
+			if err := resolveStepOutputs(&configuration, pluginOutputs); err != nil {
+				operation = failStep
+				logMessage = fmt.Sprintf("Failed to resolve the inputs of the step, %v. Step name: %s", err, pluginID)
+			}
+		}
+
 		switch operation {
 		case executeStep:
 			context.Log().Infof("Running plugin %s", pluginName)
-			r = runPlugin(context, pluginFactory, pluginName, configuration, cancelFlag, ioConfig)
+			r = runStep(context, pluginFactory, pluginName, configuration, cancelFlag, ioConfig, pluginOutputs[pluginID].Attempts)
+			r.Outputs = captureStepOutputs(context.Log(), pluginID, configuration.Outputs, r)
+			if isFailedStatus(r.Status) && !isCancelled(cancelFlag) {
+				skipReason, skipToStep = onFailureDecision(configuration, &r)
+			}
 			pluginOutputs[pluginID].Code = r.Code
 			pluginOutputs[pluginID].Status = r.Status
 			pluginOutputs[pluginID].Error = r.Error
@@ -183,6 +228,8 @@ func RunPlugins(
 			pluginOutputs[pluginID].StandardOutput = r.StandardOutput
 			pluginOutputs[pluginID].StandardError = r.StandardError
 			pluginOutputs[pluginID].StepName = r.StepName
+			pluginOutputs[pluginID].Attempts = r.Attempts
+			pluginOutputs[pluginID].Outputs = r.Outputs
 
 		case skipStep:
 			context.Log().Info(logMessage)
@@ -429,36 +476,16 @@ func evaluatePreconditions(
 	var isAllowed = true
 	var unrecognizedPreconditionList []string
 
-	// For current release, we only support "StringEquals" operator and "platformType"
-	// operand, so explicitly checking for those and number of operands must be 2
+	// Each key is an operator, see precondition.go for the supported operators and operands.
+	// Operands and values can be in any order, i.e. both "StringEquals": ["platformType", "Windows"]
+	// and "StringEquals": ["Windows", "platformType"] are valid
+	evaluator := newPreconditionEvaluator(log)
 	for key, value := range preconditions {
-		switch key {
-		case "StringEquals":
-			// Platform type of OS on the instance
-			instancePlatformType, _ := platform.PlatformType(log)
-			log.Debugf("OS platform type of this instance = %s", instancePlatformType)
-
-			if len(value) != 2 ||
-				(strings.Compare(value[0], "platformType") == 0 && strings.Compare(value[1], "platformType") == 0) ||
-				(strings.Compare(value[0], "platformType") != 0 && strings.Compare(value[1], "platformType") != 0) {
-
-				unrecognizedPreconditionList = append(unrecognizedPreconditionList, fmt.Sprintf("\"%s\": %v", key, value))
-			} else {
-				// Variable and value can be in any order, i.e. both "StringEquals": ["platformType", "Windows"]
-				// and "StringEquals": ["Windows", "platformType"] are valid
-				var platformTypeValue string
-				if strings.Compare(value[0], "platformType") == 0 {
-					platformTypeValue = value[1]
-				} else {
-					platformTypeValue = value[0]
-				}
-
-				if strings.Compare(instancePlatformType, strings.ToLower(platformTypeValue)) != 0 {
-					// if precondition doesn't match for platformType, mark step for skip
-					isAllowed = false
-				}
-			}
-		default:
+		switch evaluator.evaluate(key, value) {
+		case conditionFalse:
+			// if precondition doesn't match, mark step for skip
+			isAllowed = false
+		case conditionUnknown:
 			// mark for unrecognizedPrecondition (which is a form of failure)
 			unrecognizedPreconditionList = append(unrecognizedPreconditionList, fmt.Sprintf("\"%s\": %v", key, value))
 		}
@@ -1550,3 +1550,17 @@ func TestGetStepNameError(t *testing.T) {
 	_, err := getStepName(inputPluginName, config)
 	assert.Nil(t, err)
 }
+
+func TestRecordPluginResult(t *testing.T) {
+	start := time.Now()
+	RecordPluginResult(contracts.PluginResult{
+		PluginName:    "aws:recordTest",
+		Status:        contracts.ResultStatusSuccess,
+		StartDateTime: start,
+		EndDateTime:   start.Add(2 * time.Second),
+	})
+	// results without a start time are not recorded
+	RecordPluginResult(contracts.PluginResult{PluginName: "aws:recordTest", Status: contracts.ResultStatusSuccess})
+
+	assert.Equal(t, uint64(1), pluginDurations.Count("aws:recordTest", string(contracts.ResultStatusSuccess)))
+}
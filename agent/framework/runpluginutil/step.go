@@ -0,0 +1,230 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package runpluginutil
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/context"
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/task"
+)
+
+const (
+	// attemptDecisionRetry is the decision recorded for a failed attempt which is retried
+	attemptDecisionRetry = "retry"
+
+	// maxStepRetryDelay caps the delay between the attempts of a step
+	maxStepRetryDelay = time.Minute
+
+	// cancelPollInterval is how often a step with a timeout checks whether the document was cancelled
+	cancelPollInterval = 100 * time.Millisecond
+
+	// stepTimeoutProperty is the input of the plugins overridden by the timeout of the step
+	stepTimeoutProperty = "timeoutSeconds"
+)
+
+// stepRetryDelay returns the delay before retrying a step after the failed attempt, doubled after each attempt.
+// Assign method to global variables to allow unittest to override
+var stepRetryDelay = func(attempt int) time.Duration {
+	if attempt > 6 {
+		return maxStepRetryDelay
+	}
+	delay := time.Second << uint(attempt-1)
+	if delay > maxStepRetryDelay {
+		return maxStepRetryDelay
+	}
+	return delay
+}
+
+// isFailedStatus returns whether a step ended with a status which can be retried or handled by onFailure
+func isFailedStatus(status contracts.ResultStatus) bool {
+	return status == contracts.ResultStatusFailed || status == contracts.ResultStatusTimedOut
+}
+
+// isCancelled returns whether the document was cancelled or the agent is shutting down
+func isCancelled(cancelFlag task.CancelFlag) bool {
+	return cancelFlag.Canceled() || cancelFlag.ShutDown()
+}
+
+// runStep runs the plugin of a step until an attempt succeeds, maxAttempts attempts failed or the document is cancelled.
+// The attempts recorded before a reboot are kept, only the failed ones count towards maxAttempts.
+func runStep(
+	context context.T,
+	factory PluginFactory,
+	pluginName string,
+	config contracts.Configuration,
+	cancelFlag task.CancelFlag,
+	ioConfig contracts.IOConfiguration,
+	previousAttempts []contracts.PluginAttempt) (res contracts.PluginResult) {
+
+	// steps without execution options run once and record no attempts, as before schema 2.2
+	if config.MaxAttempts <= 1 && config.OnFailure == "" && config.TimeoutSeconds <= 0 {
+		return runPlugin(context, factory, pluginName, config, cancelFlag, ioConfig)
+	}
+
+	log := context.Log()
+	maxAttempts := config.MaxAttempts
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+	attempts := append([]contracts.PluginAttempt{}, previousAttempts...)
+	failedAttempts := 0
+	for _, attempt := range attempts {
+		if isFailedStatus(attempt.Status) {
+			failedAttempts++
+		}
+	}
+
+	for {
+		res = runAttempt(context, factory, pluginName, config, cancelFlag, ioConfig)
+		attempt := contracts.PluginAttempt{
+			Attempt:       len(attempts) + 1,
+			Status:        res.Status,
+			Code:          res.Code,
+			StartDateTime: res.StartDateTime,
+			EndDateTime:   res.EndDateTime,
+			Error:         res.Error,
+		}
+		if isFailedStatus(res.Status) {
+			failedAttempts++
+		}
+		retry := isFailedStatus(res.Status) && failedAttempts < maxAttempts && !isCancelled(cancelFlag)
+		if retry {
+			attempt.Decision = attemptDecisionRetry
+		}
+		attempts = append(attempts, attempt)
+		if !retry {
+			break
+		}
+
+		delay := stepRetryDelay(failedAttempts)
+		log.Infof("Attempt %v of step %v ended with status %v, retrying in %v", attempt.Attempt, config.PluginID, res.Status, delay)
+		if !sleepUnlessCancelled(cancelFlag, delay) {
+			log.Infof("Document cancelled, step %v is not retried", config.PluginID)
+			break
+		}
+	}
+	res.Attempts = attempts
+	return
+}
+
+// sleepUnlessCancelled waits for the delay and returns false if the document was cancelled in the meantime
+func sleepUnlessCancelled(cancelFlag task.CancelFlag, delay time.Duration) bool {
+	deadline := time.Now().Add(delay)
+	for time.Now().Before(deadline) {
+		if isCancelled(cancelFlag) {
+			return false
+		}
+		wait := deadline.Sub(time.Now())
+		if wait > cancelPollInterval {
+			wait = cancelPollInterval
+		}
+		time.Sleep(wait)
+	}
+	return !isCancelled(cancelFlag)
+}
+
+// runAttempt runs the plugin once, cancelling it when the timeout of the step expires
+func runAttempt(
+	context context.T,
+	factory PluginFactory,
+	pluginName string,
+	config contracts.Configuration,
+	cancelFlag task.CancelFlag,
+	ioConfig contracts.IOConfiguration) (res contracts.PluginResult) {
+
+	if config.TimeoutSeconds <= 0 {
+		return runPlugin(context, factory, pluginName, config, cancelFlag, ioConfig)
+	}
+	config.Properties = withStepTimeout(config.Properties, config.TimeoutSeconds)
+
+	// the plugin gets its own flag, set when either the document is cancelled or the step times out
+	stepCancelFlag := task.NewChanneledCancelFlag()
+	done := make(chan struct{})
+	timedOut := make(chan bool, 1)
+	go func() {
+		timer := time.NewTimer(time.Duration(config.TimeoutSeconds) * time.Second)
+		defer timer.Stop()
+		ticker := time.NewTicker(cancelPollInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				timedOut <- false
+				return
+			case <-timer.C:
+				stepCancelFlag.Set(task.Canceled)
+				timedOut <- true
+				return
+			case <-ticker.C:
+				if isCancelled(cancelFlag) {
+					stepCancelFlag.Set(cancelFlag.State())
+					timedOut <- false
+					return
+				}
+			}
+		}
+	}()
+
+	res = runPlugin(context, factory, pluginName, config, stepCancelFlag, ioConfig)
+	close(done)
+	if <-timedOut && res.Status != contracts.ResultStatusSuccess && res.Status != contracts.ResultStatusSuccessAndReboot {
+		res.Status = contracts.ResultStatusTimedOut
+		res.Error = strings.TrimSpace(fmt.Sprintf("Step timed out after %v seconds. %v", config.TimeoutSeconds, res.Error))
+		context.Log().Error(res.Error)
+	}
+	if !isCancelled(stepCancelFlag) {
+		// wake up the plugin routines waiting for a cancellation
+		stepCancelFlag.Set(task.Completed)
+	}
+	return
+}
+
+// withStepTimeout returns a copy of the plugin inputs whose timeout is the timeout of the step,
+// so that the timeout of the step takes precedence over the timeout of the inputs
+func withStepTimeout(properties interface{}, timeoutSeconds int) interface{} {
+	inputs, ok := properties.(map[string]interface{})
+	if !ok {
+		return properties
+	}
+	updated := make(map[string]interface{}, len(inputs)+1)
+	for key, value := range inputs {
+		updated[key] = value
+	}
+	updated[stepTimeoutProperty] = timeoutSeconds
+	return updated
+}
+
+// onFailureDecision returns the steps to skip after a step failed, based on its onFailure property:
+// skipReason is empty when the next step runs, otherwise the steps are skipped until the step named skipToStep,
+// or until the end of the document if skipToStep is empty
+func onFailureDecision(config contracts.Configuration, result *contracts.PluginResult) (skipReason string, skipToStep string) {
+	switch {
+	case config.OnFailure == contracts.OnFailureExit:
+		skipReason = fmt.Sprintf("due to the failure of step %v", config.PluginID)
+	case config.OnFailure == contracts.OnFailureSuccessAndExit:
+		result.Status = contracts.ResultStatusSuccess
+		skipReason = fmt.Sprintf("due to the failure of step %v", config.PluginID)
+	case strings.HasPrefix(config.OnFailure, contracts.OnFailureStepPrefix):
+		skipToStep = strings.TrimPrefix(config.OnFailure, contracts.OnFailureStepPrefix)
+		skipReason = fmt.Sprintf("due to the failure of step %v, continuing with step %v", config.PluginID, skipToStep)
+	}
+	if config.OnFailure != "" && len(result.Attempts) > 0 {
+		result.Attempts[len(result.Attempts)-1].Decision = config.OnFailure
+	}
+	return
+}
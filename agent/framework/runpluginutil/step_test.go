@@ -0,0 +1,190 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package runpluginutil
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/context"
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/iohandler"
+	"github.com/aws/amazon-ssm-agent/agent/task"
+	"github.com/stretchr/testify/assert"
+)
+
+// scriptedPlugin fails its first failures executions, waits for cancellation when blocking and otherwise prints stdout
+type scriptedPlugin struct {
+	failures   int
+	blocking   bool
+	stdout     string
+	executions int
+	properties []interface{}
+}
+
+func (p *scriptedPlugin) Execute(context context.T, config contracts.Configuration, cancelFlag task.CancelFlag, output iohandler.IOHandler) {
+	p.executions++
+	p.properties = append(p.properties, config.Properties)
+	if p.blocking {
+		cancelFlag.Wait()
+		output.MarkAsCancelled()
+		return
+	}
+	if p.executions <= p.failures {
+		output.MarkAsFailed(fmt.Errorf("execution %v failed", p.executions))
+		return
+	}
+	output.AppendInfo(p.stdout)
+	output.MarkAsSucceeded()
+}
+
+type scriptedPluginFactory struct {
+	plugin *scriptedPlugin
+}
+
+func (f scriptedPluginFactory) Create(context context.T) (T, error) {
+	return f.plugin, nil
+}
+
+func setStepRetryDelayMock() func() {
+	original := stepRetryDelay
+	stepRetryDelay = func(int) time.Duration { return time.Millisecond }
+	return func() { stepRetryDelay = original }
+}
+
+// tempIOConfig returns an io configuration writing the step outputs in a temporary directory, and the function removing it
+func tempIOConfig() (contracts.IOConfiguration, func()) {
+	dir, _ := ioutil.TempDir("", "runpluginutil")
+	return contracts.IOConfiguration{OrchestrationDirectory: dir}, func() { os.RemoveAll(dir) }
+}
+
+// runScriptedSteps runs a document whose steps use the plugins, in the given order
+func runScriptedSteps(configs []contracts.Configuration, plugins []*scriptedPlugin) map[string]*contracts.PluginResult {
+	registry := PluginRegistry{}
+	states := make([]contracts.PluginState, len(configs))
+	for index, config := range configs {
+		config.PluginName = fmt.Sprintf("plugin%v", index)
+		registry[config.PluginName] = scriptedPluginFactory{plugins[index]}
+		states[index] = contracts.PluginState{Configuration: config, Id: config.PluginID, Name: config.PluginName}
+	}
+	ioConfig, cleanup := tempIOConfig()
+	defer cleanup()
+	ch := make(chan contracts.PluginResult, len(configs))
+	return RunPlugins(context.NewMockDefault(), states, ioConfig, registry, ch, task.NewChanneledCancelFlag())
+}
+
+func TestRunStepRetriesUntilSuccess(t *testing.T) {
+	setIsSupportedMock()
+	defer restoreIsSupported()
+	defer setStepRetryDelayMock()()
+	plugin := &scriptedPlugin{failures: 2}
+
+	outputs := runScriptedSteps([]contracts.Configuration{{PluginID: "step1", MaxAttempts: 3}}, []*scriptedPlugin{plugin})
+
+	assert.Equal(t, 3, plugin.executions)
+	result := outputs["step1"]
+	assert.Equal(t, contracts.ResultStatusSuccess, result.Status)
+	assert.Len(t, result.Attempts, 3)
+	assert.Equal(t, contracts.ResultStatusFailed, result.Attempts[0].Status)
+	assert.Equal(t, attemptDecisionRetry, result.Attempts[0].Decision)
+	assert.Equal(t, attemptDecisionRetry, result.Attempts[1].Decision)
+	assert.Equal(t, contracts.ResultStatusSuccess, result.Attempts[2].Status)
+	assert.Equal(t, "", result.Attempts[2].Decision)
+}
+
+func TestRunStepOnFailureExit(t *testing.T) {
+	setIsSupportedMock()
+	defer restoreIsSupported()
+	defer setStepRetryDelayMock()()
+	plugins := []*scriptedPlugin{{failures: 2}, {}}
+
+	outputs := runScriptedSteps([]contracts.Configuration{
+		{PluginID: "step1", MaxAttempts: 2, OnFailure: contracts.OnFailureExit},
+		{PluginID: "step2"},
+	}, plugins)
+
+	assert.Equal(t, 2, plugins[0].executions)
+	assert.Equal(t, 0, plugins[1].executions)
+	assert.Equal(t, contracts.ResultStatusFailed, outputs["step1"].Status)
+	assert.Equal(t, contracts.OnFailureExit, outputs["step1"].Attempts[1].Decision)
+	assert.Equal(t, contracts.ResultStatusSkipped, outputs["step2"].Status)
+}
+
+func TestRunStepOnFailureSuccessAndExit(t *testing.T) {
+	setIsSupportedMock()
+	defer restoreIsSupported()
+	plugins := []*scriptedPlugin{{failures: 1}, {}}
+
+	outputs := runScriptedSteps([]contracts.Configuration{
+		{PluginID: "step1", OnFailure: contracts.OnFailureSuccessAndExit},
+		{PluginID: "step2"},
+	}, plugins)
+
+	assert.Equal(t, contracts.ResultStatusSuccess, outputs["step1"].Status)
+	assert.Equal(t, contracts.ResultStatusFailed, outputs["step1"].Attempts[0].Status)
+	assert.Equal(t, contracts.ResultStatusSkipped, outputs["step2"].Status)
+	assert.Equal(t, 0, plugins[1].executions)
+}
+
+func TestRunStepOnFailureGoesToStep(t *testing.T) {
+	setIsSupportedMock()
+	defer restoreIsSupported()
+	plugins := []*scriptedPlugin{{failures: 1}, {}, {}}
+
+	outputs := runScriptedSteps([]contracts.Configuration{
+		{PluginID: "step1", OnFailure: contracts.OnFailureStepPrefix + "step3"},
+		{PluginID: "step2"},
+		{PluginID: "step3"},
+	}, plugins)
+
+	assert.Equal(t, contracts.ResultStatusFailed, outputs["step1"].Status)
+	assert.Equal(t, contracts.ResultStatusSkipped, outputs["step2"].Status)
+	assert.Equal(t, contracts.ResultStatusSuccess, outputs["step3"].Status)
+	assert.Equal(t, 1, plugins[2].executions)
+}
+
+func TestRunStepTimeout(t *testing.T) {
+	setIsSupportedMock()
+	defer restoreIsSupported()
+	plugins := []*scriptedPlugin{{blocking: true}, {}}
+
+	outputs := runScriptedSteps([]contracts.Configuration{
+		{PluginID: "step1", TimeoutSeconds: 1, Properties: map[string]interface{}{"timeoutSeconds": 3600, "commands": "sleep"}},
+		{PluginID: "step2"},
+	}, plugins)
+
+	result := outputs["step1"]
+	assert.Equal(t, contracts.ResultStatusTimedOut, result.Status)
+	assert.Contains(t, result.Error, "Step timed out after 1 seconds")
+	assert.Equal(t, contracts.ResultStatusTimedOut, result.Attempts[0].Status)
+	assert.Equal(t, map[string]interface{}{"timeoutSeconds": 1, "commands": "sleep"}, plugins[0].properties[0])
+	// onFailure defaults to continue
+	assert.Equal(t, contracts.ResultStatusSuccess, outputs["step2"].Status)
+}
+
+func TestRunStepIsNotRetriedWhenCancelled(t *testing.T) {
+	plugin := &scriptedPlugin{failures: 5}
+	cancelFlag := task.NewChanneledCancelFlag()
+	cancelFlag.Set(task.Canceled)
+
+	ioConfig, cleanup := tempIOConfig()
+	defer cleanup()
+	result := runStep(context.NewMockDefault(), scriptedPluginFactory{plugin}, "plugin", contracts.Configuration{PluginID: "step1", MaxAttempts: 5}, cancelFlag, ioConfig, nil)
+
+	assert.Equal(t, 1, plugin.executions)
+	assert.Len(t, result.Attempts, 1)
+}
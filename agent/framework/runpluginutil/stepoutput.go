@@ -0,0 +1,127 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package runpluginutil
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/jsonutil"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/parameters"
+)
+
+// resolveStepOutputs replaces the references to the outputs of previous steps in the inputs of a step
+func resolveStepOutputs(config *contracts.Configuration, pluginOutputs map[string]*contracts.PluginResult) error {
+	if len(parameters.StepOutputReferences(config.Properties)) == 0 {
+		return nil
+	}
+	outputs := make(map[string]map[string]string)
+	for stepName, result := range pluginOutputs {
+		if len(result.Outputs) > 0 {
+			outputs[stepName] = result.Outputs
+		}
+	}
+	properties, err := parameters.ReplaceStepOutputs(config.Properties, outputs)
+	if err != nil {
+		return err
+	}
+	config.Properties = properties
+	return nil
+}
+
+// captureStepOutputs returns the values of the outputs declared by a step, the outputs which cannot be selected are left out
+func captureStepOutputs(log log.T, stepName string, declared []contracts.StepOutput, result contracts.PluginResult) map[string]string {
+	if len(declared) == 0 {
+		return nil
+	}
+	outputs := make(map[string]string)
+	for _, output := range declared {
+		switch output.Selector {
+		case contracts.StepOutputSelectorStdout:
+			outputs[output.Name] = strings.TrimRight(result.StandardOutput, "\r\n")
+		case contracts.StepOutputSelectorStderr:
+			outputs[output.Name] = strings.TrimRight(result.StandardError, "\r\n")
+		case contracts.StepOutputSelectorExitCode:
+			outputs[output.Name] = strconv.Itoa(result.Code)
+		default:
+			value, err := selectJSONPath(result.Output, output.Selector)
+			if err != nil {
+				log.Warnf("Failed to capture output %v of step %v, %v", output.Name, stepName, err)
+				continue
+			}
+			outputs[output.Name] = value
+		}
+	}
+	return outputs
+}
+
+// selectJSONPath returns the value at a path such as $.packages[0].version in the plugin output.
+// Plugin outputs which are strings are parsed as JSON, strings are selected as is and other values as JSON.
+func selectJSONPath(output interface{}, path string) (string, error) {
+	var document interface{}
+	if text, ok := output.(string); ok {
+		if err := json.Unmarshal([]byte(text), &document); err != nil {
+			return "", fmt.Errorf("the output is not JSON, %v", err)
+		}
+	} else if err := jsonutil.Remarshal(output, &document); err != nil {
+		return "", err
+	}
+
+	if !strings.HasPrefix(path, contracts.StepOutputSelectorJSONPath) {
+		return "", fmt.Errorf("invalid JSON path %v", path)
+	}
+	remaining := path[len(contracts.StepOutputSelectorJSONPath):]
+	for remaining != "" {
+		switch remaining[0] {
+		case '.':
+			end := strings.IndexAny(remaining[1:], ".[") + 1
+			if end == 0 {
+				end = len(remaining)
+			}
+			key := remaining[1:end]
+			object, ok := document.(map[string]interface{})
+			if !ok || key == "" {
+				return "", fmt.Errorf("no key %v at %v", key, path[:len(path)-len(remaining)])
+			}
+			if document, ok = object[key]; !ok {
+				return "", fmt.Errorf("no key %v at %v", key, path[:len(path)-len(remaining)])
+			}
+			remaining = remaining[end:]
+		case '[':
+			end := strings.Index(remaining, "]")
+			if end < 0 {
+				return "", fmt.Errorf("invalid JSON path %v", path)
+			}
+			index, err := strconv.Atoi(remaining[1:end])
+			array, ok := document.([]interface{})
+			if err != nil || !ok || index < 0 || index >= len(array) {
+				return "", fmt.Errorf("no element %v at %v", remaining[:end+1], path[:len(path)-len(remaining)])
+			}
+			document = array[index]
+			remaining = remaining[end+1:]
+		default:
+			return "", fmt.Errorf("invalid JSON path %v", path)
+		}
+	}
+
+	if text, ok := document.(string); ok {
+		return text, nil
+	}
+	value, err := json.Marshal(document)
+	return string(value), err
+}
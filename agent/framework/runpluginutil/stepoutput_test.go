@@ -0,0 +1,87 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package runpluginutil
+
+import (
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStepOutputsArePassedToLaterSteps(t *testing.T) {
+	setIsSupportedMock()
+	defer restoreIsSupported()
+	plugins := []*scriptedPlugin{{stdout: "i-123\n"}, {}}
+
+	outputs := runScriptedSteps([]contracts.Configuration{
+		{PluginID: "first", Outputs: []contracts.StepOutput{{Name: "instance", Selector: "stdout"}, {Name: "code", Selector: "exitCode"}}},
+		{PluginID: "second", Properties: map[string]interface{}{"runCommand": []interface{}{"echo {{ steps.first.instance }} {{steps.first.code}}"}}},
+	}, plugins)
+
+	assert.Equal(t, map[string]string{"instance": "i-123", "code": "0"}, outputs["first"].Outputs)
+	assert.Equal(t, contracts.ResultStatusSuccess, outputs["second"].Status)
+	assert.Equal(t, map[string]interface{}{"runCommand": []interface{}{"echo i-123 0"}}, plugins[1].properties[0])
+}
+
+func TestStepFailsWhenStepOutputIsMissing(t *testing.T) {
+	setIsSupportedMock()
+	defer restoreIsSupported()
+	plugins := []*scriptedPlugin{{failures: 1}, {}}
+
+	outputs := runScriptedSteps([]contracts.Configuration{
+		{PluginID: "first", Outputs: []contracts.StepOutput{{Name: "version", Selector: "$.version"}}},
+		{PluginID: "second", Properties: map[string]interface{}{"version": "{{ steps.first.version }}"}},
+	}, plugins)
+
+	assert.Equal(t, contracts.ResultStatusFailed, outputs["second"].Status)
+	assert.Contains(t, outputs["second"].Error, "steps.first.version")
+	assert.Equal(t, 0, plugins[1].executions)
+}
+
+func TestSelectJSONPath(t *testing.T) {
+	output := `{"packages": [{"name": "a", "version": "1.0"}, {"name": "b", "size": 3}]}`
+
+	value, err := selectJSONPath(output, "$.packages[0].version")
+	assert.NoError(t, err)
+	assert.Equal(t, "1.0", value)
+
+	value, err = selectJSONPath(output, "$.packages[1]")
+	assert.NoError(t, err)
+	assert.Equal(t, `{"name":"b","size":3}`, value)
+
+	value, err = selectJSONPath(map[string]interface{}{"count": 2}, "$.count")
+	assert.NoError(t, err)
+	assert.Equal(t, "2", value)
+
+	_, err = selectJSONPath(output, "$.packages[2]")
+	assert.Error(t, err)
+	_, err = selectJSONPath(output, "$.missing")
+	assert.Error(t, err)
+	_, err = selectJSONPath("not json", "$.key")
+	assert.Error(t, err)
+}
+
+func TestCaptureStepOutputsSkipsUnselectableOutputs(t *testing.T) {
+	result := contracts.PluginResult{Code: 1, StandardError: "error\r\n", Output: "plain text"}
+
+	outputs := captureStepOutputs(log.NewMockLog(), "step", []contracts.StepOutput{
+		{Name: "error", Selector: "stderr"},
+		{Name: "code", Selector: "exitCode"},
+		{Name: "value", Selector: "$.value"},
+	}, result)
+
+	assert.Equal(t, map[string]string{"error": "error", "code": "1"}, outputs)
+}
@@ -20,6 +20,7 @@ import (
 
 	"github.com/aws/amazon-ssm-agent/agent/context"
 	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/metrics"
 	"github.com/aws/amazon-ssm-agent/agent/sdkutil"
 	"github.com/aws/amazon-ssm-agent/agent/ssm"
 	"github.com/aws/amazon-ssm-agent/agent/version"
@@ -49,6 +50,19 @@ const (
 
 var healthModule *HealthCheck
 
+var healthCalls = metrics.NewCounter("ssm_agent_health_pings_total",
+	"Number of health calls to Systems Manager, by call and result.",
+	"call", "result")
+
+// recordHealthCall counts the result of a health call
+func recordHealthCall(call string, err error) {
+	result := "success"
+	if err != nil {
+		result = "failure"
+	}
+	healthCalls.Inc(call, result)
+}
+
 // AgentState enumerates active and passive agentMode
 type AgentState int32
 
@@ -94,7 +108,9 @@ func (h *HealthCheck) updateHealth() {
 	var err error
 	//TODO when will status become inactive?
 	// If both ssm config and command is inactive => agent is inactive.
-	if _, err = h.service.UpdateInstanceInformation(log, version.Version, "Active", AgentName); err != nil {
+	_, err = h.service.UpdateInstanceInformation(log, version.Version, "Active", AgentName)
+	recordHealthCall("UpdateInstanceInformation", err)
+	if err != nil {
 		sdkutil.HandleAwsError(log, err, h.healthCheckStopPolicy)
 	}
 
@@ -170,6 +186,7 @@ func (h *HealthCheck) ping() (err error) {
 	}
 
 	_, err = h.service.UpdateEmptyInstanceInformation(h.context.Log(), version.Version, AgentName)
+	recordHealthCall("ping", err)
 	if err != nil {
 		h.healthCheckStopPolicy.AddErrorCount(1)
 	}
@@ -19,6 +19,7 @@ import (
 
 	"sync"
 
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
 	"github.com/aws/amazon-ssm-agent/agent/log"
 	"github.com/cihub/seelog"
 )
@@ -44,7 +45,7 @@ func SSMLogger(useWatcher bool) log.T {
 // initLogger initializes a new logger based on current configurations and starts file watcher on the configurations file
 func initLogger(useWatcher bool) (logger log.T) {
 	// Read the current configurations or get the default configurations
-	logConfigBytes := log.GetLogConfigBytes()
+	logConfigBytes := getLogConfigBytes()
 	// Initialize the base seelog logger
 	baseLogger, _ := initBaseLoggerFromBytes(logConfigBytes)
 	// Create the wrapper logger
@@ -56,6 +57,16 @@ func initLogger(useWatcher bool) (logger log.T) {
 	return
 }
 
+// getLogConfigBytes returns the seelog configurations, with all the formats replaced by
+// the json format when the agent is configured to write structured logs
+func getLogConfigBytes() []byte {
+	logConfigBytes := log.GetLogConfigBytes()
+	if config, _ := appconfig.Config(false); config.Agent.LogFormat == appconfig.LogFormatJSON {
+		return log.StructuredConfig(logConfigBytes)
+	}
+	return logConfigBytes
+}
+
 // withContext creates a wrapper logger on the base logger passed with context is passed
 func withContext(logger seelog.LoggerInterface, context ...string) (contextLogger log.T) {
 	loggerInstance.BaseLoggerInstance = logger
@@ -135,7 +146,7 @@ func replaceLogger() {
 	logger := getCached()
 
 	//Create new logger
-	logConfigBytes := log.GetLogConfigBytes()
+	logConfigBytes := getLogConfigBytes()
 	baseLogger, err := initBaseLoggerFromBytes(logConfigBytes)
 
 	// If err in creating logger, do not replace logger
@@ -0,0 +1,134 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/cihub/seelog"
+)
+
+const (
+	// JSONFormatterName is the name of the seelog formatter writing a log message as a json object.
+	// Use %JSON%n as the format of a seelog.xml output to get one json object per line.
+	JSONFormatterName = "JSON"
+
+	// JSONFormat is the seelog format writing one json object per line
+	JSONFormat = "%" + JSONFormatterName + "%n"
+
+	jsonTimeFormat = "2006-01-02T15:04:05.000Z07:00"
+)
+
+// contextFields maps the keys of the key=value log contexts to the json fields they populate
+var contextFields = map[string]string{
+	"instanceID":    "instanceId",
+	"messageID":     "messageId",
+	"commandId":     "commandId",
+	"associationId": "associationId",
+	"documentName":  "documentName",
+	"pluginName":    "pluginName",
+	"sessionId":     "sessionId",
+}
+
+// fieldOrder is the order of the known fields in the json object, the other fields follow in alphabetical order
+var fieldOrder = []string{"module", "instanceId", "messageId", "commandId", "associationId", "documentName", "pluginName", "sessionId"}
+
+// formatAttributePattern matches the format attribute of the format elements of a seelog configuration
+var formatAttributePattern = regexp.MustCompile(`(<format\s[^>]*\bformat=")[^"]*(")`)
+
+func init() {
+	seelog.RegisterCustomFormatter(JSONFormatterName, func(param string) seelog.FormatterFunc {
+		return formatJSON
+	})
+}
+
+// StructuredConfig returns the seelog configuration with all its formats replaced by JSONFormat.
+func StructuredConfig(seelogConfig []byte) []byte {
+	return formatAttributePattern.ReplaceAll(seelogConfig, []byte("${1}"+JSONFormat+"${2}"))
+}
+
+// formatJSON writes the message as a json object, the contexts that ContextFormatFilter
+// put in front of the message become fields of the object.
+func formatJSON(message string, level seelog.LogLevel, context seelog.LogContextInterface) interface{} {
+	fields, message := parseContext(message)
+
+	var buffer bytes.Buffer
+	callTime := time.Now()
+	if context != nil && context.IsValid() {
+		callTime = context.CallTime()
+	}
+	buffer.WriteString(`{"time":`)
+	writeJSONString(&buffer, callTime.UTC().Format(jsonTimeFormat))
+	buffer.WriteString(`,"level":`)
+	writeJSONString(&buffer, level.String())
+	for _, name := range fieldOrder {
+		if value, found := fields[name]; found {
+			writeJSONField(&buffer, name, value)
+			delete(fields, name)
+		}
+	}
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		writeJSONField(&buffer, name, fields[name])
+	}
+	writeJSONField(&buffer, "message", message)
+	buffer.WriteString("}")
+	return buffer.String()
+}
+
+// parseContext extracts the [context] prefixes of the message.
+// The first context without a key is the module, key=value contexts set the field of the key.
+func parseContext(message string) (fields map[string]string, remaining string) {
+	fields = make(map[string]string)
+	remaining = message
+	for strings.HasPrefix(remaining, "[") {
+		end := strings.Index(remaining, "] ")
+		if end < 0 {
+			break
+		}
+		context := remaining[1:end]
+		remaining = remaining[end+2:]
+		if separator := strings.Index(context, "="); separator > 0 {
+			key := context[:separator]
+			if field, found := contextFields[key]; found {
+				key = field
+			}
+			fields[key] = context[separator+1:]
+		} else if _, found := fields["module"]; !found {
+			fields["module"] = context
+		}
+	}
+	return fields, remaining
+}
+
+func writeJSONField(buffer *bytes.Buffer, name string, value string) {
+	buffer.WriteString(",")
+	writeJSONString(buffer, name)
+	buffer.WriteString(":")
+	writeJSONString(buffer, value)
+}
+
+func writeJSONString(buffer *bytes.Buffer, value string) {
+	encoded, _ := json.Marshal(value)
+	buffer.Write(encoded)
+}
@@ -0,0 +1,63 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/cihub/seelog"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStructuredLogger(t *testing.T) {
+	var out bytes.Buffer
+	seelogger, err := seelog.LoggerFromWriterWithMinLevelAndFormat(&out, seelog.TraceLvl, JSONFormat)
+	assert.NoError(t, err)
+	logger := &Wrapper{Format: &ContextFormatFilter{}, M: new(sync.Mutex), Delegate: &DelegateLogger{BaseLoggerInstance: seelogger}}
+
+	contextLogger := logger.WithContext("[instanceID=i-123]", "[EngineProcessor]", "[BasicExecuter]", "[commandId=abc]", "[documentName=AWS-RunShellScript]", "[pluginName=aws:runShellScript]")
+	contextLogger.Infof("running \"%v\"", "echo [1] ")
+	logger.Warn("no context")
+	logger.Flush()
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	assert.Len(t, lines, 2)
+
+	assert.Regexp(t, `^\{"time":"[^"]+","level":"info","module":"EngineProcessor","instanceId":"i-123","commandId":"abc","documentName":"AWS-RunShellScript","pluginName":"aws:runShellScript","message":"running \\"echo \[1\] \\""\}$`, lines[0])
+	var entry map[string]string
+	assert.NoError(t, json.Unmarshal([]byte(lines[1]), &entry))
+	assert.Equal(t, "warn", entry["level"])
+	assert.Equal(t, "no context", entry["message"])
+	assert.NotContains(t, entry, "module")
+}
+
+func TestParseContextKeepsUnknownKeys(t *testing.T) {
+	fields, message := parseContext("[ssm-document-worker] [runId=2] [x] message with [brackets]")
+	assert.Equal(t, map[string]string{"module": "ssm-document-worker", "runId": "2"}, fields)
+	assert.Equal(t, "message with [brackets]", message)
+}
+
+func TestStructuredConfig(t *testing.T) {
+	config := string(StructuredConfig(DefaultConfig()))
+	assert.Contains(t, config, `<format id="fmterror" format="%JSON%n"/>`)
+	assert.Contains(t, config, `<format id="fmtinfo" format="%JSON%n"/>`)
+	assert.Contains(t, config, `<outputs formatid="fmtinfo">`)
+
+	_, err := seelog.LoggerFromConfigAsString(config)
+	assert.NoError(t, err)
+}
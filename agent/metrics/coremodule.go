@@ -0,0 +1,99 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metrics
+
+import (
+	stdcontext "context"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/context"
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+)
+
+const (
+	name = "MetricsServer"
+
+	// metricsPath is the path the metrics are served on
+	metricsPath = "/metrics"
+
+	// shutdownTimeout is how long the server waits for in-flight scrapes when stopping
+	shutdownTimeout = 5 * time.Second
+)
+
+// MetricsServer is the core module serving the metrics of the default registry on localhost
+type MetricsServer struct {
+	context  context.T
+	registry *Registry
+	server   *http.Server
+	listener net.Listener
+}
+
+// NewMetricsServer creates the metrics core module, it listens on the loopback interface only
+func NewMetricsServer(context context.T) *MetricsServer {
+	return &MetricsServer{
+		context:  context.With("[" + name + "]"),
+		registry: DefaultRegistry,
+	}
+}
+
+// Handler returns an http handler writing the metrics of the registry in the Prometheus text format
+func Handler(registry *Registry) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		registry.WriteText(w)
+	})
+}
+
+// ICoreModule implementation
+
+// ModuleName returns the module name
+func (m *MetricsServer) ModuleName() string {
+	return name
+}
+
+// ModuleExecute starts serving the metrics endpoint
+func (m *MetricsServer) ModuleExecute(context context.T) (err error) {
+	log := m.context.Log()
+	address := fmt.Sprintf("127.0.0.1:%d", m.context.AppConfig().Metrics.Port)
+	if m.listener, err = net.Listen("tcp", address); err != nil {
+		log.Errorf("unable to listen on %v for metrics: %v", address, err)
+		return
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle(metricsPath, Handler(m.registry))
+	m.server = &http.Server{Handler: mux}
+	log.Infof("serving metrics on http://%v%v", m.listener.Addr(), metricsPath)
+
+	go func() {
+		if err := m.server.Serve(m.listener); err != nil && err != http.ErrServerClosed {
+			log.Errorf("metrics server stopped: %v", err)
+		}
+	}()
+	return nil
+}
+
+// ModuleRequestStop stops serving the metrics endpoint
+func (m *MetricsServer) ModuleRequestStop(stopType contracts.StopType) (err error) {
+	if m.server == nil {
+		return nil
+	}
+	m.context.Log().Info("stopping metrics server.")
+	ctx, cancel := stdcontext.WithTimeout(stdcontext.Background(), shutdownTimeout)
+	defer cancel()
+	return m.server.Shutdown(ctx)
+}
@@ -0,0 +1,336 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package metrics records counters, gauges and histograms of the agent and exposes them in the Prometheus text format.
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefaultDurationBuckets are the upper bounds in seconds of the histogram buckets used for durations
+var DefaultDurationBuckets = []float64{0.01, 0.05, 0.1, 0.5, 1, 5, 10, 30, 60, 300, 900, 3600}
+
+// DefaultRegistry holds the metrics created by NewCounter, NewGauge and NewHistogram
+var DefaultRegistry = NewRegistry()
+
+// collector is a metric that can write its series in the text format
+type collector interface {
+	metricName() string
+	write(w io.Writer)
+}
+
+// Registry is a set of metrics with unique names.
+type Registry struct {
+	mu      sync.Mutex
+	metrics map[string]collector
+}
+
+// NewRegistry creates an empty registry.
+func NewRegistry() *Registry {
+	return &Registry{metrics: make(map[string]collector)}
+}
+
+// register adds the metric to the registry, metric names must be unique
+func (r *Registry) register(metric collector) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, found := r.metrics[metric.metricName()]; found {
+		panic(fmt.Sprintf("metric %v is already registered", metric.metricName()))
+	}
+	r.metrics[metric.metricName()] = metric
+}
+
+// WriteText writes all the metrics of the registry in the Prometheus text exposition format.
+func (r *Registry) WriteText(w io.Writer) error {
+	r.mu.Lock()
+	names := make([]string, 0, len(r.metrics))
+	for name := range r.metrics {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	metrics := make([]collector, 0, len(names))
+	for _, name := range names {
+		metrics = append(metrics, r.metrics[name])
+	}
+	r.mu.Unlock()
+
+	buffered := bufio.NewWriter(w)
+	for _, metric := range metrics {
+		metric.write(buffered)
+	}
+	return buffered.Flush()
+}
+
+// desc holds the description shared by all the series of a metric
+type desc struct {
+	name       string
+	help       string
+	metricType string
+	labelNames []string
+}
+
+func (d *desc) metricName() string {
+	return d.name
+}
+
+// key returns the key of the series of the label values, false if the number of values does not match the labels
+func (d *desc) key(labelValues []string) (string, bool) {
+	if len(labelValues) != len(d.labelNames) {
+		return "", false
+	}
+	return strings.Join(labelValues, "\xff"), true
+}
+
+// writeHeader writes the HELP and TYPE lines of the metric
+func (d *desc) writeHeader(w io.Writer) {
+	fmt.Fprintf(w, "# HELP %s %s\n", d.name, strings.Replace(strings.Replace(d.help, `\`, `\\`, -1), "\n", `\n`, -1))
+	fmt.Fprintf(w, "# TYPE %s %s\n", d.name, d.metricType)
+}
+
+// labels formats the label pairs of a series, extra pairs such as the histogram bucket bound are appended
+func (d *desc) labels(labelValues []string, extra ...string) string {
+	pairs := make([]string, 0, len(labelValues)+len(extra)/2)
+	for i, value := range labelValues {
+		pairs = append(pairs, fmt.Sprintf(`%s="%s"`, d.labelNames[i], escapeLabelValue(value)))
+	}
+	for i := 0; i+1 < len(extra); i += 2 {
+		pairs = append(pairs, fmt.Sprintf(`%s="%s"`, extra[i], escapeLabelValue(extra[i+1])))
+	}
+	if len(pairs) == 0 {
+		return ""
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+// escapeLabelValue escapes backslashes, quotes and new lines in a label value
+func escapeLabelValue(value string) string {
+	value = strings.Replace(value, `\`, `\\`, -1)
+	value = strings.Replace(value, `"`, `\"`, -1)
+	return strings.Replace(value, "\n", `\n`, -1)
+}
+
+// formatValue formats a sample value
+func formatValue(value float64) string {
+	switch {
+	case math.IsInf(value, 1):
+		return "+Inf"
+	case math.IsInf(value, -1):
+		return "-Inf"
+	default:
+		return strconv.FormatFloat(value, 'g', -1, 64)
+	}
+}
+
+// valueSeries is a series of a counter or a gauge
+type valueSeries struct {
+	labelValues []string
+	value       float64
+}
+
+// valueMetric implements the series bookkeeping shared by counters and gauges
+type valueMetric struct {
+	desc
+	mu     sync.Mutex
+	series map[string]*valueSeries
+}
+
+func (m *valueMetric) update(labelValues []string, update func(value float64) float64) {
+	key, ok := m.key(labelValues)
+	if !ok {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s, found := m.series[key]
+	if !found {
+		s = &valueSeries{labelValues: append([]string(nil), labelValues...)}
+		m.series[key] = s
+	}
+	s.value = update(s.value)
+}
+
+// Value returns the current value of the series of the label values.
+func (m *valueMetric) Value(labelValues ...string) float64 {
+	key, _ := m.key(labelValues)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if s, found := m.series[key]; found {
+		return s.value
+	}
+	return 0
+}
+
+func (m *valueMetric) write(w io.Writer) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.writeHeader(w)
+	for _, key := range sortedKeys(m.series) {
+		s := m.series[key]
+		fmt.Fprintf(w, "%s%s %s\n", m.name, m.labels(s.labelValues), formatValue(s.value))
+	}
+}
+
+// Counter is a metric whose series only go up.
+type Counter struct {
+	valueMetric
+}
+
+// NewCounter creates a counter in the default registry.
+// Each series of the counter is identified by a value for every label name.
+func NewCounter(name string, help string, labelNames ...string) *Counter {
+	c := &Counter{valueMetric{desc: desc{name: name, help: help, metricType: "counter", labelNames: labelNames}, series: make(map[string]*valueSeries)}}
+	DefaultRegistry.register(c)
+	return c
+}
+
+// Inc increments the series of the label values by one.
+func (c *Counter) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Add increments the series of the label values by delta, negative deltas are ignored.
+func (c *Counter) Add(delta float64, labelValues ...string) {
+	if delta < 0 {
+		return
+	}
+	c.update(labelValues, func(value float64) float64 { return value + delta })
+}
+
+// Gauge is a metric whose series can go up and down.
+type Gauge struct {
+	valueMetric
+}
+
+// NewGauge creates a gauge in the default registry.
+// Each series of the gauge is identified by a value for every label name.
+func NewGauge(name string, help string, labelNames ...string) *Gauge {
+	g := &Gauge{valueMetric{desc: desc{name: name, help: help, metricType: "gauge", labelNames: labelNames}, series: make(map[string]*valueSeries)}}
+	DefaultRegistry.register(g)
+	return g
+}
+
+// Set sets the series of the label values.
+func (g *Gauge) Set(value float64, labelValues ...string) {
+	g.update(labelValues, func(float64) float64 { return value })
+}
+
+// Add adds delta to the series of the label values.
+func (g *Gauge) Add(delta float64, labelValues ...string) {
+	g.update(labelValues, func(value float64) float64 { return value + delta })
+}
+
+// histogramSeries is a series of a histogram, counts are per bucket and not cumulative
+type histogramSeries struct {
+	labelValues []string
+	counts      []uint64
+	count       uint64
+	sum         float64
+}
+
+// Histogram is a metric that counts observations in buckets.
+type Histogram struct {
+	desc
+	buckets []float64
+	mu      sync.Mutex
+	series  map[string]*histogramSeries
+}
+
+// NewHistogram creates a histogram in the default registry with the given bucket upper bounds.
+// Each series of the histogram is identified by a value for every label name.
+func NewHistogram(name string, help string, buckets []float64, labelNames ...string) *Histogram {
+	sorted := append([]float64(nil), buckets...)
+	sort.Float64s(sorted)
+	h := &Histogram{
+		desc:    desc{name: name, help: help, metricType: "histogram", labelNames: labelNames},
+		buckets: sorted,
+		series:  make(map[string]*histogramSeries),
+	}
+	DefaultRegistry.register(h)
+	return h
+}
+
+// Observe adds an observation to the series of the label values.
+func (h *Histogram) Observe(value float64, labelValues ...string) {
+	key, ok := h.key(labelValues)
+	if !ok {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	s, found := h.series[key]
+	if !found {
+		s = &histogramSeries{labelValues: append([]string(nil), labelValues...), counts: make([]uint64, len(h.buckets))}
+		h.series[key] = s
+	}
+	for i, bound := range h.buckets {
+		if value <= bound {
+			s.counts[i]++
+			break
+		}
+	}
+	s.count++
+	s.sum += value
+}
+
+// Count returns the number of observations of the series of the label values.
+func (h *Histogram) Count(labelValues ...string) uint64 {
+	key, _ := h.key(labelValues)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if s, found := h.series[key]; found {
+		return s.count
+	}
+	return 0
+}
+
+func (h *Histogram) write(w io.Writer) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.writeHeader(w)
+	for _, key := range sortedKeys(h.series) {
+		s := h.series[key]
+		var cumulative uint64
+		for i, bound := range h.buckets {
+			cumulative += s.counts[i]
+			fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, h.labels(s.labelValues, "le", formatValue(bound)), cumulative)
+		}
+		fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, h.labels(s.labelValues, "le", "+Inf"), s.count)
+		fmt.Fprintf(w, "%s_sum%s %s\n", h.name, h.labels(s.labelValues), formatValue(s.sum))
+		fmt.Fprintf(w, "%s_count%s %d\n", h.name, h.labels(s.labelValues), s.count)
+	}
+}
+
+// sortedKeys returns the keys of the series sorted so the output is stable
+func sortedKeys(series interface{}) []string {
+	var keys []string
+	switch s := series.(type) {
+	case map[string]*valueSeries:
+		for key := range s {
+			keys = append(keys, key)
+		}
+	case map[string]*histogramSeries:
+		for key := range s {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,98 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metrics
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	testCounter   = NewCounter("test_requests_total", "Requests by status.", "method", "status")
+	testGauge     = NewGauge("test_queue_depth", "Queued jobs.")
+	testHistogram = NewHistogram("test_duration_seconds", "Durations.", []float64{1, 0.1}, "plugin")
+)
+
+func TestCounter(t *testing.T) {
+	testCounter.Inc("GET", "200")
+	testCounter.Add(2, "GET", "200")
+	testCounter.Add(-1, "GET", "200")
+	testCounter.Inc("POST", "a \"quoted\"\nvalue")
+	// series with the wrong number of label values are ignored
+	testCounter.Inc("GET")
+
+	assert.Equal(t, float64(3), testCounter.Value("GET", "200"))
+
+	var buffer bytes.Buffer
+	testCounter.write(&buffer)
+	assert.Equal(t, `# HELP test_requests_total Requests by status.
+# TYPE test_requests_total counter
+test_requests_total{method="GET",status="200"} 3
+test_requests_total{method="POST",status="a \"quoted\"\nvalue"} 1
+`, buffer.String())
+}
+
+func TestGauge(t *testing.T) {
+	testGauge.Set(5)
+	testGauge.Add(-2)
+
+	var buffer bytes.Buffer
+	testGauge.write(&buffer)
+	assert.Equal(t, `# HELP test_queue_depth Queued jobs.
+# TYPE test_queue_depth gauge
+test_queue_depth 3
+`, buffer.String())
+}
+
+func TestHistogram(t *testing.T) {
+	testHistogram.Observe(0.05, "aws:runShellScript")
+	testHistogram.Observe(0.5, "aws:runShellScript")
+	testHistogram.Observe(2, "aws:runShellScript")
+
+	assert.Equal(t, uint64(3), testHistogram.Count("aws:runShellScript"))
+
+	var buffer bytes.Buffer
+	testHistogram.write(&buffer)
+	assert.Equal(t, `# HELP test_duration_seconds Durations.
+# TYPE test_duration_seconds histogram
+test_duration_seconds_bucket{plugin="aws:runShellScript",le="0.1"} 1
+test_duration_seconds_bucket{plugin="aws:runShellScript",le="1"} 2
+test_duration_seconds_bucket{plugin="aws:runShellScript",le="+Inf"} 3
+test_duration_seconds_sum{plugin="aws:runShellScript"} 2.55
+test_duration_seconds_count{plugin="aws:runShellScript"} 3
+`, buffer.String())
+}
+
+func TestRegistryRejectsDuplicateNames(t *testing.T) {
+	assert.Panics(t, func() { NewCounter("test_requests_total", "duplicate") })
+}
+
+func TestHandler(t *testing.T) {
+	testGauge.Set(1)
+	server := httptest.NewServer(Handler(DefaultRegistry))
+	defer server.Close()
+
+	response, err := server.Client().Get(server.URL + metricsPath)
+	assert.NoError(t, err)
+	defer response.Body.Close()
+	body, _ := ioutil.ReadAll(response.Body)
+
+	assert.Equal(t, "text/plain; version=0.0.4; charset=utf-8", response.Header.Get("Content-Type"))
+	assert.Contains(t, string(body), "# TYPE test_queue_depth gauge\ntest_queue_depth 1\n")
+	assert.Contains(t, string(body), "# TYPE test_duration_seconds histogram\n")
+}
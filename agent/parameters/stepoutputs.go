@@ -0,0 +1,99 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package parameters
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// StepOutputNameRegex is the format of the names of step outputs
+const StepOutputNameRegex = "^[a-zA-Z0-9_-]+$"
+
+// stepOutputReferenceRegex matches {{ steps.<step name>.<output name> }}, the step name may contain dots
+var stepOutputReferenceRegex = regexp.MustCompile(`{{\s*steps\.([^\s{}]+)\.([a-zA-Z0-9_-]+)\s*}}`)
+
+// StepOutputReference is a reference to the output of a previous step
+type StepOutputReference struct {
+	Step   string
+	Output string
+}
+
+// String returns the reference as written in documents
+func (reference StepOutputReference) String() string {
+	return fmt.Sprintf("steps.%v.%v", reference.Step, reference.Output)
+}
+
+// StepOutputReferences returns the step output references found in the strings of an input object, sorted and without duplicates.
+func StepOutputReferences(input interface{}) []StepOutputReference {
+	found := make(map[StepOutputReference]struct{})
+	visitStrings(input, func(value string) string {
+		for _, match := range stepOutputReferenceRegex.FindAllStringSubmatch(value, -1) {
+			found[StepOutputReference{Step: match[1], Output: match[2]}] = struct{}{}
+		}
+		return value
+	})
+
+	references := make([]StepOutputReference, 0, len(found))
+	for reference := range found {
+		references = append(references, reference)
+	}
+	sort.Slice(references, func(i, j int) bool { return references[i].String() < references[j].String() })
+	return references
+}
+
+// ReplaceStepOutputs returns a copy of the input object where the step output references are replaced by their values.
+// The outputs of a step are indexed by step name and then by output name; an error lists the references without value.
+func ReplaceStepOutputs(input interface{}, outputs map[string]map[string]string) (interface{}, error) {
+	var missing []string
+	replaced := visitStrings(input, func(value string) string {
+		return stepOutputReferenceRegex.ReplaceAllStringFunc(value, func(match string) string {
+			groups := stepOutputReferenceRegex.FindStringSubmatch(match)
+			if outputValue, found := outputs[groups[1]][groups[2]]; found {
+				return outputValue
+			}
+			missing = append(missing, StepOutputReference{Step: groups[1], Output: groups[2]}.String())
+			return match
+		})
+	})
+	if len(missing) > 0 {
+		return input, fmt.Errorf("no value for step output(s) %v", strings.Join(missing, ", "))
+	}
+	return replaced, nil
+}
+
+// visitStrings returns a copy of the input object where each string is replaced by the result of the function.
+// Like ReplaceParameters, it only traverses the composite types produced by json.Unmarshal.
+func visitStrings(input interface{}, visit func(string) string) interface{} {
+	switch input := input.(type) {
+	case string:
+		return visit(input)
+	case []interface{}:
+		out := make([]interface{}, len(input))
+		for i, v := range input {
+			out[i] = visitStrings(v, visit)
+		}
+		return out
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(input))
+		for k, v := range input {
+			out[k] = visitStrings(v, visit)
+		}
+		return out
+	default:
+		return input
+	}
+}
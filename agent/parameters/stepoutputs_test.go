@@ -0,0 +1,58 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package parameters
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStepOutputReferences(t *testing.T) {
+	input := map[string]interface{}{
+		"runCommand": []interface{}{
+			"echo {{ steps.install.package.version }}",
+			"echo {{steps.install.package.version}} {{ steps.query.count }} {{ commands }}",
+		},
+		"workingDirectory": "{{ steps.query.dir-name }}",
+	}
+
+	references := StepOutputReferences(input)
+	assert.Equal(t, []StepOutputReference{
+		{Step: "install.package", Output: "version"},
+		{Step: "query", Output: "count"},
+		{Step: "query", Output: "dir-name"},
+	}, references)
+	assert.Empty(t, StepOutputReferences("{{ commands }}"))
+}
+
+func TestReplaceStepOutputs(t *testing.T) {
+	input := map[string]interface{}{
+		"runCommand": []interface{}{"echo {{ steps.query.count }}", "echo {{steps.query.count}}"},
+		"timeout":    3600,
+	}
+	outputs := map[string]map[string]string{"query": {"count": "3"}}
+
+	replaced, err := ReplaceStepOutputs(input, outputs)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"runCommand": []interface{}{"echo 3", "echo 3"},
+		"timeout":    3600,
+	}, replaced)
+	// the input is not modified
+	assert.Equal(t, "echo {{ steps.query.count }}", input["runCommand"].([]interface{})[0])
+
+	_, err = ReplaceStepOutputs("{{ steps.query.size }}", outputs)
+	assert.EqualError(t, err, "no value for step output(s) steps.query.size")
+}
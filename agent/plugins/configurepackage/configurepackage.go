@@ -243,6 +243,11 @@ func buildDownloadDelegate(tracer trace.Tracer, packageService packageservice.Pa
 			return fmt.Errorf("failed to extract package installer package %v from %v, %v", filePath, targetDirectory, uncompressErr.Error())
 		}
 
+		// keep the artifact of the package in the cache for as long as the package version is in the repository
+		if pinErr := cachedep.PinFile(targetDirectory, filePath); pinErr != nil {
+			trace.AppendDebugf("failed to pin %v in the artifact cache, %v", filePath, pinErr)
+		}
+
 		// NOTE: this could be considered a warning - it likely points to a real problem, but if uncompress succeeded, we could continue
 		// delete compressed package after using
 		if cleanupErr := filesysdep.RemoveAll(filePath); cleanupErr != nil {
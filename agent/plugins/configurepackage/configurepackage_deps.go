@@ -20,6 +20,7 @@ import (
 	"os"
 
 	"github.com/aws/amazon-ssm-agent/agent/fileutil"
+	"github.com/aws/amazon-ssm-agent/agent/fileutil/artifactcache"
 )
 
 // TODO:MF: This should be able to go away when localpackages has encapsulated all filesystem access
@@ -50,3 +51,20 @@ func (fileSysDepImp) Uncompress(src, dest string) error {
 func (fileSysDepImp) RemoveAll(path string) error {
 	return os.RemoveAll(path)
 }
+
+var cachedep artifactCacheDep = &artifactCacheDepImp{}
+
+// dependency on the cache of downloaded artifacts
+type artifactCacheDep interface {
+	PinFile(owner string, filePath string) error
+}
+
+type artifactCacheDepImp struct{}
+
+// PinFile keeps the cached artifact of the file as long as the owner path exists
+func (artifactCacheDepImp) PinFile(owner string, filePath string) error {
+	if cache := artifactcache.Default(); cache != nil {
+		return cache.PinFile(owner, filePath)
+	}
+	return nil
+}
@@ -435,6 +435,7 @@ type ConfigurePackageStubs struct {
 	// individual stub functions or interfaces go here with a temp variable for the original version
 	fileSysDepStub fileSysDep
 	fileSysDepOrig fileSysDep
+	cacheDepOrig   artifactCacheDep
 	stubsSet       bool
 }
 
@@ -445,6 +446,8 @@ func (m *ConfigurePackageStubs) Set() {
 		m.fileSysDepOrig = filesysdep
 		filesysdep = m.fileSysDepStub
 	}
+	m.cacheDepOrig = cachedep
+	cachedep = &cacheDepStub{}
 	m.stubsSet = true
 }
 
@@ -453,6 +456,7 @@ func (m *ConfigurePackageStubs) Clear() {
 	if m.fileSysDepStub != nil {
 		filesysdep = m.fileSysDepOrig
 	}
+	cachedep = m.cacheDepOrig
 	m.stubsSet = false
 }
 
@@ -484,3 +488,9 @@ func (m *FileSysDepStub) RemoveAll(path string) error {
 func (m *FileSysDepStub) WriteFile(filename string, content string) error {
 	return m.writeError
 }
+
+type cacheDepStub struct{}
+
+func (cacheDepStub) PinFile(owner string, filePath string) error {
+	return nil
+}
@@ -80,7 +80,7 @@ func (m *execDepImp) ExecuteDocument(context context.T, pluginInput []contracts.
 		log.Error("failed to load instance id")
 		return
 	}
-	docStore := executer.NewDocumentFileStore(context, documentID, instanceID, appconfig.DefaultLocationOfCurrent, &docState, docmanager.NewDocumentFileMgr(appconfig.DefaultDataStorePath, appconfig.DefaultDocumentRootDirName, appconfig.DefaultLocationOfState))
+	docStore := executer.NewDocumentFileStore(context, documentID, instanceID, appconfig.DefaultLocationOfCurrent, &docState, docmanager.NewDocumentMgr(context.AppConfig(), appconfig.DefaultDataStorePath))
 	cancelFlag := task.NewChanneledCancelFlag()
 	resChan := exe.Run(cancelFlag, &docStore)
 
@@ -84,9 +84,18 @@ func (ssmdoc *SSMDocResource) DownloadRemoteResource(log log.T, filesys filemana
 		return err, nil
 	}
 
+	// the document is saved with the extension of the format it was written in, and as it is when it cannot be parsed
+	extension := remoteresource.JSONExtension
+	var content map[string]interface{}
+	if format, parseErr := docparser.LoadDocumentContent([]byte(*docResponse.Content), &content); parseErr != nil {
+		log.Debugf("Saving ssm document %v as it is, it could not be parsed. %v", docName, parseErr)
+	} else if format == docparser.DocumentFormatYAML {
+		extension = remoteresource.YAMLExtension
+	}
+
 	var destinationFilePath string
 	if filesys.Exists(destinationPath) && filesys.IsDirectory(destinationPath) || os.IsPathSeparator(destinationPath[len(destinationPath)-1]) {
-		destinationFilePath = filepath.Join(destinationPath, filepath.Base(docName)+remoteresource.JSONExtension)
+		destinationFilePath = filepath.Join(destinationPath, filepath.Base(docName)+extension)
 
 	} else {
 		destinationFilePath = destinationPath
@@ -235,6 +235,75 @@ func TestSSMDocResource_DownloadToOtherName(t *testing.T) {
 	assert.Equal(t, "destination", result.Files[0])
 }
 
+func TestSSMDocResource_DownloadYAML(t *testing.T) {
+	depMock := new(ssmDocDepMock)
+	fileMock := &filemock.FileSystemMock{}
+
+	locationInfo := `{
+		"name": "AWS-ExecuteCommand:10"
+	}`
+	content := "schemaVersion: \"2.2\"\nmainSteps: []\n"
+	docOutput := ssm.GetDocumentOutput{
+		Content: &content,
+	}
+	ssmDocInfo, err := parseSourceInfo(locationInfo)
+	ssmresource := &SSMDocResource{
+		Info: ssmDocInfo,
+	}
+	dir := "destination"
+	depMock.On("GetDocument", logMock, "AWS-ExecuteCommand", "10").Return(&docOutput, nil)
+
+	fileMock.On("Exists", "destination").Return(true)
+	fileMock.On("IsDirectory", "destination").Return(true)
+	fileMock.On("MakeDirs", dir).Return(nil)
+	fileMock.On("WriteFile", filepath.Join(dir, "AWS-ExecuteCommand.yaml"), content).Return(nil)
+
+	ssmresource.ssmdocdep = depMock
+
+	err, result := ssmresource.DownloadRemoteResource(logMock, fileMock, "destination")
+
+	assert.NoError(t, err)
+	depMock.AssertExpectations(t)
+	fileMock.AssertExpectations(t)
+	assert.NotNil(t, result)
+	assert.Equal(t, filepath.Join(dir, "AWS-ExecuteCommand.yaml"), result.Files[0])
+}
+
+func TestSSMDocResource_DownloadUnparsedDocument(t *testing.T) {
+	depMock := new(ssmDocDepMock)
+	fileMock := &filemock.FileSystemMock{}
+
+	locationInfo := `{
+		"name": "AWS-ExecuteCommand:10"
+	}`
+	content := "{\n  \"schemaVersion\": \"2.2\",\n}"
+	docOutput := ssm.GetDocumentOutput{
+		Content: &content,
+	}
+	ssmDocInfo, err := parseSourceInfo(locationInfo)
+	ssmresource := &SSMDocResource{
+		Info: ssmDocInfo,
+	}
+	dir := "destination"
+	depMock.On("GetDocument", logMock, "AWS-ExecuteCommand", "10").Return(&docOutput, nil)
+
+	// the content is written as it is, with the extension of json documents
+	fileMock.On("Exists", "destination").Return(true)
+	fileMock.On("IsDirectory", "destination").Return(true)
+	fileMock.On("MakeDirs", dir).Return(nil)
+	fileMock.On("WriteFile", filepath.Join(dir, "AWS-ExecuteCommand.json"), content).Return(nil)
+
+	ssmresource.ssmdocdep = depMock
+
+	err, result := ssmresource.DownloadRemoteResource(logMock, fileMock, "destination")
+
+	assert.NoError(t, err)
+	depMock.AssertExpectations(t)
+	fileMock.AssertExpectations(t)
+	assert.NotNil(t, result)
+	assert.Equal(t, filepath.Join(dir, "AWS-ExecuteCommand.json"), result.Files[0])
+}
+
 type ssmDocDepMock struct {
 	mock.Mock
 }
@@ -0,0 +1,370 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package externalplugin runs worker plugins installed outside of the agent, as executables speaking a JSON protocol.
+package externalplugin
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/context"
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/fileutil"
+	"github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/iohandler"
+	"github.com/aws/amazon-ssm-agent/agent/framework/runpluginutil"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/task"
+)
+
+const (
+	// ManifestFileName is the name of the file describing a plugin, in the directory of the plugin
+	ManifestFileName = "plugin.json"
+
+	// reservedPrefix is the prefix of the plugins of the agent
+	reservedPrefix = "aws:"
+)
+
+// pluginNameRegex is the format of the plugin names, such as acme:deployApp
+var pluginNameRegex = regexp.MustCompile(`^[a-zA-Z0-9_-]+:[a-zA-Z0-9_.-]+$`)
+
+// cancelGracePeriod is how long a plugin has to exit after a cancel or shutdown message before it is killed
+var cancelGracePeriod = 10 * time.Second
+
+// Manifest describes an external plugin
+type Manifest struct {
+	// Name is the name of the plugin used as the action of the steps
+	Name string `json:"name"`
+	// Executable is the path of the executable of the plugin, relative to the directory of the plugin
+	Executable string `json:"executable"`
+	// ProtocolVersions lists the protocol versions supported by the plugin
+	ProtocolVersions []string `json:"protocolVersions"`
+}
+
+// Factory creates the instances of an external plugin
+type Factory struct {
+	Name       string
+	Directory  string
+	Executable string
+}
+
+// Create returns an instance of the plugin
+func (f Factory) Create(context context.T) (runpluginutil.T, error) {
+	return NewPlugin(f.Name, f.Directory, f.Executable), nil
+}
+
+// ExternalPluginPath returns the path of the executable of the plugin
+func (f Factory) ExternalPluginPath() string {
+	return f.Executable
+}
+
+// Discover returns the factories of the plugins installed in the subdirectories of root, each described by a manifest.
+// Invalid plugins are logged and ignored, and none are returned when users other than root or the agent can modify root.
+func Discover(log log.T, root string) runpluginutil.PluginRegistry {
+	plugins := runpluginutil.PluginRegistry{}
+	dirs, err := ioutil.ReadDir(root)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Warnf("Failed to list external plugins in %v, %v", root, err)
+		}
+		return plugins
+	}
+	if err = fileutil.CheckTrustedPermissions(root); err != nil {
+		log.Warnf("Ignoring external plugins in %v, %v", root, err)
+		return plugins
+	}
+
+	for _, dir := range dirs {
+		if !dir.IsDir() {
+			continue
+		}
+		factory, err := loadPlugin(filepath.Join(root, dir.Name()))
+		if err != nil {
+			log.Warnf("Ignoring external plugin %v, %v", dir.Name(), err)
+			continue
+		}
+		if _, duplicate := plugins[factory.Name]; duplicate {
+			log.Warnf("Ignoring external plugin %v, plugin %v is already installed", dir.Name(), factory.Name)
+			continue
+		}
+		plugins[factory.Name] = factory
+	}
+	return plugins
+}
+
+// loadPlugin reads and validates the manifest of the plugin installed in the directory
+func loadPlugin(dir string) (factory Factory, err error) {
+	manifestPath := filepath.Join(dir, ManifestFileName)
+	content, err := ioutil.ReadFile(manifestPath)
+	if err != nil {
+		return factory, err
+	}
+	var manifest Manifest
+	if err = json.Unmarshal(content, &manifest); err != nil {
+		return factory, fmt.Errorf("invalid manifest %v, %v", manifestPath, err)
+	}
+
+	if !pluginNameRegex.MatchString(manifest.Name) || strings.HasPrefix(manifest.Name, reservedPrefix) {
+		return factory, fmt.Errorf("invalid plugin name %v, expected a name such as vendor:action outside of the %v namespace", manifest.Name, reservedPrefix)
+	}
+	if !supportsProtocol(manifest.ProtocolVersions) {
+		return factory, fmt.Errorf("plugin %v supports protocol versions %v, the agent supports version %v", manifest.Name, manifest.ProtocolVersions, ProtocolVersion)
+	}
+	executable := filepath.Join(dir, filepath.Clean(manifest.Executable))
+	if manifest.Executable == "" || filepath.IsAbs(manifest.Executable) || !strings.HasPrefix(executable, filepath.Clean(dir)+string(filepath.Separator)) {
+		return factory, fmt.Errorf("invalid executable %v, expected a path in the directory of the plugin", manifest.Executable)
+	}
+	if !fileutil.Exists(executable) {
+		return factory, fmt.Errorf("executable %v not found", executable)
+	}
+	if err = checkPluginPermissions(dir, executable); err != nil {
+		return factory, err
+	}
+	return Factory{Name: manifest.Name, Directory: dir, Executable: executable}, nil
+}
+
+// checkPluginPermissions checks that only root or the agent can modify the plugin: the root of the plugins, the
+// directory of the plugin, its manifest, its executable and the directories between them
+func checkPluginPermissions(dir string, executable string) error {
+	paths := []string{filepath.Dir(dir), dir, filepath.Join(dir, ManifestFileName), executable}
+	for parent := filepath.Dir(executable); strings.HasPrefix(parent, dir+string(filepath.Separator)); parent = filepath.Dir(parent) {
+		paths = append(paths, parent)
+	}
+	for _, path := range paths {
+		if err := fileutil.CheckTrustedPermissions(path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func supportsProtocol(versions []string) bool {
+	for _, version := range versions {
+		if version == ProtocolVersion {
+			return true
+		}
+	}
+	return false
+}
+
+// Plugin runs the executable of an external plugin
+type Plugin struct {
+	name       string
+	dir        string
+	executable string
+}
+
+// NewPlugin returns a new instance of the plugin installed in dir.
+func NewPlugin(name string, dir string, executable string) *Plugin {
+	return &Plugin{name: name, dir: dir, executable: executable}
+}
+
+// Execute runs the executable of the plugin with the configuration of the step
+func (p *Plugin) Execute(context context.T, config contracts.Configuration, cancelFlag task.CancelFlag, output iohandler.IOHandler) {
+	log := context.Log()
+	log.Infof("Running external plugin %v", p.name)
+	if cancelFlag.ShutDown() {
+		output.MarkAsShutdown()
+		return
+	}
+	if cancelFlag.Canceled() {
+		output.MarkAsCancelled()
+		return
+	}
+	// the files may have been modified since the plugin was discovered
+	if err := checkPluginPermissions(p.dir, p.executable); err != nil {
+		output.MarkAsFailed(fmt.Errorf("refusing to run external plugin %v, %v", p.name, err))
+		return
+	}
+
+	workingDirectory := config.DefaultWorkingDirectory
+	if workingDirectory == "" {
+		workingDirectory = config.OrchestrationDirectory
+	}
+	if workingDirectory != "" {
+		if err := fileutil.MakeDirs(workingDirectory); err != nil {
+			output.MarkAsFailed(fmt.Errorf("failed to create directory %v, %v", workingDirectory, err))
+			return
+		}
+	}
+
+	command := exec.Command(p.executable)
+	command.Dir = workingDirectory
+	command.Env = append(os.Environ(), ProtocolVersionEnvironmentVariable+"="+ProtocolVersion)
+	if stderrWriter := output.GetStderrWriter(); stderrWriter != nil {
+		command.Stderr = stderrWriter
+	}
+	stdin, err := command.StdinPipe()
+	if err != nil {
+		output.MarkAsFailed(err)
+		return
+	}
+	stdout, err := command.StdoutPipe()
+	if err != nil {
+		output.MarkAsFailed(err)
+		return
+	}
+	if err = command.Start(); err != nil {
+		output.MarkAsFailed(fmt.Errorf("failed to start external plugin %v, %v", p.name, err))
+		return
+	}
+
+	messages := &messageWriter{writer: stdin}
+	if err = messages.send(ExecuteMessage{
+		Type:                   MessageTypeExecute,
+		ProtocolVersion:        ProtocolVersion,
+		PluginName:             p.name,
+		StepName:               config.PluginID,
+		MessageID:              config.MessageId,
+		Properties:             config.Properties,
+		Settings:               config.Settings,
+		OrchestrationDirectory: config.OrchestrationDirectory,
+		WorkingDirectory:       workingDirectory,
+	}); err != nil {
+		log.Warnf("Failed to send the configuration to external plugin %v, %v", p.name, err)
+	}
+
+	done := make(chan struct{})
+	go stopOnCancel(log, p.name, command, messages, cancelFlag, done)
+
+	result := readMessages(log, stdout, output)
+	waitErr := command.Wait()
+	close(done)
+	stdin.Close()
+
+	switch {
+	case result != nil:
+		applyResult(result, output)
+	case cancelFlag.ShutDown():
+		output.MarkAsShutdown()
+	case cancelFlag.Canceled():
+		output.MarkAsCancelled()
+	default:
+		output.MarkAsFailed(fmt.Errorf("external plugin %v exited without a result, %v", p.name, waitErr))
+	}
+}
+
+// messageWriter writes messages to the stdin of the plugin
+type messageWriter struct {
+	mutex  sync.Mutex
+	writer io.Writer
+}
+
+func (m *messageWriter) send(message interface{}) error {
+	content, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	_, err = m.writer.Write(append(content, '\n'))
+	return err
+}
+
+// stopOnCancel asks the plugin to stop when the document is cancelled or the agent stops,
+// and kills it if it does not exit within the grace period
+func stopOnCancel(log log.T, name string, command *exec.Cmd, messages *messageWriter, cancelFlag task.CancelFlag, done chan struct{}) {
+	cancelled := make(chan task.State, 1)
+	go func() { cancelled <- cancelFlag.Wait() }()
+
+	select {
+	case <-done:
+		return
+	case state := <-cancelled:
+		messageType := MessageTypeCancel
+		if state == task.ShutDown {
+			messageType = MessageTypeShutdown
+		} else if state != task.Canceled {
+			return
+		}
+		log.Infof("Requesting external plugin %v to stop", name)
+		if err := messages.send(ControlMessage{Type: messageType}); err != nil {
+			log.Debugf("Failed to send %v to external plugin %v, %v", messageType, name, err)
+		}
+	}
+
+	select {
+	case <-done:
+	case <-time.After(cancelGracePeriod):
+		log.Warnf("External plugin %v did not stop within %v, killing it", name, cancelGracePeriod)
+		if err := command.Process.Kill(); err != nil {
+			log.Warnf("Failed to kill external plugin %v, %v", name, err)
+		}
+	}
+}
+
+// readMessages streams the output messages of the plugin until it closes its stdout and returns its result, nil if none
+func readMessages(log log.T, stdout io.Reader, output iohandler.IOHandler) (result *contracts.PluginResult) {
+	reader := bufio.NewReader(stdout)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if len(strings.TrimSpace(string(line))) > 0 {
+			var message PluginMessage
+			if jsonErr := json.Unmarshal(line, &message); jsonErr != nil {
+				// not a message, keep it as output rather than losing it
+				writeOutput(output.GetStdoutWriter(), string(line))
+			} else {
+				switch message.Type {
+				case MessageTypeStdout:
+					writeOutput(output.GetStdoutWriter(), message.Data)
+				case MessageTypeStderr:
+					writeOutput(output.GetStderrWriter(), message.Data)
+				case MessageTypeResult:
+					result = message.Result
+				default:
+					log.Debugf("Ignoring external plugin message of type %v", message.Type)
+				}
+			}
+		}
+		if err != nil {
+			if err != io.EOF {
+				log.Warnf("Failed to read the output of the external plugin, %v", err)
+			}
+			return
+		}
+	}
+}
+
+func writeOutput(writer io.Writer, data string) {
+	if writer != nil {
+		writer.Write([]byte(data))
+	}
+}
+
+// applyResult sets the status, code, output and error of the result received from the plugin
+func applyResult(result *contracts.PluginResult, output iohandler.IOHandler) {
+	switch result.Status {
+	case contracts.ResultStatusSuccess, contracts.ResultStatusSuccessAndReboot, contracts.ResultStatusFailed,
+		contracts.ResultStatusCancelled, contracts.ResultStatusTimedOut:
+		output.SetStatus(result.Status)
+	default:
+		output.MarkAsFailed(fmt.Errorf("external plugin returned invalid status %v", result.Status))
+	}
+	output.SetExitCode(result.Code)
+	if result.Error != "" {
+		output.AppendError(result.Error)
+	}
+	if result.Output != nil {
+		output.SetOutput(result.Output)
+	}
+}
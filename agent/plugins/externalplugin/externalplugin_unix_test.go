@@ -0,0 +1,175 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+//
+// +build darwin freebsd linux netbsd openbsd
+
+package externalplugin
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/context"
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/iohandler"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/task"
+	"github.com/stretchr/testify/assert"
+)
+
+// installPlugin writes the manifest and the script of a plugin in a subdirectory of root
+func installPlugin(t *testing.T, root string, dir string, manifest string, script string) string {
+	pluginDir := filepath.Join(root, dir)
+	assert.NoError(t, os.MkdirAll(pluginDir, 0755))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(pluginDir, ManifestFileName), []byte(manifest), 0644))
+	executable := filepath.Join(pluginDir, "plugin.sh")
+	assert.NoError(t, ioutil.WriteFile(executable, []byte("#!/bin/sh\n"+script), 0755))
+	return executable
+}
+
+// runPlugin executes the script as a plugin and returns its output once closed
+func runPlugin(t *testing.T, script string, config contracts.Configuration, cancelFlag task.CancelFlag) *iohandler.DefaultIOHandler {
+	return runPluginWithMode(t, script, 0755, config, cancelFlag)
+}
+
+// runPluginWithMode executes the script as a plugin once its executable has the mode and returns its output once closed
+func runPluginWithMode(t *testing.T, script string, mode os.FileMode, config contracts.Configuration, cancelFlag task.CancelFlag) *iohandler.DefaultIOHandler {
+	root, _ := ioutil.TempDir("", "externalplugin")
+	defer os.RemoveAll(root)
+	executable := installPlugin(t, root, "test", `{}`, script)
+	assert.NoError(t, os.Chmod(executable, mode))
+	config.OrchestrationDirectory = filepath.Join(root, "orchestration")
+
+	output := iohandler.NewDefaultIOHandler(log.NewMockLog(), contracts.IOConfiguration{OrchestrationDirectory: config.OrchestrationDirectory})
+	output.Init(log.NewMockLog(), "acme:test")
+	NewPlugin("acme:test", filepath.Dir(executable), executable).Execute(context.NewMockDefault(), config, cancelFlag, output)
+	output.Close(log.NewMockLog())
+	return output
+}
+
+func TestDiscover(t *testing.T) {
+	root, _ := ioutil.TempDir("", "externalplugin")
+	defer os.RemoveAll(root)
+	executable := installPlugin(t, root, "deploy", `{"name": "acme:deployApp", "executable": "plugin.sh", "protocolVersions": ["1"]}`, "")
+	installPlugin(t, root, "reserved", `{"name": "aws:runShellScript", "executable": "plugin.sh", "protocolVersions": ["1"]}`, "")
+	installPlugin(t, root, "future", `{"name": "acme:future", "executable": "plugin.sh", "protocolVersions": ["2"]}`, "")
+	installPlugin(t, root, "outside", `{"name": "acme:outside", "executable": "../deploy/plugin.sh", "protocolVersions": ["1"]}`, "")
+	writable := installPlugin(t, root, "writable", `{"name": "acme:writable", "executable": "plugin.sh", "protocolVersions": ["1"]}`, "")
+	os.Chmod(writable, 0777)
+	writableDir := installPlugin(t, root, "writableDir", `{"name": "acme:writableDir", "executable": "plugin.sh", "protocolVersions": ["1"]}`, "")
+	os.Chmod(filepath.Dir(writableDir), 0777)
+	writableManifest := installPlugin(t, root, "writableManifest", `{"name": "acme:writableManifest", "executable": "plugin.sh", "protocolVersions": ["1"]}`, "")
+	os.Chmod(filepath.Join(filepath.Dir(writableManifest), ManifestFileName), 0666)
+
+	plugins := Discover(log.NewMockLog(), root)
+	assert.Equal(t, 1, len(plugins))
+	assert.Equal(t, Factory{Name: "acme:deployApp", Directory: filepath.Dir(executable), Executable: executable}, plugins["acme:deployApp"])
+
+	assert.Empty(t, Discover(log.NewMockLog(), filepath.Join(root, "missing")))
+}
+
+func TestDiscoverRefusesWritableRoot(t *testing.T) {
+	root, _ := ioutil.TempDir("", "externalplugin")
+	defer os.RemoveAll(root)
+	installPlugin(t, root, "deploy", `{"name": "acme:deployApp", "executable": "plugin.sh", "protocolVersions": ["1"]}`, "")
+	assert.NoError(t, os.Chmod(root, 0777))
+
+	assert.Empty(t, Discover(log.NewMockLog(), root))
+}
+
+func TestDiscoverRefusesPluginsOfOtherUsers(t *testing.T) {
+	if os.Geteuid() != 0 {
+		t.Skip("changing the owner of the plugin requires root")
+	}
+	root, _ := ioutil.TempDir("", "externalplugin")
+	defer os.RemoveAll(root)
+	executable := installPlugin(t, root, "deploy", `{"name": "acme:deployApp", "executable": "plugin.sh", "protocolVersions": ["1"]}`, "")
+	assert.NoError(t, os.Chown(executable, 1000, 1000))
+
+	assert.Empty(t, Discover(log.NewMockLog(), root))
+}
+
+func TestExecuteRefusesWritableExecutable(t *testing.T) {
+	output := runPluginWithMode(t, `echo '{"type": "result", "result": {"status": "Success"}}'`, 0777, contracts.Configuration{}, task.NewChanneledCancelFlag())
+
+	assert.Equal(t, contracts.ResultStatusFailed, output.GetStatus())
+	assert.Contains(t, output.GetStderr(), "writable by group or others")
+}
+
+func TestExecuteStreamsOutputAndReturnsResult(t *testing.T) {
+	script := `read message
+echo "$message" | grep -q '"stepName":"deploy"' || exit 3
+echo "$message" | grep -q '"version":"1.2"' || exit 4
+printf '%s\n' '{"type": "stdout", "data": "deploying\n"}'
+printf '%s\n' '{"type": "stderr", "data": "warning\n"}'
+echo "protocol $SSM_PLUGIN_PROTOCOL_VERSION"
+echo '{"type": "result", "result": {"status": "Failed", "code": 2, "error": "deployment failed"}}'
+`
+	config := contracts.Configuration{PluginID: "deploy", Properties: map[string]interface{}{"version": "1.2"}}
+
+	output := runPlugin(t, script, config, task.NewChanneledCancelFlag())
+
+	assert.Equal(t, contracts.ResultStatusFailed, output.GetStatus())
+	assert.Equal(t, 2, output.GetExitCode())
+	assert.Equal(t, "deploying\nprotocol 1\n", output.GetStdout())
+	assert.Contains(t, output.GetStderr(), "warning\n")
+	assert.Contains(t, output.GetStderr(), "deployment failed")
+}
+
+func TestExecuteFailsWithoutResult(t *testing.T) {
+	output := runPlugin(t, "exit 5", contracts.Configuration{}, task.NewChanneledCancelFlag())
+
+	assert.Equal(t, contracts.ResultStatusFailed, output.GetStatus())
+	assert.Contains(t, output.GetStderr(), "exited without a result")
+}
+
+func TestExecuteRequestsCancellation(t *testing.T) {
+	script := `read message
+read control
+echo "$control" | grep -q '"type":"cancel"' || exit 3
+echo '{"type": "result", "result": {"status": "Cancelled", "code": 1}}'
+`
+	cancelFlag := task.NewChanneledCancelFlag()
+	go func() {
+		time.Sleep(200 * time.Millisecond)
+		cancelFlag.Set(task.Canceled)
+	}()
+
+	output := runPlugin(t, script, contracts.Configuration{}, cancelFlag)
+
+	assert.Equal(t, contracts.ResultStatusCancelled, output.GetStatus())
+}
+
+func TestExecuteKillsPluginIgnoringCancellation(t *testing.T) {
+	original := cancelGracePeriod
+	cancelGracePeriod = 100 * time.Millisecond
+	defer func() { cancelGracePeriod = original }()
+	cancelFlag := task.NewChanneledCancelFlag()
+	cancelFlag.Set(task.ShutDown)
+	// the flag is checked before starting the plugin
+	output := runPlugin(t, "sleep 60", contracts.Configuration{}, cancelFlag)
+	assert.Equal(t, contracts.ResultStatusCancelled, output.GetStatus())
+
+	cancelFlag = task.NewChanneledCancelFlag()
+	go func() {
+		time.Sleep(200 * time.Millisecond)
+		cancelFlag.Set(task.ShutDown)
+	}()
+	start := time.Now()
+	output = runPlugin(t, "exec sleep 60", contracts.Configuration{}, cancelFlag)
+	assert.True(t, time.Since(start) < 10*time.Second)
+	assert.Equal(t, contracts.ResultStatusCancelled, output.GetStatus())
+}
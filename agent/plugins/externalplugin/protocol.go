@@ -0,0 +1,74 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package externalplugin
+
+import "github.com/aws/amazon-ssm-agent/agent/contracts"
+
+// ProtocolVersion is the version of the protocol spoken with external plugins.
+//
+// Version 1 exchanges JSON messages, one per line:
+//   - the agent starts the executable of the plugin with the environment variable SSM_PLUGIN_PROTOCOL_VERSION,
+//     then writes an execute message with the configuration of the step on its stdin
+//   - the plugin writes stdout and stderr messages on its stdout, streamed to the output of the step,
+//     and ends with a result message whose status, code, output and error become the result of the step
+//   - the agent writes a cancel or shutdown message on stdin when the document is cancelled or the agent stops,
+//     and kills the plugin if it has not exited after a grace period
+//
+// What the plugin writes on its stderr is appended to the stderr of the step.
+const ProtocolVersion = "1"
+
+// ProtocolVersionEnvironmentVariable is set in the environment of the plugin to the negotiated protocol version
+const ProtocolVersionEnvironmentVariable = "SSM_PLUGIN_PROTOCOL_VERSION"
+
+// Types of the messages
+const (
+	// MessageTypeExecute is the first message sent to the plugin
+	MessageTypeExecute = "execute"
+	// MessageTypeCancel requests the plugin to stop because the document was cancelled
+	MessageTypeCancel = "cancel"
+	// MessageTypeShutdown requests the plugin to stop because the agent is stopping
+	MessageTypeShutdown = "shutdown"
+	// MessageTypeStdout carries output of the plugin
+	MessageTypeStdout = "stdout"
+	// MessageTypeStderr carries errors of the plugin
+	MessageTypeStderr = "stderr"
+	// MessageTypeResult is the last message sent by the plugin
+	MessageTypeResult = "result"
+)
+
+// ExecuteMessage is sent to the plugin with the configuration of the step to run
+type ExecuteMessage struct {
+	Type                   string      `json:"type"`
+	ProtocolVersion        string      `json:"protocolVersion"`
+	PluginName             string      `json:"pluginName"`
+	StepName               string      `json:"stepName"`
+	MessageID              string      `json:"messageId"`
+	Properties             interface{} `json:"properties"`
+	Settings               interface{} `json:"settings"`
+	OrchestrationDirectory string      `json:"orchestrationDirectory"`
+	WorkingDirectory       string      `json:"workingDirectory"`
+}
+
+// ControlMessage is sent to the plugin to cancel the step
+type ControlMessage struct {
+	Type string `json:"type"`
+}
+
+// PluginMessage is sent by the plugin, Data is set for stdout and stderr messages and Result for the result message.
+// The status, code, output and error of the result are used, the other fields are set by the agent.
+type PluginMessage struct {
+	Type   string                  `json:"type"`
+	Data   string                  `json:"data,omitempty"`
+	Result *contracts.PluginResult `json:"result,omitempty"`
+}
@@ -0,0 +1,65 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package containerimage contains a gatherer of the container images present in the local Docker and containerd stores.
+package containerimage
+
+import (
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/context"
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/model"
+)
+
+const (
+	// GathererName captures name of container image gatherer, a custom inventory type as it has no schema in SSM
+	GathererName = "Custom:ContainerImage"
+	// SchemaVersionOfContainerImageGatherer represents schema version of container image gatherer
+	SchemaVersionOfContainerImageGatherer = "1.0"
+)
+
+// T represents container image gatherer which implements all contracts for gatherers.
+type T struct{}
+
+// Gatherer returns new container image gatherer
+func Gatherer(context context.T) *T {
+	return new(T)
+}
+
+var collectData = collectContainerImageData
+
+// Name returns name of container image gatherer
+func (t *T) Name() string {
+	return GathererName
+}
+
+// Run executes container image gatherer and returns list of inventory.Item comprising of container image data
+func (t *T) Run(context context.T, configuration model.Config) (items []model.Item, err error) {
+	//CaptureTime must comply with format: 2016-07-30T18:15:37Z to comply with regex at SSM.
+	captureTime := time.Now().UTC().Format(time.RFC3339)
+
+	items = append(items, model.Item{
+		Name:          t.Name(),
+		SchemaVersion: SchemaVersionOfContainerImageGatherer,
+		Content:       collectData(context),
+		CaptureTime:   captureTime,
+	})
+	return
+}
+
+// RequestStop stops the execution of container image gatherer.
+func (t *T) RequestStop(stopType contracts.StopType) error {
+	var err error
+	return err
+}
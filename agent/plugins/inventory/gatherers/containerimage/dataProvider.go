@@ -0,0 +1,176 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package containerimage
+
+import (
+	"bufio"
+	"encoding/json"
+	"os/exec"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/context"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/model"
+)
+
+const (
+	// PackageManagerDocker is the package manager of the images of the Docker store
+	PackageManagerDocker = "docker"
+	// PackageManagerContainerd is the package manager of the images of the containerd store
+	PackageManagerContainerd = "containerd"
+
+	dockerCmd = "docker"
+	ctrCmd    = "ctr"
+
+	// dockerNone is the repository and tag docker reports for untagged images
+	dockerNone = "<none>"
+	// dockerCreatedAtLayout is the format of the creation time of the images listed by docker
+	dockerCreatedAtLayout = "2006-01-02 15:04:05 -0700 MST"
+)
+
+// decoupling exec.Command for easy testability
+var cmdExecutor = executeCommand
+
+func executeCommand(command string, args ...string) ([]byte, error) {
+	return exec.Command(command, args...).Output()
+}
+
+// dockerImage is an image listed by docker images --format '{{json .}}'
+type dockerImage struct {
+	Repository string
+	Tag        string
+	ID         string
+	Digest     string
+	CreatedAt  string
+}
+
+// collectContainerImageData returns the images of the Docker and containerd stores.
+// A store whose command line tool is missing or fails, e.g. because its daemon is not running, is skipped.
+func collectContainerImageData(context context.T) (data []model.ApplicationData) {
+	log := context.Log()
+	data = append(data, collectDockerImages(log)...)
+	data = append(data, collectContainerdImages(log)...)
+	sort.SliceStable(data, func(i, j int) bool {
+		if data[i].PackageManager != data[j].PackageManager {
+			return data[i].PackageManager < data[j].PackageManager
+		}
+		if data[i].Name != data[j].Name {
+			return data[i].Name < data[j].Name
+		}
+		return data[i].Version < data[j].Version
+	})
+	log.Infof("Number of container images detected - %v", len(data))
+	return
+}
+
+// collectDockerImages returns the tagged images of the Docker store, images without repository are left out
+func collectDockerImages(log log.T) (data []model.ApplicationData) {
+	output, err := cmdExecutor(dockerCmd, "images", "--no-trunc", "--format", "{{json .}}")
+	if err != nil {
+		log.Debugf("Unable to list the images of the Docker store, %v", err)
+		return
+	}
+	scanner := bufio.NewScanner(strings.NewReader(string(output)))
+	for scanner.Scan() {
+		var image dockerImage
+		if err := json.Unmarshal([]byte(scanner.Text()), &image); err != nil {
+			log.Debugf("Ignoring invalid docker image %v, %v", scanner.Text(), err)
+			continue
+		}
+		if image.Repository == "" || image.Repository == dockerNone {
+			continue
+		}
+		imageData := model.ApplicationData{
+			Name:           image.Repository,
+			Version:        image.Tag,
+			PackageId:      image.ID,
+			PackageManager: PackageManagerDocker,
+		}
+		if imageData.Version == dockerNone {
+			imageData.Version = ""
+		}
+		if createdAt, err := time.Parse(dockerCreatedAtLayout, image.CreatedAt); err == nil {
+			imageData.InstalledTime = createdAt.UTC().Format(time.RFC3339)
+		}
+		data = append(data, imageData)
+	}
+	return
+}
+
+// collectContainerdImages returns the images of all the namespaces of the containerd store, the namespace is set as
+// the application type. The images listed by ctr are formatted as
+//   REF                            TYPE                                                 DIGEST          SIZE    PLATFORMS   LABELS
+//   docker.io/library/nginx:latest application/vnd.docker.distribution.manifest.list... sha256:0123...  51.2 MiB linux/amd64 -
+func collectContainerdImages(log log.T) (data []model.ApplicationData) {
+	output, err := cmdExecutor(ctrCmd, "namespaces", "list", "--quiet")
+	if err != nil {
+		log.Debugf("Unable to list the namespaces of the containerd store, %v", err)
+		return
+	}
+	for _, namespace := range strings.Fields(string(output)) {
+		if output, err = cmdExecutor(ctrCmd, "--namespace", namespace, "images", "list"); err != nil {
+			log.Debugf("Unable to list the images of the containerd namespace %v, %v", namespace, err)
+			continue
+		}
+		scanner := bufio.NewScanner(strings.NewReader(string(output)))
+		// skip the header
+		scanner.Scan()
+		for scanner.Scan() {
+			fields := strings.Fields(scanner.Text())
+			if len(fields) < 3 {
+				continue
+			}
+			name, version := splitImageReference(fields[0])
+			imageData := model.ApplicationData{
+				Name:            name,
+				Version:         version,
+				PackageId:       fields[2],
+				ApplicationType: namespace,
+				PackageManager:  PackageManagerContainerd,
+			}
+			// the size is a number and a unit, the platforms follow
+			if len(fields) > 5 {
+				imageData.Architecture = imageArchitecture(fields[5])
+			}
+			data = append(data, imageData)
+		}
+	}
+	return
+}
+
+// splitImageReference splits a reference such as docker.io/library/nginx:1.15 into its repository and tag,
+// the tag is empty for references by digest
+func splitImageReference(reference string) (repository string, tag string) {
+	if index := strings.Index(reference, "@"); index >= 0 {
+		return reference[:index], ""
+	}
+	if index := strings.LastIndex(reference, ":"); index > strings.LastIndex(reference, "/") {
+		return reference[:index], reference[index+1:]
+	}
+	return reference, ""
+}
+
+// imageArchitecture returns the architecture of single platform images such as linux/amd64, empty for multi-platform images
+func imageArchitecture(platforms string) string {
+	if strings.Contains(platforms, ",") {
+		return ""
+	}
+	parts := strings.Split(platforms, "/")
+	if len(parts) < 2 {
+		return ""
+	}
+	return model.FormatArchitecture(parts[1])
+}
@@ -0,0 +1,83 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package containerimage
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/context"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/model"
+	"github.com/stretchr/testify/assert"
+)
+
+var testDockerImagesOutput = `{"Containers":"N/A","CreatedAt":"2018-05-01 10:00:00 +0200 CEST","CreatedSince":"2 weeks ago","Digest":"<none>","ID":"sha256:ae513a47849c","Repository":"nginx","SharedSize":"N/A","Size":"109MB","Tag":"1.14","UniqueSize":"N/A","VirtualSize":"109MB"}
+{"Containers":"N/A","CreatedAt":"2018-04-01 10:00:00 +0000 UTC","CreatedSince":"6 weeks ago","Digest":"<none>","ID":"sha256:3fd9065eaf02","Repository":"<none>","SharedSize":"N/A","Size":"4.15MB","Tag":"<none>","UniqueSize":"N/A","VirtualSize":"4.15MB"}
+{"Containers":"N/A","CreatedAt":"2018-03-01 10:00:00 +0000 UTC","CreatedSince":"2 months ago","Digest":"<none>","ID":"sha256:5d0da3dc9764","Repository":"amazonlinux","SharedSize":"N/A","Size":"162MB","Tag":"<none>","UniqueSize":"N/A","VirtualSize":"162MB"}
+`
+
+var testCtrImagesOutput = `REF                                                TYPE                                                 DIGEST                  SIZE     PLATFORMS               LABELS
+docker.io/library/redis:4.0                        application/vnd.docker.distribution.manifest.v2+json sha256:1b1f7e9b2f6a     29.1 MiB linux/amd64             -
+docker.io/library/busybox@sha256:58ac43b2cc92c6871 application/vnd.docker.distribution.manifest.list.v2+json sha256:58ac43b2cc92 716.2 KiB linux/amd64,linux/arm64 -
+`
+
+func mockContainerCommands(docker, ctr error) func(string, ...string) ([]byte, error) {
+	return func(command string, args ...string) ([]byte, error) {
+		switch {
+		case command == dockerCmd:
+			return []byte(testDockerImagesOutput), docker
+		case args[0] == "namespaces":
+			return []byte("k8s.io\n"), ctr
+		default:
+			return []byte(testCtrImagesOutput), ctr
+		}
+	}
+}
+
+func TestCollectContainerImageData(t *testing.T) {
+	cmdExecutor = mockContainerCommands(nil, nil)
+
+	data := collectContainerImageData(context.NewMockDefault())
+
+	assert.Equal(t, []model.ApplicationData{
+		{Name: "docker.io/library/busybox", PackageId: "sha256:58ac43b2cc92", ApplicationType: "k8s.io", PackageManager: PackageManagerContainerd},
+		{Name: "docker.io/library/redis", Version: "4.0", PackageId: "sha256:1b1f7e9b2f6a", ApplicationType: "k8s.io", Architecture: "x86_64", PackageManager: PackageManagerContainerd},
+		{Name: "amazonlinux", PackageId: "sha256:5d0da3dc9764", InstalledTime: "2018-03-01T10:00:00Z", PackageManager: PackageManagerDocker},
+		{Name: "nginx", Version: "1.14", PackageId: "sha256:ae513a47849c", InstalledTime: "2018-05-01T08:00:00Z", PackageManager: PackageManagerDocker},
+	}, data)
+}
+
+func TestCollectContainerImageDataSkipsMissingStores(t *testing.T) {
+	cmdExecutor = mockContainerCommands(errors.New("executable file not found"), nil)
+	data := collectContainerImageData(context.NewMockDefault())
+	assert.Len(t, data, 2)
+
+	cmdExecutor = mockContainerCommands(errors.New("executable file not found"), errors.New("executable file not found"))
+	data = collectContainerImageData(context.NewMockDefault())
+	assert.Empty(t, data)
+}
+
+func TestSplitImageReference(t *testing.T) {
+	for reference, expected := range map[string][2]string{
+		"nginx":                                 {"nginx", ""},
+		"nginx:1.14":                            {"nginx", "1.14"},
+		"localhost:5000/app":                    {"localhost:5000/app", ""},
+		"localhost:5000/app:v2":                 {"localhost:5000/app", "v2"},
+		"docker.io/library/busybox@sha256:58ac": {"docker.io/library/busybox", ""},
+	} {
+		repository, tag := splitImageReference(reference)
+		assert.Equal(t, expected[0], repository, reference)
+		assert.Equal(t, expected[1], tag, reference)
+	}
+}
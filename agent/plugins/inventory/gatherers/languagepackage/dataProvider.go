@@ -0,0 +1,209 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package languagepackage
+
+import (
+	"bufio"
+	"encoding/json"
+	"io/ioutil"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/aws/amazon-ssm-agent/agent/context"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/model"
+)
+
+// Package managers of the language packages
+const (
+	PackageManagerPip = "pip"
+	PackageManagerNpm = "npm"
+	PackageManagerGem = "gem"
+	PackageManagerGo  = "go"
+)
+
+const (
+	npmCmd = "npm"
+	gemCmd = "gem"
+
+	// goStandardLibrary is the name of the package reporting the Go version a binary was built with
+	goStandardLibrary = "stdlib"
+)
+
+// pipCommands are the pip commands of the Python installations, packages reported by several commands are deduplicated
+var pipCommands = []string{"pip3", "pip"}
+
+// gemListRegex matches the gems listed by gem list --local, such as nokogiri (1.8.2 x86_64-linux, 1.8.1)
+var gemListRegex = regexp.MustCompile(`^(\S+) \((.+)\)$`)
+
+// decoupling exec.Command for easy testability
+var cmdExecutor = executeCommand
+
+func executeCommand(command string, args ...string) ([]byte, error) {
+	return exec.Command(command, args...).Output()
+}
+
+// decoupling readGoBuildInfo for easy testability
+var readBuildInfo = readGoBuildInfo
+
+// collectLanguagePackageData returns the packages installed by pip, npm globally and gem, and the modules of the
+// Go binaries in goBinaryDirectories. A package manager which is missing or fails is skipped.
+func collectLanguagePackageData(context context.T) (data []model.ApplicationData) {
+	log := context.Log()
+	data = append(data, collectPipPackages(log)...)
+	data = append(data, collectNpmPackages(log)...)
+	data = append(data, collectGemPackages(log)...)
+	data = append(data, collectGoBinaryPackages(log, goBinaryDirectories)...)
+	log.Infof("Number of language packages detected - %v", len(data))
+	return
+}
+
+// collectPipPackages returns the packages listed by pip list --format=json, such as [{"name": "boto3", "version": "1.7.4"}]
+func collectPipPackages(log log.T) (data []model.ApplicationData) {
+	found := make(map[string]struct{})
+	for _, pipCmd := range pipCommands {
+		output, err := cmdExecutor(pipCmd, "list", "--format=json")
+		if err != nil {
+			log.Debugf("Unable to list the packages installed by %v, %v", pipCmd, err)
+			continue
+		}
+		var packages []struct {
+			Name    string `json:"name"`
+			Version string `json:"version"`
+		}
+		if err = json.Unmarshal(output, &packages); err != nil {
+			log.Debugf("Unable to parse the packages listed by %v, %v", pipCmd, err)
+			continue
+		}
+		for _, pipPackage := range packages {
+			key := pipPackage.Name + "==" + pipPackage.Version
+			if _, duplicate := found[key]; duplicate {
+				continue
+			}
+			found[key] = struct{}{}
+			data = append(data, model.ApplicationData{Name: pipPackage.Name, Version: pipPackage.Version, PackageManager: PackageManagerPip})
+		}
+	}
+	sortPackages(data)
+	return
+}
+
+// collectNpmPackages returns the packages installed globally, listed by npm ls --global --depth=0 --json such as
+// {"dependencies": {"npm": {"version": "5.6.0"}}}
+func collectNpmPackages(log log.T) (data []model.ApplicationData) {
+	// npm exits with an error for problems such as extraneous packages but still lists the packages
+	output, err := cmdExecutor(npmCmd, "ls", "--global", "--depth=0", "--json")
+	var packages struct {
+		Dependencies map[string]struct {
+			Version string `json:"version"`
+		} `json:"dependencies"`
+	}
+	if jsonErr := json.Unmarshal(output, &packages); jsonErr != nil {
+		log.Debugf("Unable to list the packages installed globally by npm, %v %v", err, jsonErr)
+		return
+	}
+	for name, npmPackage := range packages.Dependencies {
+		data = append(data, model.ApplicationData{Name: name, Version: npmPackage.Version, PackageManager: PackageManagerNpm})
+	}
+	sortPackages(data)
+	return
+}
+
+// collectGemPackages returns the gems listed by gem list --local, one per installed version
+func collectGemPackages(log log.T) (data []model.ApplicationData) {
+	output, err := cmdExecutor(gemCmd, "list", "--local")
+	if err != nil {
+		log.Debugf("Unable to list the gems, %v", err)
+		return
+	}
+	scanner := bufio.NewScanner(strings.NewReader(string(output)))
+	for scanner.Scan() {
+		match := gemListRegex.FindStringSubmatch(strings.TrimSpace(scanner.Text()))
+		if match == nil {
+			continue
+		}
+		for _, version := range strings.Split(match[2], ",") {
+			// versions may be marked as default gems and followed by a platform
+			fields := strings.Fields(strings.TrimPrefix(strings.TrimSpace(version), "default:"))
+			if len(fields) == 0 {
+				continue
+			}
+			gem := model.ApplicationData{Name: match[1], Version: fields[0], PackageManager: PackageManagerGem}
+			if len(fields) > 1 {
+				gem.Architecture = fields[1]
+			}
+			data = append(data, gem)
+		}
+	}
+	return
+}
+
+// collectGoBinaryPackages returns the Go version and the modules of the Go binaries of the directories, read from
+// the build information embedded in the binaries. The path of the binary is set as the package id.
+func collectGoBinaryPackages(log log.T, directories []string) (data []model.ApplicationData) {
+	visited := make(map[string]struct{})
+	for _, directory := range directories {
+		// directories such as /bin may be links to other directories
+		if resolved, err := filepath.EvalSymlinks(directory); err == nil {
+			directory = resolved
+		}
+		if _, duplicate := visited[directory]; duplicate {
+			continue
+		}
+		visited[directory] = struct{}{}
+		files, err := ioutil.ReadDir(directory)
+		if err != nil {
+			log.Debugf("Unable to list the binaries of %v, %v", directory, err)
+			continue
+		}
+		for _, file := range files {
+			// links are skipped as their target is listed in its own directory
+			if !file.Mode().IsRegular() {
+				continue
+			}
+			path := filepath.Join(directory, file.Name())
+			info, err := readBuildInfo(path)
+			if err != nil {
+				// not a Go binary
+				continue
+			}
+			binary := func(name, version string) model.ApplicationData {
+				return model.ApplicationData{Name: name, Version: version, PackageId: path, PackageManager: PackageManagerGo}
+			}
+			data = append(data, binary(goStandardLibrary, strings.TrimPrefix(info.GoVersion, "go")))
+			if info.Main.Path != "" {
+				data = append(data, binary(info.Main.Path, info.Main.Version))
+			}
+			for _, module := range info.Deps {
+				if module.Replace != nil {
+					module = module.Replace
+				}
+				data = append(data, binary(module.Path, module.Version))
+			}
+		}
+	}
+	return
+}
+
+func sortPackages(data []model.ApplicationData) {
+	sort.SliceStable(data, func(i, j int) bool {
+		if data[i].Name != data[j].Name {
+			return data[i].Name < data[j].Name
+		}
+		return data[i].Version < data[j].Version
+	})
+}
@@ -0,0 +1,123 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package languagepackage
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/model"
+	"github.com/stretchr/testify/assert"
+)
+
+var testPip3Output = `[{"name": "boto3", "version": "1.7.4"}, {"name": "awscli", "version": "1.15.4"}]`
+
+var testPipOutput = `[{"name": "awscli", "version": "1.15.4"}, {"name": "awscli", "version": "1.14.0"}]`
+
+var testNpmOutput = `{
+  "dependencies": {
+    "npm": {"version": "5.6.0", "from": "npm@latest"},
+    "yarn": {"version": "1.6.0"}
+  }
+}`
+
+var testGemOutput = `
+*** LOCAL GEMS ***
+
+bigdecimal (default: 1.3.4)
+json (2.1.0, default: 2.0.4)
+nokogiri (1.8.2 x86_64-linux)
+`
+
+func mockPackageManagers(outputs map[string]string, errs map[string]error) func(string, ...string) ([]byte, error) {
+	return func(command string, args ...string) ([]byte, error) {
+		output, found := outputs[command]
+		if !found {
+			return nil, errors.New("executable file not found")
+		}
+		return []byte(output), errs[command]
+	}
+}
+
+func TestCollectPipPackages(t *testing.T) {
+	cmdExecutor = mockPackageManagers(map[string]string{"pip3": testPip3Output, "pip": testPipOutput}, nil)
+
+	data := collectPipPackages(log.NewMockLog())
+
+	assert.Equal(t, []model.ApplicationData{
+		{Name: "awscli", Version: "1.14.0", PackageManager: PackageManagerPip},
+		{Name: "awscli", Version: "1.15.4", PackageManager: PackageManagerPip},
+		{Name: "boto3", Version: "1.7.4", PackageManager: PackageManagerPip},
+	}, data)
+}
+
+func TestCollectNpmPackagesWithErrorExitCode(t *testing.T) {
+	cmdExecutor = mockPackageManagers(map[string]string{"npm": testNpmOutput}, map[string]error{"npm": errors.New("exit status 1")})
+
+	data := collectNpmPackages(log.NewMockLog())
+
+	assert.Equal(t, []model.ApplicationData{
+		{Name: "npm", Version: "5.6.0", PackageManager: PackageManagerNpm},
+		{Name: "yarn", Version: "1.6.0", PackageManager: PackageManagerNpm},
+	}, data)
+}
+
+func TestCollectGemPackages(t *testing.T) {
+	cmdExecutor = mockPackageManagers(map[string]string{"gem": testGemOutput}, nil)
+
+	data := collectGemPackages(log.NewMockLog())
+
+	assert.Equal(t, []model.ApplicationData{
+		{Name: "bigdecimal", Version: "1.3.4", PackageManager: PackageManagerGem},
+		{Name: "json", Version: "2.1.0", PackageManager: PackageManagerGem},
+		{Name: "json", Version: "2.0.4", PackageManager: PackageManagerGem},
+		{Name: "nokogiri", Version: "1.8.2", Architecture: "x86_64-linux", PackageManager: PackageManagerGem},
+	}, data)
+}
+
+func TestCollectWithoutPackageManagers(t *testing.T) {
+	cmdExecutor = mockPackageManagers(map[string]string{}, nil)
+	log := log.NewMockLog()
+
+	assert.Empty(t, collectPipPackages(log))
+	assert.Empty(t, collectNpmPackages(log))
+	assert.Empty(t, collectGemPackages(log))
+}
+
+func TestCollectGoBinaryPackages(t *testing.T) {
+	// the test binary is a Go binary
+	executable, err := os.Executable()
+	assert.NoError(t, err)
+	directory := filepath.Dir(executable)
+
+	data := collectGoBinaryPackages(log.NewMockLog(), []string{directory, directory, filepath.Join(directory, "missing")})
+
+	var stdlib []model.ApplicationData
+	for _, goPackage := range data {
+		if goPackage.Name == goStandardLibrary {
+			stdlib = append(stdlib, goPackage)
+		}
+	}
+	assert.Equal(t, []model.ApplicationData{{
+		Name:           goStandardLibrary,
+		Version:        strings.TrimPrefix(runtime.Version(), "go"),
+		PackageId:      executable,
+		PackageManager: PackageManagerGo,
+	}}, stdlib)
+}
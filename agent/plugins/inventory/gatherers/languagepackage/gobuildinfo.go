@@ -0,0 +1,332 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package languagepackage
+
+import (
+	"bytes"
+	"debug/elf"
+	"debug/macho"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// The build information of Go binaries is read without debug/buildinfo, which needs Go 1.18.
+// The linker writes a header starting with buildInfoMagic at the start of the data of the binary. Since Go 1.18 the
+// version and the module information follow the header as length prefixed strings, before they are pointed to by it.
+const (
+	buildInfoAlign      = 16
+	buildInfoHeaderSize = 32
+	// buildInfoSearchSize is the size of the data searched for the header
+	buildInfoSearchSize = 64 * 1024
+	// buildInfoMaxStringSize bounds the strings read from the binary
+	buildInfoMaxStringSize = 16 * 1024 * 1024
+
+	buildInfoFlagBigEndian = 0x1
+	buildInfoFlagInline    = 0x2
+)
+
+var (
+	buildInfoMagic = []byte("\xff Go buildinf:")
+
+	errNotGoBinary = errors.New("not a Go binary")
+)
+
+// goBuildInfo is the build information embedded in a Go binary
+type goBuildInfo struct {
+	GoVersion string
+	Main      goModule
+	Deps      []*goModule
+}
+
+// goModule is a module a Go binary was built with
+type goModule struct {
+	Path    string
+	Version string
+	Replace *goModule
+}
+
+// executable reads the content of a binary at the virtual addresses it is loaded at
+type executable interface {
+	// dataStart returns the address of the data holding the build information
+	dataStart() uint64
+	// readData returns up to size bytes at addr
+	readData(addr, size uint64) ([]byte, error)
+}
+
+// readGoBuildInfo returns the build information of the Go binary at path
+func readGoBuildInfo(path string) (*goBuildInfo, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	exe, err := openExecutable(file)
+	if err != nil {
+		return nil, err
+	}
+	version, modInfo, err := readBuildInfoStrings(exe)
+	if err != nil {
+		return nil, err
+	}
+	info := parseModInfo(modInfo)
+	info.GoVersion = version
+	return info, nil
+}
+
+// openExecutable reads the headers of ELF and Mach-O binaries
+func openExecutable(file *os.File) (executable, error) {
+	ident := make([]byte, 4)
+	if _, err := io.ReadFull(file, ident); err != nil {
+		return nil, errNotGoBinary
+	}
+	switch {
+	case bytes.Equal(ident, []byte(elf.ELFMAG)):
+		f, err := elf.NewFile(file)
+		if err != nil {
+			return nil, err
+		}
+		return &elfExecutable{f}, nil
+	case isMachO(ident):
+		f, err := macho.NewFile(file)
+		if err != nil {
+			return nil, err
+		}
+		return &machoExecutable{f}, nil
+	}
+	return nil, errNotGoBinary
+}
+
+// isMachO checks the magic number of Mach-O binaries, in either byte order
+func isMachO(ident []byte) bool {
+	for _, magic := range []uint32{macho.Magic32, macho.Magic64} {
+		if binary.BigEndian.Uint32(ident) == magic || binary.LittleEndian.Uint32(ident) == magic {
+			return true
+		}
+	}
+	return false
+}
+
+// readBuildInfoStrings returns the Go version and the module information of the binary
+func readBuildInfoStrings(exe executable) (version string, modInfo string, err error) {
+	start := exe.dataStart()
+	data, err := exe.readData(start, buildInfoSearchSize)
+	if err != nil {
+		return "", "", err
+	}
+	offset := 0
+	for {
+		i := bytes.Index(data[offset:], buildInfoMagic)
+		if i < 0 || len(data)-offset-i < buildInfoHeaderSize {
+			return "", "", errNotGoBinary
+		}
+		offset += i
+		if offset%buildInfoAlign == 0 {
+			break
+		}
+		offset = (offset + buildInfoAlign - 1) &^ (buildInfoAlign - 1)
+	}
+	header := data[offset : offset+buildInfoHeaderSize]
+	ptrSize := int(header[14])
+	flags := header[15]
+
+	if flags&buildInfoFlagInline != 0 {
+		addr := start + uint64(offset) + buildInfoHeaderSize
+		var next uint64
+		if version, next, err = readVarintString(exe, addr); err != nil {
+			return
+		}
+		if modInfo, _, err = readVarintString(exe, next); err != nil {
+			return
+		}
+	} else {
+		if ptrSize != 4 && ptrSize != 8 {
+			return "", "", errNotGoBinary
+		}
+		var order binary.ByteOrder = binary.LittleEndian
+		if flags&buildInfoFlagBigEndian != 0 {
+			order = binary.BigEndian
+		}
+		reader := pointerReader{exe: exe, ptrSize: ptrSize, order: order}
+		if version, err = reader.readString(reader.pointer(header[16:])); err != nil {
+			return
+		}
+		if modInfo, err = reader.readString(reader.pointer(header[16+ptrSize:])); err != nil {
+			return
+		}
+	}
+	if version == "" {
+		return "", "", errNotGoBinary
+	}
+	// the module information is wrapped in 16 bytes sentinels
+	if len(modInfo) >= 33 && modInfo[len(modInfo)-17] == '\n' {
+		modInfo = modInfo[16 : len(modInfo)-16]
+	} else {
+		modInfo = ""
+	}
+	return version, modInfo, nil
+}
+
+// readVarintString reads a string prefixed with its varint encoded length, and returns the address following it
+func readVarintString(exe executable, addr uint64) (value string, next uint64, err error) {
+	prefix, err := exe.readData(addr, binary.MaxVarintLen64)
+	if err != nil {
+		return "", 0, err
+	}
+	length, n := binary.Uvarint(prefix)
+	if n <= 0 || length > buildInfoMaxStringSize {
+		return "", 0, errNotGoBinary
+	}
+	content, err := exe.readData(addr+uint64(n), length)
+	if err != nil {
+		return "", 0, err
+	}
+	if uint64(len(content)) < length {
+		return "", 0, io.ErrUnexpectedEOF
+	}
+	return string(content), addr + uint64(n) + length, nil
+}
+
+// pointerReader reads the strings pointed to by the build information header of binaries built before Go 1.18
+type pointerReader struct {
+	exe     executable
+	ptrSize int
+	order   binary.ByteOrder
+}
+
+func (r pointerReader) pointer(data []byte) uint64 {
+	if r.ptrSize == 4 {
+		return uint64(r.order.Uint32(data))
+	}
+	return r.order.Uint64(data)
+}
+
+// readString reads the Go string header at addr and the content it points to
+func (r pointerReader) readString(addr uint64) (string, error) {
+	header, err := r.exe.readData(addr, uint64(2*r.ptrSize))
+	if err != nil {
+		return "", err
+	}
+	if len(header) < 2*r.ptrSize {
+		return "", io.ErrUnexpectedEOF
+	}
+	length := r.pointer(header[r.ptrSize:])
+	if length > buildInfoMaxStringSize {
+		return "", errNotGoBinary
+	}
+	content, err := r.exe.readData(r.pointer(header), length)
+	if err != nil {
+		return "", err
+	}
+	if uint64(len(content)) < length {
+		return "", io.ErrUnexpectedEOF
+	}
+	return string(content), nil
+}
+
+// parseModInfo parses the tab separated module lines of the build information. The main module is on the mod line,
+// each dependency on a dep line, and a => line replaces the module of the line before it.
+func parseModInfo(modInfo string) *goBuildInfo {
+	info := &goBuildInfo{}
+	var last *goModule
+	for _, line := range strings.Split(modInfo, "\n") {
+		fields := strings.Split(line, "\t")
+		if len(fields) < 3 {
+			continue
+		}
+		module := &goModule{Path: fields[1], Version: fields[2]}
+		switch fields[0] {
+		case "mod":
+			info.Main = *module
+			last = &info.Main
+		case "dep":
+			info.Deps = append(info.Deps, module)
+			last = module
+		case "=>":
+			if last != nil {
+				last.Replace = module
+				last = nil
+			}
+		}
+	}
+	return info
+}
+
+// elfExecutable reads the loaded segments of ELF binaries
+type elfExecutable struct {
+	f *elf.File
+}
+
+func (x *elfExecutable) dataStart() uint64 {
+	if section := x.f.Section(".go.buildinfo"); section != nil {
+		return section.Addr
+	}
+	for _, prog := range x.f.Progs {
+		if prog.Type == elf.PT_LOAD && prog.Flags&(elf.PF_X|elf.PF_W) == elf.PF_W {
+			return prog.Vaddr
+		}
+	}
+	return 0
+}
+
+func (x *elfExecutable) readData(addr, size uint64) ([]byte, error) {
+	for _, prog := range x.f.Progs {
+		if prog.Type == elf.PT_LOAD && prog.Vaddr <= addr && addr < prog.Vaddr+prog.Filesz {
+			return readAt(prog, addr-prog.Vaddr, prog.Vaddr+prog.Filesz-addr, size)
+		}
+	}
+	return nil, fmt.Errorf("address %#x is not loaded from the binary", addr)
+}
+
+// machoExecutable reads the segments of Mach-O binaries
+type machoExecutable struct {
+	f *macho.File
+}
+
+func (x *machoExecutable) dataStart() uint64 {
+	if section := x.f.Section("__go_buildinfo"); section != nil {
+		return section.Addr
+	}
+	if segment := x.f.Segment("__DATA"); segment != nil {
+		return segment.Addr
+	}
+	return 0
+}
+
+func (x *machoExecutable) readData(addr, size uint64) ([]byte, error) {
+	for _, load := range x.f.Loads {
+		segment, ok := load.(*macho.Segment)
+		if ok && segment.Name != "__PAGEZERO" && segment.Addr <= addr && addr < segment.Addr+segment.Filesz {
+			return readAt(segment, addr-segment.Addr, segment.Addr+segment.Filesz-addr, size)
+		}
+	}
+	return nil, fmt.Errorf("address %#x is not loaded from the binary", addr)
+}
+
+// readAt reads up to size bytes at offset of reader, of which available bytes are left
+func readAt(reader io.ReaderAt, offset, available, size uint64) ([]byte, error) {
+	if size > available {
+		size = available
+	}
+	data := make([]byte, size)
+	n, err := reader.ReadAt(data, int64(offset))
+	if err == io.EOF && n > 0 {
+		err = nil
+	}
+	return data[:n], err
+}
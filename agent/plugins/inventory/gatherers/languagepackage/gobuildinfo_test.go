@@ -0,0 +1,136 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package languagepackage
+
+import (
+	"encoding/binary"
+	"fmt"
+	"os"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testModInfo = "path\tgithub.com/user/tool\n" +
+	"mod\tgithub.com/user/tool\tv1.2.0\th1:abc=\n" +
+	"dep\tgolang.org/x/sys\tv0.1.0\th1:def=\n" +
+	"=>\t../sys\t(devel)\t\n" +
+	"dep\tgolang.org/x/text\tv0.3.0\th1:ghi=\n"
+
+// testExecutable is an executable whose data is loaded at address base
+type testExecutable struct {
+	base uint64
+	data []byte
+}
+
+func (x *testExecutable) dataStart() uint64 {
+	return x.base
+}
+
+func (x *testExecutable) readData(addr, size uint64) ([]byte, error) {
+	if addr < x.base || addr >= x.base+uint64(len(x.data)) {
+		return nil, fmt.Errorf("address %#x is not loaded", addr)
+	}
+	return readAt(testReaderAt(x.data), addr-x.base, x.base+uint64(len(x.data))-addr, size)
+}
+
+type testReaderAt []byte
+
+func (r testReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	return copy(p, r[off:]), nil
+}
+
+// wrapModInfo adds the sentinels the linker writes around the module information
+func wrapModInfo(modInfo string) string {
+	sentinel := "0123456789abcdef"
+	return sentinel + modInfo + sentinel
+}
+
+func buildInfoHeader(ptrSize int, flags byte) []byte {
+	header := make([]byte, buildInfoHeaderSize)
+	copy(header, buildInfoMagic)
+	header[14] = byte(ptrSize)
+	header[15] = flags
+	return header
+}
+
+func TestReadBuildInfoStringsInline(t *testing.T) {
+	data := append(make([]byte, buildInfoAlign), buildInfoHeader(8, buildInfoFlagInline)...)
+	for _, value := range []string{"go1.20.1", wrapModInfo(testModInfo)} {
+		data = append(data, make([]byte, binary.MaxVarintLen64)...)
+		n := binary.PutUvarint(data[len(data)-binary.MaxVarintLen64:], uint64(len(value)))
+		data = append(data[:len(data)-binary.MaxVarintLen64+n], value...)
+	}
+
+	version, modInfo, err := readBuildInfoStrings(&testExecutable{base: 0x1000, data: data})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "go1.20.1", version)
+	assert.Equal(t, testModInfo, modInfo)
+}
+
+func TestReadBuildInfoStringsPointers(t *testing.T) {
+	const base = 0x2000
+	version := "go1.16.5"
+	modInfo := wrapModInfo(testModInfo)
+
+	// the header points to two string headers, which point to the content following them
+	data := buildInfoHeader(4, buildInfoFlagBigEndian)
+	binary.BigEndian.PutUint32(data[16:], base+buildInfoHeaderSize)
+	binary.BigEndian.PutUint32(data[20:], base+buildInfoHeaderSize+8)
+	stringHeaders := make([]byte, 16)
+	binary.BigEndian.PutUint32(stringHeaders[0:], base+buildInfoHeaderSize+16)
+	binary.BigEndian.PutUint32(stringHeaders[4:], uint32(len(version)))
+	binary.BigEndian.PutUint32(stringHeaders[8:], base+buildInfoHeaderSize+16+uint32(len(version)))
+	binary.BigEndian.PutUint32(stringHeaders[12:], uint32(len(modInfo)))
+	data = append(append(append(data, stringHeaders...), version...), modInfo...)
+
+	readVersion, readModInfo, err := readBuildInfoStrings(&testExecutable{base: base, data: data})
+
+	assert.NoError(t, err)
+	assert.Equal(t, version, readVersion)
+	assert.Equal(t, testModInfo, readModInfo)
+}
+
+func TestReadBuildInfoStringsNotGoBinary(t *testing.T) {
+	_, _, err := readBuildInfoStrings(&testExecutable{base: 0x1000, data: make([]byte, 256)})
+
+	assert.Equal(t, errNotGoBinary, err)
+}
+
+func TestParseModInfo(t *testing.T) {
+	info := parseModInfo(testModInfo)
+
+	assert.Equal(t, goModule{Path: "github.com/user/tool", Version: "v1.2.0"}, info.Main)
+	assert.Equal(t, []*goModule{
+		{Path: "golang.org/x/sys", Version: "v0.1.0", Replace: &goModule{Path: "../sys", Version: "(devel)"}},
+		{Path: "golang.org/x/text", Version: "v0.3.0"},
+	}, info.Deps)
+}
+
+func TestReadGoBuildInfo(t *testing.T) {
+	executable, err := os.Executable()
+	assert.NoError(t, err)
+
+	info, err := readGoBuildInfo(executable)
+
+	if runtime.GOOS == "windows" {
+		// Go binaries are not read on Windows
+		assert.Equal(t, errNotGoBinary, err)
+		return
+	}
+	assert.NoError(t, err)
+	assert.Equal(t, runtime.Version(), info.GoVersion)
+}
@@ -0,0 +1,218 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// +build linux
+
+package listeningports
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"unsafe"
+
+	"github.com/aws/amazon-ssm-agent/agent/context"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/model"
+)
+
+const (
+	// tcpStateListen is the state of the listening TCP sockets in /proc/net/tcp
+	tcpStateListen = "0A"
+	// udpStateUnconnected is the state of the bound UDP sockets not connected to a peer in /proc/net/udp
+	udpStateUnconnected = "07"
+	// socketLinkPrefix is the prefix of the targets of the file descriptors of sockets, such as socket:[12345]
+	socketLinkPrefix = "socket:["
+)
+
+// procRoot is the mount point of procfs
+var procRoot = "/proc"
+
+// socketTable is a table of sockets in /proc/net and the state of its listening sockets
+type socketTable struct {
+	protocol string
+	state    string
+}
+
+var socketTables = []socketTable{
+	{protocol: "tcp", state: tcpStateListen},
+	{protocol: "tcp6", state: tcpStateListen},
+	{protocol: "udp", state: udpStateUnconnected},
+	{protocol: "udp6", state: udpStateUnconnected},
+}
+
+// listeningSocket is a listening socket read from /proc/net
+type listeningSocket struct {
+	protocol string
+	address  string
+	port     int
+	inode    string
+}
+
+// collectListeningPortData returns the listening TCP and UDP sockets with the process owning them,
+// the process is left empty when it cannot be found, e.g. when the agent does not run as root.
+func collectListeningPortData(context context.T, config model.Config) (data []model.ListeningPortData, err error) {
+	log := context.Log()
+	log.Infof("collectListeningPortData called")
+
+	var sockets []listeningSocket
+	for _, table := range socketTables {
+		path := filepath.Join(procRoot, "net", table.protocol)
+		tableSockets, err := readSocketTable(path, table)
+		if os.IsNotExist(err) {
+			// the table of IPv6 sockets is missing when IPv6 is disabled
+			log.Debugf("Socket table %v not found", path)
+			continue
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to read socket table %v, %v", path, err)
+		}
+		sockets = append(sockets, tableSockets...)
+	}
+
+	processes := socketProcesses(log)
+	for _, socket := range sockets {
+		portData := model.ListeningPortData{
+			Protocol:     socket.protocol,
+			LocalAddress: socket.address,
+			LocalPort:    strconv.Itoa(socket.port),
+		}
+		if pid, found := processes[socket.inode]; found {
+			portData.ProcessId = pid
+			portData.ProcessName = processName(pid)
+		}
+		data = append(data, portData)
+	}
+	sort.SliceStable(data, func(i, j int) bool {
+		if data[i].Protocol != data[j].Protocol {
+			return data[i].Protocol < data[j].Protocol
+		}
+		if data[i].LocalPort != data[j].LocalPort {
+			portI, _ := strconv.Atoi(data[i].LocalPort)
+			portJ, _ := strconv.Atoi(data[j].LocalPort)
+			return portI < portJ
+		}
+		return data[i].LocalAddress < data[j].LocalAddress
+	})
+	return
+}
+
+// readSocketTable returns the listening sockets of a table such as /proc/net/tcp, whose lines have the format
+//   sl  local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode
+//    0: 00000000:0016 00000000:0000 0A 00000000:00000000 00:00000000 00000000     0        0 12345 ...
+func readSocketTable(path string, table socketTable) (sockets []listeningSocket, err error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	// skip the header
+	scanner.Scan()
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 10 || fields[3] != table.state {
+			continue
+		}
+		// unconnected UDP sockets may still have a remote port
+		if _, remotePort, _ := parseAddress(fields[2]); remotePort != 0 {
+			continue
+		}
+		address, port, parseErr := parseAddress(fields[1])
+		if parseErr != nil {
+			continue
+		}
+		sockets = append(sockets, listeningSocket{protocol: table.protocol, address: address, port: port, inode: fields[9]})
+	}
+	return sockets, scanner.Err()
+}
+
+// parseAddress parses an address such as 0100007F:0016, the IP address is made of 32 bits words in host byte order
+func parseAddress(value string) (address string, port int, err error) {
+	parts := strings.Split(value, ":")
+	if len(parts) != 2 {
+		return "", 0, fmt.Errorf("invalid address %v", value)
+	}
+	words, err := hex.DecodeString(parts[0])
+	if err != nil || (len(words) != net.IPv4len && len(words) != net.IPv6len) {
+		return "", 0, fmt.Errorf("invalid address %v", value)
+	}
+	ip := make(net.IP, len(words))
+	for i := 0; i < len(words); i += 4 {
+		nativeByteOrder.PutUint32(ip[i:], binary.BigEndian.Uint32(words[i:]))
+	}
+	portValue, err := strconv.ParseUint(parts[1], 16, 16)
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid port in address %v", value)
+	}
+	return ip.String(), int(portValue), nil
+}
+
+// nativeByteOrder is the byte order of the host
+var nativeByteOrder = func() binary.ByteOrder {
+	one := uint16(1)
+	if *(*byte)(unsafe.Pointer(&one)) == 1 {
+		return binary.LittleEndian
+	}
+	return binary.BigEndian
+}()
+
+// socketProcesses returns the ids of the processes owning the sockets indexed by inode,
+// the file descriptors of the processes which cannot be read are ignored
+func socketProcesses(log log.T) map[string]string {
+	processes := make(map[string]string)
+	entries, err := ioutil.ReadDir(procRoot)
+	if err != nil {
+		log.Warnf("Failed to list processes, %v", err)
+		return processes
+	}
+	for _, entry := range entries {
+		pid := entry.Name()
+		if _, err := strconv.Atoi(pid); err != nil || !entry.IsDir() {
+			continue
+		}
+		fdDir := filepath.Join(procRoot, pid, "fd")
+		fds, err := ioutil.ReadDir(fdDir)
+		if err != nil {
+			continue
+		}
+		for _, fd := range fds {
+			target, err := os.Readlink(filepath.Join(fdDir, fd.Name()))
+			if err != nil || !strings.HasPrefix(target, socketLinkPrefix) {
+				continue
+			}
+			inode := strings.TrimSuffix(strings.TrimPrefix(target, socketLinkPrefix), "]")
+			if _, found := processes[inode]; !found {
+				processes[inode] = pid
+			}
+		}
+	}
+	return processes
+}
+
+// processName returns the name of the executable of the process
+func processName(pid string) string {
+	content, err := ioutil.ReadFile(filepath.Join(procRoot, pid, "comm"))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(content))
+}
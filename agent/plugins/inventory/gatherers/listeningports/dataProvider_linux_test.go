@@ -0,0 +1,109 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// +build linux
+
+package listeningports
+
+import (
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/context"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/model"
+	"github.com/stretchr/testify/assert"
+)
+
+const socketTableHeader = "  sl  local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode\n"
+
+var testTCPTable = socketTableHeader +
+	"   0: 00000000:0016 00000000:0000 0A 00000000:00000000 00:00000000 00000000     0        0 1001 1 0 100 0 0 10 0\n" +
+	"   1: 00000000:1F90 00000000:0000 0A 00000000:00000000 00:00000000 00000000  1000        0 1002 1 0 100 0 0 10 0\n" +
+	"   2: 00000000:0016 00000000:C350 01 00000000:00000000 00:00000000 00000000     0        0 1003 1 0 100 0 0 10 0\n"
+
+var testUDPTable = socketTableHeader +
+	"  10: 00000000:0044 00000000:0000 07 00000000:00000000 00:00000000 00000000     0        0 2001 2 0 0\n" +
+	"  11: 00000000:D431 00000000:0035 07 00000000:00000000 00:00000000 00000000     0        0 2002 2 0 0\n"
+
+var testTCP6Table = socketTableHeader +
+	"   0: 00000000000000000000000000000000:0016 00000000000000000000000000000000:0000 0A 00000000:00000000 00:00000000 00000000     0        0 1004 1 0 100 0 0 10 0\n"
+
+// setProcRootMock creates a procfs with the socket tables and processes owning sockets, indexed by pid
+func setProcRootMock(t *testing.T, processes map[string]string) func() {
+	dir, err := ioutil.TempDir("", "proc")
+	assert.NoError(t, err)
+	assert.NoError(t, os.MkdirAll(filepath.Join(dir, "net"), 0755))
+	for name, content := range map[string]string{"tcp": testTCPTable, "tcp6": testTCP6Table, "udp": testUDPTable} {
+		assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "net", name), []byte(content), 0644))
+	}
+	for pid, inode := range processes {
+		fdDir := filepath.Join(dir, pid, "fd")
+		assert.NoError(t, os.MkdirAll(fdDir, 0755))
+		assert.NoError(t, os.Symlink("/dev/null", filepath.Join(fdDir, "0")))
+		assert.NoError(t, os.Symlink("socket:["+inode+"]", filepath.Join(fdDir, "3")))
+		assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, pid, "comm"), []byte("process"+pid+"\n"), 0644))
+	}
+
+	original := procRoot
+	procRoot = dir
+	return func() {
+		procRoot = original
+		os.RemoveAll(dir)
+	}
+}
+
+func TestCollectListeningPortData(t *testing.T) {
+	defer setProcRootMock(t, map[string]string{"42": "1001", "43": "2001"})()
+
+	data, err := collectListeningPortData(context.NewMockDefault(), model.Config{})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []model.ListeningPortData{
+		{Protocol: "tcp", LocalAddress: "0.0.0.0", LocalPort: "22", ProcessId: "42", ProcessName: "process42"},
+		{Protocol: "tcp", LocalAddress: "0.0.0.0", LocalPort: "8080"},
+		{Protocol: "tcp6", LocalAddress: "::", LocalPort: "22"},
+		{Protocol: "udp", LocalAddress: "0.0.0.0", LocalPort: "68", ProcessId: "43", ProcessName: "process43"},
+	}, data)
+}
+
+func TestCollectListeningPortDataWithoutSocketTables(t *testing.T) {
+	dir, err := ioutil.TempDir("", "proc")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	original := procRoot
+	procRoot = dir
+	defer func() { procRoot = original }()
+
+	data, err := collectListeningPortData(context.NewMockDefault(), model.Config{})
+
+	assert.NoError(t, err)
+	assert.Empty(t, data)
+}
+
+func TestParseAddress(t *testing.T) {
+	loopback := make([]byte, 4)
+	nativeByteOrder.PutUint32(loopback, 0x7F000001)
+
+	address, port, err := parseAddress(hex.EncodeToString(loopback) + ":0050")
+	assert.NoError(t, err)
+	assert.Equal(t, "127.0.0.1", address)
+	assert.Equal(t, 80, port)
+
+	_, _, err = parseAddress("0100007F")
+	assert.Error(t, err)
+	_, _, err = parseAddress("0100:0050")
+	assert.Error(t, err)
+}
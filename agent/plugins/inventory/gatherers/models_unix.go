@@ -11,7 +11,7 @@
 // either express or implied. See the License for the specific language governing
 // permissions and limitations under the License.
 
-// +build darwin freebsd linux netbsd openbsd
+// +build darwin freebsd netbsd openbsd
 
 // Package gatherers contains routines for different types of inventory gatherers
 package gatherers
@@ -20,9 +20,11 @@ import (
 	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/gatherers/application"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/gatherers/awscomponent"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/gatherers/billinginfo"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/gatherers/containerimage"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/gatherers/custom"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/gatherers/file"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/gatherers/instancedetailedinformation"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/gatherers/languagepackage"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/gatherers/network"
 )
 
@@ -34,4 +36,6 @@ var supportedGathererNames = []string{
 	network.GathererName,
 	file.GathererName,
 	instancedetailedinformation.GathererName,
+	containerimage.GathererName,
+	languagepackage.GathererName,
 }
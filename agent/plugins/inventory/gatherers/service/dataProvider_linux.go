@@ -0,0 +1,160 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// +build linux
+
+package service
+
+import (
+	"bufio"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/aws/amazon-ssm-agent/agent/context"
+	"github.com/aws/amazon-ssm-agent/agent/fileutil"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/model"
+)
+
+const (
+	systemctlCmd = "systemctl"
+	// systemdServiceSuffix is the suffix of the names of service units
+	systemdServiceSuffix = ".service"
+	// systemdLoadStateNotFound is the load state of the units referenced but not installed
+	systemdLoadStateNotFound = "not-found"
+	// systemdShowBatchSize is the number of units shown per systemctl call
+	systemdShowBatchSize = 100
+)
+
+// systemdRuntimeDir exists when the system was booted with systemd
+var systemdRuntimeDir = "/run/systemd/system"
+
+// systemdProperties are the properties of the units read with systemctl show
+var systemdProperties = []string{"Id", "Description", "LoadState", "ActiveState", "SubState", "UnitFileState", "FragmentPath", "Type", "Requires", "RequiredBy"}
+
+// collectPlatformServiceData collects the systemd services, no data is collected on systems not running systemd
+func collectPlatformServiceData(context context.T, config model.Config) ([]model.ServiceData, error) {
+	return collectSystemdServiceData(context, config)
+}
+
+func collectSystemdServiceData(context context.T, config model.Config) (data []model.ServiceData, err error) {
+	log := context.Log()
+	log.Infof("collectSystemdServiceData called")
+	if !fileutil.Exists(systemdRuntimeDir) {
+		log.Infof("The system is not running systemd, no service data to collect")
+		return
+	}
+
+	names, err := listSystemdServices(log)
+	if err != nil {
+		return nil, err
+	}
+	for start := 0; start < len(names); start += systemdShowBatchSize {
+		end := start + systemdShowBatchSize
+		if end > len(names) {
+			end = len(names)
+		}
+		args := []string{"show", "--no-pager", "-p", strings.Join(systemdProperties, ","), "--"}
+		output, err := executeSystemctl(log, append(args, names[start:end]...)...)
+		if err != nil {
+			return nil, err
+		}
+		data = append(data, parseSystemctlShowOutput(output)...)
+	}
+	sort.Slice(data, func(i, j int) bool { return data[i].Name < data[j].Name })
+	return
+}
+
+// listSystemdServices returns the names of the loaded and installed service units, without template units
+func listSystemdServices(log log.T) ([]string, error) {
+	loaded, err := executeSystemctl(log, "list-units", "--type=service", "--all", "--no-legend", "--no-pager", "--plain")
+	if err != nil {
+		return nil, err
+	}
+	installed, err := executeSystemctl(log, "list-unit-files", "--type=service", "--no-legend", "--no-pager")
+	if err != nil {
+		return nil, err
+	}
+
+	found := make(map[string]struct{})
+	var names []string
+	for _, output := range []string{loaded, installed} {
+		for _, name := range firstColumn(output) {
+			if _, duplicate := found[name]; duplicate || !strings.HasSuffix(name, systemdServiceSuffix) || strings.HasSuffix(name, "@"+systemdServiceSuffix) {
+				continue
+			}
+			found[name] = struct{}{}
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func executeSystemctl(log log.T, args ...string) (string, error) {
+	log.Debugf("Executing command: %v %v", systemctlCmd, args[0])
+	output, err := cmdExecutor(systemctlCmd, args...)
+	if err != nil {
+		log.Debugf("Command Stderr: %v", string(output))
+		return "", fmt.Errorf("command %v %v failed with error: %v", systemctlCmd, args[0], err)
+	}
+	return string(output), nil
+}
+
+// firstColumn returns the first field of the lines of the output
+func firstColumn(output string) (values []string) {
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		if fields := strings.Fields(scanner.Text()); len(fields) > 0 {
+			values = append(values, fields[0])
+		}
+	}
+	return
+}
+
+// parseSystemctlShowOutput parses the properties of units written by systemctl show, one Key=Value per line
+// and units separated by empty lines. Units which are not installed are left out.
+func parseSystemctlShowOutput(output string) (data []model.ServiceData) {
+	properties := make(map[string]string)
+	flush := func() {
+		if properties["Id"] != "" && properties["LoadState"] != systemdLoadStateNotFound {
+			data = append(data, model.ServiceData{
+				Name:               properties["Id"],
+				DisplayName:        properties["Description"],
+				Status:             properties["ActiveState"],
+				DependentServices:  properties["RequiredBy"],
+				ServicesDependedOn: properties["Requires"],
+				ServiceType:        properties["Type"],
+				StartType:          properties["UnitFileState"],
+				SubState:           properties["SubState"],
+				UnitFilePath:       properties["FragmentPath"],
+			})
+		}
+		properties = make(map[string]string)
+	}
+
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			flush()
+			continue
+		}
+		if index := strings.Index(line, "="); index > 0 {
+			properties[line[:index]] = line[index+1:]
+		}
+	}
+	flush()
+	return
+}
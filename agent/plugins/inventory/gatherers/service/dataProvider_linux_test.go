@@ -0,0 +1,142 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// +build linux
+
+package service
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/context"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/model"
+	"github.com/stretchr/testify/assert"
+)
+
+var testListUnitsOutput = `amazon-ssm-agent.service loaded active running amazon-ssm-agent
+missing.service          not-found inactive dead missing.service
+sshd.service             loaded active running OpenSSH server daemon
+`
+
+var testListUnitFilesOutput = `amazon-ssm-agent.service enabled
+getty@.service           enabled
+rsyslog.service          disabled
+sshd.service             enabled
+`
+
+var testSystemctlShowOutput = `Id=amazon-ssm-agent.service
+Description=amazon-ssm-agent
+LoadState=loaded
+ActiveState=active
+SubState=running
+UnitFileState=enabled
+FragmentPath=/etc/systemd/system/amazon-ssm-agent.service
+Type=simple
+Requires=
+RequiredBy=
+
+Id=missing.service
+Description=missing.service
+LoadState=not-found
+ActiveState=inactive
+SubState=dead
+
+Id=rsyslog.service
+Description=System Logging Service
+LoadState=loaded
+ActiveState=inactive
+SubState=dead
+UnitFileState=disabled
+FragmentPath=/usr/lib/systemd/system/rsyslog.service
+Type=notify
+Requires=syslog.socket
+RequiredBy=
+`
+
+func setSystemdRuntimeDirMock(t *testing.T) func() {
+	dir, err := ioutil.TempDir("", "systemd")
+	assert.NoError(t, err)
+	original := systemdRuntimeDir
+	systemdRuntimeDir = dir
+	return func() {
+		systemdRuntimeDir = original
+		os.RemoveAll(dir)
+	}
+}
+
+func TestSystemdServiceData(t *testing.T) {
+	defer setSystemdRuntimeDirMock(t)()
+	var shown []string
+	cmdExecutor = func(command string, args ...string) ([]byte, error) {
+		switch args[0] {
+		case "list-units":
+			return []byte(testListUnitsOutput), nil
+		case "list-unit-files":
+			return []byte(testListUnitFilesOutput), nil
+		default:
+			shown = args[5:]
+			return []byte(testSystemctlShowOutput), nil
+		}
+	}
+
+	data, err := collectSystemdServiceData(context.NewMockDefault(), model.Config{})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"amazon-ssm-agent.service", "missing.service", "rsyslog.service", "sshd.service"}, shown)
+	assert.Equal(t, []model.ServiceData{
+		{
+			Name:         "amazon-ssm-agent.service",
+			DisplayName:  "amazon-ssm-agent",
+			Status:       "active",
+			ServiceType:  "simple",
+			StartType:    "enabled",
+			SubState:     "running",
+			UnitFilePath: "/etc/systemd/system/amazon-ssm-agent.service",
+		},
+		{
+			Name:               "rsyslog.service",
+			DisplayName:        "System Logging Service",
+			Status:             "inactive",
+			ServicesDependedOn: "syslog.socket",
+			ServiceType:        "notify",
+			StartType:          "disabled",
+			SubState:           "dead",
+			UnitFilePath:       "/usr/lib/systemd/system/rsyslog.service",
+		},
+	}, data)
+}
+
+func TestSystemdServiceDataCmdErr(t *testing.T) {
+	defer setSystemdRuntimeDirMock(t)()
+	cmdExecutor = createMockTestExecuteCommand("", errors.New("error"))
+
+	data, err := collectSystemdServiceData(context.NewMockDefault(), model.Config{})
+
+	assert.Error(t, err)
+	assert.Nil(t, data)
+}
+
+func TestSystemdServiceDataWithoutSystemd(t *testing.T) {
+	original := systemdRuntimeDir
+	systemdRuntimeDir = "/nonexistent/systemd"
+	defer func() { systemdRuntimeDir = original }()
+	cmdExecutor = createMockTestExecuteCommand("", errors.New("error"))
+
+	data, err := collectSystemdServiceData(context.NewMockDefault(), model.Config{})
+
+	assert.NoError(t, err)
+	assert.Nil(t, data)
+}
@@ -36,7 +36,7 @@ func Gatherer(context context.T) *T {
 	return new(T)
 }
 
-var collectData = collectServiceData
+var collectData = collectPlatformServiceData
 
 // Name returns name of Process gatherer
 func (t *T) Name() string {
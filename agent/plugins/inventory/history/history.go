@@ -0,0 +1,324 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package history keeps the last snapshots of the inventory collected on the instance
+// and computes the items added, removed or changed between them.
+package history
+
+import (
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/fileutil"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/model"
+)
+
+const historyFileExtension = ".json"
+
+// unsafeFileNameCharacters are replaced in the inventory type names to name the history files, e.g. AWS:Application
+var unsafeFileNameCharacters = regexp.MustCompile(`[^a-zA-Z0-9_-]`)
+
+// keyAttributes identify the items of the inventory types, items of other types are identified by their Name.
+// Items which have none of the key attributes are identified by their whole content.
+var keyAttributes = map[string][]string{
+	"AWS:Application":        {"Name", "Architecture"},
+	"AWS:AWSComponent":       {"Name", "Architecture"},
+	"AWS:File":               {"InstalledDir", "Name"},
+	"AWS:Service":            {"Name"},
+	"AWS:Network":            {"Name"},
+	"AWS:WindowsRole":        {"Name"},
+	"AWS:WindowsUpdate":      {"HotFixId"},
+	"AWS:WindowsRegistry":    {"KeyPath", "ValueName"},
+	"Custom:ListeningPorts":  {"Protocol", "LocalAddress", "LocalPort"},
+	"Custom:ContainerImage":  {"PackageManager", "Name", "Version"},
+	"Custom:LanguagePackage": {"PackageManager", "PackageId", "Name"},
+}
+
+// Snapshot is the content of an inventory type collected at a time
+type Snapshot struct {
+	CaptureTime string
+	ContentHash string
+	Content     json.RawMessage
+}
+
+// TypeHistory is the history of an inventory type, the oldest snapshot first
+type TypeHistory struct {
+	TypeName  string
+	Snapshots []Snapshot
+}
+
+// LastChanges returns the changes between the last two snapshots, none when there are less than two snapshots
+func (h TypeHistory) LastChanges() ([]model.InventoryChangeData, error) {
+	count := len(h.Snapshots)
+	if count < 2 {
+		return nil, nil
+	}
+	return Diff(h.TypeName, h.Snapshots[count-2], h.Snapshots[count-1])
+}
+
+// Store persists the history of each inventory type in a file
+type Store struct {
+	mutex     sync.Mutex
+	directory string
+	size      int
+}
+
+// NewStore returns the store in the data store of the instance, keeping size snapshots per inventory type
+func NewStore(machineID string, size int) *Store {
+	return NewStoreWithLocation(filepath.Join(appconfig.DefaultDataStorePath,
+		machineID,
+		appconfig.InventoryRootDirName,
+		appconfig.InventoryHistoryDirName), size)
+}
+
+// NewStoreWithLocation returns the store in the directory, keeping size snapshots per inventory type
+func NewStoreWithLocation(directory string, size int) *Store {
+	return &Store{directory: directory, size: size}
+}
+
+// Record adds the content of the item as a new snapshot of its type when it changed since the last snapshot,
+// and returns the changes from the last snapshot. There is no change for the first snapshot of a type.
+func (s *Store) Record(item model.Item) (changes []model.InventoryChangeData, err error) {
+	if s.size <= 0 || item.Name == model.AWSInventoryChange {
+		return nil, nil
+	}
+	content, err := json.Marshal(item.Content)
+	if err != nil {
+		return nil, err
+	}
+	sum := md5.Sum(content)
+	current := Snapshot{
+		CaptureTime: item.CaptureTime,
+		ContentHash: base64.StdEncoding.EncodeToString(sum[:]),
+		Content:     content,
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	history, err := s.read(item.Name)
+	if err != nil {
+		return nil, err
+	}
+	if count := len(history.Snapshots); count > 0 {
+		previous := history.Snapshots[count-1]
+		if previous.ContentHash == current.ContentHash {
+			return nil, nil
+		}
+		if changes, err = Diff(item.Name, previous, current); err != nil {
+			return nil, err
+		}
+	}
+
+	history.Snapshots = append(history.Snapshots, current)
+	if len(history.Snapshots) > s.size {
+		history.Snapshots = history.Snapshots[len(history.Snapshots)-s.size:]
+	}
+	return changes, s.write(history)
+}
+
+// Get returns the history of the inventory type, without snapshot if none was recorded
+func (s *Store) Get(typeName string) (TypeHistory, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.read(typeName)
+}
+
+// List returns the history of all the inventory types, sorted by type name
+func (s *Store) List() (histories []TypeHistory, err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	files, err := ioutil.ReadDir(s.directory)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	for _, file := range files {
+		if file.IsDir() || filepath.Ext(file.Name()) != historyFileExtension {
+			continue
+		}
+		var history TypeHistory
+		if history, err = readFile(filepath.Join(s.directory, file.Name())); err != nil {
+			return nil, err
+		}
+		histories = append(histories, history)
+	}
+	sort.Slice(histories, func(i, j int) bool { return histories[i].TypeName < histories[j].TypeName })
+	return histories, nil
+}
+
+func (s *Store) path(typeName string) string {
+	return filepath.Join(s.directory, unsafeFileNameCharacters.ReplaceAllString(typeName, "_")+historyFileExtension)
+}
+
+func (s *Store) read(typeName string) (TypeHistory, error) {
+	path := s.path(typeName)
+	if !fileutil.Exists(path) {
+		return TypeHistory{TypeName: typeName}, nil
+	}
+	history, err := readFile(path)
+	if err != nil {
+		return history, err
+	}
+	if history.TypeName != typeName {
+		// another type mapping to the same file name, its history is replaced
+		return TypeHistory{TypeName: typeName}, nil
+	}
+	return history, nil
+}
+
+func (s *Store) write(history TypeHistory) error {
+	if err := fileutil.MakeDirs(s.directory); err != nil {
+		return fmt.Errorf("failed to create directory %v, %v", s.directory, err)
+	}
+	content, err := json.Marshal(history)
+	if err != nil {
+		return err
+	}
+	_, err = fileutil.WriteIntoFileWithPermissions(s.path(history.TypeName), string(content), appconfig.ReadWriteAccess)
+	return err
+}
+
+func readFile(path string) (history TypeHistory, err error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return
+	}
+	if err = json.Unmarshal(content, &history); err != nil {
+		err = fmt.Errorf("invalid inventory history %v, %v", path, err)
+	}
+	return
+}
+
+// Diff returns the items of the inventory type added, removed or changed between the snapshots, sorted by key.
+// An item is changed when a single item has its key in both snapshots, otherwise the items with the same key
+// are compared as a whole.
+func Diff(typeName string, previous, current Snapshot) (changes []model.InventoryChangeData, err error) {
+	previousItems, err := groupItems(typeName, previous.Content)
+	if err != nil {
+		return nil, err
+	}
+	currentItems, err := groupItems(typeName, current.Content)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]string, 0, len(previousItems)+len(currentItems))
+	for key := range previousItems {
+		keys = append(keys, key)
+	}
+	for key := range currentItems {
+		if _, found := previousItems[key]; !found {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	change := func(changeType, key, previousValue, currentValue string) model.InventoryChangeData {
+		return model.InventoryChangeData{
+			TypeName:            typeName,
+			ChangeType:          changeType,
+			Key:                 key,
+			PreviousValue:       previousValue,
+			CurrentValue:        currentValue,
+			PreviousCaptureTime: previous.CaptureTime,
+			CaptureTime:         current.CaptureTime,
+		}
+	}
+	for _, key := range keys {
+		before, after := previousItems[key], currentItems[key]
+		if len(before) == 1 && len(after) == 1 {
+			if before[0] != after[0] {
+				changes = append(changes, change(model.InventoryChangeChanged, key, before[0], after[0]))
+			}
+			continue
+		}
+		for _, value := range subtract(before, after) {
+			changes = append(changes, change(model.InventoryChangeRemoved, key, value, ""))
+		}
+		for _, value := range subtract(after, before) {
+			changes = append(changes, change(model.InventoryChangeAdded, key, "", value))
+		}
+	}
+	return changes, nil
+}
+
+// groupItems returns the items of the content in canonical JSON, indexed by key.
+// Content which is not a list is a single item.
+func groupItems(typeName string, content json.RawMessage) (map[string][]string, error) {
+	var items []json.RawMessage
+	if err := json.Unmarshal(content, &items); err != nil {
+		items = []json.RawMessage{content}
+	}
+	attributes, found := keyAttributes[typeName]
+	if !found {
+		attributes = []string{"Name"}
+	}
+
+	groups := make(map[string][]string)
+	for _, item := range items {
+		var value interface{}
+		if err := json.Unmarshal(item, &value); err != nil {
+			return nil, fmt.Errorf("invalid %v inventory item, %v", typeName, err)
+		}
+		// map keys are sorted when marshalled
+		canonical, _ := json.Marshal(value)
+		key := itemKey(value, attributes)
+		if key == "" {
+			key = string(canonical)
+		}
+		groups[key] = append(groups[key], string(canonical))
+	}
+	return groups, nil
+}
+
+// itemKey returns the key attributes of the item such as Name=curl, Architecture=x86_64, empty if it has none
+func itemKey(item interface{}, attributes []string) string {
+	object, ok := item.(map[string]interface{})
+	if !ok {
+		return ""
+	}
+	var parts []string
+	for _, attribute := range attributes {
+		if value, found := object[attribute]; found {
+			parts = append(parts, fmt.Sprintf("%v=%v", attribute, value))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// subtract returns the values which are not in others, each of the others matching one value
+func subtract(values, others []string) (remaining []string) {
+	counts := make(map[string]int)
+	for _, other := range others {
+		counts[other]++
+	}
+	for _, value := range values {
+		if counts[value] > 0 {
+			counts[value]--
+			continue
+		}
+		remaining = append(remaining, value)
+	}
+	return
+}
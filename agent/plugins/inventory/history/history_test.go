@@ -0,0 +1,161 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package history
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestStore(t *testing.T, size int) (*Store, func()) {
+	dir, err := ioutil.TempDir("", "history")
+	assert.NoError(t, err)
+	return NewStoreWithLocation(dir, size), func() { os.RemoveAll(dir) }
+}
+
+func applications(captureTime string, applications ...model.ApplicationData) model.Item {
+	return model.Item{Name: "AWS:Application", Content: applications, CaptureTime: captureTime}
+}
+
+func TestRecordKeepsLastSnapshots(t *testing.T) {
+	store, cleanup := newTestStore(t, 2)
+	defer cleanup()
+
+	for _, item := range []model.Item{
+		applications("2018-01-01T00:00:00Z", model.ApplicationData{Name: "curl", Version: "7.53"}),
+		applications("2018-01-02T00:00:00Z", model.ApplicationData{Name: "curl", Version: "7.53"}),
+		applications("2018-01-03T00:00:00Z", model.ApplicationData{Name: "curl", Version: "7.61"}),
+		applications("2018-01-04T00:00:00Z", model.ApplicationData{Name: "curl", Version: "7.62"}),
+	} {
+		_, err := store.Record(item)
+		assert.NoError(t, err)
+	}
+
+	typeHistory, err := store.Get("AWS:Application")
+	assert.NoError(t, err)
+	assert.Equal(t, "AWS:Application", typeHistory.TypeName)
+	// unchanged content is not recorded
+	assert.Len(t, typeHistory.Snapshots, 2)
+	assert.Equal(t, "2018-01-03T00:00:00Z", typeHistory.Snapshots[0].CaptureTime)
+	assert.Equal(t, "2018-01-04T00:00:00Z", typeHistory.Snapshots[1].CaptureTime)
+
+	changes, err := typeHistory.LastChanges()
+	assert.NoError(t, err)
+	assert.Len(t, changes, 1)
+	assert.Equal(t, "2018-01-03T00:00:00Z", changes[0].PreviousCaptureTime)
+}
+
+func TestRecordReturnsChanges(t *testing.T) {
+	store, cleanup := newTestStore(t, 5)
+	defer cleanup()
+
+	changes, err := store.Record(applications("2018-01-01T00:00:00Z",
+		model.ApplicationData{Name: "curl", Version: "7.53", Architecture: "x86_64"},
+		model.ApplicationData{Name: "vim", Version: "8.0", Architecture: "x86_64"}))
+	assert.NoError(t, err)
+	assert.Empty(t, changes)
+
+	changes, err = store.Record(applications("2018-01-02T00:00:00Z",
+		model.ApplicationData{Name: "curl", Version: "7.61", Architecture: "x86_64"},
+		model.ApplicationData{Name: "git", Version: "2.17", Architecture: "x86_64"}))
+	assert.NoError(t, err)
+	assert.Equal(t, []model.InventoryChangeData{
+		{
+			TypeName:            "AWS:Application",
+			ChangeType:          model.InventoryChangeChanged,
+			Key:                 "Name=curl, Architecture=x86_64",
+			PreviousValue:       `{"Architecture":"x86_64","Name":"curl","Publisher":"","Version":"7.53"}`,
+			CurrentValue:        `{"Architecture":"x86_64","Name":"curl","Publisher":"","Version":"7.61"}`,
+			PreviousCaptureTime: "2018-01-01T00:00:00Z",
+			CaptureTime:         "2018-01-02T00:00:00Z",
+		},
+		{
+			TypeName:            "AWS:Application",
+			ChangeType:          model.InventoryChangeAdded,
+			Key:                 "Name=git, Architecture=x86_64",
+			CurrentValue:        `{"Architecture":"x86_64","Name":"git","Publisher":"","Version":"2.17"}`,
+			PreviousCaptureTime: "2018-01-01T00:00:00Z",
+			CaptureTime:         "2018-01-02T00:00:00Z",
+		},
+		{
+			TypeName:            "AWS:Application",
+			ChangeType:          model.InventoryChangeRemoved,
+			Key:                 "Name=vim, Architecture=x86_64",
+			PreviousValue:       `{"Architecture":"x86_64","Name":"vim","Publisher":"","Version":"8.0"}`,
+			PreviousCaptureTime: "2018-01-01T00:00:00Z",
+			CaptureTime:         "2018-01-02T00:00:00Z",
+		},
+	}, changes)
+}
+
+func TestRecordIgnoresInventoryChangesAndDisabledHistory(t *testing.T) {
+	store, cleanup := newTestStore(t, 5)
+	defer cleanup()
+	_, err := store.Record(model.Item{Name: model.AWSInventoryChange, Content: []model.InventoryChangeData{}})
+	assert.NoError(t, err)
+
+	disabled := NewStoreWithLocation(store.directory, 0)
+	_, err = disabled.Record(applications("2018-01-01T00:00:00Z", model.ApplicationData{Name: "curl"}))
+	assert.NoError(t, err)
+
+	histories, err := store.List()
+	assert.NoError(t, err)
+	assert.Empty(t, histories)
+}
+
+func TestDiffItemsWithSameKey(t *testing.T) {
+	previous := Snapshot{Content: []byte(`[{"Name":"kernel","Version":"4.9"},{"Name":"kernel","Version":"4.14"}]`)}
+	current := Snapshot{Content: []byte(`[{"Version":"4.14","Name":"kernel"},{"Name":"kernel","Version":"4.19"}]`)}
+
+	changes, err := Diff("AWS:Application", previous, current)
+
+	assert.NoError(t, err)
+	assert.Len(t, changes, 2)
+	assert.Equal(t, model.InventoryChangeRemoved, changes[0].ChangeType)
+	assert.Equal(t, `{"Name":"kernel","Version":"4.9"}`, changes[0].PreviousValue)
+	assert.Equal(t, model.InventoryChangeAdded, changes[1].ChangeType)
+	assert.Equal(t, `{"Name":"kernel","Version":"4.19"}`, changes[1].CurrentValue)
+}
+
+func TestDiffItemsWithoutKey(t *testing.T) {
+	previous := Snapshot{Content: []byte(`{"CPUs":"2"}`)}
+	current := Snapshot{Content: []byte(`{"CPUs":"4"}`)}
+
+	changes, err := Diff("AWS:InstanceDetailedInformation", previous, current)
+
+	assert.NoError(t, err)
+	assert.Len(t, changes, 2)
+	assert.Equal(t, model.InventoryChangeRemoved, changes[0].ChangeType)
+	assert.Equal(t, model.InventoryChangeAdded, changes[1].ChangeType)
+}
+
+func TestList(t *testing.T) {
+	store, cleanup := newTestStore(t, 5)
+	defer cleanup()
+	_, err := store.Record(model.Item{Name: "Custom:ListeningPorts", Content: []model.ListeningPortData{{LocalPort: "22"}}})
+	assert.NoError(t, err)
+	_, err = store.Record(applications("2018-01-01T00:00:00Z", model.ApplicationData{Name: "curl"}))
+	assert.NoError(t, err)
+
+	histories, err := store.List()
+
+	assert.NoError(t, err)
+	assert.Len(t, histories, 2)
+	assert.Equal(t, "AWS:Application", histories[0].TypeName)
+	assert.Equal(t, "Custom:ListeningPorts", histories[1].TypeName)
+}
@@ -34,17 +34,22 @@ import (
 	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/gatherers/application"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/gatherers/awscomponent"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/gatherers/billinginfo"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/gatherers/containerimage"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/gatherers/custom"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/gatherers/file"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/gatherers/instancedetailedinformation"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/gatherers/languagepackage"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/gatherers/listeningports"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/gatherers/network"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/gatherers/registry"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/gatherers/role"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/gatherers/service"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/gatherers/windowsUpdate"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/history"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/model"
 	"github.com/aws/amazon-ssm-agent/agent/sdkutil"
 	"github.com/aws/amazon-ssm-agent/agent/task"
+	"github.com/aws/amazon-ssm-agent/agent/times"
 	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/service/ssm"
 )
@@ -74,6 +79,9 @@ type PluginInput struct {
 	WindowsRegistry             string
 	WindowsUpdates              string
 	InstanceDetailedInformation string
+	ListeningPorts              string
+	ContainerImages             string
+	LanguagePackages            string
 	CustomInventory             string
 	CustomInventoryDirectory    string
 }
@@ -102,6 +110,12 @@ type Plugin struct {
 
 	// machineID of the machine where agent is running - useful during command detection
 	machineID string
+
+	// history keeps the last snapshots of each inventory type, nil when disabled
+	history *history.Store
+
+	// reportChanges uploads the changes from the last snapshots as the AWS:InventoryChange type
+	reportChanges bool
 }
 
 // Name returns the plugin name
@@ -129,6 +143,12 @@ func NewPlugin(context context.T) (*Plugin, error) {
 
 	//loads all registered gatherers (for now only a dummy application gatherer is loaded in memory)
 	p.supportedGatherers, p.installedGatherers = gatherers.InitializeGatherers(p.context)
+
+	if appCfg, cfgErr := appconfig.Config(false); cfgErr == nil && appCfg.Inventory.HistorySize > 0 {
+		p.history = history.NewStore(p.machineID, appCfg.Inventory.HistorySize)
+		p.reportChanges = appCfg.Inventory.ReportChanges
+	}
+
 	//initializes SSM Inventory uploader
 	if p.uploader, err = datauploader.NewInventoryUploader(c); err != nil {
 		err = log.Errorf("Unable to configure SSM Inventory uploader - %v", err.Error())
@@ -172,6 +192,8 @@ func (p *Plugin) ApplyInventoryPolicy(context context.T, inventoryInput PluginIn
 		return
 	}
 
+	items = p.RecordHistory(items)
+
 	//log collected data before sending
 	d, _ := json.Marshal(items)
 	log.Debugf("Collected Inventory data: %v", string(d))
@@ -237,6 +259,8 @@ func (p Plugin) ApplyInventoryFrequentCollector(context context.T, gatherers map
 		return
 	}
 
+	items = p.RecordHistory(items)
+
 	if dirtyItems, err = p.uploader.GetDirtySsmInventoryItems(context, items); err != nil {
 		log.Debugf("Encountered error in collecting dirty Inventory items - %#v. Skipping upload to SSM", err.Error())
 		output.SetExitCode(1)
@@ -266,6 +290,39 @@ func (p Plugin) ApplyInventoryFrequentCollector(context context.T, gatherers map
 	return
 }
 
+// RecordHistory adds the collected items to the local inventory history and, if configured, returns them with
+// an AWS:InventoryChange item listing the changes since the previous snapshots. Failing to record is not fatal.
+func (p *Plugin) RecordHistory(items []model.Item) []model.Item {
+	if p.history == nil {
+		return items
+	}
+	log := p.context.Log()
+
+	changes := []model.InventoryChangeData{}
+	for _, item := range items {
+		itemChanges, err := p.history.Record(item)
+		if err != nil {
+			log.Warnf("Failed to record the history of inventory type %v, %v", item.Name, err)
+			continue
+		}
+		if len(itemChanges) > 0 {
+			log.Infof("Detected %v changes of inventory type %v", len(itemChanges), item.Name)
+		}
+		changes = append(changes, itemChanges...)
+	}
+
+	if !p.reportChanges {
+		return items
+	}
+	//CaptureTime must comply with format: 2016-07-30T18:15:37Z to comply with regex at SSM.
+	return append(items, model.Item{
+		Name:          model.AWSInventoryChange,
+		SchemaVersion: model.SchemaVersionOfInventoryChange,
+		Content:       changes,
+		CaptureTime:   time.Now().UTC().Format(time.RFC3339),
+	})
+}
+
 // shouldRetryWithNonOptimizedData will return true if the Exception occurred is one of ItemContentMismatchException
 // or InvalidItemContentException and will retry sending data to SSM. It will return false, if any other error occurs.
 func shouldRetryWithNonOptimizedData(err error, log log.T) bool {
@@ -383,6 +440,9 @@ func (p *Plugin) ValidateInventoryInput(context context.T, input PluginInput) (c
 		billinginfo.GathererName:                 input.BillingInfo,
 		windowsUpdate.GathererName:               input.WindowsUpdates,
 		instancedetailedinformation.GathererName: input.InstanceDetailedInformation,
+		listeningports.GathererName:              input.ListeningPorts,
+		containerimage.GathererName:              input.ContainerImages,
+		languagepackage.GathererName:             input.LanguagePackages,
 	}
 
 	predefinedGatherersWithFilters := map[string]string{
@@ -539,6 +599,11 @@ func (p *Plugin) IsInventoryBeingInvokedAsAssociation(fileName string) (status b
 			}
 		}
 
+	} else if isAssociationBookKeepingFileName(fileName) {
+		// document states kept in the embedded store are not visible on disk, association documents are
+		// identified by their bookkeeping file name instead
+		log.Debugf("Document %v is not on disk, detected association from its name", fileName)
+		status = true
 	} else {
 		err = fmt.Errorf("Inventory plugin could not locate the execution document which invoked it. The document is expected to be in the location - %v", absPathOfDoc)
 	}
@@ -546,6 +611,16 @@ func (p *Plugin) IsInventoryBeingInvokedAsAssociation(fileName string) (status b
 	return
 }
 
+// isAssociationBookKeepingFileName checks whether the input is of format AssociationID.RunID, RunID being the IsoDash time of the run
+func isAssociationBookKeepingFileName(input string) bool {
+	parts := strings.SplitN(input, ".", 2)
+	if len(parts) != 2 || parts[0] == "" {
+		return false
+	}
+	_, err := times.ParseIsoDashUTC(parts[1])
+	return err == nil
+}
+
 // ParseAssociationIdFromFileName parses associationID from the given input
 // NOTE: Input will be of format - AssociationID.RunID -> as per the format of bookkeepingfilename for associate documents
 func (p *Plugin) ParseAssociationIdFromFileName(input string) string {
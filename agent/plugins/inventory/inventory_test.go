@@ -17,12 +17,15 @@ package inventory
 import (
 	"bytes"
 	"fmt"
+	"io/ioutil"
+	"os"
 	"testing"
 
 	"github.com/aws/amazon-ssm-agent/agent/context"
 	"github.com/aws/amazon-ssm-agent/agent/contracts"
 	"github.com/aws/amazon-ssm-agent/agent/log"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/gatherers"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/history"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/model"
 	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/stretchr/testify/assert"
@@ -219,3 +222,33 @@ func TestShouldRetryWithNonOptimizedData(t *testing.T) {
 		assert.Equal(t, testCase.shouldRetry, shouldRetryWithNonOptimizedData(testCase.err, log))
 	}
 }
+
+func TestRecordHistory(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "history")
+	defer os.RemoveAll(dir)
+	p, _ := MockInventoryPlugin([]string{}, []string{})
+	p.history = history.NewStoreWithLocation(dir, 2)
+	p.reportChanges = true
+	collect := func(version string) []model.Item {
+		return []model.Item{{
+			Name:        "AWS:Application",
+			Content:     []model.ApplicationData{{Name: "curl", Version: version, Architecture: "x86_64"}},
+			CaptureTime: "2018-01-01T00:00:00Z",
+		}}
+	}
+
+	items := p.RecordHistory(collect("7.53"))
+	assert.Len(t, items, 2)
+	assert.Equal(t, model.AWSInventoryChange, items[1].Name)
+	assert.Equal(t, []model.InventoryChangeData{}, items[1].Content)
+
+	items = p.RecordHistory(collect("7.61"))
+	changes := items[1].Content.([]model.InventoryChangeData)
+	assert.Len(t, changes, 1)
+	assert.Equal(t, model.InventoryChangeChanged, changes[0].ChangeType)
+	assert.Equal(t, "Name=curl, Architecture=x86_64", changes[0].Key)
+
+	p.reportChanges = false
+	items = p.RecordHistory(collect("7.62"))
+	assert.Len(t, items, 1)
+}
@@ -24,6 +24,10 @@ import (
 const (
 	// AWSInstanceInformation is inventory type of instance information
 	AWSInstanceInformation = "AWS:InstanceInformation"
+	// AWSInventoryChange is inventory type of the changes between the last two snapshots of the other inventory types
+	AWSInventoryChange = "AWS:InventoryChange"
+	// SchemaVersionOfInventoryChange represents schema version of AWS:InventoryChange inventory type
+	SchemaVersionOfInventoryChange = "1.0"
 	// Enabled represents constant string used to enable various components of inventory plugin
 	Enabled = "Enabled"
 	// ErrorThreshold represents error threshold for inventory plugin
@@ -74,7 +78,8 @@ const (
 	AWSComponent ComponentType = 1 << iota
 )
 
-// ApplicationData captures all attributes present in AWS:Application inventory type
+// ApplicationData captures all attributes present in AWS:Application inventory type.
+// It is also the content of the Custom:ContainerImage and Custom:LanguagePackage types which set PackageManager.
 type ApplicationData struct {
 	Name            string
 	Publisher       string
@@ -87,6 +92,7 @@ type ApplicationData struct {
 	URL             string        `json:",omitempty"`
 	Summary         string        `json:",omitempty"`
 	PackageId       string        `json:",omitempty"`
+	PackageManager  string        `json:",omitempty"`
 	CompType        ComponentType `json:"-"`
 }
 
@@ -120,6 +126,9 @@ type RoleData struct {
 	Parent                    string
 }
 
+// ServiceData captures all attributes present in AWS:Service inventory type.
+// On Linux, Status is the active state of the systemd unit, StartType its enablement state,
+// and SubState and UnitFilePath are also set.
 type ServiceData struct {
 	Name               string
 	DisplayName        string
@@ -128,6 +137,8 @@ type ServiceData struct {
 	ServicesDependedOn string
 	ServiceType        string
 	StartType          string
+	SubState           string `json:",omitempty"`
+	UnitFilePath       string `json:",omitempty"`
 }
 
 type RegistryData struct {
@@ -154,6 +165,35 @@ type NetworkData struct {
 	IPV6       string
 }
 
+// ListeningPortData captures all attributes present in Custom:ListeningPorts inventory type.
+// Attributes are strings as required for custom inventory types.
+type ListeningPortData struct {
+	Protocol     string
+	LocalAddress string
+	LocalPort    string
+	ProcessId    string
+	ProcessName  string
+}
+
+// Types of inventory changes
+const (
+	InventoryChangeAdded   = "Added"
+	InventoryChangeRemoved = "Removed"
+	InventoryChangeChanged = "Changed"
+)
+
+// InventoryChangeData captures all attributes present in AWS:InventoryChange inventory type.
+// Key identifies the changed item within its type, the values are the items in JSON.
+type InventoryChangeData struct {
+	TypeName            string
+	ChangeType          string
+	Key                 string
+	PreviousValue       string `json:",omitempty"`
+	CurrentValue        string `json:",omitempty"`
+	PreviousCaptureTime string
+	CaptureTime         string
+}
+
 // WindowsUpdateData captures all attributes present in AWS:WindowsUpdate inventory type
 type WindowsUpdateData struct {
 	// SSM Inventory expects it HotFixId and not HotFixID
@@ -16,8 +16,6 @@
 package rundocument
 
 import (
-	"encoding/json"
-
 	"path/filepath"
 
 	"github.com/aws/amazon-ssm-agent/agent/appconfig"
@@ -28,7 +26,6 @@ import (
 	"github.com/aws/amazon-ssm-agent/agent/framework/processor/executer"
 	"github.com/aws/amazon-ssm-agent/agent/log"
 	"github.com/aws/amazon-ssm-agent/agent/task"
-	"github.com/go-yaml/yaml"
 )
 
 type ExecDocument interface {
@@ -49,11 +46,9 @@ func (exec ExecDocumentImpl) ParseDocument(log log.T, documentRaw []byte, orches
 	s3Bucket string, s3KeyPrefix string, messageID string, documentID string, defaultWorkingDirectory string,
 	params map[string]interface{}) (pluginsInfo []contracts.PluginState, err error) {
 	docContent := docparser.DocContent{}
-	if err := json.Unmarshal(documentRaw, &docContent); err != nil {
-		if err := yaml.Unmarshal(documentRaw, &docContent); err != nil {
-			log.Error("Unmarshaling remote resource document failed. Please make sure the document is in the correct JSON or YAML formal")
-			return pluginsInfo, err
-		}
+	if _, err := docparser.LoadDocumentContent(documentRaw, &docContent); err != nil {
+		log.Error("Unmarshaling remote resource document failed. Please make sure the document is in the correct JSON or YAML formal")
+		return pluginsInfo, err
 	}
 	parserInfo := docparser.DocumentParserInfo{
 		OrchestrationDir:  orchestrationDir,
@@ -99,7 +94,7 @@ func (exec ExecDocumentImpl) ExecuteDocument(config contracts.Configuration, con
 		return resultChannels, err
 	}
 	docStore := executer.NewDocumentFileStore(context, documentID, instanceID, appconfig.DefaultLocationOfCurrent,
-		&docState, docmanager.NewDocumentFileMgr(appconfig.DefaultDataStorePath, appconfig.DefaultDocumentRootDirName, appconfig.DefaultLocationOfState))
+		&docState, docmanager.NewDocumentMgr(context.AppConfig(), appconfig.DefaultDataStorePath))
 	cancelFlag := task.NewChanneledCancelFlag()
 	resultChannels = exec.DocExecutor.Run(cancelFlag, &docStore)
 
@@ -0,0 +1,103 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package redaction masks secrets in the output of the commands before it is saved, uploaded or returned.
+//
+// The secrets are the values of the SecureString parameters resolved by the process, and the text matching the
+// patterns of the Redaction section of the agent configuration, such as AWS access keys.
+package redaction
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+)
+
+const (
+	// Mask replaces the redacted text
+	Mask = "********"
+
+	// minSecretLength is the length under which values are not masked, masking them would garble the output
+	minSecretLength = 4
+)
+
+var (
+	mutex sync.RWMutex
+	// secrets sorted from the longest, so that a secret containing another one is masked whole
+	secrets []string
+
+	patternsOnce sync.Once
+	patterns     []*regexp.Regexp
+)
+
+// AddSecret registers a value to mask in the output of the commands run by this process
+func AddSecret(value string) {
+	if len(value) < minSecretLength {
+		return
+	}
+	mutex.Lock()
+	defer mutex.Unlock()
+	for _, secret := range secrets {
+		if secret == value {
+			return
+		}
+	}
+	secrets = append(secrets, value)
+	sort.SliceStable(secrets, func(i, j int) bool { return len(secrets[i]) > len(secrets[j]) })
+}
+
+// Redact returns the text where the secrets and the text matching the configured patterns are masked
+func Redact(text string) string {
+	if text == "" {
+		return text
+	}
+	mutex.RLock()
+	for _, secret := range secrets {
+		text = strings.Replace(text, secret, Mask, -1)
+	}
+	mutex.RUnlock()
+
+	for _, pattern := range getPatterns() {
+		text = pattern.ReplaceAllString(text, Mask)
+	}
+	return text
+}
+
+// getPatterns compiles the patterns of the agent configuration once per process
+func getPatterns() []*regexp.Regexp {
+	patternsOnce.Do(func() {
+		config, err := appconfig.Config(false)
+		if err != nil {
+			config = appconfig.DefaultConfig()
+		}
+		patterns = compilePatterns(config.Redaction.Patterns)
+	})
+	return patterns
+}
+
+// compilePatterns returns the compiled patterns, the parser of the configuration already dropped the invalid ones
+func compilePatterns(expressions []string) []*regexp.Regexp {
+	var compiled []*regexp.Regexp
+	for _, expression := range expressions {
+		if expression == "" {
+			continue
+		}
+		if pattern, err := regexp.Compile(expression); err == nil {
+			compiled = append(compiled, pattern)
+		}
+	}
+	return compiled
+}
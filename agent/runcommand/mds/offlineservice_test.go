@@ -62,6 +62,22 @@ func TestInvalid(t *testing.T) {
 	assert.Equal(t, 1, FileCount(invalidCommands))
 }
 
+func TestValidYAML(t *testing.T) {
+	service := GetTestService()
+
+	defer CleanTestDirs()
+	err := SubmitTestDoc("validcommand22.yaml")
+	assert.Nil(t, err)
+
+	messages, err := service.GetMessages(logger, "i-bar")
+
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(messages.Messages))
+	assert.Contains(t, *messages.Messages[0].Payload, "echo foo")
+	assert.Equal(t, 0, FileCount(newCommands))
+	assert.Equal(t, 1, FileCount(submittedCommands))
+}
+
 func TestBothVersions(t *testing.T) {
 	service := GetTestService()
 
@@ -21,6 +21,7 @@ import (
 	"time"
 
 	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/metrics"
 	"github.com/aws/amazon-ssm-agent/agent/sdkutil"
 	"github.com/carlescere/scheduler"
 )
@@ -30,6 +31,20 @@ var lock sync.RWMutex
 
 var processMessage = (*RunCommandService).processMessage
 
+var mdsPollDurations = metrics.NewHistogram("ssm_agent_mds_poll_duration_seconds",
+	"Duration of the MDS GetMessages calls, by result.",
+	metrics.DefaultDurationBuckets,
+	"result")
+
+// recordPoll records the latency of a GetMessages call
+func recordPoll(duration time.Duration, err error) {
+	result := "success"
+	if err != nil {
+		result = "failure"
+	}
+	mdsPollDurations.Observe(duration.Seconds(), result)
+}
+
 func updateLastPollTime(processorType string, currentTime time.Time) {
 	lock.Lock()
 	defer lock.Unlock()
@@ -144,7 +159,11 @@ func (s *RunCommandService) pollOnce() {
 	if s.name == mdsName {
 		log.Debugf("Polling for messages")
 	}
+	pollStart := time.Now()
 	messages, err := s.service.GetMessages(log, s.config.InstanceID)
+	if s.name == mdsName {
+		recordPoll(time.Since(pollStart), err)
+	}
 	if err != nil {
 		sdkutil.HandleAwsError(log, err, s.processorStopPolicy)
 		return
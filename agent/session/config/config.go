@@ -64,16 +64,30 @@ const (
 	DataChannelRetryInitialDelayMillis = 100
 	DataChannelRetryMaxIntervalMillis  = 5000
 
-	IpcFileName      = "ipcTempFile"
-	LogFileExtension = ".log"
-	ScreenBufferSize = 30000
-	Exit             = "exit"
+	IpcFileName       = "ipcTempFile"
+	LogFileExtension  = ".log"
+	CastFileExtension = ".cast"
+	ScreenBufferSize  = 30000
+	Exit              = "exit"
 
 	// ResumeReadExitCode indicates to resume reading from established connection.
 	ResumeReadExitCode = -1
 	// LocalPortForwarding is one of types supported by port plugin and is used to differentiate handling of error
 	// with scenario of sshd server port forwarding.
 	LocalPortForwarding = "LocalPortForwarding"
+	// MultiplexedPortForwarding is the type of port sessions carrying many connections over the data channel,
+	// each connection being a stream of StreamFrame payloads.
+	MultiplexedPortForwarding = "MultiplexedPortForwarding"
+	// StreamFrameHeaderSize is the size of the frame type and the stream ID at the start of every StreamFrame payload.
+	StreamFrameHeaderSize = 8
+	// StreamInitialWindowSize is the number of bytes each side may send on a new stream before receiving a window update.
+	StreamInitialWindowSize = 256 * 1024
+	// StreamWindowUpdateThreshold is the number of bytes consumed on a stream before a window update is sent.
+	StreamWindowUpdateThreshold = StreamInitialWindowSize / 4
+	// StreamMaxWindowSize is the largest window the client may grant on a stream, the stream is closed beyond it.
+	StreamMaxWindowSize = 1<<31 - 1
+	// MaxStreamsPerSession is the maximum number of connections opened at the same time in a multiplexed port session.
+	MaxStreamsPerSession = 256
 
 	CloudWatchEncryptionErrorMsg = "We couldn't start the session because encryption is not set up on the selected CloudWatch Logs log group. Either encrypt the log group or choose an option to enable logging without encryption."
 	S3EncryptionErrorMsg         = "We couldn't start the session because encryption is not set up on the selected Amazon S3 bucket. Either encrypt the bucket or choose an option to enable logging without encryption."
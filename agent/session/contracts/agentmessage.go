@@ -352,6 +352,8 @@ func buildDocumentInfo(
 		DocumentName:   parsedMessagePayload.DocumentName,
 		DocumentStatus: contracts.ResultStatusInProgress,
 		RunAsUser:      parsedMessagePayload.RunAsUser,
+		PrincipalArn:   parsedMessagePayload.PrincipalArn,
+		PrincipalTags:  parsedMessagePayload.PrincipalTags,
 	}
 }
 
@@ -193,6 +193,8 @@ func TestParseAgentMessage(t *testing.T) {
 		"\"properties\":{\"windows\":{\"commands\":\"date\",\"runAsElevated\":true},\"linux\":{\"commands\":\"ls\",\"runAsElevated\":true}}}," +
 		"\"sessionId\":\"44da928d-1200-4501-a38a-f10d72e38cc4\"," +
 		"\"runAsUser\":\"test-user\"," +
+		"\"principalArn\":\"arn:aws:sts::123456789012:assumed-role/dev/alice\"," +
+		"\"principalTags\":{\"team\":\"dba\"}," +
 		"\"DataChannelToken\":\"AAEAAdDZESkS1C2/AWLlDccG608LYJUJZJLkxcjxl0x1T70kAAAAAFrozgJYbJT2fY6yQPDqQZhygozZ83LhsoYdP7VWmuo\"}"
 	mgsPayload := MGSPayload{
 		Payload:       string(agentJson),
@@ -239,6 +241,8 @@ func TestParseAgentMessage(t *testing.T) {
 	assert.Equal(t, "44da928d-1200-4501-a38a-f10d72e38cc4", pluginInfo[0].Configuration.SessionId)
 	assert.Equal(t, shellProps, pluginInfo[0].Configuration.Properties)
 	assert.Equal(t, "test-user", pluginInfo[0].Configuration.RunAsUser)
+	assert.Equal(t, "arn:aws:sts::123456789012:assumed-role/dev/alice", pluginInfo[0].Configuration.PrincipalArn)
+	assert.Equal(t, map[string]string{"team": "dba"}, pluginInfo[0].Configuration.PrincipalTags)
 }
 
 func TestValidateReturnsErrorWithEmptyAgentMessage(t *testing.T) {
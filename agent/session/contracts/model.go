@@ -104,6 +104,8 @@ type AgentTaskPayload struct {
 	SessionId       string                           `json:"SessionId"`
 	Parameters      map[string]interface{}           `json:"Parameters"`
 	RunAsUser       string                           `json:"RunAsUser"`
+	PrincipalArn    string                           `json:"PrincipalArn"`
+	PrincipalTags   map[string]string                `json:"PrincipalTags"`
 }
 
 // AcknowledgeContent is used to inform the sender of an acknowledge message that the message has been received.
@@ -123,6 +125,7 @@ type AgentSessionStateContent struct {
 	SchemaVersion int    `json:"SchemaVersion"`
 	SessionState  string `json:"SessionState"`
 	SessionId     string `json:"SessionId"`
+	Reason        string `json:"Reason,omitempty"`
 }
 
 // Deserialize parses AcknowledgeContent message from payload of AgentMessage.
@@ -223,6 +226,7 @@ const (
 	EncChallengeRequest  PayloadType = 8
 	EncChallengeResponse PayloadType = 9
 	Flag                 PayloadType = 10
+	StreamFrame          PayloadType = 11
 )
 
 type PayloadTypeFlag uint32
@@ -232,6 +236,21 @@ const (
 	TerminateSession PayloadTypeFlag = 2
 )
 
+// StreamFrameType identifies the frames carried by the StreamFrame payloads of the multiplexed port sessions.
+// Every frame starts with the 4 byte frame type followed by the 4 byte stream ID, both big endian.
+type StreamFrameType uint32
+
+const (
+	// StreamOpen is sent by the client to open a new connection identified by the stream ID
+	StreamOpen StreamFrameType = 1
+	// StreamData carries the bytes of the connection after the frame header
+	StreamData StreamFrameType = 2
+	// StreamClose is sent by either side when the connection of the stream is closed
+	StreamClose StreamFrameType = 3
+	// StreamWindowUpdate grants the peer the number of additional bytes, as 4 bytes after the header, it may send on the stream
+	StreamWindowUpdate StreamFrameType = 4
+)
+
 type SessionStatus string
 
 const (
@@ -239,6 +258,12 @@ const (
 	Terminating SessionStatus = "Terminating"
 )
 
+// Reasons reported with the Terminating session state when the agent terminates the session
+const (
+	IdleTimeoutReason        = "IdleTimeout"
+	MaxSessionDurationReason = "MaxSessionDuration"
+)
+
 type SizeData struct {
 	Cols uint32 `json:"cols"`
 	Rows uint32 `json:"rows"`
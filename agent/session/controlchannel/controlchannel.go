@@ -26,6 +26,7 @@ import (
 	"github.com/aws/amazon-ssm-agent/agent/contracts"
 	"github.com/aws/amazon-ssm-agent/agent/framework/processor"
 	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/metrics"
 	"github.com/aws/amazon-ssm-agent/agent/platform"
 	"github.com/aws/amazon-ssm-agent/agent/session/communicator"
 	mgsConfig "github.com/aws/amazon-ssm-agent/agent/session/config"
@@ -232,6 +233,10 @@ func controlChannelIncomingMessageHandler(context context.T,
 	return fmt.Errorf("invalid message type: %s", agentMessage.MessageType)
 }
 
+var sessionRequests = metrics.NewCounter("ssm_agent_session_requests_total",
+	"Number of session requests received from the control channel, by request type.",
+	"type")
+
 // sendStartSessionMessageToProcessor sends a StartSession message to the processor.
 func sendStartSessionMessageToProcessor(
 	processor processor.Processor,
@@ -251,6 +256,7 @@ func sendStartSessionMessageToProcessor(
 	}
 
 	// Submit message to processor
+	sessionRequests.Inc(string(contracts.StartSession))
 	processor.Submit(*docState)
 	return nil
 }
@@ -296,6 +302,7 @@ func sendTerminateSessionMessageToProcessor(
 	}
 
 	// Submit message to processor
+	sessionRequests.Inc(string(contracts.TerminateSession))
 	processor.Cancel(docState)
 	return nil
 }
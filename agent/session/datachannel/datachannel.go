@@ -63,6 +63,7 @@ type IDataChannel interface {
 	ProcessAcknowledgedMessage(log log.T, acknowledgeMessageContent mgsContracts.AcknowledgeContent)
 	SendAcknowledgeMessage(log log.T, agentMessage mgsContracts.AgentMessage) error
 	SendAgentSessionStateMessage(log log.T, sessionStatus mgsContracts.SessionStatus) error
+	SendAgentSessionStateMessageWithReason(log log.T, sessionStatus mgsContracts.SessionStatus, reason string) error
 	AddDataToOutgoingMessageBuffer(streamMessage StreamingMessage)
 	RemoveDataFromOutgoingMessageBuffer(streamMessageElement *list.Element)
 	AddDataToIncomingMessageBuffer(streamMessage StreamingMessage)
@@ -383,7 +384,7 @@ func (dataChannel *DataChannel) SendStreamDataMessage(log log.T, payloadType mgs
 	}
 
 	// If encryption has been enabled, encrypt the payload
-	if dataChannel.encryptionEnabled && payloadType == mgsContracts.Output {
+	if dataChannel.encryptionEnabled && isEncryptedPayloadType(payloadType) {
 		if inputData, err = dataChannel.blockCipher.EncryptWithAESGCM(inputData); err != nil {
 			return fmt.Errorf("error encrypting stream data message sequence %d, err: %v", dataChannel.StreamDataSequenceNumber, err)
 		}
@@ -498,10 +499,16 @@ func (dataChannel *DataChannel) SendAcknowledgeMessage(log log.T, streamDataMess
 
 // SendAgentSessionStateMessage sends agent session state to MGS
 func (dataChannel *DataChannel) SendAgentSessionStateMessage(log log.T, sessionStatus mgsContracts.SessionStatus) error {
+	return dataChannel.SendAgentSessionStateMessageWithReason(log, sessionStatus, "")
+}
+
+// SendAgentSessionStateMessageWithReason sends agent session state to MGS along with the reason of the state change
+func (dataChannel *DataChannel) SendAgentSessionStateMessageWithReason(log log.T, sessionStatus mgsContracts.SessionStatus, reason string) error {
 	agentSessionStateContent := &mgsContracts.AgentSessionStateContent{
 		SchemaVersion: schemaVersion,
 		SessionState:  string(sessionStatus),
 		SessionId:     dataChannel.ChannelId,
+		Reason:        reason,
 	}
 
 	var agentSessionStateContentBytes []byte
@@ -768,7 +775,7 @@ func (dataChannel *DataChannel) processIncomingMessageBufferItems(log log.T) (er
 // processStreamDataMessage gets called for all messages of type OutputStreamDataMessage
 func (dataChannel *DataChannel) processStreamDataMessage(log log.T, streamDataMessage mgsContracts.AgentMessage) (err error) {
 
-	if dataChannel.encryptionEnabled && streamDataMessage.PayloadType == uint32(mgsContracts.Output) {
+	if dataChannel.encryptionEnabled && isEncryptedPayloadType(mgsContracts.PayloadType(streamDataMessage.PayloadType)) {
 		if streamDataMessage.Payload, err = dataChannel.blockCipher.DecryptWithAESGCM(streamDataMessage.Payload); err != nil {
 			return fmt.Errorf("Error decrypting stream data message sequence %d, err: %v", streamDataMessage.SequenceNumber, err)
 		}
@@ -1089,3 +1096,8 @@ func getMgsEndpoint(region string) (string, error) {
 	endpointBuilder.WriteString(hostName)
 	return endpointBuilder.String(), nil
 }
+
+// isEncryptedPayloadType checks whether the payloads of the given type carry session data which is encrypted
+func isEncryptedPayloadType(payloadType mgsContracts.PayloadType) bool {
+	return payloadType == mgsContracts.Output || payloadType == mgsContracts.StreamFrame
+}
@@ -246,6 +246,24 @@ func TestSendAgentSessionStateMessage(t *testing.T) {
 	mockWsChannel.AssertExpectations(t)
 }
 
+func TestSendAgentSessionStateMessageWithReason(t *testing.T) {
+	dataChannel := getDataChannel()
+	wsChannel := &communicatorMocks.IWebSocketChannel{}
+	dataChannel.wsChannel = wsChannel
+
+	var content mgsContracts.AgentSessionStateContent
+	wsChannel.On("SendMessage", mock.Anything, mock.Anything, mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		agentMessage := &mgsContracts.AgentMessage{}
+		agentMessage.Deserialize(mockLog, args.Get(1).([]byte))
+		json.Unmarshal(agentMessage.Payload, &content)
+	})
+	assert.NoError(t, dataChannel.SendAgentSessionStateMessageWithReason(mockLog, mgsContracts.Terminating, mgsContracts.IdleTimeoutReason))
+
+	wsChannel.AssertExpectations(t)
+	assert.Equal(t, string(mgsContracts.Terminating), content.SessionState)
+	assert.Equal(t, mgsContracts.IdleTimeoutReason, content.Reason)
+}
+
 func TestAddDataToOutgoingMessageBuffer(t *testing.T) {
 	dataChannel := getDataChannel()
 	dataChannel.OutgoingMessageBuffer.Capacity = 2
@@ -159,6 +159,20 @@ func (_m *IDataChannel) SendAgentSessionStateMessage(_a0 log.T, sessionStatus co
 	return r0
 }
 
+// SendAgentSessionStateMessageWithReason provides a mock function with given fields: _a0, sessionStatus, reason
+func (_m *IDataChannel) SendAgentSessionStateMessageWithReason(_a0 log.T, sessionStatus contracts.SessionStatus, reason string) error {
+	ret := _m.Called(_a0, sessionStatus, reason)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(log.T, contracts.SessionStatus, string) error); ok {
+		r0 = rf(_a0, sessionStatus, reason)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // SendMessage provides a mock function with given fields: _a0, input, inputType
 func (_m *IDataChannel) SendMessage(_a0 log.T, input []byte, inputType int) error {
 	ret := _m.Called(_a0, input, inputType)
@@ -104,3 +104,8 @@ func (p *InteractiveCommandsPlugin) Execute(context context.T,
 func (p *InteractiveCommandsPlugin) InputStreamMessageHandler(log log.T, streamDataMessage mgsContracts.AgentMessage) error {
 	return p.shell.InputStreamMessageHandler(log, streamDataMessage)
 }
+
+// WriteWarning writes a warning message to the shell terminal
+func (p *InteractiveCommandsPlugin) WriteWarning(log log.T, message string) error {
+	return p.shell.WriteWarning(log, message)
+}
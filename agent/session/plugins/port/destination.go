@@ -0,0 +1,122 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package port
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"path/filepath"
+	"strings"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+)
+
+const localhost = "localhost"
+
+var LookupIP = func(host string) ([]net.IP, error) {
+	return net.LookupIP(host)
+}
+
+// resolveDestination returns the network and address the session connects to for the given parameters,
+// remote hosts and unix sockets must be allowed by the port forwarding configuration of the agent
+func resolveDestination(parameters PortParameters, config appconfig.PortForwardingCfg) (network string, address string, err error) {
+	if parameters.LocalUnixSocket != "" {
+		if parameters.Host != "" || parameters.PortNumber != "" {
+			return "", "", errors.New("Unix socket cannot be used together with host or port number in session properties")
+		}
+		return resolveUnixSocket(parameters.LocalUnixSocket, config.AllowedUnixSockets)
+	}
+
+	if parameters.PortNumber == "" {
+		return "", "", fmt.Errorf("Port number is empty in session properties. %v", parameters)
+	}
+	if parameters.Host == "" || strings.EqualFold(parameters.Host, localhost) {
+		return "tcp", net.JoinHostPort(localhost, parameters.PortNumber), nil
+	}
+	return resolveRemoteHost(parameters.Host, parameters.PortNumber, config.AllowedHosts)
+}
+
+// resolveUnixSocket returns the unix socket address if the path matches one of the allowed paths
+func resolveUnixSocket(path string, allowedPaths []string) (network string, address string, err error) {
+	if !filepath.IsAbs(path) {
+		return "", "", fmt.Errorf("Unix socket path %v is not absolute", path)
+	}
+	path = filepath.Clean(path)
+	for _, allowedPath := range allowedPaths {
+		if matched, _ := filepath.Match(allowedPath, path); matched {
+			return "unix", path, nil
+		}
+	}
+	return "", "", fmt.Errorf("Unix socket %v is not allowed by the port forwarding configuration of the agent", path)
+}
+
+// resolveRemoteHost returns the tcp address of the remote host if it matches one of the allowed hosts,
+// host names allowed by a CIDR block are resolved and the first address within the block is returned
+func resolveRemoteHost(host string, port string, allowedHosts []string) (network string, address string, err error) {
+	var resolvedIPs []net.IP
+	for _, allowedHost := range allowedHosts {
+		allowedName, allowedPort := splitAllowedHost(allowedHost)
+		if allowedPort != "" && allowedPort != port {
+			continue
+		}
+
+		if _, allowedNet, err := net.ParseCIDR(allowedName); err == nil {
+			if ip := net.ParseIP(host); ip != nil {
+				if allowedNet.Contains(ip) {
+					return "tcp", net.JoinHostPort(host, port), nil
+				}
+				continue
+			}
+			if resolvedIPs == nil {
+				if resolvedIPs, err = LookupIP(host); err != nil {
+					return "", "", fmt.Errorf("Unable to resolve host %v: %v", host, err)
+				}
+			}
+			for _, ip := range resolvedIPs {
+				if allowedNet.Contains(ip) {
+					return "tcp", net.JoinHostPort(ip.String(), port), nil
+				}
+			}
+			continue
+		}
+
+		if matchesHostName(allowedName, host) {
+			return "tcp", net.JoinHostPort(host, port), nil
+		}
+	}
+	return "", "", fmt.Errorf("Host %v and port %v are not allowed by the port forwarding configuration of the agent", host, port)
+}
+
+// splitAllowedHost splits the allowed host into the host and the optional port
+func splitAllowedHost(allowedHost string) (host string, port string) {
+	if host, port, err := net.SplitHostPort(allowedHost); err == nil {
+		return host, port
+	}
+	return allowedHost, ""
+}
+
+// matchesHostName checks whether the host equals the allowed name or address, or belongs to the domain of a *. wildcard
+func matchesHostName(allowedName string, host string) bool {
+	if allowedIP := net.ParseIP(allowedName); allowedIP != nil {
+		ip := net.ParseIP(host)
+		return ip != nil && allowedIP.Equal(ip)
+	}
+	allowedName = strings.ToLower(strings.TrimSuffix(allowedName, "."))
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	if strings.HasPrefix(allowedName, "*.") {
+		return strings.HasSuffix(host, allowedName[1:])
+	}
+	return allowedName == host
+}
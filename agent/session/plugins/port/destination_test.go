@@ -0,0 +1,112 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package port
+
+import (
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/stretchr/testify/assert"
+)
+
+var portForwardingConfig = appconfig.PortForwardingCfg{
+	AllowedHosts:       []string{"*.rds.amazonaws.com:5432", "db.internal", "10.0.0.0/16:3306", "192.168.1.10"},
+	AllowedUnixSockets: []string{"/var/run/docker.sock", "/var/run/postgresql/*"},
+}
+
+func TestResolveDestinationLocalhost(t *testing.T) {
+	network, address, err := resolveDestination(PortParameters{PortNumber: "22"}, appconfig.PortForwardingCfg{})
+	assert.NoError(t, err)
+	assert.Equal(t, "tcp", network)
+	assert.Equal(t, "localhost:22", address)
+
+	_, address, err = resolveDestination(PortParameters{PortNumber: "22", Host: "LOCALHOST"}, appconfig.PortForwardingCfg{})
+	assert.NoError(t, err)
+	assert.Equal(t, "localhost:22", address)
+
+	_, _, err = resolveDestination(PortParameters{}, appconfig.PortForwardingCfg{})
+	assert.Error(t, err)
+}
+
+func TestResolveDestinationUnixSocket(t *testing.T) {
+	network, address, err := resolveDestination(PortParameters{LocalUnixSocket: "/var/run/docker.sock"}, portForwardingConfig)
+	assert.NoError(t, err)
+	assert.Equal(t, "unix", network)
+	assert.Equal(t, "/var/run/docker.sock", address)
+
+	_, address, err = resolveDestination(PortParameters{LocalUnixSocket: "/var/run/postgresql/../postgresql/.s.PGSQL.5432"}, portForwardingConfig)
+	assert.NoError(t, err)
+	assert.Equal(t, "/var/run/postgresql/.s.PGSQL.5432", address)
+
+	for _, parameters := range []PortParameters{
+		{LocalUnixSocket: "/var/run/postgresql/../docker.sock.bak"},
+		{LocalUnixSocket: "var/run/docker.sock"},
+		{LocalUnixSocket: "/var/run/docker.sock", PortNumber: "22"},
+		{LocalUnixSocket: "/var/run/docker.sock", Host: "db.internal"},
+	} {
+		_, _, err = resolveDestination(parameters, portForwardingConfig)
+		assert.Error(t, err, parameters.LocalUnixSocket)
+	}
+
+	_, _, err = resolveDestination(PortParameters{LocalUnixSocket: "/var/run/docker.sock"}, appconfig.PortForwardingCfg{})
+	assert.Error(t, err)
+}
+
+func TestResolveDestinationRemoteHost(t *testing.T) {
+	LookupIP = func(host string) ([]net.IP, error) {
+		switch host {
+		case "app.internal":
+			return []net.IP{net.ParseIP("172.16.0.4"), net.ParseIP("10.0.3.7")}, nil
+		case "other.internal":
+			return []net.IP{net.ParseIP("172.16.0.5")}, nil
+		}
+		return nil, errors.New("no such host")
+	}
+	defer func() { LookupIP = func(host string) ([]net.IP, error) { return net.LookupIP(host) } }()
+
+	allowed := map[PortParameters]string{
+		{Host: "mydb.abc123.us-east-1.rds.amazonaws.com", PortNumber: "5432"}: "mydb.abc123.us-east-1.rds.amazonaws.com:5432",
+		{Host: "DB.internal.", PortNumber: "8080"}:                            "DB.internal.:8080",
+		{Host: "10.0.1.2", PortNumber: "3306"}:                                "10.0.1.2:3306",
+		{Host: "app.internal", PortNumber: "3306"}:                            "10.0.3.7:3306",
+		{Host: "192.168.1.10", PortNumber: "443"}:                             "192.168.1.10:443",
+	}
+	for parameters, expected := range allowed {
+		network, address, err := resolveDestination(parameters, portForwardingConfig)
+		assert.NoError(t, err, parameters.Host)
+		assert.Equal(t, "tcp", network)
+		assert.Equal(t, expected, address)
+	}
+
+	denied := []PortParameters{
+		{Host: "mydb.abc123.us-east-1.rds.amazonaws.com", PortNumber: "3306"},
+		{Host: "rds.amazonaws.com", PortNumber: "5432"},
+		{Host: "evil-rds.amazonaws.com.example.com", PortNumber: "5432"},
+		{Host: "db.internal.example.com", PortNumber: "8080"},
+		{Host: "10.1.0.1", PortNumber: "3306"},
+		{Host: "10.0.1.2", PortNumber: "22"},
+		{Host: "other.internal", PortNumber: "3306"},
+		{Host: "unknown.internal", PortNumber: "3306"},
+		{Host: "db.internal"},
+	}
+	for _, parameters := range denied {
+		_, _, err := resolveDestination(parameters, portForwardingConfig)
+		assert.Error(t, err, parameters.Host)
+	}
+
+	_, _, err := resolveDestination(PortParameters{Host: "db.internal", PortNumber: "8080"}, appconfig.PortForwardingCfg{})
+	assert.Error(t, err)
+}
@@ -0,0 +1,383 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package port
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	mgsConfig "github.com/aws/amazon-ssm-agent/agent/session/config"
+	mgsContracts "github.com/aws/amazon-ssm-agent/agent/session/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/session/datachannel"
+)
+
+// muxSession forwards the connections of a multiplexed port session, each connection opened by the client
+// is a stream identified by its stream ID and sharing the data channel with the other streams.
+type muxSession struct {
+	dataChannel datachannel.IDataChannel
+	network     string
+	address     string
+	sendLock    sync.Mutex
+	lock        sync.Mutex
+	streams     map[uint32]*muxStream
+	closed      bool
+}
+
+// muxStream is a connection of a multiplexed port session
+type muxStream struct {
+	id             uint32
+	conn           net.Conn
+	lock           sync.Mutex
+	cond           *sync.Cond
+	pending        [][]byte
+	sendWindow     int
+	receiveWindow  int
+	closed         bool
+	closedByClient bool
+}
+
+// newMuxSession returns a multiplexed session connecting the streams to the given address
+func newMuxSession(dataChannel datachannel.IDataChannel, network string, address string) *muxSession {
+	return &muxSession{
+		dataChannel: dataChannel,
+		network:     network,
+		address:     address,
+		streams:     make(map[uint32]*muxStream),
+	}
+}
+
+// newMuxStream returns a stream with the initial windows of both sides
+func newMuxStream(id uint32) *muxStream {
+	stream := &muxStream{
+		id:            id,
+		sendWindow:    mgsConfig.StreamInitialWindowSize,
+		receiveWindow: mgsConfig.StreamInitialWindowSize,
+	}
+	stream.cond = sync.NewCond(&stream.lock)
+	return stream
+}
+
+// handleFrame processes a StreamFrame payload received from the client
+func (s *muxSession) handleFrame(log log.T, payload []byte) error {
+	if len(payload) < mgsConfig.StreamFrameHeaderSize {
+		return fmt.Errorf("Stream frame of %d bytes is shorter than the frame header", len(payload))
+	}
+	frameType := mgsContracts.StreamFrameType(binary.BigEndian.Uint32(payload[0:4]))
+	streamId := binary.BigEndian.Uint32(payload[4:8])
+	data := payload[mgsConfig.StreamFrameHeaderSize:]
+
+	if frameType == mgsContracts.StreamOpen {
+		return s.openStream(log, streamId)
+	}
+
+	stream := s.getStream(streamId)
+	if stream == nil {
+		log.Debugf("Ignoring frame %d of stream %d which is not open", frameType, streamId)
+		return nil
+	}
+
+	switch frameType {
+	case mgsContracts.StreamData:
+		if err := stream.enqueue(data); err != nil {
+			log.Errorf("Closing stream %d: %v", streamId, err)
+			s.closeStream(log, stream, true)
+		}
+	case mgsContracts.StreamClose:
+		log.Debugf("Stream %d closed by client", streamId)
+		stream.closeByClient()
+	case mgsContracts.StreamWindowUpdate:
+		if len(data) < 4 {
+			return fmt.Errorf("Window update of stream %d is missing the window increment", streamId)
+		}
+		if err := stream.grant(binary.BigEndian.Uint32(data[0:4])); err != nil {
+			log.Errorf("Closing stream %d: %v", streamId, err)
+			s.closeStream(log, stream, true)
+		}
+	default:
+		log.Warnf("Ignoring unknown frame type %d of stream %d", frameType, streamId)
+	}
+	return nil
+}
+
+// openStream registers the stream and connects it to the destination of the session in the background
+func (s *muxSession) openStream(log log.T, streamId uint32) error {
+	s.lock.Lock()
+	if s.closed {
+		s.lock.Unlock()
+		return nil
+	}
+	if _, exists := s.streams[streamId]; exists {
+		s.lock.Unlock()
+		return fmt.Errorf("Stream %d is already open", streamId)
+	}
+	if len(s.streams) >= mgsConfig.MaxStreamsPerSession {
+		s.lock.Unlock()
+		log.Warnf("Rejecting stream %d as %d streams are already open", streamId, mgsConfig.MaxStreamsPerSession)
+		return s.sendFrame(log, mgsContracts.StreamClose, streamId, nil)
+	}
+	stream := newMuxStream(streamId)
+	s.streams[streamId] = stream
+	s.lock.Unlock()
+
+	log.Debugf("Opening stream %d to %s", streamId, s.address)
+	go s.runStream(log, stream)
+	return nil
+}
+
+// runStream connects the stream to the destination and copies data in both directions until either side closes it
+func (s *muxSession) runStream(log log.T, stream *muxStream) {
+	defer func() {
+		if err := recover(); err != nil {
+			log.Errorf("Stream %d crashed with message: %v", stream.id, err)
+		}
+	}()
+
+	conn, err := DialCall(s.network, s.address)
+	if err != nil {
+		log.Errorf("Unable to connect stream %d to %s: %v", stream.id, s.address, err)
+		s.closeStream(log, stream, true)
+		return
+	}
+	if !stream.attach(conn) {
+		conn.Close()
+		s.closeStream(log, stream, false)
+		return
+	}
+
+	go s.writeToConn(log, stream)
+	s.readFromConn(log, stream)
+}
+
+// readFromConn reads from the connection of the stream and sends data frames within the window granted by the client
+func (s *muxSession) readFromConn(log log.T, stream *muxStream) {
+	packet := make([]byte, mgsConfig.StreamDataPayloadSize-mgsConfig.StreamFrameHeaderSize)
+	for {
+		size := stream.acquire(len(packet))
+		if size == 0 {
+			break
+		}
+		numBytes, err := stream.conn.Read(packet[:size])
+		if numBytes > 0 {
+			stream.consume(numBytes)
+			if sendErr := s.sendFrame(log, mgsContracts.StreamData, stream.id, packet[:numBytes]); sendErr != nil {
+				log.Errorf("Unable to send data of stream %d: %v", stream.id, sendErr)
+				break
+			}
+		}
+		if err != nil {
+			log.Debugf("Connection of stream %d closed: %v", stream.id, err)
+			break
+		}
+	}
+	s.closeStream(log, stream, true)
+}
+
+// writeToConn writes the data received from the client to the connection of the stream and grants the client more window
+func (s *muxSession) writeToConn(log log.T, stream *muxStream) {
+	unacknowledged := 0
+	for {
+		data, more := stream.dequeue()
+		if data == nil {
+			break
+		}
+		if _, err := stream.conn.Write(data); err != nil {
+			log.Debugf("Unable to write to connection of stream %d: %v", stream.id, err)
+			s.closeStream(log, stream, true)
+			return
+		}
+		unacknowledged += len(data)
+		if unacknowledged >= mgsConfig.StreamWindowUpdateThreshold || !more {
+			increment := make([]byte, 4)
+			binary.BigEndian.PutUint32(increment, uint32(unacknowledged))
+			stream.release(unacknowledged)
+			unacknowledged = 0
+			if err := s.sendFrame(log, mgsContracts.StreamWindowUpdate, stream.id, increment); err != nil {
+				log.Errorf("Unable to send window update of stream %d: %v", stream.id, err)
+			}
+		}
+	}
+	// The client closed the stream and all its data has been written
+	s.closeStream(log, stream, false)
+}
+
+// closeStream closes the connection of the stream and removes it from the session,
+// the client is notified unless it closed the stream itself
+func (s *muxSession) closeStream(log log.T, stream *muxStream, notifyClient bool) {
+	s.lock.Lock()
+	if s.streams[stream.id] == stream {
+		delete(s.streams, stream.id)
+	} else {
+		notifyClient = false
+	}
+	s.lock.Unlock()
+
+	if stream.close() && notifyClient {
+		if err := s.sendFrame(log, mgsContracts.StreamClose, stream.id, nil); err != nil {
+			log.Errorf("Unable to send close of stream %d: %v", stream.id, err)
+		}
+	}
+}
+
+// stop closes all the streams of the session
+func (s *muxSession) stop(log log.T) {
+	s.lock.Lock()
+	s.closed = true
+	streams := s.streams
+	s.streams = make(map[uint32]*muxStream)
+	s.lock.Unlock()
+
+	log.Debugf("Closing %d streams", len(streams))
+	for _, stream := range streams {
+		stream.close()
+	}
+}
+
+// getStream returns the open stream with the given ID
+func (s *muxSession) getStream(streamId uint32) *muxStream {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.streams[streamId]
+}
+
+// streamCount returns the number of open streams
+func (s *muxSession) streamCount() int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return len(s.streams)
+}
+
+// sendFrame sends a frame to the client, the sends of all streams are serialized on the data channel
+func (s *muxSession) sendFrame(log log.T, frameType mgsContracts.StreamFrameType, streamId uint32, data []byte) error {
+	frame := make([]byte, mgsConfig.StreamFrameHeaderSize+len(data))
+	binary.BigEndian.PutUint32(frame[0:4], uint32(frameType))
+	binary.BigEndian.PutUint32(frame[4:8], streamId)
+	copy(frame[mgsConfig.StreamFrameHeaderSize:], data)
+
+	s.sendLock.Lock()
+	defer s.sendLock.Unlock()
+	return s.dataChannel.SendStreamDataMessage(log, mgsContracts.StreamFrame, frame)
+}
+
+// attach sets the connection of the stream, it returns false when the stream was closed while connecting
+func (stream *muxStream) attach(conn net.Conn) bool {
+	stream.lock.Lock()
+	defer stream.lock.Unlock()
+	if stream.closed {
+		return false
+	}
+	stream.conn = conn
+	return true
+}
+
+// enqueue queues the data received from the client, which must not exceed the window granted to the client
+func (stream *muxStream) enqueue(data []byte) error {
+	stream.lock.Lock()
+	defer stream.lock.Unlock()
+	if stream.closed || stream.closedByClient {
+		return nil
+	}
+	if len(data) > stream.receiveWindow {
+		return errors.New("client exceeded the stream window")
+	}
+	stream.receiveWindow -= len(data)
+	stream.pending = append(stream.pending, append([]byte{}, data...))
+	stream.cond.Broadcast()
+	return nil
+}
+
+// dequeue waits for data received from the client, it returns nil when the stream is closed
+// and whether more data is already queued
+func (stream *muxStream) dequeue() (data []byte, more bool) {
+	stream.lock.Lock()
+	defer stream.lock.Unlock()
+	for len(stream.pending) == 0 && !stream.closed && !stream.closedByClient {
+		stream.cond.Wait()
+	}
+	if stream.closed || len(stream.pending) == 0 {
+		return nil, false
+	}
+	data = stream.pending[0]
+	stream.pending = stream.pending[1:]
+	return data, len(stream.pending) > 0
+}
+
+// release gives back the window of the data written to the connection
+func (stream *muxStream) release(size int) {
+	stream.lock.Lock()
+	defer stream.lock.Unlock()
+	stream.receiveWindow += size
+}
+
+// acquire waits for window granted by the client and returns the number of bytes which may be sent, 0 once closed
+func (stream *muxStream) acquire(size int) int {
+	stream.lock.Lock()
+	defer stream.lock.Unlock()
+	for stream.sendWindow <= 0 && !stream.closed {
+		stream.cond.Wait()
+	}
+	if stream.closed {
+		return 0
+	}
+	if size > stream.sendWindow {
+		size = stream.sendWindow
+	}
+	return size
+}
+
+// consume deducts the bytes sent to the client from the window
+func (stream *muxStream) consume(size int) {
+	stream.lock.Lock()
+	defer stream.lock.Unlock()
+	stream.sendWindow -= size
+}
+
+// grant adds the window update received from the client, it fails when the window would exceed StreamMaxWindowSize
+func (stream *muxStream) grant(increment uint32) error {
+	stream.lock.Lock()
+	defer stream.lock.Unlock()
+	if int64(stream.sendWindow)+int64(increment) > mgsConfig.StreamMaxWindowSize {
+		return fmt.Errorf("client window update of %d bytes exceeds the maximum stream window", increment)
+	}
+	stream.sendWindow += int(increment)
+	stream.cond.Broadcast()
+	return nil
+}
+
+// closeByClient stops accepting data from the client, the connection is closed once the queued data is written
+func (stream *muxStream) closeByClient() {
+	stream.lock.Lock()
+	defer stream.lock.Unlock()
+	stream.closedByClient = true
+	stream.cond.Broadcast()
+}
+
+// close closes the connection of the stream, it returns false if the stream was already closed
+func (stream *muxStream) close() bool {
+	stream.lock.Lock()
+	defer stream.lock.Unlock()
+	if stream.closed {
+		return false
+	}
+	stream.closed = true
+	stream.pending = nil
+	if stream.conn != nil {
+		stream.conn.Close()
+	}
+	stream.cond.Broadcast()
+	return true
+}
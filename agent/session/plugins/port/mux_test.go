@@ -0,0 +1,228 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package port
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	mgsConfig "github.com/aws/amazon-ssm-agent/agent/session/config"
+	mgsContracts "github.com/aws/amazon-ssm-agent/agent/session/contracts"
+	dataChannelMock "github.com/aws/amazon-ssm-agent/agent/session/datachannel/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type frame struct {
+	frameType mgsContracts.StreamFrameType
+	streamId  uint32
+	data      []byte
+}
+
+// newTestMuxSession returns a session whose frames sent to the client are delivered on the returned channel
+func newTestMuxSession() (*muxSession, chan frame) {
+	frames := make(chan frame, 100)
+	dataChannel := &dataChannelMock.IDataChannel{}
+	dataChannel.On("SendStreamDataMessage", mock.Anything, mgsContracts.StreamFrame, mock.Anything).Return(nil).Run(
+		func(args mock.Arguments) {
+			payload := args.Get(2).([]byte)
+			frames <- frame{
+				frameType: mgsContracts.StreamFrameType(binary.BigEndian.Uint32(payload[0:4])),
+				streamId:  binary.BigEndian.Uint32(payload[4:8]),
+				data:      payload[mgsConfig.StreamFrameHeaderSize:],
+			}
+		})
+	return newMuxSession(dataChannel, "tcp", "localhost:80"), frames
+}
+
+func encodeFrame(frameType mgsContracts.StreamFrameType, streamId uint32, data []byte) []byte {
+	payload := make([]byte, mgsConfig.StreamFrameHeaderSize+len(data))
+	binary.BigEndian.PutUint32(payload[0:4], uint32(frameType))
+	binary.BigEndian.PutUint32(payload[4:8], streamId)
+	copy(payload[mgsConfig.StreamFrameHeaderSize:], data)
+	return payload
+}
+
+func nextFrame(t *testing.T, frames chan frame) frame {
+	select {
+	case f := <-frames:
+		return f
+	case <-time.After(2 * time.Second):
+		t.Fatal("no frame sent to the client")
+		return frame{}
+	}
+}
+
+func TestMuxStreamsShareSession(t *testing.T) {
+	logger := log.NewMockLog()
+	session, frames := newTestMuxSession()
+	servers := make(chan net.Conn, 2)
+	DialCall = func(network string, address string) (net.Conn, error) {
+		client, server := net.Pipe()
+		servers <- server
+		return client, nil
+	}
+
+	assert.NoError(t, session.handleFrame(logger, encodeFrame(mgsContracts.StreamOpen, 1, nil)))
+	first := <-servers
+	assert.NoError(t, session.handleFrame(logger, encodeFrame(mgsContracts.StreamOpen, 2, nil)))
+	second := <-servers
+	assert.Equal(t, 2, session.streamCount())
+
+	assert.NoError(t, session.handleFrame(logger, encodeFrame(mgsContracts.StreamData, 2, []byte("GET /b"))))
+	assert.NoError(t, session.handleFrame(logger, encodeFrame(mgsContracts.StreamData, 1, []byte("GET /a"))))
+
+	buffer := make([]byte, 100)
+	n, _ := first.Read(buffer)
+	assert.Equal(t, "GET /a", string(buffer[:n]))
+	n, _ = second.Read(buffer)
+	assert.Equal(t, "GET /b", string(buffer[:n]))
+
+	windowUpdates := map[uint32]uint32{}
+	for i := 0; i < 2; i++ {
+		f := nextFrame(t, frames)
+		assert.Equal(t, mgsContracts.StreamWindowUpdate, f.frameType)
+		windowUpdates[f.streamId] = binary.BigEndian.Uint32(f.data)
+	}
+	assert.Equal(t, map[uint32]uint32{1: 6, 2: 6}, windowUpdates)
+
+	go first.Write([]byte("page a"))
+	f := nextFrame(t, frames)
+	assert.Equal(t, frame{mgsContracts.StreamData, 1, []byte("page a")}, f)
+
+	first.Close()
+	f = nextFrame(t, frames)
+	assert.Equal(t, mgsContracts.StreamClose, f.frameType)
+	assert.Equal(t, uint32(1), f.streamId)
+	assert.Equal(t, 1, session.streamCount())
+
+	session.stop(logger)
+	_, err := second.Read(buffer)
+	assert.Equal(t, io.EOF, err)
+	assert.Equal(t, 0, session.streamCount())
+}
+
+func TestMuxStreamDialFailure(t *testing.T) {
+	logger := log.NewMockLog()
+	session, frames := newTestMuxSession()
+	DialCall = func(network string, address string) (net.Conn, error) {
+		return nil, errors.New("connection refused")
+	}
+
+	assert.NoError(t, session.handleFrame(logger, encodeFrame(mgsContracts.StreamOpen, 7, nil)))
+	f := nextFrame(t, frames)
+	assert.Equal(t, mgsContracts.StreamClose, f.frameType)
+	assert.Equal(t, uint32(7), f.streamId)
+	assert.Equal(t, 0, session.streamCount())
+}
+
+func TestMuxStreamClosedByClientFlushesData(t *testing.T) {
+	logger := log.NewMockLog()
+	session, _ := newTestMuxSession()
+	client, server := net.Pipe()
+	DialCall = func(network string, address string) (net.Conn, error) {
+		return client, nil
+	}
+
+	assert.NoError(t, session.handleFrame(logger, encodeFrame(mgsContracts.StreamOpen, 3, nil)))
+	assert.NoError(t, session.handleFrame(logger, encodeFrame(mgsContracts.StreamData, 3, []byte("last bytes"))))
+	assert.NoError(t, session.handleFrame(logger, encodeFrame(mgsContracts.StreamClose, 3, nil)))
+
+	received, err := ioutil.ReadAll(server)
+	assert.NoError(t, err)
+	assert.Equal(t, "last bytes", string(received))
+}
+
+func TestMuxStreamRejectsFrames(t *testing.T) {
+	logger := log.NewMockLog()
+	session, frames := newTestMuxSession()
+
+	assert.Error(t, session.handleFrame(logger, []byte{0, 0, 0, 1}))
+	assert.NoError(t, session.handleFrame(logger, encodeFrame(mgsContracts.StreamData, 9, []byte("unknown stream"))))
+
+	session.streams[1] = newMuxStream(1)
+	assert.Error(t, session.handleFrame(logger, encodeFrame(mgsContracts.StreamOpen, 1, nil)))
+
+	for id := uint32(2); len(session.streams) < mgsConfig.MaxStreamsPerSession; id++ {
+		session.streams[id] = newMuxStream(id)
+	}
+	assert.NoError(t, session.handleFrame(logger, encodeFrame(mgsContracts.StreamOpen, 1000, nil)))
+	assert.Equal(t, frame{mgsContracts.StreamClose, 1000, []byte{}}, nextFrame(t, frames))
+}
+
+func TestMuxStreamWindows(t *testing.T) {
+	stream := newMuxStream(1)
+
+	assert.NoError(t, stream.enqueue(make([]byte, mgsConfig.StreamInitialWindowSize)))
+	assert.Error(t, stream.enqueue([]byte("over the window")))
+	stream.release(10)
+	assert.NoError(t, stream.enqueue(make([]byte, 10)))
+
+	assert.Equal(t, 100, stream.acquire(100))
+	stream.consume(mgsConfig.StreamInitialWindowSize)
+
+	acquired := make(chan int)
+	go func() {
+		acquired <- stream.acquire(100)
+	}()
+	select {
+	case <-acquired:
+		t.Fatal("acquired bytes without window")
+	case <-time.After(20 * time.Millisecond):
+	}
+	assert.NoError(t, stream.grant(50))
+	assert.Equal(t, 50, <-acquired)
+
+	stream.consume(50)
+	go func() {
+		acquired <- stream.acquire(100)
+	}()
+	stream.close()
+	assert.Equal(t, 0, <-acquired)
+}
+
+func TestMuxStreamRejectsWindowOverflow(t *testing.T) {
+	stream := newMuxStream(1)
+
+	// an increment above the maximum window is rejected whatever the size of int
+	assert.Error(t, stream.grant(0xFFFFFFFF))
+	assert.Equal(t, mgsConfig.StreamInitialWindowSize, stream.acquire(mgsConfig.StreamInitialWindowSize))
+
+	// repeated updates may fill the window up to the maximum but not beyond it
+	assert.NoError(t, stream.grant(mgsConfig.StreamMaxWindowSize-mgsConfig.StreamInitialWindowSize))
+	assert.Error(t, stream.grant(1))
+	assert.Equal(t, mgsConfig.StreamMaxWindowSize, stream.acquire(mgsConfig.StreamMaxWindowSize))
+}
+
+func TestMuxStreamClosedOnWindowOverflow(t *testing.T) {
+	logger := log.NewMockLog()
+	session, frames := newTestMuxSession()
+	stream := newMuxStream(1)
+	session.streams[1] = stream
+
+	increment := make([]byte, 4)
+	binary.BigEndian.PutUint32(increment, 0xFFFFFFFF)
+	assert.NoError(t, session.handleFrame(logger, encodeFrame(mgsContracts.StreamWindowUpdate, 1, increment)))
+
+	// the stream violating the flow control is closed and the client notified
+	assert.Equal(t, frame{mgsContracts.StreamClose, 1, []byte{}}, nextFrame(t, frames))
+	assert.Nil(t, session.getStream(1))
+	assert.Equal(t, 0, stream.acquire(1))
+}
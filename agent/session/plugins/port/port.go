@@ -43,8 +43,10 @@ var DialCall = func(network string, address string) (net.Conn, error) {
 
 // PortParameters contains inputs required to execute port plugin.
 type PortParameters struct {
-	PortNumber string `json:"portNumber" yaml:"portNumber"`
-	Type       string `json:"type"`
+	PortNumber      string `json:"portNumber" yaml:"portNumber"`
+	Host            string `json:"host" yaml:"host"`
+	LocalUnixSocket string `json:"localUnixSocket" yaml:"localUnixSocket"`
+	Type            string `json:"type"`
 }
 
 // Plugin is the type for the port plugin.
@@ -53,6 +55,9 @@ type PortPlugin struct {
 	dataChannel        datachannel.IDataChannel
 	portNumber         string
 	portType           string
+	network            string
+	address            string
+	mux                *muxSession
 	reconnectToPort    bool
 	reconnectToPortErr chan (error)
 	cancelled          chan bool
@@ -134,7 +139,10 @@ func (p *PortPlugin) execute(context context.T,
 		return
 	}
 
-	if err = p.startTCPConn(log); err != nil {
+	if p.portType == mgsConfig.MultiplexedPortForwarding {
+		log.Debugf("Forwarding multiplexed connections to %s", p.address)
+		p.mux = newMuxSession(p.dataChannel, p.network, p.address)
+	} else if err = p.startTCPConn(log); err != nil {
 		log.Error(err)
 		output.SetExitCode(appconfig.ErrorExitCode)
 		output.SetStatus(agentContracts.ResultStatusFailed)
@@ -152,11 +160,13 @@ func (p *PortPlugin) execute(context context.T,
 		log.Debugf("Cancel flag set to %v in session", cancelState)
 	}()
 
-	log.Debugf("Start separate go routine to read from port connection and write to data channel")
 	done := make(chan int, 1)
-	go func() {
-		done <- p.writePump(log)
-	}()
+	if p.mux == nil {
+		log.Debugf("Start separate go routine to read from port connection and write to data channel")
+		go func() {
+			done <- p.writePump(log)
+		}()
+	}
 
 	log.Infof("Plugin %s started", p.name())
 
@@ -186,7 +196,7 @@ func (p *PortPlugin) execute(context context.T,
 
 // InputStreamMessageHandler passes payload byte stream to port
 func (p *PortPlugin) InputStreamMessageHandler(log log.T, streamDataMessage mgsContracts.AgentMessage) error {
-	if p.tcpConn == nil {
+	if p.tcpConn == nil && p.mux == nil {
 		// This is to handle scenario when cli/console starts sending data but port has not been opened yet
 		// Since packets are rejected, cli/console will resend these packets until tcp starts successfully in separate thread
 		log.Tracef("TCP connection unavailable. Reject incoming message packet")
@@ -194,14 +204,24 @@ func (p *PortPlugin) InputStreamMessageHandler(log log.T, streamDataMessage mgsC
 	}
 
 	switch mgsContracts.PayloadType(streamDataMessage.PayloadType) {
+	case mgsContracts.StreamFrame:
+		if p.mux == nil {
+			log.Warnf("Ignoring stream frame received in a session which is not multiplexed: %d", streamDataMessage.SequenceNumber)
+			return nil
+		}
+		return p.mux.handleFrame(log, streamDataMessage.Payload)
 	case mgsContracts.Output:
 		log.Tracef("Output message received: %d", streamDataMessage.SequenceNumber)
+		if p.mux != nil {
+			log.Warnf("Ignoring output message received in a multiplexed session: %d", streamDataMessage.SequenceNumber)
+			return nil
+		}
 
 		if p.reconnectToPort {
-			log.Debugf("Reconnect to port: %s", p.portNumber)
+			log.Debugf("Reconnect to %s", p.address)
 			err := p.startTCPConn(log)
 
-			// Pass err to reconnectToPortErr chan to unblock writePump go routine to resume reading from localhost:p.portNumber
+			// Pass err to reconnectToPortErr chan to unblock writePump go routine to resume reading from p.address
 			p.reconnectToPortErr <- err
 			if err != nil {
 				return err
@@ -223,8 +243,11 @@ func (p *PortPlugin) InputStreamMessageHandler(log log.T, streamDataMessage mgsC
 		case mgsContracts.DisconnectToPort:
 			// DisconnectToPort flag is sent by client when tcp connection on client side is closed.
 			// In this case agent should also close tcp connection with server and wait for new data from client to reconnect.
+			// Multiplexed sessions close each connection with its own StreamClose frame instead.
 			log.Debugf("DisconnectToPort flag received: %d", streamDataMessage.SequenceNumber)
-			p.stop(log)
+			if p.mux == nil {
+				p.stop(log)
+			}
 		case mgsContracts.TerminateSession:
 			log.Debugf("TerminateSession flag received: %d", streamDataMessage.SequenceNumber)
 			p.cancelled <- true
@@ -235,6 +258,9 @@ func (p *PortPlugin) InputStreamMessageHandler(log log.T, streamDataMessage mgsC
 
 // Stop closes the TCP Connection to the instance
 func (p *PortPlugin) stop(log log.T) {
+	if p.mux != nil {
+		p.mux.stop(log)
+	}
 	if p.tcpConn != nil {
 		log.Debug("Closing TCP connection")
 		if err := p.tcpConn.Close(); err != nil {
@@ -258,7 +284,7 @@ func (p *PortPlugin) writePump(log log.T) (errorCode int) {
 		if err != nil {
 			var exitCode int
 			if exitCode = p.handleTCPReadError(log, err); exitCode == mgsConfig.ResumeReadExitCode {
-				log.Debugf("Reconnection to port %v is successful, resume reading from port.", p.portNumber)
+				log.Debugf("Reconnection to %v is successful, resume reading from connection.", p.address)
 				continue
 			}
 			return exitCode
@@ -276,7 +302,7 @@ func (p *PortPlugin) writePump(log log.T) (errorCode int) {
 // handleTCPReadError handles TCP read error
 func (p *PortPlugin) handleTCPReadError(log log.T, err error) int {
 	if p.portType == mgsConfig.LocalPortForwarding {
-		log.Debugf("Initiating reconnection to port %s as existing connection resulted in read error: %v", p.portNumber, err)
+		log.Debugf("Initiating reconnection to %s as existing connection resulted in read error: %v", p.address, err)
 		return p.handlePortError(log, err)
 	}
 	return p.handleSSHDPortError(log, err)
@@ -295,10 +321,10 @@ func (p *PortPlugin) handleSSHDPortError(log log.T, err error) int {
 
 // handlePortError handles error by initiating reconnection to port in case of read failure
 func (p *PortPlugin) handlePortError(log log.T, err error) int {
-	// Read from tcp connection to localhost:p.portNumber resulted in error. Close existing connection and
+	// Read from tcp connection to p.address resulted in error. Close existing connection and
 	// set reconnectToPort to true. ReconnectToPort is used when new steam data message arrives on
-	// web socket channel to trigger reconnection to localhost:p.portNumber.
-	log.Debugf("Encountered error while reading from port %v, %v", p.portNumber, err)
+	// web socket channel to trigger reconnection to p.address.
+	log.Debugf("Encountered error while reading from %v, %v", p.address, err)
 	p.stop(log)
 	p.reconnectToPort = true
 
@@ -314,9 +340,10 @@ func (p *PortPlugin) handlePortError(log log.T, err error) int {
 	return mgsConfig.ResumeReadExitCode
 }
 
-// startTCPConn starts TCP connection to the specified port
+// startTCPConn starts the connection to the specified port or unix socket
 func (p *PortPlugin) startTCPConn(log log.T) (err error) {
-	if p.tcpConn, err = DialCall("tcp", "localhost:"+p.portNumber); err != nil {
+	log.Debugf("Connecting to %s over %s", p.address, p.network)
+	if p.tcpConn, err = DialCall(p.network, p.address); err != nil {
 		return errors.New(fmt.Sprintf("Unable to connect to specified port: %v", err))
 	}
 
@@ -330,8 +357,9 @@ func (p *PortPlugin) initializeParameters(log log.T, parameters interface{}) (er
 		return errors.New(fmt.Sprintf("Unable to remarshal session properties. %v", err))
 	}
 
-	if portParameters.PortNumber == "" {
-		return errors.New(fmt.Sprintf("Port number is empty in session properties. %v", parameters))
+	appConfig, _ := appconfig.Config(false)
+	if p.network, p.address, err = resolveDestination(portParameters, appConfig.PortForwarding); err != nil {
+		return err
 	}
 	p.portNumber = portParameters.PortNumber
 	p.portType = portParameters.Type
@@ -142,6 +142,30 @@ func (suite *PortTestSuite) TestExecuteWithInvalidPortNumber() {
 	suite.mockIohandler.AssertExpectations(suite.T())
 }
 
+func (suite *PortTestSuite) TestExecuteWithRemoteHostNotAllowed() {
+	suite.mockCancelFlag.On("Canceled").Return(false)
+	suite.mockCancelFlag.On("ShutDown").Return(false)
+	suite.mockIohandler.On("SetStatus", contracts.ResultStatusFailed).Return(nil)
+	suite.mockIohandler.On("SetExitCode", 1).Return(nil)
+	suite.mockIohandler.On("SetOutput", mock.Anything).Return()
+
+	dialed := false
+	DialCall = func(network string, address string) (net.Conn, error) {
+		dialed = true
+		return nil, errors.New("unexpected connection")
+	}
+
+	suite.plugin.Execute(suite.mockContext,
+		contracts.Configuration{Properties: map[string]interface{}{"portNumber": "5432", "host": "db.example.com"}},
+		suite.mockCancelFlag,
+		suite.mockIohandler,
+		suite.mockDataChannel)
+
+	assert.False(suite.T(), dialed)
+	suite.mockCancelFlag.AssertExpectations(suite.T())
+	suite.mockIohandler.AssertExpectations(suite.T())
+}
+
 func (suite *PortTestSuite) TestExecuteUnableToStartTCP() {
 	suite.mockCancelFlag.On("Canceled").Return(false)
 	suite.mockCancelFlag.On("ShutDown").Return(false)
@@ -174,7 +198,9 @@ func (suite *PortTestSuite) TestExecute() {
 	suite.mockDataChannel.On("SendStreamDataMessage", mock.Anything, mgsContracts.Output, payload).Return(nil)
 
 	out, in := net.Pipe()
+	var dialedNetwork, dialedAddress string
 	DialCall = func(network string, address string) (net.Conn, error) {
+		dialedNetwork, dialedAddress = network, address
 		return out, nil
 	}
 
@@ -191,12 +217,66 @@ func (suite *PortTestSuite) TestExecute() {
 		suite.mockDataChannel)
 
 	assert.Equal(suite.T(), "22", suite.plugin.portNumber)
+	assert.Equal(suite.T(), "tcp", dialedNetwork)
+	assert.Equal(suite.T(), "localhost:22", dialedAddress)
 	assert.Equal(suite.T(), false, suite.plugin.reconnectToPort)
 	suite.mockCancelFlag.AssertExpectations(suite.T())
 	suite.mockIohandler.AssertExpectations(suite.T())
 	suite.mockDataChannel.AssertExpectations(suite.T())
 }
 
+func (suite *PortTestSuite) TestExecuteMultiplexed() {
+	suite.mockCancelFlag.On("Canceled").Return(false)
+	suite.mockCancelFlag.On("ShutDown").Return(false)
+	suite.mockCancelFlag.On("Wait").Return(task.Completed)
+	suite.mockIohandler.On("SetExitCode", 0).Return(nil)
+	suite.mockIohandler.On("SetStatus", contracts.ResultStatusSuccess).Return()
+
+	dialed := false
+	DialCall = func(network string, address string) (net.Conn, error) {
+		dialed = true
+		return nil, errors.New("unexpected connection")
+	}
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		suite.plugin.cancelled <- true
+	}()
+
+	suite.plugin.Execute(suite.mockContext,
+		contracts.Configuration{Properties: map[string]interface{}{"portNumber": "80", "type": mgsConfig.MultiplexedPortForwarding}},
+		suite.mockCancelFlag,
+		suite.mockIohandler,
+		suite.mockDataChannel)
+
+	assert.NotNil(suite.T(), suite.plugin.mux)
+	assert.Equal(suite.T(), "localhost:80", suite.plugin.mux.address)
+	assert.False(suite.T(), dialed)
+	suite.mockIohandler.AssertExpectations(suite.T())
+}
+
+// Testing InputStreamHandler in a multiplexed session
+func (suite *PortTestSuite) TestInputStreamHandlerWithStreamFrame() {
+	suite.mockDataChannel.On("SendStreamDataMessage", mock.Anything, mgsContracts.StreamFrame, mock.Anything).Return(nil)
+	suite.plugin.mux = newMuxSession(suite.mockDataChannel, "tcp", "localhost:80")
+
+	out, in := net.Pipe()
+	defer out.Close()
+	DialCall = func(network string, address string) (net.Conn, error) {
+		return in, nil
+	}
+
+	open := []byte{0, 0, 0, byte(mgsContracts.StreamOpen), 0, 0, 0, 5}
+	data := append([]byte{0, 0, 0, byte(mgsContracts.StreamData), 0, 0, 0, 5}, payload...)
+	assert.NoError(suite.T(), suite.plugin.InputStreamMessageHandler(suite.mockLog, getAgentMessage(uint32(mgsContracts.StreamFrame), open)))
+	assert.NoError(suite.T(), suite.plugin.InputStreamMessageHandler(suite.mockLog, getAgentMessage(uint32(mgsContracts.StreamFrame), data)))
+
+	output := make([]byte, 100)
+	n, _ := out.Read(output)
+	assert.Equal(suite.T(), payload, output[:n])
+	suite.plugin.stop(suite.mockLog)
+}
+
 // Testing writepump separately
 func (suite *PortTestSuite) TestWritePump() {
 	suite.mockDataChannel.On("SendStreamDataMessage", suite.mockLog, mgsContracts.Output, payload).Return(nil)
@@ -27,6 +27,7 @@ import (
 	mgsContracts "github.com/aws/amazon-ssm-agent/agent/session/contracts"
 	"github.com/aws/amazon-ssm-agent/agent/session/datachannel"
 	"github.com/aws/amazon-ssm-agent/agent/session/retry"
+	"github.com/aws/amazon-ssm-agent/agent/session/shell"
 	"github.com/aws/amazon-ssm-agent/agent/task"
 )
 
@@ -57,10 +58,28 @@ func (p *SessionPlugin) Execute(context context.T,
 	cancelFlag task.CancelFlag,
 	output iohandler.IOHandler) {
 
+	// The session user mapping file may deny the principal whatever the type of the session
+	if err := shell.AuthorizeSessionPrincipal(context.Log(), context.AppConfig().Mgs.SessionUserMappingFile, config); err != nil {
+		errorString := fmt.Errorf("Session %s rejected: %s", config.SessionId, err)
+		output.MarkAsFailed(errorString)
+		context.Log().Error(errorString)
+		return
+	}
+
+	// Input stream data resets the idle timeout of the session
+	inputStreamMessageHandler := p.sessionPlugin.InputStreamMessageHandler
+	timeout := newSessionTimeout(context.AppConfig().Mgs)
+	if timeout != nil {
+		inputStreamMessageHandler = func(log log.T, streamDataMessage mgsContracts.AgentMessage) error {
+			timeout.recordActivity()
+			return p.sessionPlugin.InputStreamMessageHandler(log, streamDataMessage)
+		}
+	}
+
 	log := context.Log()
 	kmsKeyId := config.KmsKeyId
 
-	dataChannel, err := getDataChannelForSessionPlugin(context, config.SessionId, config.ClientId, cancelFlag, p.sessionPlugin.InputStreamMessageHandler)
+	dataChannel, err := getDataChannelForSessionPlugin(context, config.SessionId, config.ClientId, cancelFlag, inputStreamMessageHandler)
 	if err != nil {
 		errorString := fmt.Errorf("Setting up data channel with id %s failed: %s", config.SessionId, err)
 		output.MarkAsFailed(errorString)
@@ -89,7 +108,18 @@ func (p *SessionPlugin) Execute(context context.T,
 		dataChannel.SkipHandshake(log)
 	}
 
-	p.sessionPlugin.Execute(context, config, cancelFlag, output, dataChannel)
+	pluginDataChannel := dataChannel
+	if timeout != nil {
+		var stopTimeout func()
+		cancelFlag, stopTimeout = timeout.enforce(log, cancelFlag, dataChannel, p.sessionPlugin)
+		defer stopTimeout()
+		// the traffic forwarded to the client keeps port sessions active as well
+		if config.PluginName == appconfig.PluginNamePort {
+			pluginDataChannel = &activityRecordingDataChannel{IDataChannel: dataChannel, recordActivity: timeout.recordActivity}
+		}
+	}
+
+	p.sessionPlugin.Execute(context, config, cancelFlag, output, pluginDataChannel)
 }
 
 // isEncryptionEnabled checks kmsKeyId and pluginName to determine if encryption is enabled for this session
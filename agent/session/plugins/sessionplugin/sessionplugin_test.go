@@ -16,6 +16,9 @@ package sessionplugin
 
 import (
 	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/aws/amazon-ssm-agent/agent/appconfig"
@@ -28,6 +31,7 @@ import (
 	dataChannelMock "github.com/aws/amazon-ssm-agent/agent/session/datachannel/mocks"
 	sessionPluginMock "github.com/aws/amazon-ssm-agent/agent/session/plugins/sessionplugin/mocks"
 	"github.com/aws/amazon-ssm-agent/agent/task"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/suite"
 )
@@ -83,6 +87,101 @@ func (suite *SessionPluginTestSuite) TestExecute() {
 	suite.mockSessionPlugin.AssertExpectations(suite.T())
 }
 
+func (suite *SessionPluginTestSuite) TestExecuteWithSessionTimeout() {
+	config := contracts.Configuration{}
+	appConfig := appconfig.SsmagentConfig{Mgs: appconfig.MgsConfig{SessionIdleTimeoutMinutes: 20}}
+	mockContext := new(context.Mock)
+	mockContext.On("Log").Return(suite.mockLog)
+	mockContext.On("AppConfig").Return(appConfig)
+
+	var handler datachannel.InputStreamMessageHandler
+	getDataChannelForSessionPlugin =
+		func(context context.T, sessionId string, clientId string, cancelFlag task.CancelFlag, inputStreamMessageHandler datachannel.InputStreamMessageHandler) (datachannel.IDataChannel, error) {
+			handler = inputStreamMessageHandler
+			return suite.mockDataChannel, nil
+		}
+	suite.mockDataChannel.On("SendAgentSessionStateMessage", suite.mockLog, mgsContracts.Connected).Return(nil)
+	suite.mockDataChannel.On("Close", suite.mockLog).Return(nil)
+	suite.mockDataChannel.On("SkipHandshake", suite.mockLog).Return()
+	suite.mockSessionPlugin.On("RequireHandshake").Return(false)
+	suite.mockSessionPlugin.On("GetPluginParameters", config.Properties).Return(nil)
+	suite.mockSessionPlugin.On("InputStreamMessageHandler", suite.mockLog, mock.Anything).Return(nil)
+	suite.mockSessionPlugin.On("Execute", mockContext, mock.Anything, mock.Anything, suite.mockIohandler, suite.mockDataChannel).Return().Run(
+		func(args mock.Arguments) {
+			// the plugin gets the cancel flag of the session, which follows the flag of the task
+			assert.NotEqual(suite.T(), suite.mockCancelFlag, args.Get(2))
+			assert.NoError(suite.T(), handler(suite.mockLog, mgsContracts.AgentMessage{}))
+		})
+	suite.mockCancelFlag.On("Wait").Return(task.Completed)
+
+	suite.sessionPlugin.Execute(mockContext,
+		config,
+		suite.mockCancelFlag,
+		suite.mockIohandler)
+
+	suite.mockDataChannel.AssertExpectations(suite.T())
+	suite.mockSessionPlugin.AssertExpectations(suite.T())
+}
+
+func (suite *SessionPluginTestSuite) TestExecutePortSessionOutputResetsIdleTimeout() {
+	config := contracts.Configuration{PluginName: appconfig.PluginNamePort}
+	appConfig := appconfig.SsmagentConfig{Mgs: appconfig.MgsConfig{SessionIdleTimeoutMinutes: 20}}
+	mockContext := new(context.Mock)
+	mockContext.On("Log").Return(suite.mockLog)
+	mockContext.On("AppConfig").Return(appConfig)
+
+	getDataChannelForSessionPlugin =
+		func(context context.T, sessionId string, clientId string, cancelFlag task.CancelFlag, inputStreamMessageHandler datachannel.InputStreamMessageHandler) (datachannel.IDataChannel, error) {
+			return suite.mockDataChannel, nil
+		}
+	suite.mockDataChannel.On("SendAgentSessionStateMessage", suite.mockLog, mgsContracts.Connected).Return(nil)
+	suite.mockDataChannel.On("SendStreamDataMessage", suite.mockLog, mgsContracts.Output, []byte("data")).Return(nil)
+	suite.mockDataChannel.On("Close", suite.mockLog).Return(nil)
+	suite.mockDataChannel.On("SkipHandshake", suite.mockLog).Return()
+	suite.mockSessionPlugin.On("RequireHandshake").Return(false)
+	suite.mockSessionPlugin.On("GetPluginParameters", config.Properties).Return(nil)
+	suite.mockSessionPlugin.On("Execute", mockContext, mock.Anything, mock.Anything, suite.mockIohandler, mock.Anything).Return().Run(
+		func(args mock.Arguments) {
+			// the data sent to the client is recorded as activity of port sessions
+			dataChannel, ok := args.Get(4).(*activityRecordingDataChannel)
+			if !assert.True(suite.T(), ok) {
+				return
+			}
+			assert.NoError(suite.T(), dataChannel.SendStreamDataMessage(suite.mockLog, mgsContracts.Output, []byte("data")))
+		})
+	suite.mockCancelFlag.On("Wait").Return(task.Completed)
+
+	suite.sessionPlugin.Execute(mockContext,
+		config,
+		suite.mockCancelFlag,
+		suite.mockIohandler)
+
+	suite.mockDataChannel.AssertExpectations(suite.T())
+	suite.mockSessionPlugin.AssertExpectations(suite.T())
+}
+
+func (suite *SessionPluginTestSuite) TestExecuteRejectsUnmappedPrincipal() {
+	dir, _ := ioutil.TempDir("", "sessionplugin")
+	defer os.RemoveAll(dir)
+	mappingFile := filepath.Join(dir, "session-users.json")
+	assert.NoError(suite.T(), ioutil.WriteFile(mappingFile, []byte(`{"DenyUnmapped": true, "Mappings": [{"TagKey": "team", "LocalUser": "dev"}]}`), 0600))
+	appConfig := appconfig.SsmagentConfig{Mgs: appconfig.MgsConfig{SessionUserMappingFile: mappingFile}}
+	mockContext := new(context.Mock)
+	mockContext.On("Log").Return(suite.mockLog)
+	mockContext.On("AppConfig").Return(appConfig)
+	// port sessions never start a shell as the mapped user, but the principal is still denied
+	config := contracts.Configuration{SessionId: "session-id", PluginName: appconfig.PluginNamePort}
+	suite.mockIohandler.On("MarkAsFailed", mock.Anything).Return()
+
+	suite.sessionPlugin.Execute(mockContext,
+		config,
+		suite.mockCancelFlag,
+		suite.mockIohandler)
+
+	suite.mockIohandler.AssertCalled(suite.T(), "MarkAsFailed", mock.Anything)
+	suite.mockSessionPlugin.AssertNotCalled(suite.T(), "Execute", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
 func (suite *SessionPluginTestSuite) TestExecuteHandshakeEncryptionDisabled() {
 	sessionProperties := map[string]interface{}{"portNumber": "22"}
 	config := contracts.Configuration{PluginName: appconfig.PluginNamePort, Properties: sessionProperties}
@@ -0,0 +1,202 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package sessionplugin
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	mgsContracts "github.com/aws/amazon-ssm-agent/agent/session/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/session/datachannel"
+	"github.com/aws/amazon-ssm-agent/agent/task"
+)
+
+// sessionTimeoutWarningPeriod is how long before the termination the user is warned,
+// at most half of the timeout so the warning does not follow every input of short idle timeouts.
+const sessionTimeoutWarningPeriod = time.Minute
+
+// ISessionWarningWriter is implemented by the plugins which can display warnings to the user, like the shell plugins
+type ISessionWarningWriter interface {
+	WriteWarning(log log.T, message string) error
+}
+
+// sessionTimeout enforces the idle timeout and the maximum duration of a session configured on the agent
+type sessionTimeout struct {
+	idleTimeout  time.Duration
+	maxDuration  time.Duration
+	start        time.Time
+	lock         sync.Mutex
+	lastActivity time.Time
+}
+
+// newSessionTimeout returns the session timeout configured in appconfig, nil when neither limit is set
+func newSessionTimeout(config appconfig.MgsConfig) *sessionTimeout {
+	if config.SessionIdleTimeoutMinutes <= 0 && config.SessionMaxDurationMinutes <= 0 {
+		return nil
+	}
+	return newSessionTimeoutWithDurations(
+		time.Duration(config.SessionIdleTimeoutMinutes)*time.Minute,
+		time.Duration(config.SessionMaxDurationMinutes)*time.Minute)
+}
+
+// newSessionTimeoutWithDurations returns a session timeout starting now, a limit of 0 is disabled
+func newSessionTimeoutWithDurations(idleTimeout time.Duration, maxDuration time.Duration) *sessionTimeout {
+	now := time.Now()
+	return &sessionTimeout{
+		idleTimeout:  idleTimeout,
+		maxDuration:  maxDuration,
+		start:        now,
+		lastActivity: now,
+	}
+}
+
+// recordActivity resets the idle timeout
+func (t *sessionTimeout) recordActivity() {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.lastActivity = time.Now()
+}
+
+// activityRecordingDataChannel resets the idle timeout of the session on the data sent to the client.
+// Port sessions forward the traffic of both ends, a client downloading through the tunnel sends no input for long.
+type activityRecordingDataChannel struct {
+	datachannel.IDataChannel
+	recordActivity func()
+}
+
+// SendStreamDataMessage records the activity and sends the data to the client
+func (d *activityRecordingDataChannel) SendStreamDataMessage(log log.T, dataType mgsContracts.PayloadType, inputData []byte) error {
+	d.recordActivity()
+	return d.IDataChannel.SendStreamDataMessage(log, dataType, inputData)
+}
+
+// deadline returns the earliest termination time of the session, along with the warning time and the reason
+func (t *sessionTimeout) deadline() (deadline time.Time, warning time.Time, reason string) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if t.idleTimeout > 0 {
+		deadline = t.lastActivity.Add(t.idleTimeout)
+		warning = deadline.Add(-warningPeriod(t.idleTimeout))
+		reason = mgsContracts.IdleTimeoutReason
+	}
+	if t.maxDuration > 0 {
+		if maxDeadline := t.start.Add(t.maxDuration); reason == "" || maxDeadline.Before(deadline) {
+			deadline = maxDeadline
+			warning = deadline.Add(-warningPeriod(t.maxDuration))
+			reason = mgsContracts.MaxSessionDurationReason
+		}
+	}
+	return deadline, warning, reason
+}
+
+// monitor waits for the deadline of the session and terminates it, warning the user beforehand.
+// Activity recorded meanwhile postpones the idle deadline. It returns when done is closed.
+func (t *sessionTimeout) monitor(log log.T, done <-chan struct{}, warn func(message string), terminate func(reason string, message string)) {
+	var warnedDeadline time.Time
+	for {
+		deadline, warning, reason := t.deadline()
+		now := time.Now()
+		if !now.Before(deadline) {
+			log.Infof("Terminating the session, reason: %s", reason)
+			terminate(reason, terminationMessage(reason))
+			return
+		}
+
+		wakeUp := deadline
+		if !warnedDeadline.Equal(deadline) {
+			if !now.Before(warning) {
+				warn(warningMessage(reason, deadline.Sub(now)))
+				warnedDeadline = deadline
+			} else {
+				wakeUp = warning
+			}
+		}
+
+		select {
+		case <-done:
+			return
+		case <-time.After(wakeUp.Sub(now)):
+		}
+	}
+}
+
+// warningPeriod returns how long before the end of the limit the user is warned
+func warningPeriod(limit time.Duration) time.Duration {
+	if limit/2 < sessionTimeoutWarningPeriod {
+		return limit / 2
+	}
+	return sessionTimeoutWarningPeriod
+}
+
+// warningMessage returns the message displayed before the termination of the session
+func warningMessage(reason string, remaining time.Duration) string {
+	seconds := int((remaining + time.Second/2) / time.Second)
+	if reason == mgsContracts.IdleTimeoutReason {
+		return fmt.Sprintf("This session will be terminated in %d seconds due to inactivity.", seconds)
+	}
+	return fmt.Sprintf("This session will be terminated in %d seconds as it reaches its maximum duration.", seconds)
+}
+
+// terminationMessage returns the message displayed at the termination of the session
+func terminationMessage(reason string) string {
+	if reason == mgsContracts.IdleTimeoutReason {
+		return "This session is terminated due to inactivity."
+	}
+	return "This session is terminated as it reached its maximum duration."
+}
+
+// enforce starts monitoring the session and returns the cancel flag to pass to the plugin, which is cancelled
+// on timeout or when the flag of the task is set. The returned function stops the monitoring.
+func (t *sessionTimeout) enforce(log log.T,
+	cancelFlag task.CancelFlag,
+	dataChannel datachannel.IDataChannel,
+	sessionPlugin ISessionPlugin) (sessionCancelFlag task.CancelFlag, stop func()) {
+
+	flag := task.NewChanneledCancelFlag()
+	done := make(chan struct{})
+
+	go func() {
+		// the flag of the task is set to completed at the latest when the task ends
+		if state := cancelFlag.Wait(); state != task.Completed {
+			flag.Set(state)
+		}
+	}()
+
+	writeWarning := func(message string) {
+		if writer, ok := sessionPlugin.(ISessionWarningWriter); ok {
+			if err := writer.WriteWarning(log, message); err != nil {
+				log.Warnf("Unable to display the warning to the user: %v", err)
+			}
+		}
+	}
+	go t.monitor(log, done, writeWarning, func(reason string, message string) {
+		writeWarning(message)
+		if err := dataChannel.SendAgentSessionStateMessageWithReason(log, mgsContracts.Terminating, reason); err != nil {
+			log.Errorf("Unable to send AgentSessionState message with session status %s. %v", mgsContracts.Terminating, err)
+		}
+		flag.Set(task.Canceled)
+	})
+
+	return flag, func() {
+		close(done)
+		if !flag.Canceled() && !flag.ShutDown() {
+			// wake up the plugin routines waiting for a cancellation
+			flag.Set(task.Completed)
+		}
+	}
+}
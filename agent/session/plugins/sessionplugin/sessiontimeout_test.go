@@ -0,0 +1,181 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package sessionplugin
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	mgsContracts "github.com/aws/amazon-ssm-agent/agent/session/contracts"
+	dataChannelMock "github.com/aws/amazon-ssm-agent/agent/session/datachannel/mocks"
+	sessionPluginMock "github.com/aws/amazon-ssm-agent/agent/session/plugins/sessionplugin/mocks"
+	"github.com/aws/amazon-ssm-agent/agent/task"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// warningSessionPlugin is a session plugin displaying the warnings to the user
+type warningSessionPlugin struct {
+	sessionPluginMock.ISessionPlugin
+	lock     sync.Mutex
+	warnings []string
+}
+
+func (p *warningSessionPlugin) WriteWarning(log log.T, message string) error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.warnings = append(p.warnings, message)
+	return nil
+}
+
+func TestNewSessionTimeout(t *testing.T) {
+	assert.Nil(t, newSessionTimeout(appconfig.MgsConfig{}))
+
+	timeout := newSessionTimeout(appconfig.MgsConfig{SessionIdleTimeoutMinutes: 20})
+	assert.Equal(t, 20*time.Minute, timeout.idleTimeout)
+	assert.Equal(t, time.Duration(0), timeout.maxDuration)
+
+	timeout = newSessionTimeout(appconfig.MgsConfig{SessionMaxDurationMinutes: 60})
+	assert.Equal(t, time.Duration(0), timeout.idleTimeout)
+	assert.Equal(t, 60*time.Minute, timeout.maxDuration)
+}
+
+func TestSessionTimeoutDeadline(t *testing.T) {
+	timeout := newSessionTimeoutWithDurations(10*time.Minute, 5*time.Minute)
+	deadline, warning, reason := timeout.deadline()
+	assert.Equal(t, mgsContracts.MaxSessionDurationReason, reason)
+	assert.Equal(t, timeout.start.Add(5*time.Minute), deadline)
+	assert.Equal(t, deadline.Add(-time.Minute), warning)
+
+	timeout = newSessionTimeoutWithDurations(time.Minute, time.Hour)
+	deadline, warning, reason = timeout.deadline()
+	assert.Equal(t, mgsContracts.IdleTimeoutReason, reason)
+	assert.Equal(t, timeout.lastActivity.Add(time.Minute), deadline)
+	assert.Equal(t, deadline.Add(-30*time.Second), warning)
+
+	timeout.recordActivity()
+	postponed, _, _ := timeout.deadline()
+	assert.False(t, postponed.Before(deadline))
+}
+
+func TestActivityRecordingDataChannelPostponesIdleTimeout(t *testing.T) {
+	timeout := newSessionTimeoutWithDurations(time.Minute, 0)
+	mockDataChannel := &dataChannelMock.IDataChannel{}
+	mockLog := log.NewMockLog()
+	mockDataChannel.On("SendStreamDataMessage", mockLog, mgsContracts.Output, []byte("data")).Return(nil)
+	dataChannel := &activityRecordingDataChannel{IDataChannel: mockDataChannel, recordActivity: timeout.recordActivity}
+
+	deadline, _, _ := timeout.deadline()
+	time.Sleep(10 * time.Millisecond)
+	assert.NoError(t, dataChannel.SendStreamDataMessage(mockLog, mgsContracts.Output, []byte("data")))
+
+	postponed, _, _ := timeout.deadline()
+	assert.True(t, postponed.After(deadline))
+	mockDataChannel.AssertExpectations(t)
+}
+
+func TestSessionTimeoutMonitorIdle(t *testing.T) {
+	timeout := newSessionTimeoutWithDurations(100*time.Millisecond, 0)
+	var events []string
+	var terminateReason string
+
+	timeout.monitor(log.NewMockLog(), make(chan struct{}),
+		func(message string) {
+			events = append(events, message)
+		},
+		func(reason string, message string) {
+			terminateReason = reason
+			events = append(events, message)
+		})
+
+	assert.Equal(t, mgsContracts.IdleTimeoutReason, terminateReason)
+	assert.Equal(t, 2, len(events))
+	assert.True(t, strings.Contains(events[0], "due to inactivity"))
+	assert.Equal(t, terminationMessage(mgsContracts.IdleTimeoutReason), events[1])
+	assert.True(t, time.Since(timeout.start) >= 100*time.Millisecond)
+}
+
+func TestSessionTimeoutMonitorInputPostponesIdleTimeout(t *testing.T) {
+	timeout := newSessionTimeoutWithDurations(100*time.Millisecond, 0)
+	terminated := make(chan time.Time, 1)
+	go timeout.monitor(log.NewMockLog(), make(chan struct{}),
+		func(message string) {},
+		func(reason string, message string) {
+			terminated <- time.Now()
+		})
+
+	start := time.Now()
+	for i := 0; i < 6; i++ {
+		time.Sleep(30 * time.Millisecond)
+		timeout.recordActivity()
+	}
+	select {
+	case <-terminated:
+		t.Fatal("session terminated while receiving input")
+	default:
+	}
+	assert.True(t, (<-terminated).Sub(start) >= 280*time.Millisecond)
+}
+
+func TestSessionTimeoutMonitorStopped(t *testing.T) {
+	timeout := newSessionTimeoutWithDurations(0, time.Hour)
+	done := make(chan struct{})
+	stopped := make(chan bool)
+	go func() {
+		timeout.monitor(log.NewMockLog(), done,
+			func(message string) { t.Error("unexpected warning") },
+			func(reason string, message string) { t.Error("unexpected termination") })
+		stopped <- true
+	}()
+	close(done)
+	assert.True(t, <-stopped)
+}
+
+func TestSessionTimeoutEnforce(t *testing.T) {
+	logger := log.NewMockLog()
+	dataChannel := &dataChannelMock.IDataChannel{}
+	dataChannel.On("SendAgentSessionStateMessageWithReason", logger, mgsContracts.Terminating, mgsContracts.MaxSessionDurationReason).Return(nil)
+	plugin := &warningSessionPlugin{}
+
+	timeout := newSessionTimeoutWithDurations(time.Hour, 100*time.Millisecond)
+	sessionCancelFlag, stop := timeout.enforce(logger, task.NewChanneledCancelFlag(), dataChannel, plugin)
+	defer stop()
+
+	assert.Equal(t, task.Canceled, sessionCancelFlag.Wait())
+	dataChannel.AssertExpectations(t)
+	assert.Equal(t, 2, len(plugin.warnings))
+	assert.True(t, strings.Contains(plugin.warnings[0], "maximum duration"))
+	assert.Equal(t, terminationMessage(mgsContracts.MaxSessionDurationReason), plugin.warnings[1])
+}
+
+func TestSessionTimeoutEnforceFollowsTaskCancelFlag(t *testing.T) {
+	logger := log.NewMockLog()
+	dataChannel := &dataChannelMock.IDataChannel{}
+
+	taskCancelFlag := task.NewChanneledCancelFlag()
+	sessionCancelFlag, stop := newSessionTimeoutWithDurations(time.Hour, 0).enforce(logger, taskCancelFlag, dataChannel, &sessionPluginMock.ISessionPlugin{})
+	taskCancelFlag.Set(task.ShutDown)
+	assert.Equal(t, task.ShutDown, sessionCancelFlag.Wait())
+	stop()
+	assert.Equal(t, task.ShutDown, sessionCancelFlag.State())
+
+	sessionCancelFlag, stop = newSessionTimeoutWithDurations(time.Hour, 0).enforce(logger, task.NewChanneledCancelFlag(), dataChannel, &sessionPluginMock.ISessionPlugin{})
+	stop()
+	assert.Equal(t, task.Completed, sessionCancelFlag.Wait())
+	dataChannel.AssertNotCalled(t, "SendAgentSessionStateMessageWithReason", mock.Anything, mock.Anything, mock.Anything)
+}
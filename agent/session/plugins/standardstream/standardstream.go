@@ -77,3 +77,8 @@ func (p *StandardStreamPlugin) Execute(context context.T,
 func (p *StandardStreamPlugin) InputStreamMessageHandler(log log.T, streamDataMessage mgsContracts.AgentMessage) error {
 	return p.shell.InputStreamMessageHandler(log, streamDataMessage)
 }
+
+// WriteWarning writes a warning message to the shell terminal
+func (p *StandardStreamPlugin) WriteWarning(log log.T, message string) error {
+	return p.shell.WriteWarning(log, message)
+}
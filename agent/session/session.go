@@ -29,6 +29,7 @@ import (
 	"github.com/aws/amazon-ssm-agent/agent/framework/processor"
 	"github.com/aws/amazon-ssm-agent/agent/jsonutil"
 	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/metrics"
 	"github.com/aws/amazon-ssm-agent/agent/platform"
 	mgsConfig "github.com/aws/amazon-ssm-agent/agent/session/config"
 	mgsContracts "github.com/aws/amazon-ssm-agent/agent/session/contracts"
@@ -39,6 +40,10 @@ import (
 	"github.com/twinj/uuid"
 )
 
+var sessionsCompleted = metrics.NewCounter("ssm_agent_sessions_completed_total",
+	"Number of sessions completed by the agent, by status.",
+	"status")
+
 // Session encapsulates the logic on configuring, starting and stopping core modules
 type Session struct {
 	context        context.T
@@ -237,6 +242,7 @@ func (s *Session) listenReply(resultChan chan contracts.DocumentResult, instance
 			log.Infof("received plugin: %s result from Processor", res.LastPlugin)
 		} else {
 			log.Infof("session: %s complete", res.MessageID)
+			sessionsCompleted.Inc(string(res.Status))
 
 			//Deleting Old Log Files
 			instanceID, _ := platform.InstanceID()
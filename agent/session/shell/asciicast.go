@@ -0,0 +1,153 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package shell
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	asciicastVersion       = 2
+	asciicastDefaultWidth  = 80
+	asciicastDefaultHeight = 24
+	asciicastOutputEvent   = "o"
+	asciicastInputEvent    = "i"
+	asciicastResizeEvent   = "r"
+)
+
+// asciicastHeader is the first line of an asciicast v2 recording
+type asciicastHeader struct {
+	Version   int    `json:"version"`
+	Width     uint32 `json:"width"`
+	Height    uint32 `json:"height"`
+	Timestamp int64  `json:"timestamp"`
+	Title     string `json:"title,omitempty"`
+}
+
+// asciicastRecorder records a shell session in asciicast v2 format,
+// see https://github.com/asciinema/asciinema/blob/develop/doc/asciicast-v2.md
+// The header is written with the first terminal size received, or the default size if output comes first.
+type asciicastRecorder struct {
+	lock          sync.Mutex
+	file          *os.File
+	start         time.Time
+	title         string
+	recordInput   bool
+	headerWritten bool
+	err           error
+}
+
+// newAsciicastRecorder creates the recording file at the given path
+func newAsciicastRecorder(path string, title string, recordInput bool) (*asciicastRecorder, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create asciicast file: %v", err)
+	}
+	return &asciicastRecorder{
+		file:        file,
+		start:       time.Now(),
+		title:       title,
+		recordInput: recordInput,
+	}, nil
+}
+
+// output records the data sent to the client
+func (r *asciicastRecorder) output(data []byte) {
+	if r == nil || len(data) == 0 {
+		return
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.writeEvent(asciicastOutputEvent, string(data))
+}
+
+// input records the data received from the client, when input recording is enabled
+func (r *asciicastRecorder) input(data []byte) {
+	if r == nil || !r.recordInput || len(data) == 0 {
+		return
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.writeEvent(asciicastInputEvent, string(data))
+}
+
+// resize records the terminal size sent by the client
+func (r *asciicastRecorder) resize(cols uint32, rows uint32) {
+	if r == nil {
+		return
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if !r.headerWritten {
+		r.writeHeader(cols, rows)
+		return
+	}
+	r.writeEvent(asciicastResizeEvent, fmt.Sprintf("%dx%d", cols, rows))
+}
+
+// close closes the recording file and returns the first error encountered while recording
+func (r *asciicastRecorder) close() error {
+	if r == nil {
+		return nil
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if !r.headerWritten {
+		r.writeHeader(asciicastDefaultWidth, asciicastDefaultHeight)
+	}
+	if err := r.file.Close(); err != nil && r.err == nil {
+		r.err = err
+	}
+	return r.err
+}
+
+// writeHeader writes the header line with the given terminal size
+func (r *asciicastRecorder) writeHeader(cols uint32, rows uint32) {
+	r.headerWritten = true
+	r.writeLine(asciicastHeader{
+		Version:   asciicastVersion,
+		Width:     cols,
+		Height:    rows,
+		Timestamp: r.start.Unix(),
+		Title:     r.title,
+	})
+}
+
+// writeEvent writes an event line with the time elapsed since the start of the recording
+func (r *asciicastRecorder) writeEvent(eventType string, data string) {
+	if !r.headerWritten {
+		r.writeHeader(asciicastDefaultWidth, asciicastDefaultHeight)
+	}
+	elapsed := float64(time.Since(r.start)/time.Microsecond) / 1e6
+	r.writeLine([]interface{}{elapsed, eventType, data})
+}
+
+// writeLine writes the json encoding of the value as a line, the recording stops at the first error
+func (r *asciicastRecorder) writeLine(value interface{}) {
+	if r.err != nil {
+		return
+	}
+	line, err := json.Marshal(value)
+	if err != nil {
+		r.err = err
+		return
+	}
+	if _, err = r.file.Write(append(line, '\n')); err != nil {
+		r.err = err
+	}
+}
@@ -0,0 +1,117 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package shell
+
+import (
+	"bufio"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// readAsciicast returns the header and the events of the recording
+func readAsciicast(t *testing.T, path string) (header asciicastHeader, events [][]interface{}) {
+	file, err := os.Open(path)
+	assert.NoError(t, err)
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	assert.True(t, scanner.Scan())
+	assert.NoError(t, json.Unmarshal(scanner.Bytes(), &header))
+	for scanner.Scan() {
+		var event []interface{}
+		assert.NoError(t, json.Unmarshal(scanner.Bytes(), &event))
+		events = append(events, event)
+	}
+	return header, events
+}
+
+func TestAsciicastRecorder(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "asciicast")
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "session-id.cast")
+
+	recorder, err := newAsciicastRecorder(path, "session-id", true)
+	assert.NoError(t, err)
+	recorder.resize(120, 40)
+	recorder.output([]byte("$ "))
+	recorder.input([]byte("ls\r"))
+	recorder.output([]byte("fileé\r\n"))
+	recorder.resize(100, 30)
+	recorder.output([]byte{})
+	assert.NoError(t, recorder.close())
+
+	header, events := readAsciicast(t, path)
+	assert.Equal(t, 2, header.Version)
+	assert.Equal(t, uint32(120), header.Width)
+	assert.Equal(t, uint32(40), header.Height)
+	assert.Equal(t, "session-id", header.Title)
+	assert.Equal(t, recorder.start.Unix(), header.Timestamp)
+
+	assert.Equal(t, 4, len(events))
+	expected := [][]interface{}{{"o", "$ "}, {"i", "ls\r"}, {"o", "fileé\r\n"}, {"r", "100x30"}}
+	previous := 0.0
+	for i, event := range events {
+		assert.Equal(t, expected[i], event[1:])
+		assert.True(t, event[0].(float64) >= previous)
+		previous = event[0].(float64)
+	}
+}
+
+func TestAsciicastRecorderWithoutInputAndSize(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "asciicast")
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "session-id.cast")
+
+	recorder, err := newAsciicastRecorder(path, "session-id", false)
+	assert.NoError(t, err)
+	recorder.input([]byte("password\r"))
+	recorder.output([]byte("welcome"))
+	recorder.resize(100, 30)
+	assert.NoError(t, recorder.close())
+
+	header, events := readAsciicast(t, path)
+	assert.Equal(t, uint32(asciicastDefaultWidth), header.Width)
+	assert.Equal(t, uint32(asciicastDefaultHeight), header.Height)
+	assert.Equal(t, 2, len(events))
+	assert.Equal(t, []interface{}{"o", "welcome"}, events[0][1:])
+	assert.Equal(t, []interface{}{"r", "100x30"}, events[1][1:])
+}
+
+func TestAsciicastRecorderEmptySession(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "asciicast")
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "session-id.cast")
+
+	recorder, err := newAsciicastRecorder(path, "", false)
+	assert.NoError(t, err)
+	assert.NoError(t, recorder.close())
+
+	header, events := readAsciicast(t, path)
+	assert.Equal(t, 2, header.Version)
+	assert.Empty(t, events)
+
+	// Recording is disabled when the recorder is nil
+	var disabled *asciicastRecorder
+	disabled.output([]byte("data"))
+	disabled.resize(1, 1)
+	assert.NoError(t, disabled.close())
+
+	_, err = newAsciicastRecorder(filepath.Join(dir, "missing", "session-id.cast"), "", false)
+	assert.Error(t, err)
+}
@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sync"
 	"time"
 	"unicode/utf8"
 
@@ -41,17 +42,24 @@ import (
 
 // Plugin is the type for the plugin.
 type ShellPlugin struct {
-	name        string
-	stdin       *os.File
-	stdout      *os.File
-	ipcFilePath string
-	logFilePath string
-	dataChannel datachannel.IDataChannel
+	name         string
+	stdin        *os.File
+	stdout       *os.File
+	ipcFilePath  string
+	logFilePath  string
+	castFilePath string
+	dataChannel  datachannel.IDataChannel
+	sendLock     sync.Mutex
+
+	// the recorder is set once the session starts while the input of the session may already be handled
+	recorderLock sync.Mutex
+	recorder     *asciicastRecorder
 }
 
 type IShellPlugin interface {
 	Execute(context context.T, config agentContracts.Configuration, cancelFlag task.CancelFlag, output iohandler.IOHandler, dataChannel datachannel.IDataChannel, shellProps mgsContracts.ShellProperties)
 	InputStreamMessageHandler(log log.T, streamDataMessage mgsContracts.AgentMessage) error
+	WriteWarning(log log.T, message string) error
 }
 
 // NewPlugin returns a new instance of the Shell Plugin
@@ -150,8 +158,21 @@ func (p *ShellPlugin) execute(context context.T,
 		return
 	}
 
+	// Record the session in asciicast format alongside the log if enabled
+	castFileName := config.SessionId + mgsConfig.CastFileExtension
+	if appConfig, _ := appconfig.Config(false); appConfig.Mgs.AsciicastRecordingEnabled {
+		p.castFilePath = filepath.Join(config.OrchestrationDirectory, castFileName)
+		if recorder, err := newAsciicastRecorder(p.castFilePath, config.SessionId, appConfig.Mgs.AsciicastRecordInput); err != nil {
+			log.Errorf("Unable to record the session in asciicast format: %v", err)
+			p.castFilePath = ""
+		} else {
+			p.setRecorder(recorder)
+		}
+	}
+
 	p.stdin, p.stdout, err = startPty(log, shellProps, false, config)
 	if err != nil {
+		p.sessionRecorder().close()
 		errorString := fmt.Errorf("Unable to start shell: %s", err)
 		log.Error(errorString)
 		output.MarkAsFailed(errorString)
@@ -211,6 +232,10 @@ func (p *ShellPlugin) execute(context context.T,
 		}
 	}
 
+	if err = p.sessionRecorder().close(); err != nil {
+		log.Errorf("Error occurred while recording the session in asciicast format: %v", err)
+	}
+
 	// Generate log data only if customer has enabled logging.
 	// TODO: Move below logic of uploading logs to S3 and cloudwatch to IOHandler
 	if config.OutputS3BucketName != "" || config.CloudWatchLogGroup != "" {
@@ -225,14 +250,20 @@ func (p *ShellPlugin) execute(context context.T,
 		log.Debug("Starting S3 logging")
 		if config.OutputS3BucketName != "" {
 			s3KeyPrefix := fileutil.BuildS3Path(config.OutputS3KeyPrefix, logFileName)
-			p.uploadShellSessionLogsToS3(log, s3Util, config, s3KeyPrefix)
+			p.uploadShellSessionLogsToS3(log, s3Util, config, s3KeyPrefix, p.logFilePath)
 			sessionPluginResultOutput.S3Bucket = config.OutputS3BucketName
 			sessionPluginResultOutput.S3UrlSuffix = s3KeyPrefix
+			if p.castFilePath != "" {
+				p.uploadShellSessionLogsToS3(log, s3Util, config, fileutil.BuildS3Path(config.OutputS3KeyPrefix, castFileName), p.castFilePath)
+			}
 		}
 
 		log.Debug("Starting CloudWatch logging")
 		if config.CloudWatchLogGroup != "" {
 			cwl.StreamData(log, config.CloudWatchLogGroup, config.SessionId, p.logFilePath, true, false)
+			if p.castFilePath != "" {
+				cwl.StreamData(log, config.CloudWatchLogGroup, castFileName, p.castFilePath, true, false)
+			}
 			sessionPluginResultOutput.CwlGroup = config.CloudWatchLogGroup
 			sessionPluginResultOutput.CwlStream = config.SessionId
 		}
@@ -243,10 +274,10 @@ func (p *ShellPlugin) execute(context context.T,
 }
 
 // uploadShellSessionLogsToS3 uploads shell session logs to S3 bucket specified.
-func (p *ShellPlugin) uploadShellSessionLogsToS3(log log.T, s3UploaderUtil s3util.IAmazonS3Util, config agentContracts.Configuration, s3KeyPrefix string) {
+func (p *ShellPlugin) uploadShellSessionLogsToS3(log log.T, s3UploaderUtil s3util.IAmazonS3Util, config agentContracts.Configuration, s3KeyPrefix string, filePath string) {
 	log.Debugf("Preparing to upload session logs to S3 bucket %s and prefix %s", config.OutputS3BucketName, s3KeyPrefix)
 
-	if err := s3UploaderUtil.S3Upload(log, config.OutputS3BucketName, s3KeyPrefix, p.logFilePath); err != nil {
+	if err := s3UploaderUtil.S3Upload(log, config.OutputS3BucketName, s3KeyPrefix, filePath); err != nil {
 		log.Errorf("Failed to upload shell session logs to S3: %s", err)
 	}
 }
@@ -333,13 +364,14 @@ func (p *ShellPlugin) processStdoutData(
 		i += stdoutRuneLen
 	}
 
-	if err := p.dataChannel.SendStreamDataMessage(log, mgsContracts.Output, processedBuf.Bytes()); err != nil {
+	if err := p.sendOutput(log, processedBuf.Bytes()); err != nil {
 		return processedBuf, fmt.Errorf("unable to send stream data message: %s", err)
 	}
 
 	if _, err := file.Write(processedBuf.Bytes()); err != nil {
 		return processedBuf, fmt.Errorf("encountered an error while writing to file: %s", err)
 	}
+	p.sessionRecorder().output(processedBuf.Bytes())
 
 	// return incomplete utf8 encoded unicode bytes to be processed with next batch of stdoutBytes
 	unprocessedBuf.Reset()
@@ -348,3 +380,37 @@ func (p *ShellPlugin) processStdoutData(
 	}
 	return unprocessedBuf, nil
 }
+
+// WriteWarning writes a warning message on its own line to the terminal of the session.
+func (p *ShellPlugin) WriteWarning(log log.T, message string) error {
+	if p.dataChannel == nil {
+		return errors.New("shell session is not started")
+	}
+	warning := []byte("\r\n" + message + "\r\n")
+	if err := p.sendOutput(log, warning); err != nil {
+		return fmt.Errorf("unable to send warning message: %s", err)
+	}
+	p.sessionRecorder().output(warning)
+	return nil
+}
+
+// setRecorder sets the asciicast recorder of the session.
+func (p *ShellPlugin) setRecorder(recorder *asciicastRecorder) {
+	p.recorderLock.Lock()
+	defer p.recorderLock.Unlock()
+	p.recorder = recorder
+}
+
+// sessionRecorder returns the asciicast recorder of the session, nil when the session is not recorded.
+func (p *ShellPlugin) sessionRecorder() *asciicastRecorder {
+	p.recorderLock.Lock()
+	defer p.recorderLock.Unlock()
+	return p.recorder
+}
+
+// sendOutput sends output to the terminal, serializing the pty output with the warnings of the agent.
+func (p *ShellPlugin) sendOutput(log log.T, output []byte) error {
+	p.sendLock.Lock()
+	defer p.sendLock.Unlock()
+	return p.dataChannel.SendStreamDataMessage(log, mgsContracts.Output, output)
+}
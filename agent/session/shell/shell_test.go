@@ -19,6 +19,7 @@ import (
 	"errors"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -287,6 +288,45 @@ func (suite *ShellTestSuite) TestProcessStreamMessage() {
 	assert.Equal(suite.T(), "testPayload", string(stdinFileContent))
 }
 
+func (suite *ShellTestSuite) TestProcessStreamMessageWhileRecorderIsSet() {
+	stdinFile, _ := ioutil.TempFile("/tmp", "stdin")
+	stdoutFile, _ := ioutil.TempFile("/tmp", "stdout")
+	dir, _ := ioutil.TempDir("", "asciicast")
+	defer os.Remove(stdinFile.Name())
+	defer os.Remove(stdoutFile.Name())
+	defer os.RemoveAll(dir)
+	plugin := &ShellPlugin{
+		stdin:  stdinFile,
+		stdout: stdoutFile,
+	}
+
+	// the input of the session is handled while the session starts recording
+	done := make(chan bool)
+	go func() {
+		for i := 0; i < 10; i++ {
+			plugin.InputStreamMessageHandler(mockLog, *getAgentMessage(uint32(mgsContracts.Output), payload))
+		}
+		done <- true
+	}()
+	recorder, err := newAsciicastRecorder(filepath.Join(dir, "session.cast"), "session-id", true)
+	assert.NoError(suite.T(), err)
+	plugin.setRecorder(recorder)
+	<-done
+
+	assert.Equal(suite.T(), recorder, plugin.sessionRecorder())
+	assert.NoError(suite.T(), recorder.close())
+}
+
+func (suite *ShellTestSuite) TestWriteWarning() {
+	plugin := &ShellPlugin{}
+	assert.Error(suite.T(), plugin.WriteWarning(suite.mockLog, "warning"))
+
+	plugin.dataChannel = suite.mockDataChannel
+	suite.mockDataChannel.On("SendStreamDataMessage", suite.mockLog, mgsContracts.Output, []byte("\r\nThis session will be terminated.\r\n")).Return(nil)
+	assert.NoError(suite.T(), plugin.WriteWarning(suite.mockLog, "This session will be terminated."))
+	suite.mockDataChannel.AssertExpectations(suite.T())
+}
+
 //Execute the test suite
 func TestShellTestSuite(t *testing.T) {
 	suite.Run(t, new(ShellTestSuite))
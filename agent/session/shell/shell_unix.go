@@ -40,6 +40,7 @@ import (
 var ptyFile *os.File
 
 const (
+	defaultShellCmd       = "sh"
 	termEnvVariable       = "TERM=xterm-256color"
 	langEnvVariable       = "LANG=C.UTF-8"
 	langEnvVariableKey    = "LANG"
@@ -58,13 +59,29 @@ func StartPty(
 	isSessionLogger bool,
 	config agentContracts.Configuration) (stdin *os.File, stdout *os.File, err error) {
 	log.Info("Starting pty")
+	appConfig, _ := appconfig.Config(false)
+	isCustomerShell := !shellProps.Linux.RunAsElevated && !isSessionLogger && !appConfig.Agent.ContainerMode
+
+	// The session user mapping decides the local user and shell profile of customer shells.
+	var profile *sessionUserProfile
+	if isCustomerShell {
+		if profile, err = resolveSessionUserProfile(log, appConfig.Mgs.SessionUserMappingFile, config); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	shellCmd := defaultShellCmd
+	if profile != nil && profile.Shell != "" {
+		shellCmd = profile.Shell
+	}
+
 	//Start the command with a pty
 	var cmd *exec.Cmd
 	if strings.TrimSpace(shellProps.Linux.Commands) == "" || isSessionLogger {
-		cmd = exec.Command("sh")
+		cmd = exec.Command(shellCmd)
 	} else {
 		commandArgs := append(utility.ShellPluginCommandArgs, shellProps.Linux.Commands)
-		cmd = exec.Command("sh", commandArgs...)
+		cmd = exec.Command(shellCmd, commandArgs...)
 	}
 
 	//TERM is set as linux by pty which has an issue where vi editor screen does not get cleared.
@@ -78,14 +95,19 @@ func StartPty(
 		cmd.Env = append(cmd.Env, langEnvVariable)
 	}
 
-	appConfig, _ := appconfig.Config(false)
-
-	if !shellProps.Linux.RunAsElevated && !isSessionLogger && !appConfig.Agent.ContainerMode {
+	if isCustomerShell {
 		// We get here only when its a customer shell that needs to be started in a specific user mode.
 
 		var sessionUser string
 		u := &utility.SessionUtil{}
-		if config.RunAsEnabled {
+		if profile != nil {
+			// Check if the mapped user exists
+			if userExists, _ := u.DoesUserExist(profile.User); !userExists {
+				return nil, nil, fmt.Errorf("failed to start pty since mapped user %s does not exist", profile.User)
+			}
+
+			sessionUser = profile.User
+		} else if config.RunAsEnabled {
 			if strings.TrimSpace(config.RunAsUser) == "" {
 				return nil, nil, errors.New("please set the RunAs default user")
 			}
@@ -116,6 +138,12 @@ func StartPty(
 		// Setting home environment variable for RunAs user
 		runAsUserHomeEnvVariable := homeEnvVariable + sessionUser
 		cmd.Env = append(cmd.Env, runAsUserHomeEnvVariable)
+
+		// Mapped environment variables come last to override the defaults above.
+		if profile != nil {
+			cmd.Env = append(cmd.Env, profile.Environment...)
+			cmd.Dir = profile.WorkingDirectory
+		}
 	}
 
 	ptyFile, err = pty.Start(cmd)
@@ -262,6 +290,7 @@ func (p *ShellPlugin) InputStreamMessageHandler(log log.T, streamDataMessage mgs
 	switch mgsContracts.PayloadType(streamDataMessage.PayloadType) {
 	case mgsContracts.Output:
 		log.Tracef("Output message received: %d", streamDataMessage.SequenceNumber)
+		p.sessionRecorder().input(streamDataMessage.Payload)
 		if _, err := p.stdin.Write(streamDataMessage.Payload); err != nil {
 			log.Errorf("Unable to write to stdin, err: %v.", err)
 			return err
@@ -273,6 +302,7 @@ func (p *ShellPlugin) InputStreamMessageHandler(log log.T, streamDataMessage mgs
 			return err
 		}
 		log.Tracef("Resize data received: cols: %d, rows: %d", size.Cols, size.Rows)
+		p.sessionRecorder().resize(size.Cols, size.Rows)
 		if err := SetSize(log, size.Cols, size.Rows); err != nil {
 			log.Errorf("Unable to set pty size: %s", err)
 			return err
@@ -89,6 +89,12 @@ func StartPty(
 	appConfig, _ := appconfig.Config(false)
 
 	if !shellProps.Windows.RunAsElevated && !isSessionLogger && !appConfig.Agent.ContainerMode {
+		// Sessions run as ssm-user since the mapped users could only be impersonated with their password,
+		// so they are rejected when the mapping file expects unmapped principals to be denied.
+		if err = rejectUnenforceableSessionUserMappings(log, appConfig.Mgs.SessionUserMappingFile, config); err != nil {
+			return nil, nil, err
+		}
+
 		// Reset password for default ssm user
 		var newPassword string
 		newPassword, err = u.GeneratePasswordForDefaultUser()
@@ -379,6 +385,7 @@ func (p *ShellPlugin) InputStreamMessageHandler(log log.T, streamDataMessage mgs
 	switch mgsContracts.PayloadType(streamDataMessage.PayloadType) {
 	case mgsContracts.Output:
 		log.Tracef("Output message received: %d", streamDataMessage.SequenceNumber)
+		p.sessionRecorder().input(streamDataMessage.Payload)
 
 		// deal with powershell nextline issue https://github.com/lzybkr/PSReadLine/issues/579
 		payloadString := string(streamDataMessage.Payload)
@@ -401,6 +408,7 @@ func (p *ShellPlugin) InputStreamMessageHandler(log log.T, streamDataMessage mgs
 			return err
 		}
 		log.Tracef("Resize data received: cols: %d, rows: %d", size.Cols, size.Rows)
+		p.sessionRecorder().resize(size.Cols, size.Rows)
 		if err := SetSize(log, size.Cols, size.Rows); err != nil {
 			log.Errorf("Unable to set pty size: %s", err)
 			return err
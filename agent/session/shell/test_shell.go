@@ -49,3 +49,17 @@ func (_m *IShellPluginMock) InputStreamMessageHandler(_a0 log.T, streamDataMessa
 
 	return r0
 }
+
+// WriteWarning provides a mock function with given fields: _a0, message
+func (_m *IShellPluginMock) WriteWarning(_a0 log.T, message string) error {
+	ret := _m.Called(_a0, message)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(log.T, string) error); ok {
+		r0 = rf(_a0, message)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
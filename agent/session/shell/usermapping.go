@@ -0,0 +1,221 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package shell
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	agentContracts "github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/fileutil"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+)
+
+// sessionUserMappings is the content of the session user mapping file.
+type sessionUserMappings struct {
+	// DenyUnmapped rejects the sessions of the principals matching none of the mappings,
+	// instead of starting them as the RunAs user or ssm-user.
+	DenyUnmapped bool
+	Mappings     []sessionUserMapping
+}
+
+// sessionUserMapping maps the principals matching Principal and the tag to a local user.
+// Principal is an ARN pattern where * matches any sequence of characters, TagValue is a pattern
+// of the value of the TagKey principal tag, matching any value when empty.
+type sessionUserMapping struct {
+	Principal        string
+	TagKey           string
+	TagValue         string
+	LocalUser        string
+	Shell            string
+	Environment      map[string]string
+	WorkingDirectory string
+}
+
+// sessionUserProfile is the local user and shell profile a session is started with.
+type sessionUserProfile struct {
+	User             string
+	Shell            string
+	Environment      []string
+	WorkingDirectory string
+}
+
+// resolveSessionUserProfile returns the profile of the first mapping matching the principal of the session,
+// or nil when no mapping file is configured or no mapping matches and unmapped principals are allowed.
+// Every decision is logged with the session id for auditing.
+func resolveSessionUserProfile(log log.T, mappingFile string, config agentContracts.Configuration) (*sessionUserProfile, error) {
+	if mappingFile == "" {
+		return nil, nil
+	}
+
+	mappings, err := loadSessionUserMappings(mappingFile)
+	if err != nil {
+		auditSessionUserMapping(log, config, "rejected, the mapping file %s is invalid: %v", mappingFile, err)
+		return nil, fmt.Errorf("failed to load the session user mapping file %s: %v", mappingFile, err)
+	}
+
+	for i, mapping := range mappings.Mappings {
+		if !mapping.matches(config.PrincipalArn, config.PrincipalTags) {
+			continue
+		}
+		auditSessionUserMapping(log, config, "mapped to local user %s by mapping %d", mapping.LocalUser, i)
+		return mapping.profile(), nil
+	}
+
+	if mappings.DenyUnmapped {
+		auditSessionUserMapping(log, config, "rejected, no mapping matches the principal")
+		return nil, errors.New("the session principal is not mapped to a local user")
+	}
+	auditSessionUserMapping(log, config, "not mapped, using the default session user")
+	return nil, nil
+}
+
+// AuthorizeSessionPrincipal rejects the session when the mapping file denies unmapped principals and no mapping matches
+// the principal of the session. It applies to every session type, such as elevated shells and port sessions, while the
+// local user and the profile of the mappings only apply to the shells started as the session user.
+func AuthorizeSessionPrincipal(log log.T, mappingFile string, config agentContracts.Configuration) error {
+	if mappingFile == "" {
+		return nil
+	}
+
+	mappings, err := loadSessionUserMappings(mappingFile)
+	if err != nil {
+		auditSessionUserMapping(log, config, "rejected, the mapping file %s is invalid: %v", mappingFile, err)
+		return fmt.Errorf("failed to load the session user mapping file %s: %v", mappingFile, err)
+	}
+	if !mappings.DenyUnmapped {
+		return nil
+	}
+	for i, mapping := range mappings.Mappings {
+		if mapping.matches(config.PrincipalArn, config.PrincipalTags) {
+			auditSessionUserMapping(log, config, "allowed by mapping %d", i)
+			return nil
+		}
+	}
+	auditSessionUserMapping(log, config, "rejected, no mapping matches the principal")
+	return errors.New("the session principal is not mapped to a local user")
+}
+
+// rejectUnenforceableSessionUserMappings is used on the platforms which cannot start sessions as the mapped users.
+// The session would start as the default session user, so it is rejected when the mapping file denies unmapped
+// principals or cannot be read.
+func rejectUnenforceableSessionUserMappings(log log.T, mappingFile string, config agentContracts.Configuration) error {
+	if mappingFile == "" {
+		return nil
+	}
+
+	mappings, err := loadSessionUserMappings(mappingFile)
+	if err != nil {
+		auditSessionUserMapping(log, config, "rejected, the mapping file %s is invalid: %v", mappingFile, err)
+		return fmt.Errorf("failed to load the session user mapping file %s: %v", mappingFile, err)
+	}
+	if mappings.DenyUnmapped {
+		auditSessionUserMapping(log, config, "rejected, mappings are not supported on this platform and DenyUnmapped is set")
+		return errors.New("session user mappings are not supported on this platform and the mapping file denies unmapped principals")
+	}
+	auditSessionUserMapping(log, config, "not mapped, mappings are not supported on this platform, using the default session user")
+	return nil
+}
+
+// auditSessionUserMapping logs a mapping decision along with the session id and the principal.
+func auditSessionUserMapping(log log.T, config agentContracts.Configuration, format string, params ...interface{}) {
+	log.Infof("Session user mapping for session %s, principal %q: %s",
+		config.SessionId, config.PrincipalArn, fmt.Sprintf(format, params...))
+}
+
+// loadSessionUserMappings reads and validates the session user mapping file. The file decides the local user of the
+// sessions, so it is refused unless only root or the agent can modify it.
+func loadSessionUserMappings(mappingFile string) (*sessionUserMappings, error) {
+	if err := fileutil.CheckTrustedPermissions(mappingFile); err != nil {
+		return nil, err
+	}
+	content, err := ioutil.ReadFile(mappingFile)
+	if err != nil {
+		return nil, err
+	}
+
+	var mappings sessionUserMappings
+	if err = json.Unmarshal(content, &mappings); err != nil {
+		return nil, err
+	}
+	for i, mapping := range mappings.Mappings {
+		if err = mapping.validate(); err != nil {
+			return nil, fmt.Errorf("mapping %d: %v", i, err)
+		}
+	}
+	return &mappings, nil
+}
+
+// validate checks that the mapping selects principals and has a valid profile.
+func (m sessionUserMapping) validate() error {
+	if strings.TrimSpace(m.Principal) == "" && strings.TrimSpace(m.TagKey) == "" {
+		return errors.New("Principal or TagKey is required")
+	}
+	if strings.TrimSpace(m.LocalUser) == "" {
+		return errors.New("LocalUser is required")
+	}
+	if m.Shell != "" && !filepath.IsAbs(m.Shell) {
+		return fmt.Errorf("Shell %s must be an absolute path", m.Shell)
+	}
+	if m.WorkingDirectory != "" && !filepath.IsAbs(m.WorkingDirectory) {
+		return fmt.Errorf("WorkingDirectory %s must be an absolute path", m.WorkingDirectory)
+	}
+	for name := range m.Environment {
+		if name == "" || strings.ContainsAny(name, "=\x00") {
+			return fmt.Errorf("invalid environment variable name %q", name)
+		}
+	}
+	return nil
+}
+
+// matches returns true if the principal arn and tags satisfy every condition of the mapping.
+func (m sessionUserMapping) matches(principalArn string, principalTags map[string]string) bool {
+	if m.Principal != "" && !matchesWildcard(m.Principal, principalArn) {
+		return false
+	}
+	if m.TagKey != "" {
+		value, found := principalTags[m.TagKey]
+		if !found || (m.TagValue != "" && !matchesWildcard(m.TagValue, value)) {
+			return false
+		}
+	}
+	return true
+}
+
+// profile returns the session profile of the mapping, with the environment sorted by variable name.
+func (m sessionUserMapping) profile() *sessionUserProfile {
+	profile := &sessionUserProfile{
+		User:             strings.TrimSpace(m.LocalUser),
+		Shell:            m.Shell,
+		WorkingDirectory: m.WorkingDirectory,
+	}
+	for name, value := range m.Environment {
+		profile.Environment = append(profile.Environment, name+"="+value)
+	}
+	sort.Strings(profile.Environment)
+	return profile
+}
+
+// matchesWildcard returns true if value matches the pattern in full, where * matches any sequence of characters.
+func matchesWildcard(pattern string, value string) bool {
+	expression := strings.Replace(regexp.QuoteMeta(pattern), `\*`, ".*", -1)
+	matched, _ := regexp.MatchString("^"+expression+"$", value)
+	return matched
+}
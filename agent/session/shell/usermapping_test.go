@@ -0,0 +1,186 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package shell
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	agentContracts "github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/stretchr/testify/assert"
+)
+
+const testUserMappings = `{
+	"Mappings": [
+		{
+			"Principal": "arn:aws:sts::123456789012:assumed-role/DBA/*",
+			"LocalUser": "dba",
+			"Shell": "/bin/bash",
+			"Environment": {"PGHOST": "localhost", "EDITOR": "vim"},
+			"WorkingDirectory": "/var/lib/postgresql"
+		},
+		{
+			"TagKey": "team",
+			"TagValue": "web*",
+			"LocalUser": "www-data"
+		}
+	]
+}`
+
+// writeUserMappings writes the mapping file in a temporary directory and returns its path
+func writeUserMappings(t *testing.T, content string) (path string, cleanup func()) {
+	dir, _ := ioutil.TempDir("", "usermapping")
+	path = filepath.Join(dir, "session-users.json")
+	assert.NoError(t, ioutil.WriteFile(path, []byte(content), 0600))
+	return path, func() { os.RemoveAll(dir) }
+}
+
+func TestResolveSessionUserProfile(t *testing.T) {
+	path, cleanup := writeUserMappings(t, testUserMappings)
+	defer cleanup()
+
+	config := agentContracts.Configuration{
+		SessionId:    "session-id",
+		PrincipalArn: "arn:aws:sts::123456789012:assumed-role/DBA/alice",
+	}
+	profile, err := resolveSessionUserProfile(log.NewMockLog(), path, config)
+	assert.NoError(t, err)
+	assert.Equal(t, &sessionUserProfile{
+		User:             "dba",
+		Shell:            "/bin/bash",
+		Environment:      []string{"EDITOR=vim", "PGHOST=localhost"},
+		WorkingDirectory: "/var/lib/postgresql",
+	}, profile)
+
+	config.PrincipalArn = "arn:aws:sts::123456789012:assumed-role/Dev/bob"
+	config.PrincipalTags = map[string]string{"team": "web-frontend"}
+	profile, err = resolveSessionUserProfile(log.NewMockLog(), path, config)
+	assert.NoError(t, err)
+	assert.Equal(t, &sessionUserProfile{User: "www-data"}, profile)
+
+	config.PrincipalTags = map[string]string{"team": "data"}
+	profile, err = resolveSessionUserProfile(log.NewMockLog(), path, config)
+	assert.NoError(t, err)
+	assert.Nil(t, profile)
+}
+
+func TestResolveSessionUserProfileWithoutMappingFile(t *testing.T) {
+	profile, err := resolveSessionUserProfile(log.NewMockLog(), "", agentContracts.Configuration{SessionId: "session-id"})
+	assert.NoError(t, err)
+	assert.Nil(t, profile)
+}
+
+func TestResolveSessionUserProfileDeniesUnmappedPrincipals(t *testing.T) {
+	path, cleanup := writeUserMappings(t, `{"DenyUnmapped": true, "Mappings": [{"TagKey": "team", "LocalUser": "dev"}]}`)
+	defer cleanup()
+
+	config := agentContracts.Configuration{
+		SessionId:     "session-id",
+		PrincipalArn:  "arn:aws:iam::123456789012:user/carol",
+		PrincipalTags: map[string]string{"team": "anything"},
+	}
+	profile, err := resolveSessionUserProfile(log.NewMockLog(), path, config)
+	assert.NoError(t, err)
+	assert.Equal(t, "dev", profile.User)
+
+	config.PrincipalTags = nil
+	profile, err = resolveSessionUserProfile(log.NewMockLog(), path, config)
+	assert.Error(t, err)
+	assert.Nil(t, profile)
+}
+
+func TestResolveSessionUserProfileRejectsInvalidMappingFile(t *testing.T) {
+	config := agentContracts.Configuration{SessionId: "session-id"}
+
+	_, err := resolveSessionUserProfile(log.NewMockLog(), "/nonexistent/session-users.json", config)
+	assert.Error(t, err)
+
+	invalidMappings := []string{
+		`not json`,
+		`{"Mappings": [{"LocalUser": "dev"}]}`,
+		`{"Mappings": [{"Principal": "*"}]}`,
+		`{"Mappings": [{"Principal": "*", "LocalUser": "dev", "Shell": "bash"}]}`,
+		`{"Mappings": [{"Principal": "*", "LocalUser": "dev", "WorkingDirectory": "home"}]}`,
+		`{"Mappings": [{"Principal": "*", "LocalUser": "dev", "Environment": {"A=B": "C"}}]}`,
+	}
+	for _, content := range invalidMappings {
+		path, cleanup := writeUserMappings(t, content)
+		_, err = resolveSessionUserProfile(log.NewMockLog(), path, config)
+		assert.Error(t, err, content)
+		cleanup()
+	}
+}
+
+func TestResolveSessionUserProfileRejectsWritableMappingFile(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("file modes do not control write access on Windows")
+	}
+	path, cleanup := writeUserMappings(t, testUserMappings)
+	defer cleanup()
+	assert.NoError(t, os.Chmod(path, 0666))
+
+	config := agentContracts.Configuration{
+		SessionId:    "session-id",
+		PrincipalArn: "arn:aws:sts::123456789012:assumed-role/DBA/alice",
+	}
+	profile, err := resolveSessionUserProfile(log.NewMockLog(), path, config)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "writable by group or others")
+	assert.Nil(t, profile)
+}
+
+func TestAuthorizeSessionPrincipal(t *testing.T) {
+	config := agentContracts.Configuration{SessionId: "session-id", PrincipalArn: "arn:aws:iam::123456789012:user/carol"}
+	assert.NoError(t, AuthorizeSessionPrincipal(log.NewMockLog(), "", config))
+	assert.Error(t, AuthorizeSessionPrincipal(log.NewMockLog(), "/nonexistent/session-users.json", config))
+
+	path, cleanup := writeUserMappings(t, testUserMappings)
+	defer cleanup()
+	assert.NoError(t, AuthorizeSessionPrincipal(log.NewMockLog(), path, config))
+
+	denyingPath, denyingCleanup := writeUserMappings(t, `{"DenyUnmapped": true, "Mappings": [{"TagKey": "team", "LocalUser": "dev"}]}`)
+	defer denyingCleanup()
+	assert.Error(t, AuthorizeSessionPrincipal(log.NewMockLog(), denyingPath, config))
+	config.PrincipalTags = map[string]string{"team": "anything"}
+	assert.NoError(t, AuthorizeSessionPrincipal(log.NewMockLog(), denyingPath, config))
+
+	// an empty principal matches no mapping
+	assert.Error(t, AuthorizeSessionPrincipal(log.NewMockLog(), denyingPath, agentContracts.Configuration{SessionId: "session-id"}))
+}
+
+func TestRejectUnenforceableSessionUserMappings(t *testing.T) {
+	config := agentContracts.Configuration{SessionId: "session-id"}
+	assert.NoError(t, rejectUnenforceableSessionUserMappings(log.NewMockLog(), "", config))
+	assert.Error(t, rejectUnenforceableSessionUserMappings(log.NewMockLog(), "/nonexistent/session-users.json", config))
+
+	path, cleanup := writeUserMappings(t, testUserMappings)
+	defer cleanup()
+	assert.NoError(t, rejectUnenforceableSessionUserMappings(log.NewMockLog(), path, config))
+
+	denyingPath, denyingCleanup := writeUserMappings(t, `{"DenyUnmapped": true, "Mappings": [{"TagKey": "team", "LocalUser": "dev"}]}`)
+	defer denyingCleanup()
+	config.PrincipalTags = map[string]string{"team": "anything"}
+	assert.Error(t, rejectUnenforceableSessionUserMappings(log.NewMockLog(), denyingPath, config))
+}
+
+func TestMatchesWildcard(t *testing.T) {
+	assert.True(t, matchesWildcard("arn:aws:iam::*:user/admin", "arn:aws:iam::123456789012:user/admin"))
+	assert.True(t, matchesWildcard("*", ""))
+	assert.False(t, matchesWildcard("arn:aws:iam::*:user/admin", "arn:aws:iam::123456789012:user/admin2"))
+	assert.False(t, matchesWildcard("role.name", "role-name"))
+}
@@ -20,6 +20,7 @@ import (
 	"testing"
 
 	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/redaction"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -203,3 +204,15 @@ func TestResolveParametersInTextIgnoreSecureParams(t *testing.T) {
 	assert.NotNil(t, output)
 	assert.True(t, expectedOutput == output)
 }
+
+func TestResolveParameterReferenceListRegistersSecretsForRedaction(t *testing.T) {
+	serviceObject := newServiceMockedObjectWithExtraRecords(map[string]SsmParameterInfo{
+		"ssm:plainParam":        {Name: "plainParam", Type: stringType, Value: "plain-value"},
+		"ssm-secure:tokenParam": {Name: "tokenParam", Type: secureStringType, Value: "secret-token-value"},
+	})
+
+	_, err := ResolveParameterReferenceList(&serviceObject, log.DefaultLogger(), []string{"ssm:plainParam", "ssm-secure:tokenParam"}, ResolveOptions{})
+
+	assert.Nil(t, err)
+	assert.Equal(t, "plain-value "+redaction.Mask, redaction.Redact("plain-value secret-token-value"))
+}
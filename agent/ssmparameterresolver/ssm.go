@@ -19,6 +19,7 @@ import (
 	"strings"
 
 	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/redaction"
 	"github.com/aws/amazon-ssm-agent/agent/ssm"
 )
 
@@ -108,6 +109,10 @@ func getParametersFromSsmParameterStore(
 		}
 
 		for name, value := range results {
+			// mask the secret in the output of the command using it
+			if value.Type == secureStringType {
+				redaction.AddSecret(value.Value)
+			}
 			outputMap[name] = value
 		}
 	}
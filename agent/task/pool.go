@@ -22,12 +22,25 @@ import (
 	"github.com/aws/amazon-ssm-agent/agent/times"
 )
 
+const (
+	// DefaultJobClass is the class of the jobs submitted without a class
+	DefaultJobClass = ""
+	// DefaultMaxQueuedJobs is the number of jobs of a class that can wait for a worker when the class does not set it
+	DefaultMaxQueuedJobs = 100
+)
+
 // Pool is a pool of jobs.
 type Pool interface {
 	// Submit schedules a job to be executed in the associated worker pool.
 	// Returns an error if a job with the same name already exists.
 	Submit(log log.T, jobID string, job Job) error
 
+	// SubmitWithClass schedules a job of the given class, the class sets the priority
+	// of the job and how many workers the jobs of the class can use at the same time.
+	// Classes the pool was not created with are scheduled like Submit.
+	// Blocks while the queue of the class is full.
+	SubmitWithClass(log log.T, jobID string, class string, job Job) error
+
 	// Cancel cancels the given job. Jobs that have not started yet will never be started.
 	// Jobs that are running will have their CancelFlag set to the Canceled state.
 	// It is the responsibility of the job to terminate within a reasonable time.
@@ -45,12 +58,25 @@ type Pool interface {
 
 	// HasJob returns if jobStore has specified job
 	HasJob(jobID string) bool
+
+	// QueueDepth returns the number of jobs waiting for a worker, per class
+	QueueDepth() map[string]int
+}
+
+// JobClass defines how the jobs of a class are scheduled.
+type JobClass struct {
+	// Priority orders the waiting jobs, jobs of higher priority classes start first.
+	// Jobs of the same priority start in submission order.
+	Priority int
+	// MaxWorkers is the number of workers that can run jobs of the class at the same time, 0 means all of them.
+	MaxWorkers int
+	// MaxQueued is the number of jobs of the class that can wait for a worker, 0 means DefaultMaxQueuedJobs.
+	MaxQueued int
 }
 
 // pool implements a task pool where all jobs are managed by a root task
 type pool struct {
 	log            log.T
-	jobQueue       chan JobToken
 	nWorkers       int
 	doneWorker     chan struct{}
 	isShutdown     bool
@@ -58,6 +84,13 @@ type pool struct {
 	mut            sync.Mutex
 	jobStore       *JobStore
 	cancelDuration time.Duration
+
+	// the fields below are guarded by mut
+	classes  map[string]JobClass
+	queues   map[string][]JobToken
+	running  map[string]int
+	sequence uint64
+	ready    *sync.Cond
 }
 
 // JobToken embeds a job and its associated info
@@ -66,20 +99,34 @@ type JobToken struct {
 	job        Job
 	cancelFlag *ChanneledCancelFlag
 	log        log.T
+	class      string
+	sequence   uint64
 }
 
 // NewPool creates a new task pool and launches maxParallel workers.
 // The cancelWaitDuration parameter defines how long to wait for a job
 // to complete a cancellation request.
 func NewPool(log log.T, maxParallel int, cancelWaitDuration time.Duration, clock times.Clock) Pool {
+	return NewPriorityPool(log, maxParallel, nil, cancelWaitDuration, clock)
+}
+
+// NewPriorityPool creates a new task pool and launches maxParallel workers.
+// Jobs submitted with one of the given classes are scheduled according to their class.
+func NewPriorityPool(log log.T, maxParallel int, classes map[string]JobClass, cancelWaitDuration time.Duration, clock times.Clock) Pool {
 	p := &pool{
 		log:            log,
-		jobQueue:       make(chan JobToken),
 		nWorkers:       maxParallel,
 		doneWorker:     make(chan struct{}),
 		clock:          clock,
 		cancelDuration: cancelWaitDuration,
+		classes:        make(map[string]JobClass),
+		queues:         make(map[string][]JobToken),
+		running:        make(map[string]int),
 	}
+	for name, class := range classes {
+		p.classes[name] = class
+	}
+	p.ready = sync.NewCond(&p.mut)
 
 	p.jobStore = NewJobStore()
 
@@ -103,11 +150,10 @@ func (p *pool) Shutdown() {
 	p.mut.Lock()
 	defer p.mut.Unlock()
 	if !p.isShutdown {
-		// close the channel to makes all workers terminate once the pending
-		// jobs have been consumed (the pending jobs are in the Canceled state
-		// so they will simply be discarded)
-		close(p.jobQueue)
+		// makes all workers terminate once the pending jobs have been consumed
+		// (the pending jobs are in the ShutDown state so they will simply be discarded)
 		p.isShutdown = true
+		p.ready.Broadcast()
 	}
 }
 
@@ -148,7 +194,7 @@ func (p *pool) start(jobProcessor func(JobToken)) {
 		workerName := fmt.Sprintf("worker-%d", i)
 		go func() {
 			defer p.workerDone()
-			worker(workerName, p.jobQueue, jobProcessor)
+			p.worker(workerName, jobProcessor)
 		}()
 	}
 }
@@ -158,31 +204,154 @@ func (p *pool) workerDone() {
 	p.doneWorker <- struct{}{}
 }
 
-// worker processes jobs from a channel.
-func worker(workerName string, queue chan JobToken, processor func(JobToken)) {
-	for token := range queue {
+// worker processes the queued jobs until the pool is shut down.
+func (p *pool) worker(workerName string, processor func(JobToken)) {
+	for {
+		token, ok := p.next()
+		if !ok {
+			return
+		}
 		if !token.cancelFlag.Canceled() {
 			processor(token)
 		}
+		p.release(token)
+	}
+}
+
+// next blocks until a job can be started and returns it.
+// Returns false once the pool is shut down and no job is left.
+func (p *pool) next() (token JobToken, ok bool) {
+	p.mut.Lock()
+	defer p.mut.Unlock()
+	for {
+		selected := ""
+		found := false
+		for class, queue := range p.queues {
+			// jobs canceled or shut down before they started are discarded
+			for len(queue) > 0 && isDiscarded(queue[0]) {
+				queue = queue[1:]
+			}
+			if len(queue) == 0 {
+				delete(p.queues, class)
+				continue
+			}
+			p.queues[class] = queue
+			if limit := p.classes[class].MaxWorkers; limit > 0 && p.running[class] >= limit {
+				continue
+			}
+			if !found || p.before(queue[0], p.queues[selected][0]) {
+				selected, found = class, true
+			}
+		}
+		if found {
+			token = p.queues[selected][0]
+			p.queues[selected] = p.queues[selected][1:]
+			p.running[selected]++
+			// wakes the submitters waiting for room in the queue
+			p.ready.Broadcast()
+			return token, true
+		}
+		if p.isShutdown && len(p.queues) == 0 {
+			return token, false
+		}
+		p.ready.Wait()
+	}
+}
+
+// before returns whether job a should start before job b.
+func (p *pool) before(a JobToken, b JobToken) bool {
+	priorityA, priorityB := p.classes[a.class].Priority, p.classes[b.class].Priority
+	if priorityA != priorityB {
+		return priorityA > priorityB
+	}
+	return a.sequence < b.sequence
+}
+
+// release frees the worker slot taken by a job of the class.
+func (p *pool) release(token JobToken) {
+	p.mut.Lock()
+	defer p.mut.Unlock()
+	p.running[token.class]--
+	p.ready.Broadcast()
+}
+
+// isFull returns whether the queue of the class has no room left for another job.
+func (p *pool) isFull(class string) bool {
+	limit := p.classes[class].MaxQueued
+	if limit <= 0 {
+		limit = DefaultMaxQueuedJobs
+	}
+	queued := 0
+	for _, token := range p.queues[class] {
+		if !isDiscarded(token) {
+			queued++
+		}
 	}
+	return queued >= limit
+}
+
+// isDiscarded returns whether a queued job will never be started.
+func isDiscarded(token JobToken) bool {
+	return token.cancelFlag.Canceled() || token.cancelFlag.ShutDown()
 }
 
 // Submit adds a job to the execution queue of this pool.
 func (p *pool) Submit(log log.T, jobID string, job Job) (err error) {
+	return p.SubmitWithClass(log, jobID, DefaultJobClass, job)
+}
+
+// SubmitWithClass adds a job of the given class to the execution queue of this pool,
+// waiting for a worker to take a job of the class when its queue is full.
+func (p *pool) SubmitWithClass(log log.T, jobID string, class string, job Job) (err error) {
 	token := JobToken{
 		id:         jobID,
 		job:        job,
 		cancelFlag: NewChanneledCancelFlag(),
 		log:        log,
+		class:      class,
+	}
+
+	p.mut.Lock()
+	defer p.mut.Unlock()
+	if p.isShutdown {
+		return fmt.Errorf("Job %v cannot be submitted to a pool that is shut down", jobID)
 	}
+	p.sequence++
+	token.sequence = p.sequence
 	err = p.jobStore.AddJob(jobID, &token)
 	if err != nil {
 		return
 	}
-	p.jobQueue <- token
+	for p.isFull(class) && !p.isShutdown && !isDiscarded(token) {
+		p.ready.Wait()
+	}
+	if p.isShutdown {
+		return fmt.Errorf("Job %v cannot be submitted to a pool that is shut down", jobID)
+	}
+	p.queues[class] = append(p.queues[class], token)
+	p.ready.Broadcast()
 	return
 }
 
+// QueueDepth returns the number of jobs waiting for a worker, per class.
+// Classes the pool was created with are always reported.
+func (p *pool) QueueDepth() map[string]int {
+	p.mut.Lock()
+	defer p.mut.Unlock()
+	depth := make(map[string]int)
+	for class := range p.classes {
+		depth[class] = 0
+	}
+	for class, queue := range p.queues {
+		for _, token := range queue {
+			if !isDiscarded(token) {
+				depth[class]++
+			}
+		}
+	}
+	return depth
+}
+
 // HasJob returns if jobStore has specified job
 func (p *pool) HasJob(jobID string) bool {
 	_, found := p.jobStore.GetJob(jobID)
@@ -200,6 +369,11 @@ func (p *pool) Cancel(jobID string) (canceled bool) {
 	p.jobStore.DeleteJob(jobID)
 
 	jobToken.cancelFlag.Set(Canceled)
+
+	// the canceled job no longer takes room in its queue
+	p.mut.Lock()
+	defer p.mut.Unlock()
+	p.ready.Broadcast()
 	return true
 }
 
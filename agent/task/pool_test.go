@@ -119,3 +119,147 @@ func exercisePool(t *testing.T, pool Pool, jobID string, shouldCancel bool) {
 	// see that job completes
 	assert.True(t, <-jobState)
 }
+
+func TestPriorityPool_StartsHigherPriorityFirst(t *testing.T) {
+	classes := map[string]JobClass{
+		"low":  {Priority: 0},
+		"high": {Priority: 10},
+	}
+	pool := NewPriorityPool(logger, 1, classes, 100*time.Millisecond, times.DefaultClock)
+	defer pool.ShutdownAndWait(time.Second)
+
+	// block the only worker until all the jobs are queued
+	release := make(chan bool)
+	started := make(chan string, 4)
+	blocking := make(chan bool)
+	assert.Nil(t, pool.Submit(logger, "blocker", func(CancelFlag) {
+		blocking <- true
+		<-release
+	}))
+	<-blocking
+	for _, job := range []struct{ id, class string }{{"low-1", "low"}, {"high-1", "high"}, {"low-2", "low"}, {"high-2", "high"}} {
+		id := job.id
+		assert.Nil(t, pool.SubmitWithClass(logger, id, job.class, func(CancelFlag) { started <- id }))
+	}
+	assert.Equal(t, map[string]int{"low": 2, "high": 2}, pool.QueueDepth())
+	close(release)
+
+	var order []string
+	for i := 0; i < 4; i++ {
+		order = append(order, <-started)
+	}
+	assert.Equal(t, []string{"high-1", "high-2", "low-1", "low-2"}, order)
+	assert.Equal(t, map[string]int{"low": 0, "high": 0}, pool.QueueDepth())
+}
+
+func TestPriorityPool_LimitsWorkersPerClass(t *testing.T) {
+	classes := map[string]JobClass{
+		"limited": {MaxWorkers: 1},
+	}
+	pool := NewPriorityPool(logger, 2, classes, 100*time.Millisecond, times.DefaultClock)
+	defer pool.ShutdownAndWait(time.Second)
+
+	release := make(chan bool)
+	started := make(chan string, 3)
+	for _, id := range []string{"limited-1", "limited-2"} {
+		jobID := id
+		assert.Nil(t, pool.SubmitWithClass(logger, jobID, "limited", func(CancelFlag) {
+			started <- jobID
+			<-release
+		}))
+	}
+	assert.Equal(t, "limited-1", <-started)
+
+	// the second worker runs other classes but not a second limited job
+	assert.Nil(t, pool.Submit(logger, "other", func(CancelFlag) { started <- "other" }))
+	assert.Equal(t, "other", <-started)
+	assert.Equal(t, 1, pool.QueueDepth()["limited"])
+
+	release <- true
+	assert.Equal(t, "limited-2", <-started)
+	close(release)
+}
+
+func TestPriorityPool_BlocksSubmitWhenClassQueueIsFull(t *testing.T) {
+	classes := map[string]JobClass{
+		"bounded": {MaxQueued: 1},
+	}
+	pool := NewPriorityPool(logger, 1, classes, 100*time.Millisecond, times.DefaultClock)
+	defer pool.ShutdownAndWait(time.Second)
+
+	release := make(chan bool)
+	blocking := make(chan bool)
+	assert.Nil(t, pool.Submit(logger, "blocker", func(CancelFlag) {
+		blocking <- true
+		<-release
+	}))
+	<-blocking
+	assert.Nil(t, pool.SubmitWithClass(logger, "queued", "bounded", func(CancelFlag) {}))
+
+	// the queue of the class is full, the next job waits for the queued one to start
+	submitted := make(chan error)
+	go func() {
+		submitted <- pool.SubmitWithClass(logger, "waiting", "bounded", func(CancelFlag) {})
+	}()
+	select {
+	case <-submitted:
+		assert.Fail(t, "job submitted to a full queue")
+	case <-time.After(100 * time.Millisecond):
+	}
+	assert.Equal(t, 1, pool.QueueDepth()["bounded"])
+
+	// other classes are not blocked by the full queue
+	assert.Nil(t, pool.Submit(logger, "other", func(CancelFlag) {}))
+
+	close(release)
+	assert.Nil(t, <-submitted)
+}
+
+func TestPriorityPool_ShutdownReleasesBlockedSubmit(t *testing.T) {
+	classes := map[string]JobClass{
+		"bounded": {MaxQueued: 1},
+	}
+	pool := NewPriorityPool(logger, 1, classes, 100*time.Millisecond, times.DefaultClock)
+
+	release := make(chan bool)
+	blocking := make(chan bool)
+	assert.Nil(t, pool.Submit(logger, "blocker", func(CancelFlag) {
+		blocking <- true
+		<-release
+	}))
+	<-blocking
+	assert.Nil(t, pool.SubmitWithClass(logger, "queued", "bounded", func(CancelFlag) {}))
+
+	submitted := make(chan error)
+	go func() {
+		submitted <- pool.SubmitWithClass(logger, "waiting", "bounded", func(CancelFlag) {})
+	}()
+	time.Sleep(10 * time.Millisecond)
+	pool.Shutdown()
+	assert.NotNil(t, <-submitted)
+	close(release)
+	assert.True(t, pool.ShutdownAndWait(time.Second))
+}
+
+func TestPriorityPool_DiscardsCanceledQueuedJobs(t *testing.T) {
+	pool := NewPriorityPool(logger, 1, nil, 100*time.Millisecond, times.DefaultClock)
+
+	release := make(chan bool)
+	ran := make(chan string, 2)
+	blocking := make(chan bool)
+	assert.Nil(t, pool.Submit(logger, "blocker", func(CancelFlag) {
+		blocking <- true
+		<-release
+	}))
+	<-blocking
+	assert.Nil(t, pool.SubmitWithClass(logger, "canceled", "class", func(CancelFlag) { ran <- "canceled" }))
+	assert.Nil(t, pool.SubmitWithClass(logger, "kept", "class", func(CancelFlag) { ran <- "kept" }))
+	assert.True(t, pool.Cancel("canceled"))
+	assert.Equal(t, 1, pool.QueueDepth()["class"])
+	close(release)
+
+	assert.Equal(t, "kept", <-ran)
+	assert.True(t, pool.ShutdownAndWait(time.Second))
+	assert.NotNil(t, pool.Submit(logger, "late", func(CancelFlag) {}))
+	assert.Empty(t, ran)
+}
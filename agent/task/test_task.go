@@ -33,6 +33,11 @@ func (mockPool *MockedPool) Submit(log log.T, jobID string, job Job) error {
 	return mockPool.Called(log, jobID, job).Error(0)
 }
 
+// SubmitWithClass mocks the method with the same name.
+func (mockPool *MockedPool) SubmitWithClass(log log.T, jobID string, class string, job Job) error {
+	return mockPool.Called(log, jobID, class, job).Error(0)
+}
+
 // Cancel mocks the method with the same name.
 func (mockPool *MockedPool) Cancel(jobID string) bool {
 	return mockPool.Called(jobID).Bool(0)
@@ -55,6 +60,11 @@ func (mockPool *MockedPool) HasJob(jobID string) bool {
 	return args.Bool(0)
 }
 
+// QueueDepth mocks the method with the same name.
+func (mockPool *MockedPool) QueueDepth() map[string]int {
+	return mockPool.Called().Get(0).(map[string]int)
+}
+
 // MockCancelFlag mocks a cancel flag.
 type MockCancelFlag struct {
 	mock.Mock